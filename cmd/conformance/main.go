@@ -0,0 +1,616 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/contract"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/mongo"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/postgres"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/redis"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/sqlite"
+)
+
+// suite is the cases of one interface against one backend, named like
+// sqlite/users.
+type suite struct {
+	name  string
+	cases []contract.Case
+}
+
+// errUnavailable is returned when there is nothing to run a backend's cases
+// against.
+var errUnavailable = errors.New("backend is not available")
+
+func main() {
+	backends := flag.String("backends", "memory,sqlite,mongo,postgres,redis", "comma separated backends to check")
+	skip := flag.String("skip", "postgres,redis", "comma separated backends to skip, instead of failing, when they are not available")
+	mongoUrl := flag.String("mongo-url", "", "mongo to run against, a throwaway mongod is started when empty")
+	mongod := flag.String("mongod", "mongod", "mongod binary used when -mongo-url is empty")
+	postgresUrl := flag.String("postgres-url", "", "postgres to run against")
+	redisUrl := flag.String("redis-url", "", "redis to run the cache cases against")
+	flag.Parse()
+
+	ctx := context.Background()
+	logger := zap.NewNop()
+	options := backendOptions{mongoUrl: *mongoUrl, mongod: *mongod, postgresUrl: *postgresUrl, redisUrl: *redisUrl}
+
+	skipped := map[string]bool{}
+	for _, backend := range strings.Split(*skip, ",") {
+		skipped[strings.TrimSpace(backend)] = true
+	}
+
+	failed := 0
+	for _, backend := range strings.Split(*backends, ",") {
+		backend = strings.TrimSpace(backend)
+		suites, stop, err := openBackend(ctx, backend, options, logger)
+		if err != nil {
+			if errors.Is(err, errUnavailable) && skipped[backend] {
+				fmt.Printf("--- SKIP: %s (%v)\n", backend, err)
+				continue
+			}
+			fmt.Printf("--- FAIL: %s (%v)\n", backend, err)
+			failed++
+			continue
+		}
+		for _, s := range suites {
+			failed += contract.RunCases(os.Stdout, s.name, s.cases)
+		}
+		stop()
+	}
+
+	if failed > 0 {
+		fmt.Printf("FAIL: %d case(s) failed\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("PASS")
+}
+
+type backendOptions struct {
+	mongoUrl    string
+	mongod      string
+	postgresUrl string
+	redisUrl    string
+}
+
+// openBackend connects to backend and returns its suites, stop releases what
+// it connected to once they ran.
+func openBackend(ctx context.Context, backend string, options backendOptions, logger *zap.Logger) ([]suite, func(), error) {
+	switch backend {
+	case "memory":
+		return memorySuites(), func() {}, nil
+	case "sqlite":
+		dir, err := os.MkdirTemp("", "stressless-conformance-sqlite")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create sqlite directory: %w", err)
+		}
+		return sqliteSuites(ctx, dir, logger), func() { os.RemoveAll(dir) }, nil
+	case "mongo":
+		client, stop, err := connectMongo(ctx, options.mongoUrl, options.mongod)
+		if err != nil {
+			return nil, nil, err
+		}
+		return mongoSuites(ctx, client, logger), stop, nil
+	case "postgres":
+		admin, err := connectPostgres(ctx, options.postgresUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+		return postgresSuites(ctx, admin, options.postgresUrl, logger), func() { admin.Close() }, nil
+	case "redis":
+		cache, queue, err := connectRedis(ctx, options.redisUrl, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return redisSuites(cache, queue), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown backend %q", backend)
+	}
+}
+
+func memorySuites() []suite {
+	return []suite{
+		{"memory/users", contract.UserRepositoryCases(func(t contract.T) infra.UserRepository {
+			return memory.NewMemoryUserRepo()
+		})},
+		{"memory/metrics", contract.MetricRepositoryCases(func(t contract.T) infra.MetricRepository {
+			return memory.NewMemoryMetricRepo()
+		})},
+		{"memory/recommendations", contract.RecommendationRepositoryCases(func(t contract.T) infra.RecommendationRepository {
+			return memory.NewMemoryRecommendationRepo()
+		})},
+		{"memory/achievements", contract.AchievementRepositoryCases(func(t contract.T) infra.AchievementRepository {
+			return memory.NewMemoryAchievementRepo()
+		})},
+		{"memory/goals", contract.GoalRepositoryCases(func(t contract.T) infra.GoalRepository {
+			return memory.NewMemoryGoalRepo()
+		})},
+		{"memory/journal", contract.JournalRepositoryCases(func(t contract.T) infra.JournalRepository {
+			return memory.NewMemoryJournalRepo()
+		})},
+		{"memory/safety", contract.SafetyRepositoryCases(func(t contract.T) infra.SafetyRepository {
+			return memory.NewMemorySafetyRepo()
+		})},
+		{"memory/shares", contract.ShareRepositoryCases(func(t contract.T) infra.ShareRepository {
+			return memory.NewMemoryShareRepo()
+		})},
+		{"memory/cache", contract.CacheCases(func(t contract.T) infra.Cache {
+			return memory.NewMemoryCache()
+		})},
+		{"memory/queue", contract.JobQueueCases(func(t contract.T) infra.JobQueue {
+			return memory.NewMemoryJobQueue()
+		})},
+	}
+}
+
+// sqliteSuites keeps its databases in dir, the caller removes it once the
+// cases ran.
+func sqliteSuites(ctx context.Context, dir string, logger *zap.Logger) []suite {
+	// every case gets its own database file so cases can run in parallel.
+	newDatabase := func(t contract.T) *sql.DB {
+		t.Helper()
+		file, err := os.CreateTemp(dir, "*.db")
+		if err != nil {
+			t.Fatalf("failed to create sqlite file: %v", err)
+		}
+		file.Close()
+		db, err := sqlite.Open(ctx, file.Name())
+		if err != nil {
+			t.Fatalf("failed to open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	return []suite{
+		{"sqlite/users", contract.UserRepositoryCases(func(t contract.T) infra.UserRepository {
+			repo, err := sqlite.NewSQLiteUserRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteUserRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/metrics", contract.MetricRepositoryCases(func(t contract.T) infra.MetricRepository {
+			repo, err := sqlite.NewSQLiteMetricRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteMetricRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/recommendations", contract.RecommendationRepositoryCases(func(t contract.T) infra.RecommendationRepository {
+			repo, err := sqlite.NewSQLiteRecommendationRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteRecommendationRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/achievements", contract.AchievementRepositoryCases(func(t contract.T) infra.AchievementRepository {
+			repo, err := sqlite.NewSQLiteAchievementRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteAchievementRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/goals", contract.GoalRepositoryCases(func(t contract.T) infra.GoalRepository {
+			repo, err := sqlite.NewSQLiteGoalRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteGoalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/journal", contract.JournalRepositoryCases(func(t contract.T) infra.JournalRepository {
+			repo, err := sqlite.NewSQLiteJournalRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteJournalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/safety", contract.SafetyRepositoryCases(func(t contract.T) infra.SafetyRepository {
+			repo, err := sqlite.NewSQLiteSafetyRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteSafetyRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/shares", contract.ShareRepositoryCases(func(t contract.T) infra.ShareRepository {
+			repo, err := sqlite.NewSQLiteShareRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteShareRepo: %v", err)
+			}
+			return repo
+		})},
+		{"sqlite/transactions", contract.TransactorCases(func(t contract.T) (infra.Transactor, infra.MetricRepository) {
+			db := newDatabase(t)
+			transactor, err := sqlite.NewSQLiteTransactor(db)
+			if err != nil {
+				t.Fatalf("NewSQLiteTransactor: %v", err)
+			}
+			repo, err := sqlite.NewSQLiteMetricRepo(ctx, db, logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteMetricRepo: %v", err)
+			}
+			return transactor, repo
+		})},
+		{"sqlite/cache", contract.CacheCases(func(t contract.T) infra.Cache {
+			cacheCtx, cancel := context.WithCancel(ctx)
+			t.Cleanup(cancel)
+			cache, err := sqlite.NewSQLiteCache(cacheCtx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteCache: %v", err)
+			}
+			return cache
+		})},
+	}
+}
+
+// connectMongo connects to mongoUrl, or to a throwaway mongod when it is
+// empty. stop disconnects and stops that mongod.
+func connectMongo(ctx context.Context, mongoUrl, mongod string) (*mongoDriver.Client, func(), error) {
+	stopMongod := func() {}
+	if mongoUrl == "" {
+		url, stop, err := startMongod(ctx, mongod)
+		if err != nil {
+			return nil, nil, err
+		}
+		mongoUrl, stopMongod = url, stop
+	}
+
+	client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(mongoUrl))
+	if err != nil {
+		stopMongod()
+		return nil, nil, fmt.Errorf("failed to connect to mongo: %w", err)
+	}
+	return client, func() {
+		client.Disconnect(ctx)
+		stopMongod()
+	}, nil
+}
+
+func mongoSuites(ctx context.Context, client *mongoDriver.Client, logger *zap.Logger) []suite {
+	// every case gets its own migrated database so they start empty
+	newDatabase := func(t contract.T) *mongoDriver.Database {
+		database := client.Database("contract_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { database.Drop(context.Background()) })
+		if err := mongo.Migrate(ctx, database, logger); err != nil {
+			t.Fatalf("failed to migrate mongo: %v", err)
+		}
+		return database
+	}
+
+	return []suite{
+		{"mongo/users", contract.UserRepositoryCases(func(t contract.T) infra.UserRepository {
+			repo, err := mongo.NewMongoUserRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoUserRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/metrics", contract.MetricRepositoryCases(func(t contract.T) infra.MetricRepository {
+			repo, err := mongo.NewMongoMetricRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoMetricRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/recommendations", contract.RecommendationRepositoryCases(func(t contract.T) infra.RecommendationRepository {
+			repo, err := mongo.NewMongoRecommendationRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoRecommendationRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/achievements", contract.AchievementRepositoryCases(func(t contract.T) infra.AchievementRepository {
+			repo, err := mongo.NewMongoAchievementRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoAchievementRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/goals", contract.GoalRepositoryCases(func(t contract.T) infra.GoalRepository {
+			repo, err := mongo.NewMongoGoalRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoGoalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/journal", contract.JournalRepositoryCases(func(t contract.T) infra.JournalRepository {
+			repo, err := mongo.NewMongoJournalRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoJournalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/safety", contract.SafetyRepositoryCases(func(t contract.T) infra.SafetyRepository {
+			repo, err := mongo.NewMongoSafetyRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoSafetyRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/shares", contract.ShareRepositoryCases(func(t contract.T) infra.ShareRepository {
+			repo, err := mongo.NewMongoShareRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoShareRepo: %v", err)
+			}
+			return repo
+		})},
+		{"mongo/transactions", contract.TransactorCases(func(t contract.T) (infra.Transactor, infra.MetricRepository) {
+			transactor, err := mongo.NewMongoTransactor(ctx, client, logger)
+			if err != nil {
+				t.Fatalf("NewMongoTransactor: %v", err)
+			}
+			repo, err := mongo.NewMongoMetricRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewMongoMetricRepo: %v", err)
+			}
+			return transactor, repo
+		})},
+	}
+}
+
+func connectPostgres(ctx context.Context, postgresUrl string) (*sql.DB, error) {
+	if postgresUrl == "" {
+		return nil, fmt.Errorf("%w, no -postgres-url", errUnavailable)
+	}
+	admin, err := postgres.Open(ctx, postgresUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	return admin, nil
+}
+
+// postgresSuites creates a schema per case through admin and connects to it
+// with postgresUrl.
+func postgresSuites(ctx context.Context, admin *sql.DB, postgresUrl string, logger *zap.Logger) []suite {
+	// every case gets its own migrated schema so they start empty
+	newDatabase := func(t contract.T) *sql.DB {
+		schema := "contract_" + primitive.NewObjectID().Hex()
+		if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		t.Cleanup(func() { admin.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE") })
+
+		db, err := postgres.Open(ctx, withSearchPath(postgresUrl, schema))
+		if err != nil {
+			t.Fatalf("failed to connect to postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := postgres.Migrate(ctx, db); err != nil {
+			t.Fatalf("failed to migrate postgres: %v", err)
+		}
+		return db
+	}
+
+	return []suite{
+		{"postgres/users", contract.UserRepositoryCases(func(t contract.T) infra.UserRepository {
+			repo, err := postgres.NewPostgresUserRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresUserRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/metrics", contract.MetricRepositoryCases(func(t contract.T) infra.MetricRepository {
+			repo, err := postgres.NewPostgresMetricRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresMetricRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/recommendations", contract.RecommendationRepositoryCases(func(t contract.T) infra.RecommendationRepository {
+			repo, err := postgres.NewPostgresRecommendationRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresRecommendationRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/achievements", contract.AchievementRepositoryCases(func(t contract.T) infra.AchievementRepository {
+			repo, err := postgres.NewPostgresAchievementRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresAchievementRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/goals", contract.GoalRepositoryCases(func(t contract.T) infra.GoalRepository {
+			repo, err := postgres.NewPostgresGoalRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresGoalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/journal", contract.JournalRepositoryCases(func(t contract.T) infra.JournalRepository {
+			repo, err := postgres.NewPostgresJournalRepo(ctx, newDatabase(t), newKeyring(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresJournalRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/safety", contract.SafetyRepositoryCases(func(t contract.T) infra.SafetyRepository {
+			repo, err := postgres.NewPostgresSafetyRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresSafetyRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/shares", contract.ShareRepositoryCases(func(t contract.T) infra.ShareRepository {
+			repo, err := postgres.NewPostgresShareRepo(ctx, newDatabase(t), logger)
+			if err != nil {
+				t.Fatalf("NewPostgresShareRepo: %v", err)
+			}
+			return repo
+		})},
+		{"postgres/transactions", contract.TransactorCases(func(t contract.T) (infra.Transactor, infra.MetricRepository) {
+			db := newDatabase(t)
+			transactor, err := postgres.NewPostgresTransactor(db)
+			if err != nil {
+				t.Fatalf("NewPostgresTransactor: %v", err)
+			}
+			repo, err := postgres.NewPostgresMetricRepo(ctx, db, logger)
+			if err != nil {
+				t.Fatalf("NewPostgresMetricRepo: %v", err)
+			}
+			return transactor, repo
+		})},
+	}
+}
+
+// newKeyring returns a keyring with a fresh random master key and index key.
+func newKeyring(t contract.T) *encryption.Keyring {
+	t.Helper()
+	masterKey, indexKey := make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		t.Fatalf("failed to generate master key: %v", err)
+	}
+	if _, err := rand.Read(indexKey); err != nil {
+		t.Fatalf("failed to generate index key: %v", err)
+	}
+	keyring, err := encryption.NewKeyring("test", map[string][]byte{"test": masterKey}, indexKey)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+// withSearchPath points every connection made with databaseUrl at schema,
+// lib/pq passes unknown url parameters on as run time settings.
+func withSearchPath(databaseUrl, schema string) string {
+	separator := "?"
+	if strings.Contains(databaseUrl, "?") {
+		separator = "&"
+	}
+	return databaseUrl + separator + "search_path=" + schema
+}
+
+func connectRedis(ctx context.Context, redisUrl string, logger *zap.Logger) (*redis.RedisCache, infra.JobQueue, error) {
+	if redisUrl == "" {
+		return nil, nil, fmt.Errorf("%w, no -redis-url", errUnavailable)
+	}
+	cache, err := redis.New(ctx, &config.Configurations{CacheAddress: redisUrl}, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	queue, err := redis.NewRedisJobQueue(cache.Client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create redis job queue: %w", err)
+	}
+	return cache, queue, nil
+}
+
+func redisSuites(cache *redis.RedisCache, queue infra.JobQueue) []suite {
+	return []suite{
+		{"redis/cache", contract.CacheCases(func(t contract.T) infra.Cache {
+			return cache
+		})},
+		{"redis/queue", contract.JobQueueCases(func(t contract.T) infra.JobQueue {
+			return queue
+		})},
+	}
+}
+
+// startMongod starts a throwaway mongod on a free port with its data in a
+// temporary directory. stop kills it and removes the data.
+func startMongod(ctx context.Context, mongod string) (string, func(), error) {
+	binary, err := exec.LookPath(mongod)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w, %s not found on PATH", errUnavailable, mongod)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	dataDir, err := os.MkdirTemp("", "conformance-mongod-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	// a single node replica set so the transactor runs real transactions
+	cmd := exec.Command(binary, "--dbpath", dataDir, "--port", fmt.Sprint(port), "--bind_ip", "127.0.0.1", "--replSet", "rs0", "--quiet")
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return "", nil, err
+	}
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dataDir)
+	}
+
+	host := fmt.Sprintf("127.0.0.1:%d", port)
+	url := fmt.Sprintf("mongodb://%s/?directConnection=true", host)
+	client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(url))
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+	defer client.Disconnect(ctx)
+
+	deadline := time.Now().Add(30 * time.Second)
+	initiated := false
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Second)
+		err := client.Ping(attemptCtx, nil)
+		if err == nil && !initiated {
+			err = initiateReplicaSet(attemptCtx, client, host)
+			initiated = err == nil
+		}
+		if err == nil {
+			err = requirePrimary(attemptCtx, client)
+		}
+		cancel()
+		if err == nil {
+			return url, stop, nil
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return "", nil, fmt.Errorf("mongod did not become ready: %w", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// initiateReplicaSet makes a fresh mongod the only member of the rs0 set.
+func initiateReplicaSet(ctx context.Context, client *mongoDriver.Client, host string) error {
+	config := bson.D{
+		{Key: "_id", Value: "rs0"},
+		{Key: "members", Value: bson.A{bson.D{{Key: "_id", Value: 0}, {Key: "host", Value: host}}}},
+	}
+	return client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetInitiate", Value: config}}).Err()
+}
+
+// requirePrimary fails until the mongod has been elected primary of its set.
+func requirePrimary(ctx context.Context, client *mongoDriver.Client) error {
+	var hello struct {
+		IsWritablePrimary bool `bson:"isWritablePrimary"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return err
+	}
+	if !hello.IsWritablePrimary {
+		return errors.New("replica set has no primary yet")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// The memory and sqlite cases always run. Mongo cases run against
+// CONFORMANCE_MONGO_URL, or a throwaway mongod from PATH when it is empty,
+// postgres and redis cases against CONFORMANCE_POSTGRES_URL and
+// CONFORMANCE_REDIS_URL. A backend that cannot run fails the test unless it
+// is listed in CONFORMANCE_SKIP, which is postgres,redis when unset like the
+// -skip flag.
+
+func TestMemory(t *testing.T) {
+	runSuites(t, memorySuites())
+}
+
+func TestSQLite(t *testing.T) {
+	runSuites(t, sqliteSuites(context.Background(), t.TempDir(), zap.NewNop()))
+}
+
+func TestMongo(t *testing.T) {
+	runBackend(t, "mongo", backendOptions{mongoUrl: os.Getenv("CONFORMANCE_MONGO_URL"), mongod: "mongod"})
+}
+
+func TestPostgres(t *testing.T) {
+	runBackend(t, "postgres", backendOptions{postgresUrl: os.Getenv("CONFORMANCE_POSTGRES_URL")})
+}
+
+func TestRedis(t *testing.T) {
+	runBackend(t, "redis", backendOptions{redisUrl: os.Getenv("CONFORMANCE_REDIS_URL")})
+}
+
+func runBackend(t *testing.T, backend string, options backendOptions) {
+	suites, stop, err := openBackend(context.Background(), backend, options, zap.NewNop())
+	if err != nil {
+		if errors.Is(err, errUnavailable) && skippedBackends()[backend] {
+			t.Skipf("%s: %v", backend, err)
+		}
+		t.Fatalf("openBackend: %v", err)
+	}
+	t.Cleanup(stop)
+	runSuites(t, suites)
+}
+
+func skippedBackends() map[string]bool {
+	skip, ok := os.LookupEnv("CONFORMANCE_SKIP")
+	if !ok {
+		skip = "postgres,redis"
+	}
+	skipped := map[string]bool{}
+	for _, backend := range strings.Split(skip, ",") {
+		skipped[strings.TrimSpace(backend)] = true
+	}
+	return skipped
+}
+
+func runSuites(t *testing.T, suites []suite) {
+	for _, s := range suites {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			for _, c := range s.cases {
+				c := c
+				t.Run(c.Name, func(t *testing.T) { c.Run(t) })
+			}
+		})
+	}
+}
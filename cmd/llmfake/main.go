@@ -0,0 +1,19 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations/llmfake"
+)
+
+func main() {
+	addr := flag.String("addr", ":3600", "address the fake llm server listens on")
+	flag.Parse()
+
+	log.Printf("fake llm server listening on %s, set LLM_BASE_URL=http://localhost%s", *addr, *addr)
+	if err := http.ListenAndServe(*addr, llmfake.New()); err != nil {
+		log.Fatal(err)
+	}
+}
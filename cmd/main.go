@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -17,62 +21,145 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	authMiddleware "github.com/olad5/AfriHacks2023-stressless-backend/internal/handlers/auth"
 	loggingMiddleware "github.com/olad5/AfriHacks2023-stressless-backend/internal/handlers/logging"
 	userHandlers "github.com/olad5/AfriHacks2023-stressless-backend/internal/handlers/users"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/mongo"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/postgres"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/redis"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/sqlite"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/achievements"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/goals"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/idempotency"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/mailer"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/notifications"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/reminders"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/safety"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/verification"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
 	"github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils/logger"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
 )
 
-func NewHttpRouter(ctx context.Context, configurations *config.Configurations, logger *zap.Logger) http.Handler {
-	opts := options.Client()
-	mongoClient, err := mongoDriver.Connect(ctx, opts.ApplyURI(configurations.DatabaseUrl))
+func NewHttpRouter(ctx context.Context, configurations *config.Configurations, logger *zap.Logger) (http.Handler, *recommendations.Worker, *reminders.Scheduler) {
+	repos, err := newRepositories(ctx, configurations, logger)
 	if err != nil {
-		log.Fatal("failed to create a mongo client: %w", err)
+		log.Fatal("Error Initializing Repositories: ", err)
 	}
-	mongoDatabase := mongoClient.Database(configurations.DatabaseName)
 
-	userRepo, err := mongo.NewMongoUserRepo(ctx, mongoDatabase, logger)
+	cache, err := newCache(ctx, configurations, logger)
 	if err != nil {
-		log.Fatal("Error Initializing User Repo", err)
+		log.Fatal("Error Initializing Cache: ", err)
 	}
 
-	redisCache, err := redis.New(ctx, configurations, logger)
+	authService, err := auth.NewRedisAuthService(ctx, cache, configurations, logger)
 	if err != nil {
-		log.Fatal("Error Initializing redisCache", err)
+		log.Fatal("Error Initializing Auth Service", err)
 	}
 
-	authService, err := auth.NewRedisAuthService(ctx, redisCache, configurations, logger)
+	scoreWeights, err := recommendations.ParseScoreWeights(configurations.ScoreWeights)
 	if err != nil {
-		log.Fatal("Error Initializing Auth Service", err)
+		log.Fatal("Error Parsing Score Weights: ", err)
+	}
+
+	catalogue, err := recommendations.LoadDefaultCatalogue()
+	if err != nil {
+		log.Fatal("Error Loading Recommendation Catalogue: ", err)
 	}
 
-	metricRepo, err := mongo.NewMongoMetricRepo(ctx, mongoDatabase, logger)
+	sentimentAnalyzer, err := sentiment.LoadDefaultAnalyzer()
 	if err != nil {
-		log.Fatal("Error Initializing Metric Repo", err)
+		log.Fatal("Error Loading Sentiment Lexicon: ", err)
 	}
 
-	recommendationRepo, err := mongo.NewMongoRecommendationRepo(ctx, mongoDatabase, logger)
+	ruleBasedService, err := recommendations.NewRuleBasedRecommendationService(scoreWeights, catalogue, sentimentAnalyzer, logger)
 	if err != nil {
-		log.Fatal("Error Initializing Recommendation Repo", err)
+		log.Fatal("Error Initializing Recommendation Service: ", err)
 	}
+	logger.Info("loaded recommendation catalogue", zap.String("version", ruleBasedService.CatalogueVersion()), zap.String("lexicon_version", sentimentAnalyzer.Version()))
 
-	stubService := &recommendations.StubRecommendationService{
-		// TODO:TODO: I dont know why this is not compiling
-		// client: &http.Client{},
-		// url:    "",
+	var recommendationService recommendations.RecommendationService = ruleBasedService
+	if configurations.RecommendationProvider == "llm" {
+		recommendationService, err = newLLMRecommendationService(configurations, ruleBasedService, logger)
+		if err != nil {
+			log.Fatal("Error Initializing LLM Recommendation Service: ", err)
+		}
 	}
-	userService, err := users.NewUserService(userRepo, authService, metricRepo, stubService, recommendationRepo, logger)
+
+	safetyModule, err := newSafetyModule(configurations, repos, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Safety Module: ", err)
+	}
+	logger.Info("loaded safety catalogue", zap.String("version", safetyModule.CatalogueVersion()))
+
+	recommendationService, err = safety.NewEscalatingRecommendationService(recommendationService, safetyModule, repos.users, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Recommendation Service: ", err)
+	}
+
+	jobQueue, err := newJobQueue(configurations, cache, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Job Queue: ", err)
+	}
+
+	recommendationWorker, err := newRecommendationWorker(configurations, jobQueue, cache, repos, recommendationService, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Recommendation Worker: ", err)
+	}
+
+	appMailer, err := newMailer(configurations, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Mailer: ", err)
+	}
+
+	tokenStore, err := verification.NewTokenStore(cache)
+	if err != nil {
+		log.Fatal("Error Initializing Token Store: ", err)
+	}
+
+	idempotencyStore, err := idempotency.NewStore(cache, 24*time.Hour)
+	if err != nil {
+		log.Fatal("Error Initializing Idempotency Store: ", err)
+	}
+
+	achievementEngine, err := achievements.NewEngine(repos.metrics, repos.achievements, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Achievements Engine: ", err)
+	}
+
+	goalEvaluator, err := goals.NewEvaluator(repos.metrics, repos.goals, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Goals Evaluator: ", err)
+	}
+
+	userService, err := users.NewUserService(repos.users, authService, repos.metrics, recommendationService, sentimentAnalyzer, safetyModule, repos.recommendations, repos.transactor, jobQueue, repos.achievements, achievementEngine, repos.goals, goalEvaluator, repos.journal, repos.safety, repos.shares, appMailer, tokenStore, idempotencyStore, configurations.AppBaseUrl, logger)
 	if err != nil {
 		log.Fatal("Error Initializing UserService")
 	}
 
-	userHandler, err := userHandlers.NewUserHandler(*userService, authService, logger)
+	notifier, webPushEnabled, err := newNotifier(configurations, repos.users, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Notifier: ", err)
+	}
+
+	reminderScheduler, err := newReminderScheduler(configurations, repos.users, cache, notifier, logger)
+	if err != nil {
+		log.Fatal("Error Initializing Reminder Scheduler: ", err)
+	}
+
+	vapidPublicKey := ""
+	if webPushEnabled {
+		vapidPublicKey = configurations.VapidPublicKey
+	}
+
+	userHandler, err := userHandlers.NewUserHandler(*userService, authService, vapidPublicKey, logger)
 	if err != nil {
 		log.Fatal("failed to create the User handler: ", err)
 	}
@@ -89,7 +176,13 @@ func NewHttpRouter(ctx context.Context, configurations *config.Configurations, l
 			middleware.SetHeader("Content-Type", "application/json"),
 		)
 		r.Post("/users/login", userHandler.Login)
+		r.Post("/users/token/refresh", userHandler.RefreshToken)
+		r.Post("/users/verify-email", userHandler.VerifyEmail)
+		r.Post("/users/password/forgot", userHandler.ForgotPassword)
+		r.Post("/users/password/reset", userHandler.ResetPassword)
 		r.Post("/users", userHandler.CreateUser)
+		r.Get("/push/vapid-public-key", userHandler.GetVapidPublicKey)
+		r.Post("/shares/accept", userHandler.AcceptShare)
 	})
 
 	// -------------------------------------------------------------------------
@@ -102,7 +195,27 @@ func NewHttpRouter(ctx context.Context, configurations *config.Configurations, l
 		r.Use(authMiddleware.EnsureAuthenticated(authService))
 
 		r.Get("/users/me", userHandler.GetLoggedInUser)
+		r.Delete("/users/me", userHandler.DeleteLoggedInUser)
+		r.Get("/users/me/export", userHandler.ExportUserData)
+		r.Post("/users/logout", userHandler.Logout)
+		r.Post("/users/verify-email/resend", userHandler.ResendVerificationEmail)
+		r.Get("/users/me/sessions", userHandler.GetSessions)
+		r.Delete("/users/me/sessions/{id}", userHandler.RevokeSession)
 		r.Patch("/users/onboarding", userHandler.CompleteOnboarding)
+		r.Get("/users/me/achievements", userHandler.GetAchievements)
+		r.Get("/users/me/goals", userHandler.GetGoals)
+		r.Post("/users/me/goals", userHandler.CreateGoal)
+		r.Get("/users/me/goals/completions", userHandler.GetGoalCompletions)
+		r.Get("/users/me/goals/{id}", userHandler.GetGoal)
+		r.Patch("/users/me/goals/{id}", userHandler.UpdateGoal)
+		r.Delete("/users/me/goals/{id}", userHandler.DeleteGoal)
+		r.Get("/users/me/reminders", userHandler.GetReminderPreferences)
+		r.Put("/users/me/reminders", userHandler.UpdateReminderPreferences)
+		r.Post("/users/me/push-subscriptions", userHandler.AddPushSubscription)
+		r.Delete("/users/me/push-subscriptions", userHandler.RemovePushSubscription)
+		r.Get("/users/me/shares", userHandler.GetShares)
+		r.Post("/users/me/shares", userHandler.CreateShare)
+		r.Delete("/users/me/shares/{id}", userHandler.RevokeShare)
 	})
 
 	router.Group(func(r chi.Router) {
@@ -112,16 +225,415 @@ func NewHttpRouter(ctx context.Context, configurations *config.Configurations, l
 		)
 		r.Use(authMiddleware.EnsureAuthenticated(authService))
 
-		r.Get("/metrics/{id}", userHandler.GetMetricByMetricId)
 		r.Get("/metrics/today/", userHandler.GetMetricForToday)
-		r.Get("/metrics/stats/stress_less_scores", userHandler.GetRecentStresslessScores)
-		r.Get("/metrics/stats/moods", userHandler.GetRecentMoods)
-		r.Get("/metrics/stats/sleep_quality_scores", userHandler.GetRecentSleepQualityStats)
 		r.Get("/metrics/recommendations/{id}", userHandler.GetRecommendationByMetricId)
 		r.Post("/metrics", userHandler.CreateDailyLog)
+		r.Patch("/metrics/{id}", userHandler.UpdateDailyLog)
+		r.Delete("/metrics/{id}", userHandler.DeleteDailyLog)
 	})
 
-	return router
+	// the owner's session or the delegated token of a share granting the scope
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middleware.AllowContentType("application/json"),
+			middleware.SetHeader("Content-Type", "application/json"),
+		)
+		stats := authMiddleware.EnsureAuthenticatedOrShared(authService, userService, domain.StatsShareScope)
+		logs := authMiddleware.EnsureAuthenticatedOrShared(authService, userService, domain.LogsShareScope)
+
+		r.With(logs).Get("/metrics", userHandler.GetMetrics)
+		r.With(logs).Get("/metrics/{id}", userHandler.GetMetricByMetricId)
+		r.With(stats).Get("/metrics/stats/stress_less_scores", userHandler.GetRecentStresslessScores)
+		r.With(stats).Get("/metrics/stats/moods", userHandler.GetRecentMoods)
+		r.With(stats).Get("/metrics/stats/sleep_quality_scores", userHandler.GetRecentSleepQualityStats)
+		r.With(stats).Get("/metrics/stats/summary", userHandler.GetStatsSummary)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middleware.AllowContentType("application/json"),
+			middleware.SetHeader("Content-Type", "application/json"),
+		)
+		r.Use(authMiddleware.EnsureAuthenticated(authService))
+
+		r.Get("/journal", userHandler.GetJournalEntries)
+		r.Post("/journal", userHandler.CreateJournalEntry)
+		r.Get("/journal/{id}", userHandler.GetJournalEntry)
+		r.Patch("/journal/{id}", userHandler.UpdateJournalEntry)
+		r.Delete("/journal/{id}", userHandler.DeleteJournalEntry)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middleware.AllowContentType("application/json"),
+			middleware.SetHeader("Content-Type", "application/json"),
+		)
+		r.Use(authMiddleware.EnsureAuthenticated(authService))
+
+		r.Post("/clinician/shares/accept", userHandler.AcceptShareAsClinician)
+		r.Get("/clinician/users/{id}/safety", userHandler.GetUserSafety)
+		r.Put("/clinician/users/{id}/safety/override", userHandler.SetSafetyOverride)
+		r.Delete("/clinician/users/{id}/safety/override", userHandler.RemoveSafetyOverride)
+	})
+
+	return router, recommendationWorker, reminderScheduler
+}
+
+type repositories struct {
+	users           infra.UserRepository
+	metrics         infra.MetricRepository
+	recommendations infra.RecommendationRepository
+	transactor      infra.Transactor
+	achievements    infra.AchievementRepository
+	goals           infra.GoalRepository
+	journal         infra.JournalRepository
+	safety          infra.SafetyRepository
+	shares          infra.ShareRepository
+}
+
+func newRepositories(ctx context.Context, configurations *config.Configurations, logger *zap.Logger) (repositories, error) {
+	var keyring *encryption.Keyring
+	if configurations.DatabaseDriver != "memory" {
+		var err error
+		keyring, err = encryption.ParseKeyring(configurations.EncryptionKeys, configurations.EncryptionIndexKey)
+		if err != nil {
+			return repositories{}, fmt.Errorf("invalid ENCRYPTION_KEYS or ENCRYPTION_INDEX_KEY: %w", err)
+		}
+	}
+
+	switch configurations.DatabaseDriver {
+	case "memory":
+		logger.Warn("using in-memory repositories, data will be lost on restart")
+		return repositories{memory.NewMemoryUserRepo(), memory.NewMemoryMetricRepo(), memory.NewMemoryRecommendationRepo(), memory.NewMemoryTransactor(), memory.NewMemoryAchievementRepo(), memory.NewMemoryGoalRepo(), memory.NewMemoryJournalRepo(), memory.NewMemorySafetyRepo(), memory.NewMemoryShareRepo()}, nil
+	case "", "mongo":
+		opts := options.Client()
+		mongoClient, err := mongoDriver.Connect(ctx, opts.ApplyURI(configurations.DatabaseUrl))
+		if err != nil {
+			return repositories{}, fmt.Errorf("failed to create a mongo client: %w", err)
+		}
+		mongoDatabase := mongoClient.Database(configurations.DatabaseName)
+
+		if configurations.MigrateOnStartup != "" {
+			migrateOnStartup, err := strconv.ParseBool(configurations.MigrateOnStartup)
+			if err != nil {
+				return repositories{}, fmt.Errorf("invalid MIGRATE_ON_STARTUP: %w", err)
+			}
+			if migrateOnStartup {
+				if err := mongo.Migrate(ctx, mongoDatabase, logger); err != nil {
+					return repositories{}, fmt.Errorf("failed to migrate mongo: %w", err)
+				}
+			}
+		}
+
+		userRepo, err := mongo.NewMongoUserRepo(ctx, mongoDatabase, keyring, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		metricRepo, err := mongo.NewMongoMetricRepo(ctx, mongoDatabase, keyring, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		recommendationRepo, err := mongo.NewMongoRecommendationRepo(ctx, mongoDatabase, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		transactor, err := mongo.NewMongoTransactor(ctx, mongoClient, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		achievementRepo, err := mongo.NewMongoAchievementRepo(ctx, mongoDatabase, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		goalRepo, err := mongo.NewMongoGoalRepo(ctx, mongoDatabase, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		journalRepo, err := mongo.NewMongoJournalRepo(ctx, mongoDatabase, keyring, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		safetyRepo, err := mongo.NewMongoSafetyRepo(ctx, mongoDatabase, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		shareRepo, err := mongo.NewMongoShareRepo(ctx, mongoDatabase, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{userRepo, metricRepo, recommendationRepo, transactor, achievementRepo, goalRepo, journalRepo, safetyRepo, shareRepo}, nil
+	case "postgres":
+		db, err := postgres.Open(ctx, configurations.DatabaseUrl)
+		if err != nil {
+			return repositories{}, err
+		}
+		if err := postgres.Migrate(ctx, db); err != nil {
+			return repositories{}, fmt.Errorf("failed to migrate postgres: %w", err)
+		}
+
+		userRepo, err := postgres.NewPostgresUserRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		metricRepo, err := postgres.NewPostgresMetricRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		recommendationRepo, err := postgres.NewPostgresRecommendationRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		transactor, err := postgres.NewPostgresTransactor(db)
+		if err != nil {
+			return repositories{}, err
+		}
+		achievementRepo, err := postgres.NewPostgresAchievementRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		goalRepo, err := postgres.NewPostgresGoalRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		journalRepo, err := postgres.NewPostgresJournalRepo(ctx, db, keyring, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		safetyRepo, err := postgres.NewPostgresSafetyRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		shareRepo, err := postgres.NewPostgresShareRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{userRepo, metricRepo, recommendationRepo, transactor, achievementRepo, goalRepo, journalRepo, safetyRepo, shareRepo}, nil
+	case "sqlite":
+		db, err := sqlite.Open(ctx, configurations.SQLitePath)
+		if err != nil {
+			return repositories{}, err
+		}
+
+		userRepo, err := sqlite.NewSQLiteUserRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		metricRepo, err := sqlite.NewSQLiteMetricRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		recommendationRepo, err := sqlite.NewSQLiteRecommendationRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		transactor, err := sqlite.NewSQLiteTransactor(db)
+		if err != nil {
+			return repositories{}, err
+		}
+		achievementRepo, err := sqlite.NewSQLiteAchievementRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		goalRepo, err := sqlite.NewSQLiteGoalRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		journalRepo, err := sqlite.NewSQLiteJournalRepo(ctx, db, keyring, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		safetyRepo, err := sqlite.NewSQLiteSafetyRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		shareRepo, err := sqlite.NewSQLiteShareRepo(ctx, db, logger)
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{userRepo, metricRepo, recommendationRepo, transactor, achievementRepo, goalRepo, journalRepo, safetyRepo, shareRepo}, nil
+	default:
+		return repositories{}, fmt.Errorf("unknown DATABASE_DRIVER %q", configurations.DatabaseDriver)
+	}
+}
+
+func newCache(ctx context.Context, configurations *config.Configurations, logger *zap.Logger) (infra.Cache, error) {
+	cacheDriver := configurations.CacheDriver
+	if cacheDriver == "" && configurations.DatabaseDriver == "sqlite" {
+		cacheDriver = "sqlite"
+	}
+
+	switch cacheDriver {
+	case "memory":
+		logger.Warn("using in-memory cache, sessions will be lost on restart")
+		return memory.NewMemoryCache(), nil
+	case "sqlite":
+		db, err := sqlite.Open(ctx, configurations.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return sqlite.NewSQLiteCache(ctx, db, logger)
+	case "", "redis":
+		return redis.New(ctx, configurations, logger)
+	default:
+		return nil, fmt.Errorf("unknown CACHE_DRIVER %q", configurations.CacheDriver)
+	}
+}
+
+func newJobQueue(configurations *config.Configurations, cache infra.Cache, logger *zap.Logger) (infra.JobQueue, error) {
+	redisCache, isRedis := cache.(*redis.RedisCache)
+
+	switch configurations.JobQueueDriver {
+	case "memory":
+		return memory.NewMemoryJobQueue(), nil
+	case "redis":
+		if !isRedis {
+			return nil, errors.New("JOB_QUEUE_DRIVER redis needs the redis cache")
+		}
+		return redis.NewRedisJobQueue(redisCache.Client)
+	case "":
+		// jobs are shared through redis when it is there already
+		if isRedis {
+			return redis.NewRedisJobQueue(redisCache.Client)
+		}
+		logger.Warn("using in-memory job queue, queued jobs will be lost on restart")
+		return memory.NewMemoryJobQueue(), nil
+	default:
+		return nil, fmt.Errorf("unknown JOB_QUEUE_DRIVER %q", configurations.JobQueueDriver)
+	}
+}
+
+func newRecommendationWorker(configurations *config.Configurations, jobQueue infra.JobQueue, cache infra.Cache, repos repositories, recommendationService recommendations.RecommendationService, logger *zap.Logger) (*recommendations.Worker, error) {
+	options := recommendations.WorkerOptions{}
+	if configurations.RecommendationWorkers != "" {
+		workers, err := strconv.Atoi(configurations.RecommendationWorkers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RECOMMENDATION_WORKERS: %w", err)
+		}
+		options.Concurrency = workers
+	}
+	// the lock on recovering pending jobs is shared with the instances that
+	// share the queue, every start recovers a queue kept in process.
+	lockCache := cache
+	if _, isMemory := jobQueue.(*memory.MemoryJobQueue); isMemory {
+		lockCache = memory.NewMemoryCache()
+	}
+	return recommendations.NewWorker(jobQueue, lockCache, repos.metrics, repos.recommendations, recommendationService, options, logger)
+}
+
+// newNotifier builds the notifiers listed in NOTIFIERS and reports whether web
+// push is one of them.
+func newNotifier(configurations *config.Configurations, userRepo infra.UserRepository, logger *zap.Logger) (notifications.Notifier, bool, error) {
+	names := configurations.Notifiers
+	if names == "" {
+		names = "log"
+	}
+
+	notifiers := notifications.MultiNotifier{}
+	webPushEnabled := false
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "log":
+			notifiers = append(notifiers, notifications.NewLogNotifier(logger))
+		case "webhook":
+			notifier, err := notifications.NewWebhookNotifier(configurations.NotifyWebhookUrl, configurations.NotifyWebhookSecret)
+			if err != nil {
+				return nil, false, err
+			}
+			notifiers = append(notifiers, notifier)
+		case "webpush":
+			notifier, err := notifications.NewWebPushNotifier(configurations.VapidPublicKey, configurations.VapidPrivateKey, configurations.VapidSubject, userRepo, logger)
+			if err != nil {
+				return nil, false, err
+			}
+			notifiers = append(notifiers, notifier)
+			webPushEnabled = true
+		default:
+			return nil, false, fmt.Errorf("unknown notifier %q in NOTIFIERS", name)
+		}
+	}
+	return notifiers, webPushEnabled, nil
+}
+
+func newReminderScheduler(configurations *config.Configurations, userRepo infra.UserRepository, cache infra.Cache, notifier notifications.Notifier, logger *zap.Logger) (*reminders.Scheduler, error) {
+	options := reminders.SchedulerOptions{AppBaseUrl: configurations.AppBaseUrl}
+	if configurations.ReminderInterval != "" {
+		interval, err := time.ParseDuration(configurations.ReminderInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REMINDER_INTERVAL: %w", err)
+		}
+		options.Interval = interval
+	}
+	return reminders.NewScheduler(userRepo, cache, notifier, options, logger)
+}
+
+func newMailer(configurations *config.Configurations, logger *zap.Logger) (mailer.Mailer, error) {
+	switch configurations.MailerDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(configurations.SMTPHost, configurations.SMTPPort, configurations.SMTPUsername, configurations.SMTPPassword, configurations.MailFrom)
+	case "log":
+		return mailer.NewLogMailer(configurations.MailLogFile, logger), nil
+	case "":
+		// emails carry live tokens, so never fall back to a mailer that keeps them
+		return nil, errors.New("MAILER_DRIVER must be set to smtp or log")
+	default:
+		return nil, fmt.Errorf("unknown MAILER_DRIVER %q", configurations.MailerDriver)
+	}
+}
+
+func newLLMRecommendationService(configurations *config.Configurations, fallback recommendations.RecommendationService, logger *zap.Logger) (*recommendations.LLMRecommendationService, error) {
+	options := recommendations.LLMOptions{
+		BaseUrl:    configurations.LLMBaseUrl,
+		ApiKey:     configurations.LLMApiKey,
+		Model:      configurations.LLMModel,
+		MaxRetries: 2,
+	}
+	if configurations.LLMTimeout != "" {
+		timeout, err := time.ParseDuration(configurations.LLMTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_TIMEOUT: %w", err)
+		}
+		options.Timeout = timeout
+	}
+	if configurations.LLMMaxRetries != "" {
+		maxRetries, err := strconv.Atoi(configurations.LLMMaxRetries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_MAX_RETRIES: %w", err)
+		}
+		options.MaxRetries = maxRetries
+	}
+	return recommendations.NewLLMRecommendationService(options, fallback, logger)
+}
+
+// newSafetyModule uses the embedded crisis resources unless
+// SAFETY_CATALOGUE_PATH points at a catalogue of your own.
+func newSafetyModule(configurations *config.Configurations, repos repositories, logger *zap.Logger) (*safety.Module, error) {
+	thresholds, err := safety.ParseThresholds(configurations.SafetyThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAFETY_THRESHOLDS: %w", err)
+	}
+
+	var catalogue *safety.Catalogue
+	if configurations.SafetyCataloguePath != "" {
+		content, err := os.ReadFile(configurations.SafetyCataloguePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SAFETY_CATALOGUE_PATH: %w", err)
+		}
+		catalogue, err = safety.LoadCatalogue(content)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		catalogue, err = safety.LoadDefaultCatalogue()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	options := safety.Options{Thresholds: thresholds}
+	if configurations.ClinicianEmails != "" {
+		options.ClinicianEmails = strings.Split(configurations.ClinicianEmails, ",")
+	}
+	return safety.NewModule(catalogue, options, repos.metrics, repos.safety, logger)
 }
 
 func main() {
@@ -129,7 +641,11 @@ func main() {
 	ctx := context.Background()
 
 	l := logger.Get(configurations)
-	appRouter := NewHttpRouter(ctx, configurations, l)
+	appRouter, recommendationWorker, reminderScheduler := NewHttpRouter(ctx, configurations, l)
+	if err := recommendationWorker.Start(ctx); err != nil {
+		log.Fatal("Error Starting Recommendation Worker: ", err)
+	}
+	reminderScheduler.Start(ctx)
 
 	port := configurations.Port
 
@@ -154,6 +670,14 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		fmt.Printf("Server forced to shutdown: %v", err)
 	}
+	if err := reminderScheduler.Shutdown(ctx); err != nil {
+		fmt.Printf("Reminder scheduler forced to shutdown: %v", err)
+	}
+	// the server is drained first so no new jobs are enqueued while the
+	// workers finish theirs
+	if err := recommendationWorker.Shutdown(ctx); err != nil {
+		fmt.Printf("Recommendation worker forced to shutdown: %v", err)
+	}
 
 	fmt.Println("Server exiting gracefully")
 }
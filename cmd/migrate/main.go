@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+	_ "time/tzdata"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/mongo"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/postgres"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/sqlite"
+	"github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils/logger"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	envFile := flag.String("env", ".env", "env file to read the database settings from")
+	status := flag.Bool("status", false, "list applied and pending migrations instead of applying them")
+	flag.Parse()
+
+	configurations := config.GetConfig(*envFile)
+	l := logger.Get(configurations)
+	ctx := context.Background()
+
+	switch configurations.DatabaseDriver {
+	case "", "mongo":
+		client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(configurations.DatabaseUrl))
+		if err != nil {
+			log.Fatalf("failed to create a mongo client: %v", err)
+		}
+		defer client.Disconnect(ctx)
+		db := client.Database(configurations.DatabaseName)
+
+		if *status {
+			printMongoStatus(ctx, db)
+			return
+		}
+		if err := mongo.Migrate(ctx, db, l); err != nil {
+			log.Fatalf("failed to migrate mongo: %v", err)
+		}
+	case "postgres":
+		db, err := postgres.Open(ctx, configurations.DatabaseUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		if err := postgres.Migrate(ctx, db); err != nil {
+			log.Fatalf("failed to migrate postgres: %v", err)
+		}
+	case "sqlite":
+		// Open applies the migrations itself.
+		db, err := sqlite.Open(ctx, configurations.SQLitePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+	default:
+		log.Fatalf("nothing to migrate for DATABASE_DRIVER %q", configurations.DatabaseDriver)
+	}
+	l.Info("migrations are up to date", zap.String("driver", configurations.DatabaseDriver))
+}
+
+func printMongoStatus(ctx context.Context, db *mongoDriver.Database) {
+	applied, err := mongo.AppliedMigrations(ctx, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pending, err := mongo.PendingMigrations(ctx, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, migration := range applied {
+		fmt.Printf("applied  %04d  %s  %s\n", migration.Version, migration.AppliedAt.Format(time.RFC3339), migration.Description)
+	}
+	for _, migration := range pending {
+		fmt.Printf("pending  %04d  %s\n", migration.Version, migration.Description)
+	}
+}
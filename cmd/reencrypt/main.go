@@ -0,0 +1,92 @@
+// Command reencrypt brings every encrypted document under the current master
+// key, the first one in ENCRYPTION_KEYS. Run it after adding a new key in
+// front of the list and before removing the old one.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/mongo"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/postgres"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/sqlite"
+	"github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils/logger"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	envFile := flag.String("env", ".env", "env file to read the database settings and keys from")
+	flag.Parse()
+
+	configurations := config.GetConfig(*envFile)
+	l := logger.Get(configurations)
+	ctx := context.Background()
+
+	keyring, err := encryption.ParseKeyring(configurations.EncryptionKeys, configurations.EncryptionIndexKey)
+	if err != nil {
+		log.Fatalf("invalid ENCRYPTION_KEYS or ENCRYPTION_INDEX_KEY: %v", err)
+	}
+
+	var changed map[string]int64
+	switch configurations.DatabaseDriver {
+	case "", "mongo":
+		client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(configurations.DatabaseUrl))
+		if err != nil {
+			log.Fatalf("failed to create a mongo client: %v", err)
+		}
+		defer client.Disconnect(ctx)
+		changed, err = mongo.Reencrypt(ctx, client.Database(configurations.DatabaseName), keyring, l)
+		if err != nil {
+			printChanged(changed)
+			log.Fatalf("failed to reencrypt mongo: %v", err)
+		}
+	case "postgres":
+		db, err := postgres.Open(ctx, configurations.DatabaseUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		if err := postgres.Migrate(ctx, db); err != nil {
+			log.Fatalf("failed to migrate postgres: %v", err)
+		}
+		changed, err = postgres.Reencrypt(ctx, db, keyring)
+		if err != nil {
+			printChanged(changed)
+			log.Fatalf("failed to reencrypt postgres: %v", err)
+		}
+	case "sqlite":
+		db, err := sqlite.Open(ctx, configurations.SQLitePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		changed, err = sqlite.Reencrypt(ctx, db, keyring)
+		if err != nil {
+			printChanged(changed)
+			log.Fatalf("failed to reencrypt sqlite: %v", err)
+		}
+	default:
+		log.Fatalf("nothing to reencrypt for DATABASE_DRIVER %q", configurations.DatabaseDriver)
+	}
+	printChanged(changed)
+	l.Info("everything is encrypted under the current key", zap.String("driver", configurations.DatabaseDriver), zap.String("key_id", keyring.CurrentKeyId()))
+}
+
+func printChanged(changed map[string]int64) {
+	collections := make([]string, 0, len(changed))
+	for collection := range changed {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	for _, collection := range collections {
+		fmt.Printf("%-16s %d reencrypted\n", collection, changed[collection])
+	}
+}
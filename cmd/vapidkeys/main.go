@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/notifications"
+)
+
+func main() {
+	publicKey, privateKey, err := notifications.GenerateVapidKeys()
+	if err != nil {
+		log.Fatalf("failed to generate vapid keys: %v", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", publicKey, privateKey)
+}
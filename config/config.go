@@ -8,12 +8,55 @@ import (
 )
 
 type Configurations struct {
+	DatabaseDriver string
+	CacheDriver    string
+	JobQueueDriver string
+	SQLitePath     string
+
+	MigrateOnStartup string
+
 	DatabaseUrl  string
 	DatabaseName string
 	Port         string
 	JwtSecretKey string
 	CacheAddress string
 	LogLevel     string
+	ScoreWeights string
+
+	EncryptionKeys     string
+	EncryptionIndexKey string
+
+	AccessTokenTTL  string
+	RefreshTokenTTL string
+
+	AppBaseUrl   string
+	MailerDriver string
+	MailFrom     string
+	MailLogFile  string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	RecommendationProvider string
+	LLMBaseUrl             string
+	LLMApiKey              string
+	LLMModel               string
+	LLMTimeout             string
+	LLMMaxRetries          string
+	RecommendationWorkers  string
+
+	Notifiers           string
+	NotifyWebhookUrl    string
+	NotifyWebhookSecret string
+	VapidPublicKey      string
+	VapidPrivateKey     string
+	VapidSubject        string
+	ReminderInterval    string
+
+	SafetyThresholds    string
+	SafetyCataloguePath string
+	ClinicianEmails     string
 }
 
 func GetConfig(filepath string) *Configurations {
@@ -23,12 +66,55 @@ func GetConfig(filepath string) *Configurations {
 	}
 
 	configurations := Configurations{
+		DatabaseDriver: os.Getenv("DATABASE_DRIVER"),
+		CacheDriver:    os.Getenv("CACHE_DRIVER"),
+		JobQueueDriver: os.Getenv("JOB_QUEUE_DRIVER"),
+		SQLitePath:     os.Getenv("SQLITE_PATH"),
+
+		MigrateOnStartup: os.Getenv("MIGRATE_ON_STARTUP"),
+
 		DatabaseUrl:  os.Getenv("DATABASE_URL"),
 		DatabaseName: os.Getenv("DATABASE_NAME"),
 		Port:         os.Getenv("PORT"),
 		JwtSecretKey: os.Getenv("SECRET_KEY"),
 		CacheAddress: os.Getenv("REDIS_URL"),
 		LogLevel:     os.Getenv("LOG_LEVEL"),
+		ScoreWeights: os.Getenv("SCORE_WEIGHTS"),
+
+		EncryptionKeys:     os.Getenv("ENCRYPTION_KEYS"),
+		EncryptionIndexKey: os.Getenv("ENCRYPTION_INDEX_KEY"),
+
+		AccessTokenTTL:  os.Getenv("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL: os.Getenv("REFRESH_TOKEN_TTL"),
+
+		AppBaseUrl:   os.Getenv("APP_BASE_URL"),
+		MailerDriver: os.Getenv("MAILER_DRIVER"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+		MailLogFile:  os.Getenv("MAIL_LOG_FILE"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		RecommendationProvider: os.Getenv("RECOMMENDATION_PROVIDER"),
+		LLMBaseUrl:             os.Getenv("LLM_BASE_URL"),
+		LLMApiKey:              os.Getenv("LLM_API_KEY"),
+		LLMModel:               os.Getenv("LLM_MODEL"),
+		LLMTimeout:             os.Getenv("LLM_TIMEOUT"),
+		LLMMaxRetries:          os.Getenv("LLM_MAX_RETRIES"),
+		RecommendationWorkers:  os.Getenv("RECOMMENDATION_WORKERS"),
+
+		Notifiers:           os.Getenv("NOTIFIERS"),
+		NotifyWebhookUrl:    os.Getenv("NOTIFY_WEBHOOK_URL"),
+		NotifyWebhookSecret: os.Getenv("NOTIFY_WEBHOOK_SECRET"),
+		VapidPublicKey:      os.Getenv("VAPID_PUBLIC_KEY"),
+		VapidPrivateKey:     os.Getenv("VAPID_PRIVATE_KEY"),
+		VapidSubject:        os.Getenv("VAPID_SUBJECT"),
+		ReminderInterval:    os.Getenv("REMINDER_INTERVAL"),
+
+		SafetyThresholds:    os.Getenv("SAFETY_THRESHOLDS"),
+		SafetyCataloguePath: os.Getenv("SAFETY_CATALOGUE_PATH"),
+		ClinicianEmails:     os.Getenv("CLINICIAN_EMAILS"),
 	}
 
 	return &configurations
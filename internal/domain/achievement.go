@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BadgeId string
+
+const (
+	FirstLogBadge            BadgeId = "first_log"
+	ThreeDayStreakBadge      BadgeId = "streak_3"
+	SevenDayStreakBadge      BadgeId = "streak_7"
+	ThirtyDayStreakBadge     BadgeId = "streak_30"
+	ThirtyLogsBadge          BadgeId = "logs_30"
+	FirstExcellentSleepBadge BadgeId = "first_excellent_sleep"
+	FirstOverjoyedMoodBadge  BadgeId = "first_overjoyed_mood"
+	CalmDayBadge             BadgeId = "calm_day"
+)
+
+type Badge struct {
+	ID          BadgeId
+	Name        string
+	Description string
+}
+
+// Badges lists every badge a user can earn, in the order they are shown.
+var Badges = []Badge{
+	{FirstLogBadge, "First check-in", "Logged your first day"},
+	{ThreeDayStreakBadge, "3-day streak", "Logged three days in a row"},
+	{SevenDayStreakBadge, "7-day streak", "Logged seven days in a row"},
+	{ThirtyDayStreakBadge, "30-day streak", "Logged thirty days in a row"},
+	{ThirtyLogsBadge, "30 check-ins", "Logged thirty days in total"},
+	{FirstExcellentSleepBadge, "First excellent sleep", "Logged a night of excellent sleep"},
+	{FirstOverjoyedMoodBadge, "Overjoyed", "Logged an overjoyed mood"},
+	{CalmDayBadge, "Calm day", "Logged a day with a stress level of 1"},
+}
+
+func FindBadge(id BadgeId) (Badge, bool) {
+	for _, badge := range Badges {
+		if badge.ID == id {
+			return badge, true
+		}
+	}
+	return Badge{}, false
+}
+
+// Achievement is a badge a user earned, MetricId is the log that earned it. A
+// user earns each badge at most once.
+type Achievement struct {
+	ID        primitive.ObjectID
+	UserId    primitive.ObjectID
+	Badge     BadgeId
+	MetricId  primitive.ObjectID
+	AwardedAt time.Time
+}
+
+// Streak summarises a user's logging history, counted in days of their own
+// timezone.
+type Streak struct {
+	// Current is how many days in a row the user logged up to today, or up to
+	// yesterday while today is not logged yet.
+	Current int
+	Longest int
+	// MissedDays are the days without a log since the first one, today only
+	// counts once it is logged.
+	MissedDays  int
+	TotalDays   int
+	LastLogDate string
+}
+
+// CalculateStreak works out the streak from the local dates a user logged on,
+// in any order and possibly repeated, as of the local date today. Dates after
+// today, possible after a timezone change, count as today.
+func CalculateStreak(logDates []string, today string) Streak {
+	todayDay, err := time.Parse(localDateLayout, today)
+	if err != nil {
+		return Streak{}
+	}
+
+	seen := map[string]bool{}
+	days := []time.Time{}
+	for _, date := range logDates {
+		day, err := time.Parse(localDateLayout, date)
+		if err != nil {
+			continue
+		}
+		if day.After(todayDay) {
+			day = todayDay
+		}
+		if key := day.Format(localDateLayout); !seen[key] {
+			seen[key] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return Streak{}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	streak := Streak{TotalDays: len(days), LastLogDate: days[len(days)-1].Format(localDateLayout)}
+	run := 0
+	for i, day := range days {
+		if i > 0 && daysBetween(days[i-1], day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+	}
+
+	last := days[len(days)-1]
+	if daysBetween(last, todayDay) <= 1 {
+		streak.Current = run
+	}
+
+	end := todayDay
+	if !last.Equal(todayDay) {
+		end = todayDay.AddDate(0, 0, -1)
+	}
+	streak.MissedDays = daysBetween(days[0], end) + 1 - len(days)
+	return streak
+}
+
+// daysBetween counts calendar days from a to b, both dates at midnight UTC.
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,78 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+func TestCalculateStreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		dates []string
+		today string
+		want  domain.Streak
+	}{
+		{"no logs", []string{}, "2023-11-20", domain.Streak{}},
+		{"invalid today", []string{"2023-11-20"}, "20/11/2023", domain.Streak{}},
+		{"invalid dates are ignored", []string{"yesterday", "2023-11-20"}, "2023-11-20",
+			domain.Streak{Current: 1, Longest: 1, TotalDays: 1, LastLogDate: "2023-11-20"}},
+		{"logged today", []string{"2023-11-20"}, "2023-11-20",
+			domain.Streak{Current: 1, Longest: 1, TotalDays: 1, LastLogDate: "2023-11-20"}},
+		{"logged yesterday keeps the streak until today ends", []string{"2023-11-18", "2023-11-19"}, "2023-11-20",
+			domain.Streak{Current: 2, Longest: 2, TotalDays: 2, LastLogDate: "2023-11-19"}},
+		{"a missed day breaks the streak", []string{"2023-11-17", "2023-11-18"}, "2023-11-20",
+			domain.Streak{Current: 0, Longest: 2, MissedDays: 1, TotalDays: 2, LastLogDate: "2023-11-18"}},
+		{"gap in the middle", []string{"2023-11-14", "2023-11-15", "2023-11-16", "2023-11-19", "2023-11-20"}, "2023-11-20",
+			domain.Streak{Current: 2, Longest: 3, MissedDays: 2, TotalDays: 5, LastLogDate: "2023-11-20"}},
+		{"repeated and unordered dates", []string{"2023-11-20", "2023-11-18", "2023-11-19", "2023-11-20", "2023-11-18"}, "2023-11-20",
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-11-20"}},
+		{"dates after today count as today", []string{"2023-11-19", "2023-11-21"}, "2023-11-20",
+			domain.Streak{Current: 2, Longest: 2, TotalDays: 2, LastLogDate: "2023-11-20"}},
+		{"across a month and a year", []string{"2023-12-30", "2023-12-31", "2024-01-01"}, "2024-01-01",
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2024-01-01"}},
+		{"across a leap day", []string{"2024-02-28", "2024-02-29", "2024-03-01"}, "2024-03-01",
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2024-03-01"}},
+		{"across the start of daylight saving time", []string{"2023-03-11", "2023-03-12", "2023-03-13"}, "2023-03-13",
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-03-13"}},
+		{"across the end of daylight saving time", []string{"2023-11-04", "2023-11-05", "2023-11-06"}, "2023-11-06",
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-11-06"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.CalculateStreak(tt.dates, tt.today); got != tt.want {
+				t.Errorf("CalculateStreak(%v, %s): got %+v, want %+v", tt.dates, tt.today, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalDate(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		location *time.Location
+		want     string
+	}{
+		{"utc", time.Date(2023, 11, 20, 23, 30, 0, 0, time.UTC), time.UTC, "2023-11-20"},
+		{"ahead of utc", time.Date(2023, 11, 20, 23, 30, 0, 0, time.UTC), lagos, "2023-11-21"},
+		{"behind utc", time.Date(2023, 11, 20, 3, 30, 0, 0, time.UTC), newYork, "2023-11-19"},
+		{"behind utc in daylight saving time", time.Date(2023, 7, 20, 3, 30, 0, 0, time.UTC), newYork, "2023-07-19"},
+		{"after daylight saving time ended", time.Date(2023, 11, 6, 4, 30, 0, 0, time.UTC), newYork, "2023-11-05"},
+	}
+	for _, tt := range tests {
+		if got := domain.LocalDate(tt.at, tt.location); got != tt.want {
+			t.Errorf("%s: LocalDate(%v, %s): got %s, want %s", tt.name, tt.at, tt.location, got, tt.want)
+		}
+	}
+}
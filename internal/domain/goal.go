@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GoalKind string
+
+const (
+	// AverageScoreGoal is met when the average StressLessScore of a period is
+	// at least Target.
+	AverageScoreGoal GoalKind = "average_stressless_score"
+	// SleepQualityGoal is met when sleep quality is at least Threshold on
+	// Target days of a period.
+	SleepQualityGoal GoalKind = "sleep_quality_days"
+	// MoodGoal is met when mood is at least Threshold on Target days of a
+	// period.
+	MoodGoal GoalKind = "mood_days"
+	// LogDaysGoal is met when the user logs on Target days of a period.
+	LogDaysGoal GoalKind = "log_days"
+)
+
+func (k GoalKind) IsValid() bool {
+	return k == AverageScoreGoal || k == SleepQualityGoal || k == MoodGoal || k == LogDaysGoal
+}
+
+// CountsDays reports whether Target is a number of days rather than a score.
+// A goal that counts days is completed as soon as it is met, an average can
+// still drop so it is only completed once its period is over.
+func (k GoalKind) CountsDays() bool {
+	return k != AverageScoreGoal
+}
+
+// GoalPeriod is the calendar week, starting on Monday, or month in the user's
+// timezone a goal is measured over. Goals start again every period.
+type GoalPeriod string
+
+const (
+	WeeklyGoal  GoalPeriod = "week"
+	MonthlyGoal GoalPeriod = "month"
+)
+
+func (p GoalPeriod) IsValid() bool {
+	return p == WeeklyGoal || p == MonthlyGoal
+}
+
+// Bounds returns the start of the period containing t in t's location and the
+// start of the next one.
+func (p GoalPeriod) Bounds(t time.Time) (time.Time, time.Time) {
+	granularity := SummaryGranularity(p)
+	start := granularity.BucketStart(t)
+	return start, granularity.BucketEnd(start)
+}
+
+// MaxDays is the most days a period can have.
+func (p GoalPeriod) MaxDays() int {
+	if p == MonthlyGoal {
+		return 31
+	}
+	return 7
+}
+
+type Goal struct {
+	ID     primitive.ObjectID
+	UserId primitive.ObjectID
+	Kind   GoalKind
+	Period GoalPeriod
+	// Target is the average score of an AverageScoreGoal and the number of
+	// days of the others.
+	Target int
+	// Threshold is the lowest sleep quality of a SleepQualityGoal or mood of a
+	// MoodGoal that counts, other goals have none.
+	Threshold string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var sleepQualityRanks = map[SleepQuality]int{WORST: 0, POOR: 1, FAIR: 2, GOOD: 3, EXCELLENT: 4}
+
+var moodRanks = map[Mood]int{DEPRESSED: 0, SAD: 1, NEUTRAL: 2, HAPPY: 3, OVERJOYED: 4}
+
+func (g Goal) HasValidThreshold() bool {
+	switch g.Kind {
+	case SleepQualityGoal:
+		_, ok := sleepQualityRanks[SleepQuality(g.Threshold)]
+		return ok
+	case MoodGoal:
+		_, ok := moodRanks[Mood(g.Threshold)]
+		return ok
+	default:
+		return g.Threshold == ""
+	}
+}
+
+func (g Goal) HasValidTarget() bool {
+	if g.Kind == AverageScoreGoal {
+		return g.Target >= 1 && g.Target <= 100
+	}
+	return g.Target >= 1 && g.Target <= g.Period.MaxDays()
+}
+
+// GoalResult is how far a goal got in one period. Value is the average score
+// or the number of days counted.
+type GoalResult struct {
+	Value   int
+	Percent int
+	Met     bool
+}
+
+// Measure measures the goal against the metrics logged in one period, at most
+// one a day.
+func (g Goal) Measure(metrics []Metric) GoalResult {
+	if g.Target <= 0 {
+		return GoalResult{}
+	}
+
+	value := 0
+	switch g.Kind {
+	case AverageScoreGoal:
+		if len(metrics) == 0 {
+			return GoalResult{}
+		}
+		total := 0
+		for _, metric := range metrics {
+			total += metric.StressLessScore
+		}
+		value = int(math.Round(float64(total) / float64(len(metrics))))
+	case SleepQualityGoal:
+		threshold := sleepQualityRanks[SleepQuality(g.Threshold)]
+		for _, metric := range metrics {
+			if rank, ok := sleepQualityRanks[metric.SleepQuality]; ok && rank >= threshold {
+				value++
+			}
+		}
+	case MoodGoal:
+		threshold := moodRanks[Mood(g.Threshold)]
+		for _, metric := range metrics {
+			if rank, ok := moodRanks[metric.Mood]; ok && rank >= threshold {
+				value++
+			}
+		}
+	case LogDaysGoal:
+		value = len(metrics)
+	}
+
+	percent := value * 100 / g.Target
+	if percent > 100 {
+		percent = 100
+	}
+	return GoalResult{Value: value, Percent: percent, Met: value >= g.Target}
+}
+
+// GoalProgress is a goal measured over the period containing a point in time.
+// Completion is set when the goal was completed for that period.
+type GoalProgress struct {
+	Goal        Goal
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Result      GoalResult
+	Completion  *GoalCompletion
+}
+
+// GoalCompletion records a goal being met for the period starting on
+// PeriodStart, a local date. A goal is completed at most once a period.
+type GoalCompletion struct {
+	ID          primitive.ObjectID
+	GoalId      primitive.ObjectID
+	UserId      primitive.ObjectID
+	PeriodStart string
+	Value       int
+	CompletedAt time.Time
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JournalEntry is free text a user writes, as many a day as they like and
+// separate from the daily log.
+type JournalEntry struct {
+	ID     primitive.ObjectID
+	UserId primitive.ObjectID
+	Title  string
+	Body   string
+	Tags   []string
+	// MetricId links the entry to one of the user's daily logs, it is zero
+	// when there is none. The log may have been deleted since.
+	MetricId  primitive.ObjectID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// HasMetric reports whether the entry is linked to a daily log.
+func (e JournalEntry) HasMetric() bool {
+	return !e.MetricId.IsZero()
+}
+
+// SearchTerms are the words an entry can be found by, from its title, body
+// and tags.
+func (e JournalEntry) SearchTerms() []string {
+	return SearchTerms(e.Title + " " + e.Body + " " + strings.Join(e.Tags, " "))
+}
+
+// SearchTerms splits text into lower case words of letters and digits, each
+// once and in the order they first appear.
+func SearchTerms(text string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// NormalizeTag lower cases a tag and trims spaces and a leading #.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// IsValidTag reports whether a normalized tag is 1 to 32 letters, digits,
+// dashes or underscores.
+func IsValidTag(tag string) bool {
+	if tag == "" || len(tag) > 32 {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
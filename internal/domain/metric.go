@@ -26,14 +26,52 @@ const (
 	WORST     SleepQuality = "worst"
 )
 
+// Theme is a topic a feeling is about, detected from its words.
+type Theme string
+
+const (
+	WorkTheme          Theme = "work"
+	SchoolTheme        Theme = "school"
+	FamilyTheme        Theme = "family"
+	RelationshipsTheme Theme = "relationships"
+	MoneyTheme         Theme = "money"
+	HealthTheme        Theme = "health"
+	SleepTheme         Theme = "sleep"
+)
+
+var Themes = []Theme{WorkTheme, SchoolTheme, FamilyTheme, RelationshipsTheme, MoneyTheme, HealthTheme, SleepTheme}
+
+func (t Theme) IsValid() bool {
+	for _, theme := range Themes {
+		if t == theme {
+			return true
+		}
+	}
+	return false
+}
+
 type Metric struct {
-	ID              primitive.ObjectID
-	OwnerId         primitive.ObjectID
-	StressLevel     int
-	Mood            Mood
-	SleepQuality    SleepQuality
-	Feeling         string
+	ID           primitive.ObjectID
+	OwnerId      primitive.ObjectID
+	StressLevel  int
+	Mood         Mood
+	SleepQuality SleepQuality
+	Feeling      string
+	// FeelingPolarity is how positive Feeling reads, from -1 to 1, and 0 when
+	// it carries no sentiment.
+	FeelingPolarity float64
+	// Themes are what Feeling is about, the most mentioned first.
+	Themes          []Theme
 	StressLessScore int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// SafetyFlags are set when the log was flagged as a possible crisis.
+	SafetyFlags []SafetyFlag
+	// LocalDate is the owner's calendar day, YYYY-MM-DD in their timezone,
+	// the log was created on. A user has at most one log per LocalDate.
+	LocalDate string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (m Metric) IsFlagged() bool {
+	return len(m.SafetyFlags) > 0
 }
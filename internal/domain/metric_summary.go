@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type SummaryGranularity string
+
+const (
+	DailySummary   SummaryGranularity = "day"
+	WeeklySummary  SummaryGranularity = "week"
+	MonthlySummary SummaryGranularity = "month"
+)
+
+func (g SummaryGranularity) IsValid() bool {
+	return g == DailySummary || g == WeeklySummary || g == MonthlySummary
+}
+
+// BucketStart returns the start of the day, ISO week or month containing t in
+// t's location.
+func (g SummaryGranularity) BucketStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch g {
+	case WeeklySummary:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case MonthlySummary:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// BucketEnd returns the exclusive end of the bucket starting at start.
+func (g SummaryGranularity) BucketEnd(start time.Time) time.Time {
+	switch g {
+	case WeeklySummary:
+		return start.AddDate(0, 0, 7)
+	case MonthlySummary:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+type MetricSummaryDay struct {
+	Date                   string
+	AverageStressLessScore float64
+}
+
+type MetricSummaryBucket struct {
+	Start                    time.Time
+	End                      time.Time
+	MetricCount              int
+	AverageStressLessScore   float64
+	AverageStressLevel       float64
+	MoodDistribution         map[Mood]int
+	SleepQualityDistribution map[SleepQuality]int
+	BestDay                  MetricSummaryDay
+	WorstDay                 MetricSummaryDay
+}
+
+// MetricDayTotals are the totals of one calendar day's metrics, Date is
+// YYYY-MM-DD in the summary's location.
+type MetricDayTotals struct {
+	Date                     string
+	MetricCount              int
+	SumStressLessScore       float64
+	SumStressLevel           float64
+	MoodDistribution         map[Mood]int
+	SleepQualityDistribution map[SleepQuality]int
+}
+
+// SummarizeMetrics buckets metrics by granularity in location, oldest bucket
+// first. It is for backends that cannot aggregate by time zone themselves.
+func SummarizeMetrics(metrics []Metric, granularity SummaryGranularity, location *time.Location) ([]MetricSummaryBucket, error) {
+	days := map[string]*MetricDayTotals{}
+	for _, metric := range metrics {
+		date := metric.CreatedAt.In(location).Format("2006-01-02")
+		day, ok := days[date]
+		if !ok {
+			day = &MetricDayTotals{
+				Date:                     date,
+				MoodDistribution:         map[Mood]int{},
+				SleepQualityDistribution: map[SleepQuality]int{},
+			}
+			days[date] = day
+		}
+		day.MetricCount++
+		day.SumStressLessScore += float64(metric.StressLessScore)
+		day.SumStressLevel += float64(metric.StressLevel)
+		day.MoodDistribution[metric.Mood]++
+		day.SleepQualityDistribution[metric.SleepQuality]++
+	}
+
+	totals := make([]MetricDayTotals, 0, len(days))
+	for _, day := range days {
+		totals = append(totals, *day)
+	}
+	return SummarizeMetricDays(totals, granularity, location)
+}
+
+// SummarizeMetricDays folds per day totals into buckets of granularity in
+// location, oldest bucket first. Every backend's summary goes through it so
+// they agree on averages and on the best and worst day of a bucket.
+func SummarizeMetricDays(days []MetricDayTotals, granularity SummaryGranularity, location *time.Location) ([]MetricSummaryBucket, error) {
+	type bucketSums struct {
+		bucket      MetricSummaryBucket
+		score       float64
+		stressLevel float64
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	buckets := map[time.Time]*bucketSums{}
+	for _, day := range days {
+		if day.MetricCount == 0 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", day.Date, location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse summary day: %w", err)
+		}
+		start := granularity.BucketStart(date)
+		summaryDay := MetricSummaryDay{Date: day.Date, AverageStressLessScore: day.SumStressLessScore / float64(day.MetricCount)}
+
+		sums, ok := buckets[start]
+		if !ok {
+			sums = &bucketSums{
+				bucket: MetricSummaryBucket{
+					Start:                    start,
+					End:                      granularity.BucketEnd(start),
+					MoodDistribution:         map[Mood]int{},
+					SleepQualityDistribution: map[SleepQuality]int{},
+					BestDay:                  summaryDay,
+					WorstDay:                 summaryDay,
+				},
+			}
+			buckets[start] = sums
+		}
+		if summaryDay.AverageStressLessScore > sums.bucket.BestDay.AverageStressLessScore {
+			sums.bucket.BestDay = summaryDay
+		}
+		if summaryDay.AverageStressLessScore < sums.bucket.WorstDay.AverageStressLessScore {
+			sums.bucket.WorstDay = summaryDay
+		}
+
+		sums.bucket.MetricCount += day.MetricCount
+		sums.score += day.SumStressLessScore
+		sums.stressLevel += day.SumStressLevel
+		for mood, count := range day.MoodDistribution {
+			sums.bucket.MoodDistribution[mood] += count
+		}
+		for sleepQuality, count := range day.SleepQualityDistribution {
+			sums.bucket.SleepQualityDistribution[sleepQuality] += count
+		}
+	}
+
+	summary := []MetricSummaryBucket{}
+	for _, sums := range buckets {
+		bucket := sums.bucket
+		bucket.AverageStressLessScore = sums.score / float64(bucket.MetricCount)
+		bucket.AverageStressLevel = sums.stressLevel / float64(bucket.MetricCount)
+		summary = append(summary, bucket)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Start.Before(summary[j].Start)
+	})
+	return summary, nil
+}
@@ -6,6 +6,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	StressLessScoreMetricType = "stress_less_score"
+	StressLevelMetricType     = "stress_level"
+	SleepQualityMetricType    = "sleep_quality"
+	MoodMetricType            = "mood"
+)
+
+// RecommendationStatus tracks a recommendation that is generated in the
+// background after its metric is saved.
+type RecommendationStatus string
+
+const (
+	RecommendationPending RecommendationStatus = "pending"
+	RecommendationReady   RecommendationStatus = "ready"
+	RecommendationFailed  RecommendationStatus = "failed"
+)
+
 type RecommendationItem struct {
 	Index    int
 	Heading  string
@@ -17,6 +34,30 @@ type Recommendation struct {
 	MetricId   primitive.ObjectID
 	MetricType string
 	Items      []RecommendationItem
+	Status     RecommendationStatus
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// NewPendingRecommendation is the placeholder saved with a metric until the
+// worker generates its items.
+func NewPendingRecommendation(metric Metric, metricType string) Recommendation {
+	return Recommendation{
+		ID:         primitive.NewObjectID(),
+		MetricId:   metric.ID,
+		MetricType: metricType,
+		Items:      []RecommendationItem{},
+		Status:     RecommendationPending,
+		CreatedAt:  metric.CreatedAt,
+		UpdatedAt:  metric.UpdatedAt,
+	}
+}
+
+// RecommendationMetricTypes are the metric types a recommendation is
+// generated for on every metric.
+var RecommendationMetricTypes = []string{
+	StressLessScoreMetricType,
+	StressLevelMetricType,
+	SleepQualityMetricType,
+	MoodMetricType,
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultReminderTime = "20:00"
+
+// ReminderPreferences control the daily reminder sent to a user who has not
+// logged yet. Times are HH:MM in the user's timezone. Quiet hours may wrap past
+// midnight and are off when either end is empty.
+type ReminderPreferences struct {
+	Enabled         bool
+	Time            string
+	QuietHoursStart string
+	QuietHoursEnd   string
+}
+
+// PushSubscription is a browser's Web Push endpoint and the keys its messages
+// are encrypted for.
+type PushSubscription struct {
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	CreatedAt time.Time
+}
+
+// IsValidClock reports whether clock is a 24 hour HH:MM time.
+func IsValidClock(clock string) bool {
+	_, err := clockMinutes(clock)
+	return err == nil
+}
+
+// ReminderTime is the preferred reminder time or DefaultReminderTime.
+func (p ReminderPreferences) ReminderTime() string {
+	if p.Time == "" {
+		return DefaultReminderTime
+	}
+	return p.Time
+}
+
+// IsDue reports whether a reminder should go out at now to a user in location
+// whose last log was at lastLog.
+func (p ReminderPreferences) IsDue(now, lastLog time.Time, location *time.Location) bool {
+	if !p.Enabled {
+		return false
+	}
+	if !lastLog.IsZero() && LocalDate(lastLog, location) == LocalDate(now, location) {
+		return false
+	}
+	reminderAt, err := clockMinutes(p.ReminderTime())
+	if err != nil {
+		return false
+	}
+	local := now.In(location)
+	if local.Hour()*60+local.Minute() < reminderAt {
+		return false
+	}
+	return !p.InQuietHours(local)
+}
+
+// InQuietHours reports whether the wall clock time of t falls within the quiet
+// hours, start inclusive and end exclusive.
+func (p ReminderPreferences) InQuietHours(t time.Time) bool {
+	if p.QuietHoursStart == "" || p.QuietHoursEnd == "" {
+		return false
+	}
+	start, err := clockMinutes(p.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := clockMinutes(p.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minutes >= start && minutes < end
+	}
+	return minutes >= start || minutes < end
+}
+
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock %q: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
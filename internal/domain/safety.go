@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SafetyFlag is why a daily log was flagged as a possible crisis.
+type SafetyFlag string
+
+const (
+	// HighRiskPhraseFlag is set when the feeling mentions self-harm or
+	// suicide.
+	HighRiskPhraseFlag SafetyFlag = "high_risk_phrase"
+	// SustainedLowMoodFlag is set when the log ends a run of low mood days.
+	SustainedLowMoodFlag SafetyFlag = "sustained_low_mood"
+)
+
+type SafetyEventKind string
+
+const (
+	MetricFlaggedEvent   SafetyEventKind = "metric_flagged"
+	OverrideSetEvent     SafetyEventKind = "override_set"
+	OverrideRemovedEvent SafetyEventKind = "override_removed"
+	SafetyViewedEvent    SafetyEventKind = "safety_viewed"
+)
+
+// SafetyEvent is the audit record of a flagged daily log or of a clinician
+// viewing a user's safety checks or changing their override.
+type SafetyEvent struct {
+	ID     primitive.ObjectID
+	UserId primitive.ObjectID
+	Kind   SafetyEventKind
+	// MetricId and Flags are set on a MetricFlaggedEvent.
+	MetricId primitive.ObjectID
+	Flags    []SafetyFlag
+	// Escalated reports whether crisis resources were put in the flagged
+	// log's recommendations, Country is whose resources they were.
+	Escalated bool
+	Country   string
+	// ActorId is the clinician behind a viewed or override event.
+	ActorId   primitive.ObjectID
+	CreatedAt time.Time
+}
+
+// SafetyOverride is what a clinician following a user changed about how their
+// logs are checked.
+type SafetyOverride struct {
+	UserId primitive.ObjectID
+	// LowMoodDays replaces the configured number of low mood days in a row
+	// that flags a log, 0 keeps it.
+	LowMoodDays int
+	// SuppressEscalationUntil keeps crisis resources out of the user's
+	// recommendations until then. Their logs are still flagged and audited.
+	SuppressEscalationUntil time.Time
+	ClinicianId             primitive.ObjectID
+	UpdatedAt               time.Time
+}
+
+func (o SafetyOverride) SuppressesEscalation(at time.Time) bool {
+	return at.Before(o.SuppressEscalationUntil)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShareScope is what a share lets its invitee read, always read-only.
+type ShareScope string
+
+const (
+	// StatsShareScope gives access to the /metrics/stats endpoints, summary
+	// included.
+	StatsShareScope ShareScope = "stats"
+	// LogsShareScope gives access to the daily logs themselves, without
+	// anything read from their feeling.
+	LogsShareScope ShareScope = "logs"
+	// FeelingsShareScope adds the feeling text, and its themes and safety
+	// flags, to the daily logs an invitee can read.
+	FeelingsShareScope ShareScope = "feelings"
+)
+
+var ShareScopes = []ShareScope{StatsShareScope, LogsShareScope, FeelingsShareScope}
+
+func (s ShareScope) IsValid() bool {
+	for _, scope := range ShareScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ShareStatus string
+
+const (
+	// PendingShare has not been accepted by its invitee yet.
+	PendingShare ShareStatus = "pending"
+	ActiveShare  ShareStatus = "active"
+	RevokedShare ShareStatus = "revoked"
+	ExpiredShare ShareStatus = "expired"
+)
+
+// Share is a user's invitation for someone, like a therapist, to follow their
+// trends. The invitee accepts it once with its code and reads with the scopes
+// it grants until it expires or the user revokes it.
+type Share struct {
+	ID      primitive.ObjectID
+	OwnerId primitive.ObjectID
+	// Label is who the user shared with, for their own reference.
+	Label string
+	// CodeHash is the SHA-256 of the invitation code, the code itself is only
+	// shown to the user once.
+	CodeHash   string
+	Scopes     []ShareScope
+	ExpiresAt  time.Time
+	AcceptedAt time.Time
+	// AcceptedBy is the clinician who accepted the share while logged in,
+	// which lets them review the owner's safety checks. It is zero for shares
+	// accepted with the code alone.
+	AcceptedBy primitive.ObjectID
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (s Share) HasScope(scope ShareScope) bool {
+	for _, granted := range s.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Share) Status(at time.Time) ShareStatus {
+	switch {
+	case !s.RevokedAt.IsZero():
+		return RevokedShare
+	case !at.Before(s.ExpiresAt):
+		return ExpiredShare
+	case s.AcceptedAt.IsZero():
+		return PendingShare
+	default:
+		return ActiveShare
+	}
+}
@@ -6,14 +6,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const DefaultTimezone = "UTC"
+
 type User struct {
 	ID                   primitive.ObjectID
 	Email                string
 	FirstName            string
 	LastName             string
 	Password             string
+	Timezone             string
+	IsEmailVerified      bool
 	IsOnBoardingComplete bool
 	LastMetricLog        time.Time
+	Reminders            ReminderPreferences
+	PushSubscriptions    []PushSubscription
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 }
+
+// Location returns the user's IANA timezone, falling back to UTC when it is
+// unset or unknown.
+func (u User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func IsValidTimezone(timezone string) bool {
+	if timezone == "" || timezone == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+const localDateLayout = "2006-01-02"
+
+// LocalDate formats the day containing t in location as YYYY-MM-DD.
+func LocalDate(t time.Time, location *time.Location) string {
+	return t.In(location).Format(localDateLayout)
+}
+
+// DayBounds returns the start of the day containing t in location and the start
+// of the next day.
+func DayBounds(t time.Time, location *time.Location) (time.Time, time.Time) {
+	year, month, day := t.In(location).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, location)
+	return start, start.AddDate(0, 0, 1)
+}
@@ -3,12 +3,38 @@ package auth
 import (
 	"net/http"
 
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
 	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
 	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
 )
 
 func EnsureAuthenticated(authService auth.AuthService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			authHeader := r.Header.Get("Authorization")
+
+			jwtClaims, err := authService.DecodeJWT(ctx, authHeader)
+			if err != nil || jwtClaims.IsDelegated() {
+				response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			if isUserLoggedIn := authService.IsUserLoggedIn(ctx, jwtClaims); !isUserLoggedIn {
+				response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			ctx = auth.SetJWTClaims(ctx, jwtClaims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// EnsureAuthenticatedOrShared also lets through the delegated tokens of shares
+// granting scope, as long as the share is still active.
+func EnsureAuthenticatedOrShared(authService auth.AuthService, shareVerifier auth.ShareVerifier, scope domain.ShareScope) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -20,8 +46,16 @@ func EnsureAuthenticated(authService auth.AuthService) func(next http.Handler) h
 				return
 			}
 
-			userId := jwtClaims.ID.String()
-			if isUserLoggedIn := authService.IsUserLoggedIn(ctx, authHeader, userId); !isUserLoggedIn {
+			if jwtClaims.IsDelegated() {
+				if !jwtClaims.HasScope(scope) {
+					response.ErrorResponse(w, appErrors.ErrForbidden, http.StatusForbidden)
+					return
+				}
+				if err := shareVerifier.VerifyShare(ctx, jwtClaims); err != nil {
+					response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
+					return
+				}
+			} else if isUserLoggedIn := authService.IsUserLoggedIn(ctx, jwtClaims); !isUserLoggedIn {
 				response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
 				return
 			}
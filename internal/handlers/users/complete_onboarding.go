@@ -7,6 +7,7 @@ import (
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
 	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
 	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
 	"go.uber.org/zap"
@@ -24,6 +25,7 @@ func (u UserHandler) CompleteOnboarding(w http.ResponseWriter, r *http.Request)
 		SleepQuality domain.SleepQuality `json:"sleep_quality"`
 		StressLevel  int                 `json:"stress_level"`
 		Feeling      string              `json:"feeling"`
+		Timezone     string              `json:"timezone"`
 	}
 	var request requestDTO
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -49,12 +51,15 @@ func (u UserHandler) CompleteOnboarding(w http.ResponseWriter, r *http.Request)
 		response.ErrorResponse(w, "stress_level must be a non-negative integer", http.StatusBadRequest)
 		return
 	}
-	user, err := u.userService.CompleteUserOnboarding(ctx, request.StressLevel, domain.Mood(request.Mood), domain.SleepQuality(request.SleepQuality), request.Feeling)
+	user, err := u.userService.CompleteUserOnboarding(ctx, request.StressLevel, domain.Mood(request.Mood), domain.SleepQuality(request.SleepQuality), request.Feeling, request.Timezone)
 	if err != nil {
 		switch {
 		case errors.Is(err, infra.ErrUserNotFound):
 			response.ErrorResponse(w, "user does not exist", http.StatusNotFound)
 			return
+		case errors.Is(err, users.ErrInvalidTimezone):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
 		default:
 			u.logger.Error("[internal server error: ]", zap.Error(err))
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
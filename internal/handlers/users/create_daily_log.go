@@ -7,11 +7,14 @@ import (
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/idempotency"
 	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
 	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
 	"go.uber.org/zap"
 )
 
+const maxIdempotencyKeyLength = 255
+
 func (u UserHandler) CreateDailyLog(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if r.Body == nil {
@@ -49,12 +52,31 @@ func (u UserHandler) CreateDailyLog(w http.ResponseWriter, r *http.Request) {
 		response.ErrorResponse(w, "stress_level must be a non-negative integer", http.StatusBadRequest)
 		return
 	}
-	newMetric, err := u.userService.CreateDailyLog(ctx, request.StressLevel, domain.Mood(request.Mood), domain.SleepQuality(request.SleepQuality), request.Feeling)
+
+	var newMetric domain.Metric
+	if idempotencyKey := r.Header.Get("Idempotency-Key"); idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			response.ErrorResponse(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+		newMetric, err = u.userService.CreateDailyLogIdempotently(ctx, idempotencyKey, request.StressLevel, domain.Mood(request.Mood), domain.SleepQuality(request.SleepQuality), request.Feeling)
+	} else {
+		newMetric, err = u.userService.CreateDailyLog(ctx, request.StressLevel, domain.Mood(request.Mood), domain.SleepQuality(request.SleepQuality), request.Feeling)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, infra.ErrUserNotFound):
 			response.ErrorResponse(w, "user does not exist", http.StatusNotFound)
 			return
+		case errors.Is(err, idempotency.ErrRequestInProgress):
+			response.ErrorResponse(w, "a request with this Idempotency-Key is still in progress", http.StatusConflict)
+			return
+		case errors.Is(err, idempotency.ErrKeyReused):
+			response.ErrorResponse(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, infra.ErrMetricNotFound):
+			response.ErrorResponse(w, "the daily log created with this Idempotency-Key has been deleted", http.StatusGone)
+			return
 		default:
 			u.logger.Error("[internal server error: ]", zap.Error(err))
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
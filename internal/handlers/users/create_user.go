@@ -22,6 +22,7 @@ func (u UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		FirstName string `json:"first_name"`
 		LastName  string `json:"last_name"`
 		Password  string `json:"password"`
+		Timezone  string `json:"timezone"`
 	}
 	var request requestDTO
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -47,12 +48,15 @@ func (u UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newUser, err := u.userService.CreateUser(ctx, request.FirstName, request.LastName, request.Email, request.Password)
+	newUser, err := u.userService.CreateUser(ctx, request.FirstName, request.LastName, request.Email, request.Password, request.Timezone)
 	if err != nil {
 		switch {
 		case errors.Is(err, users.ErrUserAlreadyExists):
 			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 			return
+		case errors.Is(err, users.ErrInvalidTimezone):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
 		default:
 			u.logger.Error("[internal server error: ]", zap.Error(err))
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
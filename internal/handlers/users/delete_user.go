@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) DeleteLoggedInUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := u.userService.DeleteAccount(ctx)
+	if err != nil {
+		u.logger.Error("[internal server error: ]", zap.Error(err))
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(w, "user deleted successfully", nil)
+}
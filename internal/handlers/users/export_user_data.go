@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		response.ErrorResponse(w, "format must be one of json or csv", http.StatusBadRequest)
+		return
+	}
+
+	export, err := u.userService.ExportUserData(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	fileName := "stressless-export-" + export.ExportedAt.UTC().Format("20060102T150405Z")
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".json"))
+		response.SuccessResponse(w, "user data exported successfully", ToUserDataExportDTO(export))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".zip"))
+	w.WriteHeader(http.StatusOK)
+	if err := writeUserDataCSVArchive(w, export); err != nil {
+		u.logger.Error("failed to write csv export", zap.Error(err))
+	}
+}
+
+// writeUserDataCSVArchive writes a zip with one csv file per collection, a
+// recommendation gets one row per item. Lists are joined with semicolons and
+// times that were never set are left empty.
+func writeUserDataCSVArchive(w http.ResponseWriter, export users.UserDataExport) error {
+	archive := zip.NewWriter(w)
+
+	user := export.User
+	profile := [][]string{
+		{"id", "email", "first_name", "last_name", "timezone", "is_email_verified", "is_onboarding_complete", "last_metric_log", "created_at", "updated_at"},
+		{
+			user.ID.Hex(), user.Email, user.FirstName, user.LastName, user.Location().String(),
+			strconv.FormatBool(user.IsEmailVerified), strconv.FormatBool(user.IsOnBoardingComplete),
+			formatCSVTime(user.LastMetricLog), formatCSVTime(user.CreatedAt), formatCSVTime(user.UpdatedAt),
+		},
+	}
+
+	metrics := [][]string{
+		{"id", "local_date", "stress_level", "mood", "sleep_quality", "stress_less_score", "feeling", "feeling_polarity", "themes", "safety_flags", "created_at", "updated_at"},
+	}
+	for _, metric := range export.Metrics {
+		dto := ToMetricDTO(metric)
+		metrics = append(metrics, []string{
+			metric.ID.Hex(), metric.LocalDate, strconv.Itoa(metric.StressLevel), string(metric.Mood), string(metric.SleepQuality),
+			strconv.Itoa(metric.StressLessScore), metric.Feeling, strconv.FormatFloat(metric.FeelingPolarity, 'f', -1, 64),
+			joinCSVList(dto.Themes), joinCSVList(dto.SafetyFlags), formatCSVTime(metric.CreatedAt), formatCSVTime(metric.UpdatedAt),
+		})
+	}
+
+	recommendations := [][]string{
+		{"id", "metric_id", "metric_type", "index", "heading", "text", "image_url", "created_at", "updated_at"},
+	}
+	for _, recommendation := range export.Recommendations {
+		for _, item := range recommendation.Items {
+			recommendations = append(recommendations, []string{
+				recommendation.ID.Hex(), recommendation.MetricId.Hex(), recommendation.MetricType,
+				strconv.Itoa(item.Index), item.Heading, item.Text, item.ImageUrl,
+				formatCSVTime(recommendation.CreatedAt), formatCSVTime(recommendation.UpdatedAt),
+			})
+		}
+	}
+
+	journalEntries := [][]string{
+		{"id", "title", "body", "tags", "metric_id", "created_at", "updated_at"},
+	}
+	for _, entry := range export.JournalEntries {
+		dto := ToJournalEntryDTO(entry)
+		journalEntries = append(journalEntries, []string{
+			dto.ID, dto.Title, dto.Body, joinCSVList(dto.Tags), dto.MetricId,
+			formatCSVTime(dto.CreatedAt), formatCSVTime(dto.UpdatedAt),
+		})
+	}
+
+	goals := [][]string{
+		{"id", "kind", "period", "target", "threshold", "created_at", "updated_at"},
+	}
+	for _, goal := range export.Goals {
+		dto := ToGoalDTO(goal)
+		goals = append(goals, []string{
+			dto.ID, dto.Kind, dto.Period, strconv.Itoa(dto.Target), dto.Threshold,
+			formatCSVTime(dto.CreatedAt), formatCSVTime(dto.UpdatedAt),
+		})
+	}
+
+	goalCompletions := [][]string{
+		{"id", "goal_id", "period_start", "value", "completed_at"},
+	}
+	for _, completion := range export.GoalCompletions {
+		dto := ToGoalCompletionDTO(completion)
+		goalCompletions = append(goalCompletions, []string{
+			dto.ID, dto.GoalId, dto.PeriodStart, strconv.Itoa(dto.Value), formatCSVTime(dto.CompletedAt),
+		})
+	}
+
+	achievements := [][]string{
+		{"badge", "name", "description", "metric_id", "awarded_at"},
+	}
+	for _, achievement := range export.Achievements {
+		dto := ToAchievementDTO(achievement)
+		achievements = append(achievements, []string{
+			dto.Badge, dto.Name, dto.Description, dto.MetricId, formatCSVTime(dto.AwardedAt),
+		})
+	}
+
+	safetyEvents := [][]string{
+		{"id", "kind", "metric_id", "flags", "escalated", "country", "actor_id", "created_at"},
+	}
+	for _, event := range export.SafetyEvents {
+		dto := ToSafetyEventDTO(event)
+		safetyEvents = append(safetyEvents, []string{
+			dto.ID, dto.Kind, dto.MetricId, joinCSVList(dto.Flags), strconv.FormatBool(dto.Escalated),
+			dto.Country, dto.ActorId, formatCSVTime(dto.CreatedAt),
+		})
+	}
+
+	shares := [][]string{
+		{"id", "label", "scopes", "status", "expires_at", "accepted_at", "revoked_at", "created_at"},
+	}
+	for _, share := range export.Shares {
+		dto := ToShareDTO(share)
+		shares = append(shares, []string{
+			dto.ID, dto.Label, joinCSVList(dto.Scopes), dto.Status, formatCSVTime(share.ExpiresAt),
+			formatCSVTime(share.AcceptedAt), formatCSVTime(share.RevokedAt), formatCSVTime(share.CreatedAt),
+		})
+	}
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"profile.csv", profile},
+		{"metrics.csv", metrics},
+		{"recommendations.csv", recommendations},
+		{"journal_entries.csv", journalEntries},
+		{"goals.csv", goals},
+		{"goal_completions.csv", goalCompletions},
+		{"achievements.csv", achievements},
+		{"safety_events.csv", safetyEvents},
+		{"shares.csv", shares},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := csv.NewWriter(f).WriteAll(file.rows); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func joinCSVList(values []string) string {
+	return strings.Join(values, ";")
+}
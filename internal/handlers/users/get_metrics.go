@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	metricQuery, err := parseMetricQuery(r)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := u.userService.GetMetricsByUserId(ctx, metricQuery)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrInvalidCursor), errors.Is(err, users.ErrInvalidDateRange),
+			errors.Is(err, users.ErrInvalidFrom), errors.Is(err, users.ErrInvalidTo), errors.Is(err, users.ErrInvalidTimezone):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "metrics retrieved successfully", ToMetricPagedDTO(page))
+}
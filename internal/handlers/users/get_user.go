@@ -26,5 +26,15 @@ func (u UserHandler) GetLoggedInUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	response.SuccessResponse(w, "user retrieved successfully", ToUserDTO(user))
+	streak, err := u.userService.GetStreak(ctx, user)
+	if err != nil {
+		u.logger.Error("[internal server error: ]", zap.Error(err))
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	userDTO := ToUserDTO(user)
+	streakDTO := ToStreakDTO(streak)
+	userDTO.Streak = &streakDTO
+	response.SuccessResponse(w, "user retrieved successfully", userDTO)
 }
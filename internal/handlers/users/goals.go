@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var errInvalidSince = errors.New("since must be an RFC3339 timestamp")
+
+func (u UserHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Kind      domain.GoalKind   `json:"kind"`
+		Period    domain.GoalPeriod `json:"period"`
+		Target    int               `json:"target"`
+		Threshold string            `json:"threshold"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	progress, err := u.userService.CreateGoal(ctx, request.Kind, request.Period, request.Target, request.Threshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidGoalKind), errors.Is(err, users.ErrInvalidGoalPeriod),
+			errors.Is(err, users.ErrInvalidGoalTarget), errors.Is(err, users.ErrInvalidGoalThreshold):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, users.ErrTooManyGoals):
+			response.ErrorResponse(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "goal created successfully", ToGoalProgressDTO(progress))
+}
+
+func (u UserHandler) GetGoals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	progress, err := u.userService.GetGoals(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	goals := []GoalProgressDTO{}
+	for _, goalProgress := range progress {
+		goals = append(goals, ToGoalProgressDTO(goalProgress))
+	}
+	response.SuccessResponse(w, "goals retrieved successfully", goals)
+}
+
+func (u UserHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	goalId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	progress, err := u.userService.GetGoal(ctx, goalId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrGoalNotFound), errors.Is(err, users.ErrUserDoesNotOwnGoal):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "goal retrieved successfully", ToGoalProgressDTO(progress))
+}
+
+func (u UserHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	goalId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+
+	type requestDTO struct {
+		Period    *domain.GoalPeriod `json:"period"`
+		Target    *int               `json:"target"`
+		Threshold *string            `json:"threshold"`
+	}
+	var request requestDTO
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Period == nil && request.Target == nil && request.Threshold == nil {
+		response.ErrorResponse(w, "at least one of period, target or threshold required", http.StatusBadRequest)
+		return
+	}
+
+	progress, err := u.userService.UpdateGoal(ctx, goalId, users.GoalUpdate{
+		Period:    request.Period,
+		Target:    request.Target,
+		Threshold: request.Threshold,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidGoalKind), errors.Is(err, users.ErrInvalidGoalPeriod),
+			errors.Is(err, users.ErrInvalidGoalTarget), errors.Is(err, users.ErrInvalidGoalThreshold):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrGoalNotFound), errors.Is(err, users.ErrUserDoesNotOwnGoal):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "goal updated successfully", ToGoalProgressDTO(progress))
+}
+
+func (u UserHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	goalId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.DeleteGoal(ctx, goalId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrGoalNotFound), errors.Is(err, users.ErrUserDoesNotOwnGoal):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "goal deleted successfully", nil)
+}
+
+// GetGoalCompletions lists the completions made after the since query param,
+// clients poll it with the completed_at of the last one they saw.
+func (u UserHandler) GetGoalCompletions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	since := time.Time{}
+	if rawSince := r.URL.Query().Get("since"); rawSince != "" {
+		parsedSince, err := time.Parse(time.RFC3339, rawSince)
+		if err != nil {
+			response.ErrorResponse(w, errInvalidSince.Error(), http.StatusBadRequest)
+			return
+		}
+		since = parsedSince
+	}
+
+	completions, err := u.userService.GetGoalCompletions(ctx, since)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	completionDTOs := []GoalCompletionDTO{}
+	for _, completion := range completions {
+		completionDTOs = append(completionDTOs, ToGoalCompletionDTO(completion))
+	}
+	response.SuccessResponse(w, "goal completions retrieved successfully", completionDTOs)
+}
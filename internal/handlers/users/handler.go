@@ -11,10 +11,12 @@ import (
 type UserHandler struct {
 	userService users.UserService
 	authService auth.AuthService
-	logger      *zap.Logger
+	// vapidPublicKey is empty when web push is not enabled.
+	vapidPublicKey string
+	logger         *zap.Logger
 }
 
-func NewUserHandler(userService users.UserService, authService auth.AuthService, logger *zap.Logger) (*UserHandler, error) {
+func NewUserHandler(userService users.UserService, authService auth.AuthService, vapidPublicKey string, logger *zap.Logger) (*UserHandler, error) {
 	if userService == (users.UserService{}) {
 		return nil, errors.New("user service cannot be empty")
 	}
@@ -22,5 +24,5 @@ func NewUserHandler(userService users.UserService, authService auth.AuthService,
 		return nil, errors.New("auth service cannot be empty")
 	}
 
-	return &UserHandler{userService, authService, logger}, nil
+	return &UserHandler{userService, authService, vapidPublicKey, logger}, nil
 }
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var errInvalidMetricId = errors.New("metric_id must be a metric id or empty")
+
+func (u UserHandler) CreateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Title    string   `json:"title"`
+		Body     string   `json:"body"`
+		Tags     []string `json:"tags"`
+		MetricId string   `json:"metric_id"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	metricId, err := parseOptionalMetricId(request.MetricId)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := u.userService.CreateJournalEntry(ctx, request.Title, request.Body, request.Tags, metricId)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidJournalTitle), errors.Is(err, users.ErrInvalidJournalBody),
+			errors.Is(err, users.ErrInvalidJournalTag), errors.Is(err, users.ErrTooManyJournalTags):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrMetricNotFound), errors.Is(err, users.ErrUserDoesNotOwnMetric):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "journal entry created successfully", ToJournalEntryDTO(entry))
+}
+
+func (u UserHandler) GetJournalEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	metricQuery, err := parseMetricQuery(r)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	journalQuery := users.JournalQuery{
+		Search:    query.Get("search"),
+		Tag:       query.Get("tag"),
+		DateRange: metricQuery.DateRange,
+		Cursor:    metricQuery.Cursor,
+		Limit:     metricQuery.Limit,
+	}
+
+	page, err := u.userService.GetJournalEntries(ctx, journalQuery)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrInvalidCursor), errors.Is(err, users.ErrInvalidDateRange),
+			errors.Is(err, users.ErrInvalidFrom), errors.Is(err, users.ErrInvalidTo), errors.Is(err, users.ErrInvalidTimezone), errors.Is(err, users.ErrInvalidJournalTag):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "journal entries retrieved successfully", ToJournalEntryPagedDTO(page))
+}
+
+func (u UserHandler) GetJournalEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entryId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := u.userService.GetJournalEntry(ctx, entryId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrJournalEntryNotFound), errors.Is(err, users.ErrUserDoesNotOwnJournalEntry):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "journal entry retrieved successfully", ToJournalEntryDTO(entry))
+}
+
+func (u UserHandler) UpdateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entryId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+
+	type requestDTO struct {
+		Title    *string   `json:"title"`
+		Body     *string   `json:"body"`
+		Tags     *[]string `json:"tags"`
+		MetricId *string   `json:"metric_id"`
+	}
+	var request requestDTO
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Title == nil && request.Body == nil && request.Tags == nil && request.MetricId == nil {
+		response.ErrorResponse(w, "at least one of title, body, tags or metric_id required", http.StatusBadRequest)
+		return
+	}
+
+	update := users.JournalEntryUpdate{Title: request.Title, Body: request.Body, Tags: request.Tags}
+	if request.MetricId != nil {
+		metricId, err := parseOptionalMetricId(*request.MetricId)
+		if err != nil {
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.MetricId = &metricId
+	}
+
+	entry, err := u.userService.UpdateJournalEntry(ctx, entryId, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidJournalTitle), errors.Is(err, users.ErrInvalidJournalBody),
+			errors.Is(err, users.ErrInvalidJournalTag), errors.Is(err, users.ErrTooManyJournalTags):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrJournalEntryNotFound), errors.Is(err, users.ErrUserDoesNotOwnJournalEntry),
+			errors.Is(err, infra.ErrMetricNotFound), errors.Is(err, users.ErrUserDoesNotOwnMetric):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "journal entry updated successfully", ToJournalEntryDTO(entry))
+}
+
+func (u UserHandler) DeleteJournalEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entryId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.DeleteJournalEntry(ctx, entryId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrJournalEntryNotFound), errors.Is(err, users.ErrUserDoesNotOwnJournalEntry):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "journal entry deleted successfully", nil)
+}
+
+// parseOptionalMetricId returns the zero id for an empty metric_id.
+func parseOptionalMetricId(metricId string) (primitive.ObjectID, error) {
+	if metricId == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(metricId)
+	if err != nil {
+		return primitive.NilObjectID, errInvalidMetricId
+	}
+	return id, nil
+}
@@ -3,9 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
 	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
 	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
@@ -19,8 +22,9 @@ func (u UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	type requestDTO struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name"`
 	}
 	var request requestDTO
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -37,7 +41,12 @@ func (u UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := u.userService.LogUserIn(ctx, request.Email, request.Password)
+	device := auth.DeviceInfo{
+		Name:      request.DeviceName,
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	}
+	tokenPair, err := u.userService.LogUserIn(ctx, request.Email, request.Password, device)
 	if err != nil {
 		switch {
 		case errors.Is(err, infra.ErrUserNotFound):
@@ -53,8 +62,17 @@ func (u UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	response.SuccessResponse(w, "user logged in successfully",
-		map[string]interface{}{
-			"access_token": accessToken,
-		})
+	response.SuccessResponse(w, "user logged in successfully", ToTokenPairDTO(tokenPair))
+}
+
+func clientIP(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		ip, _, _ := strings.Cut(forwardedFor, ",")
+		return strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
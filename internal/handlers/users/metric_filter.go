@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+)
+
+var errInvalidLimit = errors.New("limit must be a positive integer")
+
+// parseMetricQuery reads the limit, cursor, from, to and timezone query
+// params. The dates are left for the service to read in the right timezone.
+func parseMetricQuery(r *http.Request) (users.MetricQuery, error) {
+	query := r.URL.Query()
+	metricQuery := users.MetricQuery{
+		DateRange: parseDateRange(r),
+		Cursor:    query.Get("cursor"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil || parsedLimit <= 0 {
+			return users.MetricQuery{}, errInvalidLimit
+		}
+		metricQuery.Limit = parsedLimit
+	}
+
+	return metricQuery, nil
+}
+
+func parseDateRange(r *http.Request) users.DateRange {
+	query := r.URL.Query()
+	return users.DateRange{
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Timezone: query.Get("timezone"),
+	}
+}
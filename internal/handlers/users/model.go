@@ -1,18 +1,43 @@
 package handlers
 
 import (
+	"math"
 	"time"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
 )
 
 type UserDTO struct {
-	ID                   string     `json:"id"`
-	Email                string     `json:"email"`
-	FirstName            string     `json:"first_name"`
-	LastName             string     `json:"last_name"`
-	IsOnBoardingComplete bool       `json:"is_onboarding_complete"`
-	LastMetricLog        *time.Time `json:"last_metric_log,omitempty"`
+	ID                   string                 `json:"id"`
+	Email                string                 `json:"email"`
+	FirstName            string                 `json:"first_name"`
+	LastName             string                 `json:"last_name"`
+	Timezone             string                 `json:"timezone"`
+	IsEmailVerified      bool                   `json:"is_email_verified"`
+	IsOnBoardingComplete bool                   `json:"is_onboarding_complete"`
+	LastMetricLog        *time.Time             `json:"last_metric_log,omitempty"`
+	Reminders            ReminderPreferencesDTO `json:"reminders"`
+	// Streak is only filled in by GET /users/me.
+	Streak *StreakDTO `json:"streak,omitempty"`
+}
+
+type ReminderPreferencesDTO struct {
+	Enabled         bool   `json:"enabled"`
+	Time            string `json:"time"`
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+}
+
+func ToReminderPreferencesDTO(preferences domain.ReminderPreferences) ReminderPreferencesDTO {
+	return ReminderPreferencesDTO{
+		Enabled:         preferences.Enabled,
+		Time:            preferences.ReminderTime(),
+		QuietHoursStart: preferences.QuietHoursStart,
+		QuietHoursEnd:   preferences.QuietHoursEnd,
+	}
 }
 
 func ToUserDTO(user domain.User) UserDTO {
@@ -22,7 +47,10 @@ func ToUserDTO(user domain.User) UserDTO {
 			Email:                user.Email,
 			FirstName:            user.FirstName,
 			LastName:             user.LastName,
+			Timezone:             user.Location().String(),
+			IsEmailVerified:      user.IsEmailVerified,
 			IsOnBoardingComplete: user.IsOnBoardingComplete,
+			Reminders:            ToReminderPreferencesDTO(user.Reminders),
 		}
 	}
 	return UserDTO{
@@ -30,8 +58,51 @@ func ToUserDTO(user domain.User) UserDTO {
 		Email:                user.Email,
 		FirstName:            user.FirstName,
 		LastName:             user.LastName,
+		Timezone:             user.Location().String(),
+		IsEmailVerified:      user.IsEmailVerified,
 		IsOnBoardingComplete: user.IsOnBoardingComplete,
 		LastMetricLog:        &user.LastMetricLog,
+		Reminders:            ToReminderPreferencesDTO(user.Reminders),
+	}
+}
+
+type TokenPairDTO struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+func ToTokenPairDTO(tokenPair auth.TokenPair) TokenPairDTO {
+	return TokenPairDTO{
+		AccessToken:           tokenPair.AccessToken,
+		AccessTokenExpiresAt:  tokenPair.AccessTokenExpiresAt,
+		RefreshToken:          tokenPair.RefreshToken,
+		RefreshTokenExpiresAt: tokenPair.RefreshTokenExpiresAt,
+	}
+}
+
+type SessionDTO struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func ToSessionDTO(session auth.Session, currentSessionId string) SessionDTO {
+	return SessionDTO{
+		ID:         session.ID,
+		DeviceName: session.Device.Name,
+		UserAgent:  session.Device.UserAgent,
+		IPAddress:  session.Device.IPAddress,
+		Current:    session.ID == currentSessionId,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
 	}
 }
 
@@ -43,11 +114,17 @@ type MetricDTO struct {
 	SleepQuality    string     `json:"sleep_quality"`
 	StressLessScore int        `json:"stress_less_score"`
 	Feeling         string     `json:"feeling"`
+	Themes          []string   `json:"themes"`
+	SafetyFlags     []string   `json:"safety_flags"`
 	CreatedAt       *time.Time `json:"created_at"`
 	UpdatedAt       *time.Time `json:"updated_at"`
 }
 
 func ToMetricDTO(metric domain.Metric) MetricDTO {
+	themes := []string{}
+	for _, theme := range metric.Themes {
+		themes = append(themes, string(theme))
+	}
 	return MetricDTO{
 		ID:              metric.ID.Hex(),
 		OwnerId:         metric.OwnerId.Hex(),
@@ -56,11 +133,33 @@ func ToMetricDTO(metric domain.Metric) MetricDTO {
 		SleepQuality:    string(metric.SleepQuality),
 		StressLessScore: metric.StressLessScore,
 		Feeling:         metric.Feeling,
+		Themes:          themes,
+		SafetyFlags:     toSafetyFlagNames(metric.SafetyFlags),
 		CreatedAt:       &metric.CreatedAt,
 		UpdatedAt:       &metric.UpdatedAt,
 	}
 }
 
+type MetricPagedDTO struct {
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor"`
+	Items      []MetricDTO `json:"items"`
+}
+
+func ToMetricPagedDTO(page infra.MetricPage) MetricPagedDTO {
+	items := []MetricDTO{}
+	for _, metric := range page.Metrics {
+		items = append(items, ToMetricDTO(metric))
+	}
+	return MetricPagedDTO{
+		Limit:      page.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Items:      items,
+	}
+}
+
 // ----------------------------------
 // stress less scores start
 // ----------------------------------
@@ -71,9 +170,10 @@ type StatsStressLessScoreDTO struct {
 	UpdatedAt       *time.Time `json:"updated_at"`
 }
 type StatsStressLessScorePagedDTO struct {
-	// TODO:TODO: i am not sure about this limit, i think it should be page
-	Limit int                       `json:"limit"`
-	Items []StatsStressLessScoreDTO `json:"items"`
+	Limit      int                       `json:"limit"`
+	Total      int64                     `json:"total"`
+	NextCursor string                    `json:"next_cursor"`
+	Items      []StatsStressLessScoreDTO `json:"items"`
 }
 
 func ToStatsStressLessScoreDTO(metric domain.Metric) StatsStressLessScoreDTO {
@@ -85,14 +185,16 @@ func ToStatsStressLessScoreDTO(metric domain.Metric) StatsStressLessScoreDTO {
 	}
 }
 
-func ToStatsStressLessScorePagedDTO(metrics []domain.Metric) StatsStressLessScorePagedDTO {
+func ToStatsStressLessScorePagedDTO(page infra.MetricPage) StatsStressLessScorePagedDTO {
 	items := []StatsStressLessScoreDTO{}
-	for _, metric := range metrics {
+	for _, metric := range page.Metrics {
 		items = append(items, ToStatsStressLessScoreDTO(metric))
 	}
 	return StatsStressLessScorePagedDTO{
-		Limit: len(items),
-		Items: items,
+		Limit:      page.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Items:      items,
 	}
 }
 
@@ -106,9 +208,10 @@ type StatsMoodDTO struct {
 	UpdatedAt *time.Time `json:"updated_at"`
 }
 type StatsMoodPagedDTO struct {
-	// TODO:TODO: i am not sure about this limit, i think it should be page
-	Limit int            `json:"limit"`
-	Items []StatsMoodDTO `json:"items"`
+	Limit      int            `json:"limit"`
+	Total      int64          `json:"total"`
+	NextCursor string         `json:"next_cursor"`
+	Items      []StatsMoodDTO `json:"items"`
 }
 
 func ToStatsMoodDTO(metric domain.Metric) StatsMoodDTO {
@@ -120,14 +223,16 @@ func ToStatsMoodDTO(metric domain.Metric) StatsMoodDTO {
 	}
 }
 
-func ToStatsMoodPagedDTO(metrics []domain.Metric) StatsMoodPagedDTO {
+func ToStatsMoodPagedDTO(page infra.MetricPage) StatsMoodPagedDTO {
 	items := []StatsMoodDTO{}
-	for _, metric := range metrics {
+	for _, metric := range page.Metrics {
 		items = append(items, ToStatsMoodDTO(metric))
 	}
 	return StatsMoodPagedDTO{
-		Limit: len(items),
-		Items: items,
+		Limit:      page.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Items:      items,
 	}
 }
 
@@ -141,9 +246,10 @@ type StatsSleepQualityDTO struct {
 	UpdatedAt    *time.Time `json:"updated_at"`
 }
 type StatsSleepQualityPagedDTO struct {
-	// TODO:TODO: i am not sure about this limit, i think it should be page
-	Limit int                    `json:"limit"`
-	Items []StatsSleepQualityDTO `json:"items"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	NextCursor string                 `json:"next_cursor"`
+	Items      []StatsSleepQualityDTO `json:"items"`
 }
 
 func ToStatsSleepQualityDTO(metric domain.Metric) StatsSleepQualityDTO {
@@ -155,14 +261,82 @@ func ToStatsSleepQualityDTO(metric domain.Metric) StatsSleepQualityDTO {
 	}
 }
 
-func ToStatsSleepQualityPagedDTO(metrics []domain.Metric) StatsSleepQualityPagedDTO {
+func ToStatsSleepQualityPagedDTO(page infra.MetricPage) StatsSleepQualityPagedDTO {
 	items := []StatsSleepQualityDTO{}
-	for _, metric := range metrics {
+	for _, metric := range page.Metrics {
 		items = append(items, ToStatsSleepQualityDTO(metric))
 	}
 	return StatsSleepQualityPagedDTO{
-		Limit: len(items),
-		Items: items,
+		Limit:      page.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Items:      items,
+	}
+}
+
+// ----------------------------------
+// summary stats start
+// ----------------------------------
+type StatsSummaryDayDTO struct {
+	Date                   string  `json:"date"`
+	AverageStressLessScore float64 `json:"average_stress_less_score"`
+}
+
+type StatsSummaryBucketDTO struct {
+	Start                    time.Time          `json:"start"`
+	End                      time.Time          `json:"end"`
+	MetricCount              int                `json:"metric_count"`
+	AverageStressLessScore   float64            `json:"average_stress_less_score"`
+	AverageStressLevel       float64            `json:"average_stress_level"`
+	MoodDistribution         map[string]int     `json:"mood_distribution"`
+	SleepQualityDistribution map[string]int     `json:"sleep_quality_distribution"`
+	BestDay                  StatsSummaryDayDTO `json:"best_day"`
+	WorstDay                 StatsSummaryDayDTO `json:"worst_day"`
+}
+
+type StatsSummaryDTO struct {
+	Granularity string                  `json:"granularity"`
+	Timezone    string                  `json:"timezone"`
+	Buckets     []StatsSummaryBucketDTO `json:"buckets"`
+}
+
+func ToStatsSummaryBucketDTO(bucket domain.MetricSummaryBucket, location *time.Location) StatsSummaryBucketDTO {
+	moods := map[string]int{}
+	for _, mood := range []domain.Mood{domain.OVERJOYED, domain.HAPPY, domain.NEUTRAL, domain.SAD, domain.DEPRESSED} {
+		moods[string(mood)] = bucket.MoodDistribution[mood]
+	}
+	sleepQualities := map[string]int{}
+	for _, sleepQuality := range []domain.SleepQuality{domain.EXCELLENT, domain.GOOD, domain.FAIR, domain.POOR, domain.WORST} {
+		sleepQualities[string(sleepQuality)] = bucket.SleepQualityDistribution[sleepQuality]
+	}
+	return StatsSummaryBucketDTO{
+		Start:                    bucket.Start.In(location),
+		End:                      bucket.End.In(location),
+		MetricCount:              bucket.MetricCount,
+		AverageStressLessScore:   math.Round(bucket.AverageStressLessScore*100) / 100,
+		AverageStressLevel:       math.Round(bucket.AverageStressLevel*100) / 100,
+		MoodDistribution:         moods,
+		SleepQualityDistribution: sleepQualities,
+		BestDay: StatsSummaryDayDTO{
+			Date:                   bucket.BestDay.Date,
+			AverageStressLessScore: math.Round(bucket.BestDay.AverageStressLessScore*100) / 100,
+		},
+		WorstDay: StatsSummaryDayDTO{
+			Date:                   bucket.WorstDay.Date,
+			AverageStressLessScore: math.Round(bucket.WorstDay.AverageStressLessScore*100) / 100,
+		},
+	}
+}
+
+func ToStatsSummaryDTO(buckets []domain.MetricSummaryBucket, granularity domain.SummaryGranularity, location *time.Location) StatsSummaryDTO {
+	items := []StatsSummaryBucketDTO{}
+	for _, bucket := range buckets {
+		items = append(items, ToStatsSummaryBucketDTO(bucket, location))
+	}
+	return StatsSummaryDTO{
+		Granularity: string(granularity),
+		Timezone:    location.String(),
+		Buckets:     items,
 	}
 }
 
@@ -178,6 +352,7 @@ type RecommendationDTO struct {
 	MetricId   string                  `json:"metric_id"`
 	MetricType string                  `json:"metric_type"`
 	Items      []RecommendationItemDTO `json:"items"`
+	Status     string                  `json:"status"`
 	CreatedAt  *time.Time              `json:"created_at"`
 	UpdatedAt  *time.Time              `json:"updated_at"`
 }
@@ -201,7 +376,389 @@ func ToRecommendationDTO(recommendation domain.Recommendation) RecommendationDTO
 		MetricId:   recommendation.MetricId.Hex(),
 		MetricType: recommendation.MetricType,
 		Items:      items,
+		Status:     string(recommendation.Status),
 		CreatedAt:  &recommendation.CreatedAt,
 		UpdatedAt:  &recommendation.UpdatedAt,
 	}
 }
+
+type UserDataExportDTO struct {
+	ExportedAt      time.Time           `json:"exported_at"`
+	Profile         UserDTO             `json:"profile"`
+	Metrics         []MetricDTO         `json:"metrics"`
+	Recommendations []RecommendationDTO `json:"recommendations"`
+	Achievements    []AchievementDTO    `json:"achievements"`
+	Goals           []GoalDTO           `json:"goals"`
+	GoalCompletions []GoalCompletionDTO `json:"goal_completions"`
+	JournalEntries  []JournalEntryDTO   `json:"journal_entries"`
+	SafetyEvents    []SafetyEventDTO    `json:"safety_events"`
+	Shares          []ShareDTO          `json:"shares"`
+}
+
+func ToUserDataExportDTO(export users.UserDataExport) UserDataExportDTO {
+	metrics := []MetricDTO{}
+	for _, metric := range export.Metrics {
+		metrics = append(metrics, ToMetricDTO(metric))
+	}
+	recommendations := []RecommendationDTO{}
+	for _, recommendation := range export.Recommendations {
+		recommendations = append(recommendations, ToRecommendationDTO(recommendation))
+	}
+	achievements := []AchievementDTO{}
+	for _, achievement := range export.Achievements {
+		achievements = append(achievements, ToAchievementDTO(achievement))
+	}
+	goals := []GoalDTO{}
+	for _, goal := range export.Goals {
+		goals = append(goals, ToGoalDTO(goal))
+	}
+	goalCompletions := []GoalCompletionDTO{}
+	for _, completion := range export.GoalCompletions {
+		goalCompletions = append(goalCompletions, ToGoalCompletionDTO(completion))
+	}
+	journalEntries := []JournalEntryDTO{}
+	for _, entry := range export.JournalEntries {
+		journalEntries = append(journalEntries, ToJournalEntryDTO(entry))
+	}
+	safetyEvents := []SafetyEventDTO{}
+	for _, event := range export.SafetyEvents {
+		safetyEvents = append(safetyEvents, ToSafetyEventDTO(event))
+	}
+	shares := []ShareDTO{}
+	for _, share := range export.Shares {
+		shares = append(shares, ToShareDTO(share))
+	}
+	return UserDataExportDTO{
+		ExportedAt:      export.ExportedAt,
+		Profile:         ToUserDTO(export.User),
+		Metrics:         metrics,
+		Recommendations: recommendations,
+		Achievements:    achievements,
+		Goals:           goals,
+		GoalCompletions: goalCompletions,
+		JournalEntries:  journalEntries,
+		SafetyEvents:    safetyEvents,
+		Shares:          shares,
+	}
+}
+
+type StreakDTO struct {
+	Current     int    `json:"current"`
+	Longest     int    `json:"longest"`
+	MissedDays  int    `json:"missed_days"`
+	TotalDays   int    `json:"total_days"`
+	LastLogDate string `json:"last_log_date,omitempty"`
+}
+
+func ToStreakDTO(streak domain.Streak) StreakDTO {
+	return StreakDTO{
+		Current:     streak.Current,
+		Longest:     streak.Longest,
+		MissedDays:  streak.MissedDays,
+		TotalDays:   streak.TotalDays,
+		LastLogDate: streak.LastLogDate,
+	}
+}
+
+type AchievementDTO struct {
+	Badge       string    `json:"badge"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	MetricId    string    `json:"metric_id"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
+
+func ToAchievementDTO(achievement domain.Achievement) AchievementDTO {
+	badge, _ := domain.FindBadge(achievement.Badge)
+	return AchievementDTO{
+		Badge:       string(achievement.Badge),
+		Name:        badge.Name,
+		Description: badge.Description,
+		MetricId:    achievement.MetricId.Hex(),
+		AwardedAt:   achievement.AwardedAt,
+	}
+}
+
+type BadgeDTO struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Earned      bool       `json:"earned"`
+	AwardedAt   *time.Time `json:"awarded_at,omitempty"`
+}
+
+type AchievementsDTO struct {
+	Streak StreakDTO `json:"streak"`
+	// Badges lists every badge, earned or not, so clients can show progress.
+	Badges []BadgeDTO `json:"badges"`
+}
+
+func ToAchievementsDTO(userAchievements users.UserAchievements) AchievementsDTO {
+	awarded := map[domain.BadgeId]domain.Achievement{}
+	for _, achievement := range userAchievements.Achievements {
+		awarded[achievement.Badge] = achievement
+	}
+	badges := []BadgeDTO{}
+	for _, badge := range domain.Badges {
+		dto := BadgeDTO{ID: string(badge.ID), Name: badge.Name, Description: badge.Description}
+		if achievement, ok := awarded[badge.ID]; ok {
+			dto.Earned = true
+			dto.AwardedAt = &achievement.AwardedAt
+		}
+		badges = append(badges, dto)
+	}
+	return AchievementsDTO{Streak: ToStreakDTO(userAchievements.Streak), Badges: badges}
+}
+
+type GoalDTO struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Period    string    `json:"period"`
+	Target    int       `json:"target"`
+	Threshold string    `json:"threshold,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ToGoalDTO(goal domain.Goal) GoalDTO {
+	return GoalDTO{
+		ID:        goal.ID.Hex(),
+		Kind:      string(goal.Kind),
+		Period:    string(goal.Period),
+		Target:    goal.Target,
+		Threshold: goal.Threshold,
+		CreatedAt: goal.CreatedAt,
+		UpdatedAt: goal.UpdatedAt,
+	}
+}
+
+type GoalCompletionDTO struct {
+	ID          string    `json:"id"`
+	GoalId      string    `json:"goal_id"`
+	PeriodStart string    `json:"period_start"`
+	Value       int       `json:"value"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func ToGoalCompletionDTO(completion domain.GoalCompletion) GoalCompletionDTO {
+	return GoalCompletionDTO{
+		ID:          completion.ID.Hex(),
+		GoalId:      completion.GoalId.Hex(),
+		PeriodStart: completion.PeriodStart,
+		Value:       completion.Value,
+		CompletedAt: completion.CompletedAt,
+	}
+}
+
+type GoalProgressDTO struct {
+	GoalDTO
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// Value is the average score so far or the number of days counted.
+	Value   int  `json:"value"`
+	Percent int  `json:"percent"`
+	Met     bool `json:"met"`
+	// Completion is set once the goal is completed for this period.
+	Completion *GoalCompletionDTO `json:"completion,omitempty"`
+}
+
+func ToGoalProgressDTO(progress domain.GoalProgress) GoalProgressDTO {
+	dto := GoalProgressDTO{
+		GoalDTO:     ToGoalDTO(progress.Goal),
+		PeriodStart: progress.PeriodStart,
+		PeriodEnd:   progress.PeriodEnd,
+		Value:       progress.Result.Value,
+		Percent:     progress.Result.Percent,
+		Met:         progress.Result.Met,
+	}
+	if progress.Completion != nil {
+		completion := ToGoalCompletionDTO(*progress.Completion)
+		dto.Completion = &completion
+	}
+	return dto
+}
+
+type JournalEntryDTO struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	MetricId  string    `json:"metric_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ToJournalEntryDTO(entry domain.JournalEntry) JournalEntryDTO {
+	dto := JournalEntryDTO{
+		ID:        entry.ID.Hex(),
+		Title:     entry.Title,
+		Body:      entry.Body,
+		Tags:      append([]string{}, entry.Tags...),
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+	if entry.HasMetric() {
+		dto.MetricId = entry.MetricId.Hex()
+	}
+	return dto
+}
+
+type JournalEntryPagedDTO struct {
+	Limit      int               `json:"limit"`
+	Total      int64             `json:"total"`
+	NextCursor string            `json:"next_cursor"`
+	Items      []JournalEntryDTO `json:"items"`
+}
+
+func ToJournalEntryPagedDTO(page infra.JournalPage) JournalEntryPagedDTO {
+	items := []JournalEntryDTO{}
+	for _, entry := range page.Entries {
+		items = append(items, ToJournalEntryDTO(entry))
+	}
+	return JournalEntryPagedDTO{
+		Limit:      page.Limit,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Items:      items,
+	}
+}
+
+type SafetyEventDTO struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	MetricId  string    `json:"metric_id,omitempty"`
+	Flags     []string  `json:"flags"`
+	Escalated bool      `json:"escalated"`
+	Country   string    `json:"country,omitempty"`
+	ActorId   string    `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToSafetyEventDTO(event domain.SafetyEvent) SafetyEventDTO {
+	dto := SafetyEventDTO{
+		ID:        event.ID.Hex(),
+		Kind:      string(event.Kind),
+		Flags:     toSafetyFlagNames(event.Flags),
+		Escalated: event.Escalated,
+		Country:   event.Country,
+		CreatedAt: event.CreatedAt,
+	}
+	if !event.MetricId.IsZero() {
+		dto.MetricId = event.MetricId.Hex()
+	}
+	if !event.ActorId.IsZero() {
+		dto.ActorId = event.ActorId.Hex()
+	}
+	return dto
+}
+
+type SafetyOverrideDTO struct {
+	LowMoodDays             int        `json:"low_mood_days"`
+	SuppressEscalationUntil *time.Time `json:"suppress_escalation_until"`
+	ClinicianId             string     `json:"clinician_id"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+func ToSafetyOverrideDTO(override domain.SafetyOverride) SafetyOverrideDTO {
+	dto := SafetyOverrideDTO{
+		LowMoodDays: override.LowMoodDays,
+		ClinicianId: override.ClinicianId.Hex(),
+		UpdatedAt:   override.UpdatedAt,
+	}
+	if !override.SuppressEscalationUntil.IsZero() {
+		dto.SuppressEscalationUntil = &override.SuppressEscalationUntil
+	}
+	return dto
+}
+
+type UserSafetyDTO struct {
+	UserId   string             `json:"user_id"`
+	Email    string             `json:"email"`
+	Override *SafetyOverrideDTO `json:"override"`
+	Events   []SafetyEventDTO   `json:"events"`
+}
+
+func ToUserSafetyDTO(userSafety users.UserSafety) UserSafetyDTO {
+	dto := UserSafetyDTO{
+		UserId: userSafety.User.ID.Hex(),
+		Email:  userSafety.User.Email,
+		Events: []SafetyEventDTO{},
+	}
+	if userSafety.HasOverride {
+		override := ToSafetyOverrideDTO(userSafety.Override)
+		dto.Override = &override
+	}
+	for _, event := range userSafety.Events {
+		dto.Events = append(dto.Events, ToSafetyEventDTO(event))
+	}
+	return dto
+}
+
+func toSafetyFlagNames(flags []domain.SafetyFlag) []string {
+	names := []string{}
+	for _, flag := range flags {
+		names = append(names, string(flag))
+	}
+	return names
+}
+
+type ShareDTO struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	Scopes     []string   `json:"scopes"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func ToShareDTO(share domain.Share) ShareDTO {
+	scopes := []string{}
+	for _, scope := range share.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	dto := ShareDTO{
+		ID:        share.ID.Hex(),
+		Label:     share.Label,
+		Scopes:    scopes,
+		Status:    string(share.Status(time.Now())),
+		ExpiresAt: share.ExpiresAt,
+		CreatedAt: share.CreatedAt,
+	}
+	if !share.AcceptedAt.IsZero() {
+		dto.AcceptedAt = &share.AcceptedAt
+	}
+	if !share.RevokedAt.IsZero() {
+		dto.RevokedAt = &share.RevokedAt
+	}
+	return dto
+}
+
+type NewShareDTO struct {
+	ShareDTO
+	Code string `json:"code"`
+	Link string `json:"link"`
+}
+
+func ToNewShareDTO(newShare users.NewShare) NewShareDTO {
+	return NewShareDTO{ToShareDTO(newShare.Share), newShare.Code, newShare.Link}
+}
+
+type AcceptedShareDTO struct {
+	OwnerId              string    `json:"owner_id"`
+	OwnerFirstName       string    `json:"owner_first_name"`
+	Scopes               []string  `json:"scopes"`
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func ToAcceptedShareDTO(accepted users.AcceptedShare) AcceptedShareDTO {
+	return AcceptedShareDTO{
+		OwnerId:              accepted.Share.OwnerId.Hex(),
+		OwnerFirstName:       accepted.OwnerFirstName,
+		Scopes:               ToShareDTO(accepted.Share).Scopes,
+		AccessToken:          accepted.Token,
+		AccessTokenExpiresAt: accepted.Share.ExpiresAt,
+	}
+}
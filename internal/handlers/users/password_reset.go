@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/verification"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Email string `json:"email"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Email == "" {
+		response.ErrorResponse(w, "email required", http.StatusBadRequest)
+		return
+	}
+
+	if err := u.userService.RequestPasswordReset(ctx, request.Email); err != nil {
+		u.logger.Error("[internal server error: ]", zap.Error(err))
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(w, "if an account exists for this email, a password reset link has been sent", nil)
+}
+
+func (u UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Token == "" {
+		response.ErrorResponse(w, "token required", http.StatusBadRequest)
+		return
+	}
+	if request.Password == "" {
+		response.ErrorResponse(w, "password required", http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.ResetPassword(ctx, request.Token, request.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, verification.ErrInvalidOrExpiredToken):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, "user does not exist", http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "password reset successfully", nil)
+}
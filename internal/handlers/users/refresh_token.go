@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.RefreshToken == "" {
+		response.ErrorResponse(w, "refresh_token required", http.StatusBadRequest)
+		return
+	}
+
+	tokenPair, err := u.userService.RefreshTokens(ctx, request.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken),
+			errors.Is(err, auth.ErrExpiredToken),
+			errors.Is(err, auth.ErrRefreshTokenReused):
+			response.ErrorResponse(w, err.Error(), http.StatusUnauthorized)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "token refreshed successfully", ToTokenPairDTO(tokenPair))
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) GetReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := u.userService.GetLoggedInUser(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "reminder preferences retrieved successfully", ToReminderPreferencesDTO(user.Reminders))
+}
+
+func (u UserHandler) UpdateReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Enabled         bool   `json:"enabled"`
+		Time            string `json:"time"`
+		QuietHoursStart string `json:"quiet_hours_start"`
+		QuietHoursEnd   string `json:"quiet_hours_end"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	user, err := u.userService.UpdateReminderPreferences(ctx, domain.ReminderPreferences{
+		Enabled:         request.Enabled,
+		Time:            request.Time,
+		QuietHoursStart: request.QuietHoursStart,
+		QuietHoursEnd:   request.QuietHoursEnd,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidReminderTime), errors.Is(err, users.ErrIncompleteQuietHours):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "reminder preferences updated successfully", ToReminderPreferencesDTO(user.Reminders))
+}
+
+func (u UserHandler) AddPushSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	// the shape of PushSubscription.toJSON() in the browser
+	type requestDTO struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Endpoint == "" {
+		response.ErrorResponse(w, "endpoint required", http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.AddPushSubscription(ctx, request.Endpoint, request.Keys.P256dh, request.Keys.Auth)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidPushSubscription):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "push subscription saved successfully", nil)
+}
+
+func (u UserHandler) RemovePushSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	endpoint := r.URL.Query().Get("endpoint")
+	if endpoint == "" {
+		response.ErrorResponse(w, "endpoint required", http.StatusBadRequest)
+		return
+	}
+
+	err := u.userService.RemovePushSubscription(ctx, endpoint)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrPushSubscriptionNotFound), errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "push subscription removed successfully", nil)
+}
+
+// GetVapidPublicKey returns the key browsers pass to pushManager.subscribe as
+// applicationServerKey.
+func (u UserHandler) GetVapidPublicKey(w http.ResponseWriter, r *http.Request) {
+	if u.vapidPublicKey == "" {
+		response.ErrorResponse(w, "web push is not enabled", http.StatusNotFound)
+		return
+	}
+	response.SuccessResponse(w, "vapid public key retrieved successfully", map[string]string{"public_key": u.vapidPublicKey})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) GetUserSafety(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userSafety, err := u.userService.GetUserSafety(ctx, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrNotClinician), errors.Is(err, users.ErrNotSharedWithClinician):
+			response.ErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "user safety retrieved successfully", ToUserSafetyDTO(userSafety))
+}
+
+func (u UserHandler) SetSafetyOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+
+	type requestDTO struct {
+		LowMoodDays             int        `json:"low_mood_days"`
+		SuppressEscalationUntil *time.Time `json:"suppress_escalation_until"`
+	}
+	var request requestDTO
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	suppressEscalationUntil := time.Time{}
+	if request.SuppressEscalationUntil != nil {
+		suppressEscalationUntil = *request.SuppressEscalationUntil
+	}
+
+	override, err := u.userService.SetSafetyOverride(ctx, userId, request.LowMoodDays, suppressEscalationUntil)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidSafetyOverride):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, users.ErrNotClinician), errors.Is(err, users.ErrNotSharedWithClinician):
+			response.ErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "safety override saved successfully", ToSafetyOverrideDTO(override))
+}
+
+func (u UserHandler) RemoveSafetyOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.RemoveSafetyOverride(ctx, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrNotClinician), errors.Is(err, users.ErrNotSharedWithClinician):
+			response.ErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrSafetyOverrideNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "safety override removed successfully", nil)
+}
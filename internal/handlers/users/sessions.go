@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessions, err := u.userService.GetSessions(ctx)
+	if err != nil {
+		u.logger.Error("[internal server error: ]", zap.Error(err))
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	jwtClaims, _ := auth.GetJWTClaims(ctx)
+	items := []SessionDTO{}
+	for _, session := range sessions {
+		items = append(items, ToSessionDTO(session, jwtClaims.SessionId))
+	}
+
+	response.SuccessResponse(w, "sessions retrieved successfully", items)
+}
+
+func (u UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	err := u.userService.RevokeSession(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrSessionNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "session revoked successfully", nil)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Label         string              `json:"label"`
+		Scopes        []domain.ShareScope `json:"scopes"`
+		ExpiresInDays int                 `json:"expires_in_days"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	newShare, err := u.userService.CreateShare(ctx, request.Label, request.Scopes, request.ExpiresInDays)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidShareScope), errors.Is(err, users.ErrInvalidShareExpiry),
+			errors.Is(err, users.ErrInvalidShareLabel):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "share created successfully", ToNewShareDTO(newShare))
+}
+
+func (u UserHandler) GetShares(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	shares, err := u.userService.GetShares(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	dtos := []ShareDTO{}
+	for _, share := range shares {
+		dtos = append(dtos, ToShareDTO(share))
+	}
+	response.SuccessResponse(w, "shares retrieved successfully", dtos)
+}
+
+func (u UserHandler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	shareId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	share, err := u.userService.RevokeShare(ctx, shareId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound), errors.Is(err, infra.ErrShareNotFound),
+			errors.Is(err, users.ErrUserDoesNotOwnShare):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "share revoked successfully", ToShareDTO(share))
+}
+
+func (u UserHandler) AcceptShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Code string `json:"code"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	accepted, err := u.userService.AcceptShare(ctx, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidShareCode):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "share accepted successfully", ToAcceptedShareDTO(accepted))
+}
+
+func (u UserHandler) AcceptShareAsClinician(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Code string `json:"code"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	accepted, err := u.userService.AcceptShareAsClinician(ctx, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrNotClinician):
+			response.ErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, users.ErrInvalidShareCode):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "share accepted successfully", ToAcceptedShareDTO(accepted))
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	appErrors "github.com/olad5/AfriHacks2023-stressless-backend/pkg/errors"
+	response "github.com/olad5/AfriHacks2023-stressless-backend/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func (u UserHandler) UpdateDailyLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	metricId, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+
+	type requestDTO struct {
+		Mood         *domain.Mood         `json:"mood"`
+		SleepQuality *domain.SleepQuality `json:"sleep_quality"`
+		StressLevel  *int                 `json:"stress_level"`
+		Feeling      *string              `json:"feeling"`
+	}
+	var request requestDTO
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Mood == nil && request.SleepQuality == nil && request.StressLevel == nil && request.Feeling == nil {
+		response.ErrorResponse(w, "at least one of mood, sleep_quality, stress_level or feeling required", http.StatusBadRequest)
+		return
+	}
+	if request.Mood != nil && !isValidMood(*request.Mood) {
+		response.ErrorResponse(w, "invalid mood", http.StatusBadRequest)
+		return
+	}
+	if request.SleepQuality != nil && !isValidSleepQuality(*request.SleepQuality) {
+		response.ErrorResponse(w, "invalid sleep_quality", http.StatusBadRequest)
+		return
+	}
+	if request.StressLevel != nil && *request.StressLevel <= 0 {
+		response.ErrorResponse(w, "stress_level must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	metric, err := u.userService.UpdateDailyLog(ctx, metricId, users.MetricUpdate{
+		StressLevel:  request.StressLevel,
+		Mood:         request.Mood,
+		SleepQuality: request.SleepQuality,
+		Feeling:      request.Feeling,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrMetricNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, users.ErrUserDoesNotOwnMetric):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			u.logger.Error("[internal server error: ]", zap.Error(err))
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "metric updated successfully", ToMetricDTO(metric))
+}
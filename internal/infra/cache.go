@@ -2,11 +2,29 @@ package infra
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
+var ErrCacheMiss = errors.New("key not found in cache")
+
 type Cache interface {
-	SetOne(ctx context.Context, key, value string) error
+	// SetOne stores value under key, a ttl of 0 keeps the key until it is deleted.
+	SetOne(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetOneIfNotExists stores value only if key is not set and reports whether it did.
+	SetOneIfNotExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// SetOneIfExists stores value only if key is already set and reports whether it did.
+	SetOneIfExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// SetOneIfEquals stores value only if key holds expected and reports whether
+	// it did, so only the holder of a lock can extend it.
+	SetOneIfEquals(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error)
 	GetOne(ctx context.Context, key string) (string, error)
+	// GetAndDeleteOne returns the value under key and deletes it in one step,
+	// so only one of any concurrent callers gets it.
+	GetAndDeleteOne(ctx context.Context, key string) (string, error)
 	DeleteOne(ctx context.Context, key string) error
+	AddToSet(ctx context.Context, key, member string) error
+	GetSetMembers(ctx context.Context, key string) ([]string, error)
+	RemoveFromSet(ctx context.Context, key, member string) error
 	Ping(ctx context.Context) error
 }
@@ -0,0 +1,81 @@
+package contract
+
+import (
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AchievementRepositoryCases returns the cases every
+// infra.AchievementRepository must pass. newRepo must return an empty
+// repository each time it is called.
+func AchievementRepositoryCases(newRepo func(t T) infra.AchievementRepository) []Case {
+	return []Case{
+		{"create_and_list_oldest_first", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			newest := newAchievement(userId, domain.SevenDayStreakBadge, now())
+			oldest := newAchievement(userId, domain.FirstLogBadge, now().Add(-time.Hour))
+			for _, achievement := range []domain.Achievement{newest, oldest, newAchievement(primitive.NewObjectID(), domain.FirstLogBadge, now())} {
+				requireNoError(t, repo.CreateAchievement(ctx(), achievement), "CreateAchievement")
+			}
+
+			got, err := repo.GetAchievementsByUserId(ctx(), userId)
+			requireNoError(t, err, "GetAchievementsByUserId")
+			if len(got) != 2 {
+				t.Fatalf("GetAchievementsByUserId: got %d achievements, want 2", len(got))
+			}
+			expectAchievement(t, got[0], oldest)
+			expectAchievement(t, got[1], newest)
+		}},
+		{"a_badge_is_awarded_once_per_user", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			requireNoError(t, repo.CreateAchievement(ctx(), newAchievement(userId, domain.CalmDayBadge, now())), "CreateAchievement")
+
+			err := repo.CreateAchievement(ctx(), newAchievement(userId, domain.CalmDayBadge, now()))
+			requireErrorIs(t, err, infra.ErrAchievementExists, "CreateAchievement with the same badge")
+
+			got, err := repo.GetAchievementsByUserId(ctx(), userId)
+			requireNoError(t, err, "GetAchievementsByUserId")
+			expectEqual(t, len(got), 1, "number of achievements")
+		}},
+		{"delete_by_user", func(t T) {
+			repo := newRepo(t)
+			userId, other := primitive.NewObjectID(), primitive.NewObjectID()
+			requireNoError(t, repo.CreateAchievement(ctx(), newAchievement(userId, domain.FirstLogBadge, now())), "CreateAchievement")
+			requireNoError(t, repo.CreateAchievement(ctx(), newAchievement(other, domain.FirstLogBadge, now())), "CreateAchievement")
+
+			requireNoError(t, repo.DeleteAchievementsByUserId(ctx(), userId), "DeleteAchievementsByUserId")
+			requireNoError(t, repo.DeleteAchievementsByUserId(ctx(), userId), "DeleteAchievementsByUserId again")
+
+			got, err := repo.GetAchievementsByUserId(ctx(), userId)
+			requireNoError(t, err, "GetAchievementsByUserId")
+			expectEqual(t, len(got), 0, "achievements left for the deleted user")
+			got, err = repo.GetAchievementsByUserId(ctx(), other)
+			requireNoError(t, err, "GetAchievementsByUserId for another user")
+			expectEqual(t, len(got), 1, "achievements left for another user")
+		}},
+	}
+}
+
+func newAchievement(userId primitive.ObjectID, badge domain.BadgeId, awardedAt time.Time) domain.Achievement {
+	return domain.Achievement{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		Badge:     badge,
+		MetricId:  primitive.NewObjectID(),
+		AwardedAt: awardedAt,
+	}
+}
+
+func expectAchievement(t T, got, want domain.Achievement) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.Badge, want.Badge, "Badge")
+	expectEqual(t, got.MetricId, want.MetricId, "MetricId")
+	expectSameTime(t, got.AwardedAt, want.AwardedAt, "AwardedAt")
+}
@@ -0,0 +1,45 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// now returns the current time the way every backend stores it, in UTC with
+// millisecond precision, so it can be compared after a round trip.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func ctx() context.Context {
+	return context.Background()
+}
+
+func requireNoError(t T, err error, action string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", action, err)
+	}
+}
+
+func requireErrorIs(t T, err, target error, action string) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: got error %v, want %v", action, err, target)
+	}
+}
+
+func expectEqual[V comparable](t T, got, want V, what string) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+}
+
+func expectSameTime(t T, got, want time.Time, what string) {
+	t.Helper()
+	if !got.Equal(want) {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package contract
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CacheCases returns the cases every infra.Cache must pass. Keys are unique
+// per case so newCache may hand out a shared cache.
+func CacheCases(newCache func(t T) infra.Cache) []Case {
+	return []Case{
+		{"set_get_and_delete", func(t T) {
+			cache := newCache(t)
+			key := cacheKey()
+
+			requireNoError(t, cache.SetOne(ctx(), key, "first", 0), "SetOne")
+			requireNoError(t, cache.SetOne(ctx(), key, "second", 0), "SetOne overwrite")
+
+			value, err := cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne")
+			expectEqual(t, value, "second", "value")
+
+			requireNoError(t, cache.DeleteOne(ctx(), key), "DeleteOne")
+			requireNoError(t, cache.DeleteOne(ctx(), key), "DeleteOne again")
+
+			_, err = cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after delete")
+		}},
+		{"missing_key_is_a_cache_miss", func(t T) {
+			cache := newCache(t)
+
+			_, err := cache.GetOne(ctx(), cacheKey())
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne")
+		}},
+		{"get_and_delete_returns_a_value_once", func(t T) {
+			cache := newCache(t)
+			key, expiring := cacheKey(), cacheKey()
+
+			requireNoError(t, cache.SetOne(ctx(), key, "value", 0), "SetOne")
+			requireNoError(t, cache.SetOne(ctx(), expiring, "value", 200*time.Millisecond), "SetOne with ttl")
+
+			const callers = 8
+			values := make(chan string, callers)
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					value, err := cache.GetAndDeleteOne(ctx(), key)
+					if err == nil {
+						values <- value
+						return
+					}
+					if !errors.Is(err, infra.ErrCacheMiss) {
+						t.Errorf("GetAndDeleteOne: got error %v, want %v", err, infra.ErrCacheMiss)
+					}
+				}()
+			}
+			wg.Wait()
+			close(values)
+			expectEqual(t, len(values), 1, "callers that got the value")
+			expectEqual(t, <-values, "value", "value")
+
+			_, err := cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after GetAndDeleteOne")
+
+			time.Sleep(400 * time.Millisecond)
+
+			_, err = cache.GetAndDeleteOne(ctx(), expiring)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetAndDeleteOne of an expired key")
+		}},
+		{"keys_expire_after_their_ttl", func(t T) {
+			cache := newCache(t)
+			expiring, persistent := cacheKey(), cacheKey()
+
+			requireNoError(t, cache.SetOne(ctx(), expiring, "value", 200*time.Millisecond), "SetOne with ttl")
+			requireNoError(t, cache.SetOne(ctx(), persistent, "value", 0), "SetOne without ttl")
+
+			_, err := cache.GetOne(ctx(), expiring)
+			requireNoError(t, err, "GetOne before expiry")
+
+			time.Sleep(400 * time.Millisecond)
+
+			_, err = cache.GetOne(ctx(), expiring)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after expiry")
+			_, err = cache.GetOne(ctx(), persistent)
+			requireNoError(t, err, "GetOne without ttl")
+		}},
+		{"set_if_not_exists_only_sets_missing_or_expired_keys", func(t T) {
+			cache := newCache(t)
+			key := cacheKey()
+
+			set, err := cache.SetOneIfNotExists(ctx(), key, "first", 200*time.Millisecond)
+			requireNoError(t, err, "SetOneIfNotExists of a missing key")
+			expectEqual(t, set, true, "SetOneIfNotExists of a missing key")
+
+			set, err = cache.SetOneIfNotExists(ctx(), key, "second", 0)
+			requireNoError(t, err, "SetOneIfNotExists of a set key")
+			expectEqual(t, set, false, "SetOneIfNotExists of a set key")
+			value, err := cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne")
+			expectEqual(t, value, "first", "GetOne after SetOneIfNotExists of a set key")
+
+			time.Sleep(400 * time.Millisecond)
+
+			set, err = cache.SetOneIfNotExists(ctx(), key, "third", 0)
+			requireNoError(t, err, "SetOneIfNotExists of an expired key")
+			expectEqual(t, set, true, "SetOneIfNotExists of an expired key")
+			value, err = cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne")
+			expectEqual(t, value, "third", "GetOne after SetOneIfNotExists of an expired key")
+		}},
+		{"set_if_exists_only_sets_live_keys", func(t T) {
+			cache := newCache(t)
+			key := cacheKey()
+
+			set, err := cache.SetOneIfExists(ctx(), key, "first", 0)
+			requireNoError(t, err, "SetOneIfExists of a missing key")
+			expectEqual(t, set, false, "SetOneIfExists of a missing key")
+			_, err = cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after SetOneIfExists of a missing key")
+
+			requireNoError(t, cache.SetOne(ctx(), key, "first", 200*time.Millisecond), "SetOne")
+			set, err = cache.SetOneIfExists(ctx(), key, "second", 200*time.Millisecond)
+			requireNoError(t, err, "SetOneIfExists of a set key")
+			expectEqual(t, set, true, "SetOneIfExists of a set key")
+			value, err := cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne")
+			expectEqual(t, value, "second", "GetOne after SetOneIfExists of a set key")
+
+			time.Sleep(400 * time.Millisecond)
+
+			set, err = cache.SetOneIfExists(ctx(), key, "third", 0)
+			requireNoError(t, err, "SetOneIfExists of an expired key")
+			expectEqual(t, set, false, "SetOneIfExists of an expired key")
+			_, err = cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after SetOneIfExists of an expired key")
+
+			requireNoError(t, cache.SetOne(ctx(), key, "fourth", 0), "SetOne")
+			requireNoError(t, cache.DeleteOne(ctx(), key), "DeleteOne")
+			set, err = cache.SetOneIfExists(ctx(), key, "fifth", 0)
+			requireNoError(t, err, "SetOneIfExists of a deleted key")
+			expectEqual(t, set, false, "SetOneIfExists of a deleted key")
+		}},
+		{"set_if_equals_only_sets_the_expected_value", func(t T) {
+			cache := newCache(t)
+			key := cacheKey()
+
+			set, err := cache.SetOneIfEquals(ctx(), key, "first", "second", 0)
+			requireNoError(t, err, "SetOneIfEquals of a missing key")
+			expectEqual(t, set, false, "SetOneIfEquals of a missing key")
+			_, err = cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after SetOneIfEquals of a missing key")
+
+			requireNoError(t, cache.SetOne(ctx(), key, "first", 200*time.Millisecond), "SetOne")
+			set, err = cache.SetOneIfEquals(ctx(), key, "other", "second", 0)
+			requireNoError(t, err, "SetOneIfEquals of another value")
+			expectEqual(t, set, false, "SetOneIfEquals of another value")
+			value, err := cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne")
+			expectEqual(t, value, "first", "GetOne after SetOneIfEquals of another value")
+
+			// extending it keeps it past its first ttl
+			set, err = cache.SetOneIfEquals(ctx(), key, "first", "first", time.Second)
+			requireNoError(t, err, "SetOneIfEquals of the expected value")
+			expectEqual(t, set, true, "SetOneIfEquals of the expected value")
+			time.Sleep(400 * time.Millisecond)
+			value, err = cache.GetOne(ctx(), key)
+			requireNoError(t, err, "GetOne after SetOneIfEquals extended the key")
+			expectEqual(t, value, "first", "GetOne after SetOneIfEquals extended the key")
+
+			requireNoError(t, cache.SetOne(ctx(), key, "first", 200*time.Millisecond), "SetOne")
+			time.Sleep(400 * time.Millisecond)
+			set, err = cache.SetOneIfEquals(ctx(), key, "first", "second", 0)
+			requireNoError(t, err, "SetOneIfEquals of an expired key")
+			expectEqual(t, set, false, "SetOneIfEquals of an expired key")
+			_, err = cache.GetOne(ctx(), key)
+			requireErrorIs(t, err, infra.ErrCacheMiss, "GetOne after SetOneIfEquals of an expired key")
+		}},
+		{"sets", func(t T) {
+			cache := newCache(t)
+			key := cacheKey()
+
+			members, err := cache.GetSetMembers(ctx(), key)
+			requireNoError(t, err, "GetSetMembers of a missing set")
+			expectEqual(t, len(members), 0, "members of a missing set")
+
+			for _, member := range []string{"a", "b", "a", "c"} {
+				requireNoError(t, cache.AddToSet(ctx(), key, member), "AddToSet")
+			}
+			requireNoError(t, cache.RemoveFromSet(ctx(), key, "b"), "RemoveFromSet")
+			requireNoError(t, cache.RemoveFromSet(ctx(), key, "missing"), "RemoveFromSet of a missing member")
+
+			members, err = cache.GetSetMembers(ctx(), key)
+			requireNoError(t, err, "GetSetMembers")
+			sort.Strings(members)
+			if len(members) != 2 || members[0] != "a" || members[1] != "c" {
+				t.Errorf("members: got %v, want [a c]", members)
+			}
+
+			requireNoError(t, cache.DeleteOne(ctx(), key), "DeleteOne")
+			members, err = cache.GetSetMembers(ctx(), key)
+			requireNoError(t, err, "GetSetMembers after delete")
+			expectEqual(t, len(members), 0, "members after delete")
+		}},
+		{"ping", func(t T) {
+			requireNoError(t, newCache(t).Ping(ctx()), "Ping")
+		}},
+	}
+}
+
+func cacheKey() string {
+	return "contract:" + primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,105 @@
+// Package contract is a conformance suite for the infra repository and cache
+// interfaces. Every backend runs the same cases so they stay interchangeable.
+//
+// From a go test, run each case as a subtest:
+//
+//	for _, c := range contract.MetricRepositoryCases(newRepo) {
+//		c := c
+//		t.Run(c.Name, func(t *testing.T) { c.Run(t) })
+//	}
+//
+// or use RunCases to run them without the testing package, as cmd/conformance
+// does.
+package contract
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// T is the subset of *testing.T the cases need.
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Cleanup(f func())
+}
+
+type Case struct {
+	Name string
+	Run  func(t T)
+}
+
+// RunCases runs every case in its own goroutine, reports each result to w in
+// the style of go test -v and returns the number of failed cases.
+func RunCases(w io.Writer, prefix string, cases []Case) int {
+	failed := 0
+	for _, c := range cases {
+		name := prefix + "/" + c.Name
+		ct := &caseT{}
+
+		start := time.Now()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer ct.runCleanups()
+			defer func() {
+				if r := recover(); r != nil {
+					ct.Errorf("panic: %v", r)
+				}
+			}()
+			c.Run(ct)
+		}()
+		<-done
+		elapsed := time.Since(start).Seconds()
+
+		if ct.failed {
+			failed++
+			fmt.Fprintf(w, "--- FAIL: %s (%.2fs)\n", name, elapsed)
+			for _, line := range ct.logs {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "--- PASS: %s (%.2fs)\n", name, elapsed)
+	}
+	return failed
+}
+
+type caseT struct {
+	mu       sync.Mutex
+	failed   bool
+	logs     []string
+	cleanups []func()
+}
+
+func (c *caseT) Helper() {}
+
+func (c *caseT) Errorf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failed = true
+	c.logs = append(c.logs, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// Fatalf stops the case like testing.T does, by exiting its goroutine.
+func (c *caseT) Fatalf(format string, args ...any) {
+	c.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (c *caseT) Cleanup(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanups = append(c.cleanups, f)
+}
+
+func (c *caseT) runCleanups() {
+	for i := len(c.cleanups) - 1; i >= 0; i-- {
+		c.cleanups[i]()
+	}
+}
@@ -0,0 +1,54 @@
+package contract
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCasesReportsEveryCase(t *testing.T) {
+	cleanups := []string{}
+	cases := []Case{
+		{"passes", func(t T) {
+			t.Cleanup(func() { cleanups = append(cleanups, "passes") })
+		}},
+		{"errors", func(t T) {
+			t.Errorf("first error")
+			t.Errorf("second error")
+		}},
+		{"stops_at_fatal", func(t T) {
+			t.Cleanup(func() { cleanups = append(cleanups, "stops_at_fatal") })
+			t.Fatalf("fatal error")
+			t.Errorf("not reached")
+		}},
+		{"panics", func(t T) {
+			panic("boom")
+		}},
+	}
+
+	var out bytes.Buffer
+	if failed := RunCases(&out, "backend/suite", cases); failed != 3 {
+		t.Errorf("got %d failed cases, want 3", failed)
+	}
+
+	report := out.String()
+	for _, want := range []string{
+		"--- PASS: backend/suite/passes",
+		"--- FAIL: backend/suite/errors",
+		"    first error\n    second error\n",
+		"--- FAIL: backend/suite/stops_at_fatal",
+		"    fatal error\n",
+		"--- FAIL: backend/suite/panics",
+		"    panic: boom\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "not reached") {
+		t.Errorf("case kept running after Fatalf:\n%s", report)
+	}
+	if len(cleanups) != 2 || cleanups[0] != "passes" || cleanups[1] != "stops_at_fatal" {
+		t.Errorf("got cleanups %v, want [passes stops_at_fatal]", cleanups)
+	}
+}
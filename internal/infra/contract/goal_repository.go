@@ -0,0 +1,191 @@
+package contract
+
+import (
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GoalRepositoryCases returns the cases every infra.GoalRepository must pass.
+// newRepo must return an empty repository each time it is called.
+func GoalRepositoryCases(newRepo func(t T) infra.GoalRepository) []Case {
+	return []Case{
+		{"create_get_and_list_oldest_first", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			newest := newGoal(userId, now())
+			oldest := newGoal(userId, now().Add(-time.Hour))
+			oldest.Kind, oldest.Target, oldest.Threshold = domain.AverageScoreGoal, 70, ""
+			for _, goal := range []domain.Goal{newest, oldest, newGoal(primitive.NewObjectID(), now())} {
+				requireNoError(t, repo.CreateGoal(ctx(), goal), "CreateGoal")
+			}
+
+			got, err := repo.GetGoalById(ctx(), newest.ID)
+			requireNoError(t, err, "GetGoalById")
+			expectGoal(t, got, newest)
+
+			goals, err := repo.GetGoalsByUserId(ctx(), userId)
+			requireNoError(t, err, "GetGoalsByUserId")
+			if len(goals) != 2 {
+				t.Fatalf("GetGoalsByUserId: got %d goals, want 2", len(goals))
+			}
+			expectGoal(t, goals[0], oldest)
+			expectGoal(t, goals[1], newest)
+		}},
+		{"missing_goal_is_not_found", func(t T) {
+			repo := newRepo(t)
+
+			_, err := repo.GetGoalById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrGoalNotFound, "GetGoalById")
+
+			err = repo.UpdateGoal(ctx(), newGoal(primitive.NewObjectID(), now()))
+			requireErrorIs(t, err, infra.ErrGoalNotFound, "UpdateGoal")
+
+			err = repo.DeleteGoal(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrGoalNotFound, "DeleteGoal")
+		}},
+		{"update", func(t T) {
+			repo := newRepo(t)
+			goal := newGoal(primitive.NewObjectID(), now().Add(-time.Hour))
+			requireNoError(t, repo.CreateGoal(ctx(), goal), "CreateGoal")
+
+			goal.Period, goal.Target, goal.Threshold = domain.MonthlyGoal, 20, string(domain.HAPPY)
+			goal.UpdatedAt = now()
+			requireNoError(t, repo.UpdateGoal(ctx(), goal), "UpdateGoal")
+
+			got, err := repo.GetGoalById(ctx(), goal.ID)
+			requireNoError(t, err, "GetGoalById")
+			expectGoal(t, got, goal)
+		}},
+		{"a_goal_is_completed_once_per_period", func(t T) {
+			repo := newRepo(t)
+			goal := newGoal(primitive.NewObjectID(), now())
+			requireNoError(t, repo.CreateGoal(ctx(), goal), "CreateGoal")
+			first := newGoalCompletion(goal, "2023-11-06", now())
+			requireNoError(t, repo.CreateGoalCompletion(ctx(), first), "CreateGoalCompletion")
+
+			err := repo.CreateGoalCompletion(ctx(), newGoalCompletion(goal, "2023-11-06", now()))
+			requireErrorIs(t, err, infra.ErrGoalCompletionExists, "CreateGoalCompletion for the same period")
+
+			requireNoError(t, repo.CreateGoalCompletion(ctx(), newGoalCompletion(goal, "2023-11-13", now())), "CreateGoalCompletion for the next period")
+			other := newGoal(goal.UserId, now())
+			requireNoError(t, repo.CreateGoalCompletion(ctx(), newGoalCompletion(other, "2023-11-06", now())), "CreateGoalCompletion for another goal")
+
+			got, err := repo.GetGoalCompletionsByUserId(ctx(), goal.UserId, time.Time{})
+			requireNoError(t, err, "GetGoalCompletionsByUserId")
+			expectEqual(t, len(got), 3, "number of completions")
+		}},
+		{"list_completions_since_oldest_first", func(t T) {
+			repo := newRepo(t)
+			goal := newGoal(primitive.NewObjectID(), now())
+			since := now().Add(-time.Hour)
+			tooOld := newGoalCompletion(goal, "2023-10-30", since)
+			newest := newGoalCompletion(goal, "2023-11-13", now())
+			oldest := newGoalCompletion(goal, "2023-11-06", since.Add(time.Minute))
+			for _, completion := range []domain.GoalCompletion{tooOld, newest, oldest, newGoalCompletion(newGoal(primitive.NewObjectID(), now()), "2023-11-06", now())} {
+				requireNoError(t, repo.CreateGoalCompletion(ctx(), completion), "CreateGoalCompletion")
+			}
+
+			got, err := repo.GetGoalCompletionsByUserId(ctx(), goal.UserId, since)
+			requireNoError(t, err, "GetGoalCompletionsByUserId")
+			if len(got) != 2 {
+				t.Fatalf("GetGoalCompletionsByUserId: got %d completions, want 2", len(got))
+			}
+			expectGoalCompletion(t, got[0], oldest)
+			expectGoalCompletion(t, got[1], newest)
+		}},
+		{"delete_removes_completions", func(t T) {
+			repo := newRepo(t)
+			goal, kept := newGoal(primitive.NewObjectID(), now()), newGoal(primitive.NewObjectID(), now())
+			kept.UserId = goal.UserId
+			for _, g := range []domain.Goal{goal, kept} {
+				requireNoError(t, repo.CreateGoal(ctx(), g), "CreateGoal")
+				requireNoError(t, repo.CreateGoalCompletion(ctx(), newGoalCompletion(g, "2023-11-06", now())), "CreateGoalCompletion")
+			}
+
+			requireNoError(t, repo.DeleteGoal(ctx(), goal.ID), "DeleteGoal")
+
+			_, err := repo.GetGoalById(ctx(), goal.ID)
+			requireErrorIs(t, err, infra.ErrGoalNotFound, "GetGoalById of the deleted goal")
+			completions, err := repo.GetGoalCompletionsByUserId(ctx(), goal.UserId, time.Time{})
+			requireNoError(t, err, "GetGoalCompletionsByUserId")
+			if len(completions) != 1 {
+				t.Fatalf("GetGoalCompletionsByUserId: got %d completions, want 1", len(completions))
+			}
+			expectEqual(t, completions[0].GoalId, kept.ID, "GoalId of the completion left")
+		}},
+		{"delete_by_user", func(t T) {
+			repo := newRepo(t)
+			userId, other := primitive.NewObjectID(), primitive.NewObjectID()
+			for _, g := range []domain.Goal{newGoal(userId, now()), newGoal(other, now())} {
+				requireNoError(t, repo.CreateGoal(ctx(), g), "CreateGoal")
+				requireNoError(t, repo.CreateGoalCompletion(ctx(), newGoalCompletion(g, "2023-11-06", now())), "CreateGoalCompletion")
+			}
+
+			requireNoError(t, repo.DeleteGoalsByUserId(ctx(), userId), "DeleteGoalsByUserId")
+			requireNoError(t, repo.DeleteGoalsByUserId(ctx(), userId), "DeleteGoalsByUserId again")
+
+			goals, err := repo.GetGoalsByUserId(ctx(), userId)
+			requireNoError(t, err, "GetGoalsByUserId")
+			expectEqual(t, len(goals), 0, "goals left for the deleted user")
+			completions, err := repo.GetGoalCompletionsByUserId(ctx(), userId, time.Time{})
+			requireNoError(t, err, "GetGoalCompletionsByUserId")
+			expectEqual(t, len(completions), 0, "completions left for the deleted user")
+
+			goals, err = repo.GetGoalsByUserId(ctx(), other)
+			requireNoError(t, err, "GetGoalsByUserId for another user")
+			expectEqual(t, len(goals), 1, "goals left for another user")
+			completions, err = repo.GetGoalCompletionsByUserId(ctx(), other, time.Time{})
+			requireNoError(t, err, "GetGoalCompletionsByUserId for another user")
+			expectEqual(t, len(completions), 1, "completions left for another user")
+		}},
+	}
+}
+
+func newGoal(userId primitive.ObjectID, createdAt time.Time) domain.Goal {
+	return domain.Goal{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		Kind:      domain.SleepQualityGoal,
+		Period:    domain.WeeklyGoal,
+		Target:    5,
+		Threshold: string(domain.GOOD),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func newGoalCompletion(goal domain.Goal, periodStart string, completedAt time.Time) domain.GoalCompletion {
+	return domain.GoalCompletion{
+		ID:          primitive.NewObjectID(),
+		GoalId:      goal.ID,
+		UserId:      goal.UserId,
+		PeriodStart: periodStart,
+		Value:       goal.Target,
+		CompletedAt: completedAt,
+	}
+}
+
+func expectGoal(t T, got, want domain.Goal) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.Kind, want.Kind, "Kind")
+	expectEqual(t, got.Period, want.Period, "Period")
+	expectEqual(t, got.Target, want.Target, "Target")
+	expectEqual(t, got.Threshold, want.Threshold, "Threshold")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
+
+func expectGoalCompletion(t T, got, want domain.GoalCompletion) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.GoalId, want.GoalId, "GoalId")
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.PeriodStart, want.PeriodStart, "PeriodStart")
+	expectEqual(t, got.Value, want.Value, "Value")
+	expectSameTime(t, got.CompletedAt, want.CompletedAt, "CompletedAt")
+}
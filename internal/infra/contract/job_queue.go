@@ -0,0 +1,64 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobQueueCases returns the cases every infra.JobQueue must pass. Queue names
+// are unique per case so newQueue may hand out a shared queue.
+func JobQueueCases(newQueue func(t T) infra.JobQueue) []Case {
+	return []Case{
+		{"dequeues_in_enqueue_order", func(t T) {
+			queue := newQueue(t)
+			name, other := queueName(), queueName()
+
+			for i := 0; i < 3; i++ {
+				requireNoError(t, queue.Enqueue(ctx(), name, fmt.Sprintf("job %d", i)), "Enqueue")
+			}
+			requireNoError(t, queue.Enqueue(ctx(), other, "other job"), "Enqueue on another queue")
+
+			for i := 0; i < 3; i++ {
+				payload, err := queue.Dequeue(ctx(), name)
+				requireNoError(t, err, "Dequeue")
+				expectEqual(t, payload, fmt.Sprintf("job %d", i), "payload")
+			}
+			payload, err := queue.Dequeue(ctx(), other)
+			requireNoError(t, err, "Dequeue on another queue")
+			expectEqual(t, payload, "other job", "payload on another queue")
+		}},
+		{"dequeue_waits_for_a_job", func(t T) {
+			queue := newQueue(t)
+			name := queueName()
+
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				_ = queue.Enqueue(context.Background(), name, "late job")
+			}()
+			waitCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			payload, err := queue.Dequeue(waitCtx, name)
+			requireNoError(t, err, "Dequeue")
+			expectEqual(t, payload, "late job", "payload")
+		}},
+		{"dequeue_returns_when_ctx_is_done", func(t T) {
+			queue := newQueue(t)
+
+			waitCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			_, err := queue.Dequeue(waitCtx, queueName())
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Dequeue on an empty queue: got error %v, want %v", err, context.DeadlineExceeded)
+			}
+		}},
+	}
+}
+
+func queueName() string {
+	return "contract:" + primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,202 @@
+package contract
+
+import (
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JournalRepositoryCases returns the cases every infra.JournalRepository must
+// pass. newRepo must return an empty repository each time it is called.
+func JournalRepositoryCases(newRepo func(t T) infra.JournalRepository) []Case {
+	return []Case{
+		{"create_and_get", func(t T) {
+			repo := newRepo(t)
+			entry := newJournalEntry(primitive.NewObjectID(), now(), "A long day", "Traffic on Third Mainland Bridge again.")
+			entry.Tags, entry.MetricId = []string{"work", "commute"}, primitive.NewObjectID()
+			requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+
+			got, err := repo.GetJournalEntryById(ctx(), entry.ID)
+			requireNoError(t, err, "GetJournalEntryById")
+			expectJournalEntry(t, got, entry)
+		}},
+		{"missing_entry_is_not_found", func(t T) {
+			repo := newRepo(t)
+
+			_, err := repo.GetJournalEntryById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrJournalEntryNotFound, "GetJournalEntryById")
+
+			err = repo.UpdateJournalEntry(ctx(), newJournalEntry(primitive.NewObjectID(), now(), "title", "body"))
+			requireErrorIs(t, err, infra.ErrJournalEntryNotFound, "UpdateJournalEntry")
+
+			err = repo.DeleteJournalEntry(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrJournalEntryNotFound, "DeleteJournalEntry")
+		}},
+		{"update_replaces_search_terms", func(t T) {
+			repo := newRepo(t)
+			entry := newJournalEntry(primitive.NewObjectID(), now().Add(-time.Hour), "Exams", "Worried about exams")
+			requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+
+			entry.Title, entry.Body, entry.Tags = "Better", "Slept well after the match", []string{"sleep"}
+			entry.MetricId = primitive.NewObjectID()
+			entry.UpdatedAt = now()
+			requireNoError(t, repo.UpdateJournalEntry(ctx(), entry), "UpdateJournalEntry")
+
+			got, err := repo.GetJournalEntryById(ctx(), entry.ID)
+			requireNoError(t, err, "GetJournalEntryById")
+			expectJournalEntry(t, got, entry)
+
+			page, err := repo.GetJournalEntriesByUserId(ctx(), entry.UserId, infra.JournalFilter{Search: "exams"})
+			requireNoError(t, err, "GetJournalEntriesByUserId for the old body")
+			expectEqual(t, len(page.Entries), 0, "entries matching the old body")
+			page, err = repo.GetJournalEntriesByUserId(ctx(), entry.UserId, infra.JournalFilter{Search: "match"})
+			requireNoError(t, err, "GetJournalEntriesByUserId for the new body")
+			expectEqual(t, len(page.Entries), 1, "entries matching the new body")
+		}},
+		{"list_newest_first_in_pages", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			createdAt := now()
+			entries := []domain.JournalEntry{}
+			for i := 0; i < 5; i++ {
+				entry := newJournalEntry(userId, createdAt.Add(-time.Duration(i)*time.Minute), "title", "body")
+				entries = append(entries, entry)
+			}
+			tied := newJournalEntry(userId, createdAt, "title", "body")
+			entries = append(entries, tied, newJournalEntry(primitive.NewObjectID(), createdAt, "title", "body"))
+			for _, entry := range entries {
+				requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+			}
+
+			seen := []domain.JournalEntry{}
+			filter := infra.JournalFilter{Limit: 4}
+			for {
+				page, err := repo.GetJournalEntriesByUserId(ctx(), userId, filter)
+				requireNoError(t, err, "GetJournalEntriesByUserId")
+				expectEqual(t, page.Total, int64(6), "Total")
+				seen = append(seen, page.Entries...)
+				if page.NextCursor == "" {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+			if len(seen) != 6 {
+				t.Fatalf("GetJournalEntriesByUserId: got %d entries over all pages, want 6", len(seen))
+			}
+			for i := 1; i < len(seen); i++ {
+				previous, current := seen[i-1], seen[i]
+				if current.CreatedAt.After(previous.CreatedAt) ||
+					current.CreatedAt.Equal(previous.CreatedAt) && current.ID.Hex() > previous.ID.Hex() {
+					t.Errorf("entry %d is not older than entry %d", i, i-1)
+				}
+			}
+		}},
+		{"search_matches_all_terms_in_title_and_body", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			both := newJournalEntry(userId, now(), "Exam week", "I could not sleep before the exam")
+			titleOnly := newJournalEntry(userId, now(), "Sleep", "Nothing to add")
+			bodyOnly := newJournalEntry(userId, now(), "Untitled", "The EXAM was fine")
+			otherUser := newJournalEntry(primitive.NewObjectID(), now(), "Exam", "sleep")
+			for _, entry := range []domain.JournalEntry{both, titleOnly, bodyOnly, otherUser} {
+				requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+			}
+
+			page, err := repo.GetJournalEntriesByUserId(ctx(), userId, infra.JournalFilter{Search: "Sleep exam"})
+			requireNoError(t, err, "GetJournalEntriesByUserId")
+			if len(page.Entries) != 1 {
+				t.Fatalf("GetJournalEntriesByUserId: got %d entries, want 1", len(page.Entries))
+			}
+			expectEqual(t, page.Entries[0].ID, both.ID, "ID of the entry matching every term")
+			expectEqual(t, page.Total, int64(1), "Total")
+
+			page, err = repo.GetJournalEntriesByUserId(ctx(), userId, infra.JournalFilter{Search: "exam"})
+			requireNoError(t, err, "GetJournalEntriesByUserId")
+			expectEqual(t, len(page.Entries), 2, "entries matching a body word")
+		}},
+		{"filter_by_tag_and_date_range", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			from := now().Add(-2 * time.Hour)
+			tooOld := newJournalEntry(userId, from.Add(-time.Minute), "title", "body")
+			inRange := newJournalEntry(userId, from, "title", "body")
+			untagged := newJournalEntry(userId, from.Add(time.Minute), "title", "body")
+			tooNew := newJournalEntry(userId, now(), "title", "body")
+			for _, entry := range []*domain.JournalEntry{&tooOld, &inRange, &tooNew} {
+				entry.Tags = []string{"family", "work"}
+			}
+			untagged.Tags = []string{"family-time"}
+			for _, entry := range []domain.JournalEntry{tooOld, inRange, untagged, tooNew} {
+				requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+			}
+
+			page, err := repo.GetJournalEntriesByUserId(ctx(), userId, infra.JournalFilter{Tag: "family", From: from, To: tooNew.CreatedAt})
+			requireNoError(t, err, "GetJournalEntriesByUserId")
+			if len(page.Entries) != 1 {
+				t.Fatalf("GetJournalEntriesByUserId: got %d entries, want 1", len(page.Entries))
+			}
+			expectEqual(t, page.Entries[0].ID, inRange.ID, "ID of the entry in range with the tag")
+		}},
+		{"delete", func(t T) {
+			repo := newRepo(t)
+			entry := newJournalEntry(primitive.NewObjectID(), now(), "title", "a searchable body")
+			requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+
+			requireNoError(t, repo.DeleteJournalEntry(ctx(), entry.ID), "DeleteJournalEntry")
+
+			_, err := repo.GetJournalEntryById(ctx(), entry.ID)
+			requireErrorIs(t, err, infra.ErrJournalEntryNotFound, "GetJournalEntryById of the deleted entry")
+			page, err := repo.GetJournalEntriesByUserId(ctx(), entry.UserId, infra.JournalFilter{Search: "searchable"})
+			requireNoError(t, err, "GetJournalEntriesByUserId")
+			expectEqual(t, len(page.Entries), 0, "entries left matching the deleted body")
+		}},
+		{"delete_by_user", func(t T) {
+			repo := newRepo(t)
+			userId, other := primitive.NewObjectID(), primitive.NewObjectID()
+			for _, entry := range []domain.JournalEntry{
+				newJournalEntry(userId, now(), "title", "body"),
+				newJournalEntry(userId, now(), "title", "body"),
+				newJournalEntry(other, now(), "title", "body"),
+			} {
+				requireNoError(t, repo.CreateJournalEntry(ctx(), entry), "CreateJournalEntry")
+			}
+
+			requireNoError(t, repo.DeleteJournalEntriesByUserId(ctx(), userId), "DeleteJournalEntriesByUserId")
+			requireNoError(t, repo.DeleteJournalEntriesByUserId(ctx(), userId), "DeleteJournalEntriesByUserId again")
+
+			page, err := repo.GetJournalEntriesByUserId(ctx(), userId, infra.JournalFilter{})
+			requireNoError(t, err, "GetJournalEntriesByUserId")
+			expectEqual(t, len(page.Entries), 0, "entries left for the deleted user")
+			page, err = repo.GetJournalEntriesByUserId(ctx(), other, infra.JournalFilter{})
+			requireNoError(t, err, "GetJournalEntriesByUserId for another user")
+			expectEqual(t, len(page.Entries), 1, "entries left for another user")
+		}},
+	}
+}
+
+func newJournalEntry(userId primitive.ObjectID, createdAt time.Time, title, body string) domain.JournalEntry {
+	return domain.JournalEntry{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		Title:     title,
+		Body:      body,
+		Tags:      []string{},
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func expectJournalEntry(t T, got, want domain.JournalEntry) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.Title, want.Title, "Title")
+	expectEqual(t, got.Body, want.Body, "Body")
+	expectEqual(t, strings.Join(got.Tags, ","), strings.Join(want.Tags, ","), "Tags")
+	expectEqual(t, got.MetricId, want.MetricId, "MetricId")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
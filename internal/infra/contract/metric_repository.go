@@ -0,0 +1,349 @@
+package contract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetricRepositoryCases returns the cases every infra.MetricRepository must
+// pass. newRepo must return an empty repository each time it is called.
+func MetricRepositoryCases(newRepo func(t T) infra.MetricRepository) []Case {
+	return []Case{
+		{"create_and_get_by_id", func(t T) {
+			repo := newRepo(t)
+			metric := newMetric(primitive.NewObjectID(), now())
+
+			requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+
+			got, err := repo.GetMetricById(ctx(), metric.ID)
+			requireNoError(t, err, "GetMetricById")
+			expectMetric(t, got, metric)
+		}},
+		{"missing_metric_is_not_found", func(t T) {
+			repo := newRepo(t)
+
+			_, err := repo.GetMetricById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetMetricById")
+
+			err = repo.DeleteMetricById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "DeleteMetricById")
+		}},
+		{"one_log_per_user_and_local_date", func(t T) {
+			repo := newRepo(t)
+			owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+			first := newMetric(owner, now())
+			first.LocalDate = "2023-11-06"
+			requireNoError(t, repo.CreateMetric(ctx(), first), "CreateMetric")
+
+			duplicate := newMetric(owner, now())
+			duplicate.LocalDate = first.LocalDate
+			requireErrorIs(t, repo.CreateMetric(ctx(), duplicate), infra.ErrDailyLogExists, "CreateMetric on the same day")
+			_, err := repo.GetMetricById(ctx(), duplicate.ID)
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetMetricById of the rejected log")
+
+			nextDay := newMetric(owner, now())
+			nextDay.LocalDate = "2023-11-07"
+			requireNoError(t, repo.CreateMetric(ctx(), nextDay), "CreateMetric on the next day")
+			otherUser := newMetric(other, now())
+			otherUser.LocalDate = first.LocalDate
+			requireNoError(t, repo.CreateMetric(ctx(), otherUser), "CreateMetric for another user")
+
+			// logs stored before local dates were recorded have none
+			requireNoError(t, repo.CreateMetric(ctx(), newMetric(owner, now())), "CreateMetric without a local date")
+			requireNoError(t, repo.CreateMetric(ctx(), newMetric(owner, now())), "CreateMetric without a local date")
+
+			got, err := repo.GetMetricById(ctx(), first.ID)
+			requireNoError(t, err, "GetMetricById")
+			expectMetric(t, got, first)
+		}},
+		{"update_by_id", func(t T) {
+			repo := newRepo(t)
+			metric := newMetric(primitive.NewObjectID(), now())
+			requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+
+			updated := metric
+			updated.Mood = domain.DEPRESSED
+			updated.SleepQuality = domain.WORST
+			updated.StressLevel = 5
+			updated.StressLessScore = 3
+			updated.Feeling = "changed my mind"
+			updated.FeelingPolarity = -0.4
+			updated.Themes = []domain.Theme{domain.MoneyTheme}
+			updated.UpdatedAt = now().Add(time.Minute)
+			requireNoError(t, repo.UpdateMetricById(ctx(), updated), "UpdateMetricById")
+
+			got, err := repo.GetMetricById(ctx(), metric.ID)
+			requireNoError(t, err, "GetMetricById")
+			expectMetric(t, got, updated)
+		}},
+		{"delete_by_id_and_by_user", func(t T) {
+			repo := newRepo(t)
+			owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+			first, second := newMetric(owner, now()), newMetric(owner, now().Add(-time.Hour))
+			kept := newMetric(other, now())
+			for _, metric := range []domain.Metric{first, second, kept} {
+				requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+			}
+
+			requireNoError(t, repo.DeleteMetricById(ctx(), first.ID), "DeleteMetricById")
+			requireErrorIs(t, repo.DeleteMetricById(ctx(), first.ID), infra.ErrMetricNotFound, "DeleteMetricById again")
+			_, err := repo.GetMetricById(ctx(), second.ID)
+			requireNoError(t, err, "GetMetricById for a sibling")
+
+			requireNoError(t, repo.DeleteMetricsByUserId(ctx(), owner), "DeleteMetricsByUserId")
+			_, err = repo.GetMetricById(ctx(), second.ID)
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetMetricById after DeleteMetricsByUserId")
+			_, err = repo.GetMetricById(ctx(), kept.ID)
+			requireNoError(t, err, "GetMetricById for another user")
+		}},
+		{"metric_days_are_oldest_first", func(t T) {
+			repo := newRepo(t)
+			owner := primitive.NewObjectID()
+			newest, oldest := newMetric(owner, now()), newMetric(owner, now().Add(-48*time.Hour))
+			newest.LocalDate = "2023-11-12"
+			for _, metric := range []domain.Metric{newest, oldest, newMetric(primitive.NewObjectID(), now())} {
+				requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+			}
+
+			days, err := repo.GetMetricDays(ctx(), owner)
+			requireNoError(t, err, "GetMetricDays")
+			if len(days) != 2 {
+				t.Fatalf("GetMetricDays: got %d days, want 2", len(days))
+			}
+			expectEqual(t, days[0].MetricId, oldest.ID, "MetricId of the oldest metric")
+			expectEqual(t, days[0].LocalDate, "", "LocalDate of a metric without one")
+			expectSameTime(t, days[0].CreatedAt, oldest.CreatedAt, "CreatedAt of the oldest metric")
+			expectEqual(t, days[1].MetricId, newest.ID, "MetricId of the newest metric")
+			expectEqual(t, days[1].LocalDate, newest.LocalDate, "LocalDate of the newest metric")
+			expectSameTime(t, days[1].CreatedAt, newest.CreatedAt, "CreatedAt of the newest metric")
+
+			days, err = repo.GetMetricDays(ctx(), primitive.NewObjectID())
+			requireNoError(t, err, "GetMetricDays for a user without metrics")
+			expectEqual(t, len(days), 0, "number of days for a user without metrics")
+		}},
+		{"today_log_uses_the_users_day_around_midnight", func(t T) {
+			repo := newRepo(t)
+			owner := primitive.NewObjectID()
+			current := now()
+			// a zone where it is half past midnight right now
+			location := zoneAt(current, 30*time.Minute)
+
+			yesterday := newMetric(owner, current.Add(-45*time.Minute))
+			requireNoError(t, repo.CreateMetric(ctx(), yesterday), "CreateMetric")
+			requireNoError(t, repo.CreateMetric(ctx(), newMetric(primitive.NewObjectID(), current)), "CreateMetric for another user")
+
+			_, err := repo.GetUserTodayLogIfExists(ctx(), owner, location)
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetUserTodayLogIfExists before midnight")
+
+			today := newMetric(owner, current.Add(-10*time.Minute))
+			requireNoError(t, repo.CreateMetric(ctx(), today), "CreateMetric")
+
+			got, err := repo.GetUserTodayLogIfExists(ctx(), owner, location)
+			requireNoError(t, err, "GetUserTodayLogIfExists after midnight")
+			expectMetric(t, got, today)
+		}},
+		{"list_is_newest_first_and_pages_with_a_cursor", func(t T) {
+			repo := newRepo(t)
+			owner := primitive.NewObjectID()
+			base := now().Add(-24 * time.Hour)
+			sameTime := base.Add(2 * time.Hour)
+			metrics := []domain.Metric{
+				newMetric(owner, base),
+				newMetric(owner, base.Add(time.Hour)),
+				newMetric(owner, sameTime),
+				newMetric(owner, sameTime),
+				newMetric(owner, base.Add(3*time.Hour)),
+			}
+			for _, metric := range metrics {
+				requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+			}
+			requireNoError(t, repo.CreateMetric(ctx(), newMetric(primitive.NewObjectID(), base.Add(time.Hour))), "CreateMetric for another user")
+
+			// newest first, ties broken by the larger id
+			want := []primitive.ObjectID{metrics[4].ID, metrics[3].ID, metrics[2].ID, metrics[1].ID, metrics[0].ID}
+
+			all, err := repo.GetMetricsByUserId(ctx(), owner, infra.MetricFilter{})
+			requireNoError(t, err, "GetMetricsByUserId without a limit")
+			expectEqual(t, all.Total, int64(len(metrics)), "Total")
+			expectEqual(t, all.NextCursor, "", "NextCursor without a limit")
+			expectIds(t, all.Metrics, want)
+
+			got := []domain.Metric{}
+			cursor := ""
+			for pages := 0; pages < len(metrics); pages++ {
+				page, err := repo.GetMetricsByUserId(ctx(), owner, infra.MetricFilter{Limit: 2, Cursor: cursor})
+				requireNoError(t, err, "GetMetricsByUserId")
+				expectEqual(t, page.Total, int64(len(metrics)), "Total of a page")
+				expectEqual(t, page.Limit, 2, "Limit")
+				got = append(got, page.Metrics...)
+				cursor = page.NextCursor
+				if cursor == "" {
+					break
+				}
+			}
+			expectIds(t, got, want)
+
+			_, err = repo.GetMetricsByUserId(ctx(), owner, infra.MetricFilter{Cursor: "not a cursor"})
+			requireErrorIs(t, err, infra.ErrInvalidCursor, "GetMetricsByUserId with an invalid cursor")
+		}},
+		{"list_from_is_inclusive_and_to_is_exclusive", func(t T) {
+			repo := newRepo(t)
+			owner := primitive.NewObjectID()
+			from := now().Add(-48 * time.Hour)
+			to := from.Add(24 * time.Hour)
+			before := newMetric(owner, from.Add(-time.Millisecond))
+			atFrom := newMetric(owner, from)
+			inside := newMetric(owner, from.Add(12*time.Hour))
+			atTo := newMetric(owner, to)
+			for _, metric := range []domain.Metric{before, atFrom, inside, atTo} {
+				requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+			}
+
+			page, err := repo.GetMetricsByUserId(ctx(), owner, infra.MetricFilter{From: from, To: to})
+			requireNoError(t, err, "GetMetricsByUserId")
+			expectEqual(t, page.Total, int64(2), "Total")
+			expectIds(t, page.Metrics, []primitive.ObjectID{inside.ID, atFrom.ID})
+
+			page, err = repo.GetMetricsByUserId(ctx(), owner, infra.MetricFilter{From: from})
+			requireNoError(t, err, "GetMetricsByUserId with only from")
+			expectIds(t, page.Metrics, []primitive.ObjectID{atTo.ID, inside.ID, atFrom.ID})
+		}},
+		{"summary_buckets_in_the_requested_location", func(t T) {
+			repo := newRepo(t)
+			owner := primitive.NewObjectID()
+			lagos, err := time.LoadLocation("Africa/Lagos")
+			requireNoError(t, err, "LoadLocation")
+
+			for _, metric := range []domain.Metric{
+				withValues(newMetric(owner, time.Date(2023, 11, 6, 10, 0, 0, 0, time.UTC)), 80, 2, domain.HAPPY, domain.GOOD),
+				withValues(newMetric(owner, time.Date(2023, 11, 6, 12, 0, 0, 0, time.UTC)), 60, 3, domain.NEUTRAL, domain.FAIR),
+				// 00:30 on Wednesday the 8th in Lagos
+				withValues(newMetric(owner, time.Date(2023, 11, 7, 23, 30, 0, 0, time.UTC)), 40, 4, domain.SAD, domain.POOR),
+				// 00:30 on Monday the 13th in Lagos, the next ISO week
+				withValues(newMetric(owner, time.Date(2023, 11, 12, 23, 30, 0, 0, time.UTC)), 90, 1, domain.OVERJOYED, domain.EXCELLENT),
+			} {
+				requireNoError(t, repo.CreateMetric(ctx(), metric), "CreateMetric")
+			}
+			requireNoError(t, repo.CreateMetric(ctx(), newMetric(primitive.NewObjectID(), time.Date(2023, 11, 6, 10, 0, 0, 0, time.UTC))), "CreateMetric for another user")
+
+			buckets, err := repo.GetMetricSummary(ctx(), owner, infra.MetricSummaryFilter{
+				From:        time.Date(2023, 11, 1, 0, 0, 0, 0, lagos),
+				To:          time.Date(2023, 12, 1, 0, 0, 0, 0, lagos),
+				Granularity: domain.WeeklySummary,
+				Location:    lagos,
+			})
+			requireNoError(t, err, "GetMetricSummary")
+			if len(buckets) != 2 {
+				t.Fatalf("got %d weekly buckets, want 2", len(buckets))
+			}
+
+			first := buckets[0]
+			expectSameTime(t, first.Start, time.Date(2023, 11, 6, 0, 0, 0, 0, lagos), "first bucket Start")
+			expectSameTime(t, first.End, time.Date(2023, 11, 13, 0, 0, 0, 0, lagos), "first bucket End")
+			expectEqual(t, first.MetricCount, 3, "first bucket MetricCount")
+			expectEqual(t, first.AverageStressLessScore, 60.0, "first bucket AverageStressLessScore")
+			expectEqual(t, first.AverageStressLevel, 3.0, "first bucket AverageStressLevel")
+			expectEqual(t, first.MoodDistribution[domain.HAPPY], 1, "first bucket happy count")
+			expectEqual(t, first.MoodDistribution[domain.SAD], 1, "first bucket sad count")
+			expectEqual(t, first.SleepQualityDistribution[domain.POOR], 1, "first bucket poor sleep count")
+			expectEqual(t, first.BestDay, domain.MetricSummaryDay{Date: "2023-11-06", AverageStressLessScore: 70}, "first bucket BestDay")
+			expectEqual(t, first.WorstDay, domain.MetricSummaryDay{Date: "2023-11-08", AverageStressLessScore: 40}, "first bucket WorstDay")
+
+			second := buckets[1]
+			expectSameTime(t, second.Start, time.Date(2023, 11, 13, 0, 0, 0, 0, lagos), "second bucket Start")
+			expectEqual(t, second.MetricCount, 1, "second bucket MetricCount")
+			expectEqual(t, second.BestDay, second.WorstDay, "second bucket BestDay and WorstDay")
+
+			inUTC, err := repo.GetMetricSummary(ctx(), owner, infra.MetricSummaryFilter{Granularity: domain.WeeklySummary, Location: time.UTC})
+			requireNoError(t, err, "GetMetricSummary in UTC")
+			if len(inUTC) != 1 {
+				t.Fatalf("got %d weekly buckets in UTC, want 1", len(inUTC))
+			}
+			expectEqual(t, inUTC[0].MetricCount, 4, "UTC bucket MetricCount")
+
+			days, err := repo.GetMetricSummary(ctx(), owner, infra.MetricSummaryFilter{
+				To:          time.Date(2023, 11, 13, 0, 0, 0, 0, lagos),
+				Granularity: domain.DailySummary,
+				Location:    lagos,
+			})
+			requireNoError(t, err, "GetMetricSummary by day")
+			if len(days) != 2 {
+				t.Fatalf("got %d daily buckets, want 2", len(days))
+			}
+			expectEqual(t, days[0].BestDay.Date, "2023-11-06", "first day")
+			expectEqual(t, days[1].BestDay.Date, "2023-11-08", "second day")
+		}},
+	}
+}
+
+func newMetric(ownerId primitive.ObjectID, createdAt time.Time) domain.Metric {
+	return domain.Metric{
+		ID:              primitive.NewObjectID(),
+		OwnerId:         ownerId,
+		StressLevel:     2,
+		Mood:            domain.HAPPY,
+		SleepQuality:    domain.GOOD,
+		Feeling:         "fine",
+		FeelingPolarity: 0.25,
+		Themes:          []domain.Theme{domain.WorkTheme, domain.SleepTheme},
+		StressLessScore: 75,
+		SafetyFlags:     []domain.SafetyFlag{domain.SustainedLowMoodFlag},
+		CreatedAt:       createdAt.UTC().Truncate(time.Millisecond),
+		UpdatedAt:       createdAt.UTC().Truncate(time.Millisecond),
+	}
+}
+
+func withValues(metric domain.Metric, score, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality) domain.Metric {
+	metric.StressLessScore = score
+	metric.StressLevel = stressLevel
+	metric.Mood = mood
+	metric.SleepQuality = sleepQuality
+	return metric
+}
+
+// zoneAt returns a fixed zone whose wall clock reads sinceMidnight at t.
+func zoneAt(t time.Time, sinceMidnight time.Duration) *time.Location {
+	utcMidnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (sinceMidnight - t.Sub(utcMidnight)) % (24 * time.Hour)
+	if offset > 12*time.Hour {
+		offset -= 24 * time.Hour
+	}
+	if offset <= -12*time.Hour {
+		offset += 24 * time.Hour
+	}
+	return time.FixedZone("contract", int(offset.Seconds()))
+}
+
+func expectMetric(t T, got, want domain.Metric) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.OwnerId, want.OwnerId, "OwnerId")
+	expectEqual(t, got.StressLevel, want.StressLevel, "StressLevel")
+	expectEqual(t, got.Mood, want.Mood, "Mood")
+	expectEqual(t, got.SleepQuality, want.SleepQuality, "SleepQuality")
+	expectEqual(t, got.Feeling, want.Feeling, "Feeling")
+	expectEqual(t, got.FeelingPolarity, want.FeelingPolarity, "FeelingPolarity")
+	expectEqual(t, fmt.Sprint(got.Themes), fmt.Sprint(want.Themes), "Themes")
+	expectEqual(t, got.StressLessScore, want.StressLessScore, "StressLessScore")
+	expectEqual(t, fmt.Sprint(got.SafetyFlags), fmt.Sprint(want.SafetyFlags), "SafetyFlags")
+	expectEqual(t, got.LocalDate, want.LocalDate, "LocalDate")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
+
+func expectIds(t T, got []domain.Metric, want []primitive.ObjectID) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %d metrics, want %d", len(got), len(want))
+		return
+	}
+	for i := range want {
+		if got[i].ID != want[i] {
+			t.Errorf("metric %d: got %s, want %s", i, got[i].ID.Hex(), want[i].Hex())
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package contract
+
+import (
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecommendationRepositoryCases returns the cases every
+// infra.RecommendationRepository must pass. newRepo must return an empty
+// repository each time it is called.
+func RecommendationRepositoryCases(newRepo func(t T) infra.RecommendationRepository) []Case {
+	return []Case{
+		{"create_and_get_by_id", func(t T) {
+			repo := newRepo(t)
+			recommendation := newRecommendation(primitive.NewObjectID(), domain.MoodMetricType)
+
+			requireNoError(t, repo.CreateRecommendation(ctx(), recommendation), "CreateRecommendation")
+
+			got, err := repo.GetRecommendationById(ctx(), recommendation.ID)
+			requireNoError(t, err, "GetRecommendationById")
+			expectRecommendation(t, got, recommendation)
+		}},
+		{"missing_recommendation_is_not_found", func(t T) {
+			repo := newRepo(t)
+
+			_, err := repo.GetRecommendationById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrRecommendationNotFound, "GetRecommendationById")
+
+			_, err = repo.GetRecommendationByMetricId(ctx(), primitive.NewObjectID(), domain.MoodMetricType)
+			requireErrorIs(t, err, infra.ErrRecommendationNotFound, "GetRecommendationByMetricId")
+		}},
+		{"get_by_metric_id_filters_on_metric_type", func(t T) {
+			repo := newRepo(t)
+			metricId := primitive.NewObjectID()
+			byType := map[string]domain.Recommendation{}
+			for _, metricType := range metricTypes {
+				recommendation := newRecommendation(metricId, metricType)
+				byType[metricType] = recommendation
+				requireNoError(t, repo.CreateRecommendation(ctx(), recommendation), "CreateRecommendation")
+			}
+			requireNoError(t, repo.CreateRecommendation(ctx(), newRecommendation(primitive.NewObjectID(), domain.MoodMetricType)), "CreateRecommendation")
+
+			for _, metricType := range metricTypes {
+				got, err := repo.GetRecommendationByMetricId(ctx(), metricId, metricType)
+				requireNoError(t, err, "GetRecommendationByMetricId "+metricType)
+				expectRecommendation(t, got, byType[metricType])
+			}
+
+			_, err := repo.GetRecommendationByMetricId(ctx(), metricId, "unknown")
+			requireErrorIs(t, err, infra.ErrRecommendationNotFound, "GetRecommendationByMetricId with unknown type")
+		}},
+		{"update_replaces_items", func(t T) {
+			repo := newRepo(t)
+			recommendation := newRecommendation(primitive.NewObjectID(), domain.SleepQualityMetricType)
+			requireNoError(t, repo.CreateRecommendation(ctx(), recommendation), "CreateRecommendation")
+
+			updated := recommendation
+			updated.Items = []domain.RecommendationItem{{Index: 0, Heading: "only", Text: "one item left"}}
+			updated.UpdatedAt = now()
+			requireNoError(t, repo.UpdateRecommendationById(ctx(), updated), "UpdateRecommendationById")
+
+			got, err := repo.GetRecommendationById(ctx(), recommendation.ID)
+			requireNoError(t, err, "GetRecommendationById")
+			expectRecommendation(t, got, updated)
+		}},
+		{"get_by_metric_ids", func(t T) {
+			repo := newRepo(t)
+			first, second, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+			for _, metricId := range []primitive.ObjectID{first, second, other} {
+				for _, metricType := range metricTypes {
+					requireNoError(t, repo.CreateRecommendation(ctx(), newRecommendation(metricId, metricType)), "CreateRecommendation")
+				}
+			}
+
+			got, err := repo.GetRecommendationsByMetricIds(ctx(), []primitive.ObjectID{first, second})
+			requireNoError(t, err, "GetRecommendationsByMetricIds")
+			expectEqual(t, len(got), 2*len(metricTypes), "number of recommendations")
+			for _, recommendation := range got {
+				if recommendation.MetricId == other {
+					t.Errorf("got a recommendation for metric %s which was not asked for", other.Hex())
+				}
+			}
+
+			none, err := repo.GetRecommendationsByMetricIds(ctx(), nil)
+			requireNoError(t, err, "GetRecommendationsByMetricIds without ids")
+			expectEqual(t, len(none), 0, "number of recommendations without ids")
+		}},
+		{"pending_oldest_first_until_ready", func(t T) {
+			repo := newRepo(t)
+			pending := []domain.Recommendation{}
+			for i := 0; i < 3; i++ {
+				recommendation := newRecommendation(primitive.NewObjectID(), domain.MoodMetricType)
+				recommendation.Items = []domain.RecommendationItem{}
+				recommendation.Status = domain.RecommendationPending
+				recommendation.CreatedAt = now().Add(time.Duration(i-3) * time.Minute)
+				requireNoError(t, repo.CreateRecommendation(ctx(), recommendation), "CreateRecommendation")
+				pending = append(pending, recommendation)
+			}
+			requireNoError(t, repo.CreateRecommendation(ctx(), newRecommendation(primitive.NewObjectID(), domain.MoodMetricType)), "CreateRecommendation")
+
+			got, err := repo.GetPendingRecommendations(ctx(), 2)
+			requireNoError(t, err, "GetPendingRecommendations")
+			expectEqual(t, len(got), 2, "number of pending recommendations")
+			for i := range got {
+				expectRecommendation(t, got[i], pending[i])
+			}
+
+			ready := pending[0]
+			ready.Items = []domain.RecommendationItem{{Index: 0, Heading: "ready", Text: "generated"}}
+			ready.Status = domain.RecommendationReady
+			ready.UpdatedAt = now()
+			requireNoError(t, repo.UpdateRecommendationById(ctx(), ready), "UpdateRecommendationById")
+
+			got, err = repo.GetPendingRecommendations(ctx(), 10)
+			requireNoError(t, err, "GetPendingRecommendations after update")
+			expectEqual(t, len(got), 2, "number of pending recommendations after update")
+			for _, recommendation := range got {
+				if recommendation.ID == ready.ID {
+					t.Errorf("recommendation %s is still pending after it was marked ready", ready.ID.Hex())
+				}
+			}
+			stored, err := repo.GetRecommendationById(ctx(), ready.ID)
+			requireNoError(t, err, "GetRecommendationById")
+			expectRecommendation(t, stored, ready)
+		}},
+		{"delete_by_metric_ids", func(t T) {
+			repo := newRepo(t)
+			first, second, kept := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+			for _, metricId := range []primitive.ObjectID{first, second, kept} {
+				requireNoError(t, repo.CreateRecommendation(ctx(), newRecommendation(metricId, domain.MoodMetricType)), "CreateRecommendation")
+			}
+
+			requireNoError(t, repo.DeleteRecommendationsByMetricId(ctx(), first), "DeleteRecommendationsByMetricId")
+			requireNoError(t, repo.DeleteRecommendationsByMetricIds(ctx(), []primitive.ObjectID{second}), "DeleteRecommendationsByMetricIds")
+			requireNoError(t, repo.DeleteRecommendationsByMetricIds(ctx(), nil), "DeleteRecommendationsByMetricIds without ids")
+
+			for _, metricId := range []primitive.ObjectID{first, second} {
+				_, err := repo.GetRecommendationByMetricId(ctx(), metricId, domain.MoodMetricType)
+				requireErrorIs(t, err, infra.ErrRecommendationNotFound, "GetRecommendationByMetricId after delete")
+			}
+			_, err := repo.GetRecommendationByMetricId(ctx(), kept, domain.MoodMetricType)
+			requireNoError(t, err, "GetRecommendationByMetricId for a kept metric")
+		}},
+	}
+}
+
+var metricTypes = []string{
+	domain.StressLessScoreMetricType,
+	domain.StressLevelMetricType,
+	domain.SleepQualityMetricType,
+	domain.MoodMetricType,
+}
+
+func newRecommendation(metricId primitive.ObjectID, metricType string) domain.Recommendation {
+	return domain.Recommendation{
+		ID:         primitive.NewObjectID(),
+		MetricId:   metricId,
+		MetricType: metricType,
+		Items: []domain.RecommendationItem{
+			{Index: 0, Heading: metricType + " heading 0", Text: "text 0", ImageUrl: "https://example.com/0.png"},
+			{Index: 1, Heading: metricType + " heading 1", Text: "text 1"},
+		},
+		Status:    domain.RecommendationReady,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+}
+
+func expectRecommendation(t T, got, want domain.Recommendation) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.MetricId, want.MetricId, "MetricId")
+	expectEqual(t, got.MetricType, want.MetricType, "MetricType")
+	expectEqual(t, got.Status, want.Status, "Status")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+	if len(got.Items) != len(want.Items) {
+		t.Errorf("Items: got %d items, want %d", len(got.Items), len(want.Items))
+		return
+	}
+	for i := range want.Items {
+		expectEqual(t, got.Items[i], want.Items[i], "Items")
+	}
+}
@@ -0,0 +1,139 @@
+package contract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SafetyRepositoryCases returns the cases every infra.SafetyRepository must
+// pass. newRepo must return an empty repository each time it is called.
+func SafetyRepositoryCases(newRepo func(t T) infra.SafetyRepository) []Case {
+	return []Case{
+		{"list_events_newest_first", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+			oldest := newSafetyEvent(userId, now().Add(-2*time.Hour))
+			newest := newSafetyEvent(userId, now())
+			override := newSafetyEvent(userId, now().Add(-time.Hour))
+			override.Kind, override.MetricId, override.Flags = domain.OverrideSetEvent, primitive.NilObjectID, []domain.SafetyFlag{}
+			override.Escalated, override.Country, override.ActorId = false, "", primitive.NewObjectID()
+			for _, event := range []domain.SafetyEvent{oldest, newest, override, newSafetyEvent(primitive.NewObjectID(), now())} {
+				requireNoError(t, repo.CreateSafetyEvent(ctx(), event), "CreateSafetyEvent")
+			}
+
+			events, err := repo.GetSafetyEventsByUserId(ctx(), userId, 0)
+			requireNoError(t, err, "GetSafetyEventsByUserId")
+			if len(events) != 3 {
+				t.Fatalf("GetSafetyEventsByUserId: got %d events, want 3", len(events))
+			}
+			expectSafetyEvent(t, events[0], newest)
+			expectSafetyEvent(t, events[1], override)
+			expectSafetyEvent(t, events[2], oldest)
+
+			events, err = repo.GetSafetyEventsByUserId(ctx(), userId, 2)
+			requireNoError(t, err, "GetSafetyEventsByUserId with a limit")
+			if len(events) != 2 {
+				t.Fatalf("GetSafetyEventsByUserId with a limit: got %d events, want 2", len(events))
+			}
+			expectEqual(t, events[0].ID, newest.ID, "ID of the newest event")
+		}},
+		{"override_upsert_get_and_delete", func(t T) {
+			repo := newRepo(t)
+			userId := primitive.NewObjectID()
+
+			_, err := repo.GetSafetyOverride(ctx(), userId)
+			requireErrorIs(t, err, infra.ErrSafetyOverrideNotFound, "GetSafetyOverride before it is saved")
+			err = repo.DeleteSafetyOverride(ctx(), userId)
+			requireErrorIs(t, err, infra.ErrSafetyOverrideNotFound, "DeleteSafetyOverride before it is saved")
+
+			override := newSafetyOverride(userId, now().Add(-time.Hour))
+			requireNoError(t, repo.SaveSafetyOverride(ctx(), override), "SaveSafetyOverride")
+			got, err := repo.GetSafetyOverride(ctx(), userId)
+			requireNoError(t, err, "GetSafetyOverride")
+			expectSafetyOverride(t, got, override)
+
+			override.LowMoodDays, override.SuppressEscalationUntil = 0, time.Time{}
+			override.ClinicianId, override.UpdatedAt = primitive.NewObjectID(), now()
+			requireNoError(t, repo.SaveSafetyOverride(ctx(), override), "SaveSafetyOverride again")
+			got, err = repo.GetSafetyOverride(ctx(), userId)
+			requireNoError(t, err, "GetSafetyOverride after it is replaced")
+			expectSafetyOverride(t, got, override)
+
+			requireNoError(t, repo.DeleteSafetyOverride(ctx(), userId), "DeleteSafetyOverride")
+			_, err = repo.GetSafetyOverride(ctx(), userId)
+			requireErrorIs(t, err, infra.ErrSafetyOverrideNotFound, "GetSafetyOverride after it is deleted")
+		}},
+		{"delete_by_user", func(t T) {
+			repo := newRepo(t)
+			userId, other := primitive.NewObjectID(), primitive.NewObjectID()
+			for _, id := range []primitive.ObjectID{userId, other} {
+				requireNoError(t, repo.CreateSafetyEvent(ctx(), newSafetyEvent(id, now())), "CreateSafetyEvent")
+				requireNoError(t, repo.SaveSafetyOverride(ctx(), newSafetyOverride(id, now())), "SaveSafetyOverride")
+			}
+
+			requireNoError(t, repo.DeleteSafetyDataByUserId(ctx(), userId), "DeleteSafetyDataByUserId")
+			requireNoError(t, repo.DeleteSafetyDataByUserId(ctx(), userId), "DeleteSafetyDataByUserId again")
+
+			events, err := repo.GetSafetyEventsByUserId(ctx(), userId, 0)
+			requireNoError(t, err, "GetSafetyEventsByUserId")
+			expectEqual(t, len(events), 0, "events left for the deleted user")
+			_, err = repo.GetSafetyOverride(ctx(), userId)
+			requireErrorIs(t, err, infra.ErrSafetyOverrideNotFound, "GetSafetyOverride for the deleted user")
+
+			events, err = repo.GetSafetyEventsByUserId(ctx(), other, 0)
+			requireNoError(t, err, "GetSafetyEventsByUserId for another user")
+			expectEqual(t, len(events), 1, "events left for another user")
+			_, err = repo.GetSafetyOverride(ctx(), other)
+			requireNoError(t, err, "GetSafetyOverride for another user")
+		}},
+	}
+}
+
+func newSafetyEvent(userId primitive.ObjectID, createdAt time.Time) domain.SafetyEvent {
+	return domain.SafetyEvent{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		Kind:      domain.MetricFlaggedEvent,
+		MetricId:  primitive.NewObjectID(),
+		Flags:     []domain.SafetyFlag{domain.HighRiskPhraseFlag, domain.SustainedLowMoodFlag},
+		Escalated: true,
+		Country:   "NG",
+		CreatedAt: createdAt,
+	}
+}
+
+func newSafetyOverride(userId primitive.ObjectID, updatedAt time.Time) domain.SafetyOverride {
+	return domain.SafetyOverride{
+		UserId:                  userId,
+		LowMoodDays:             5,
+		SuppressEscalationUntil: updatedAt.Add(24 * time.Hour),
+		ClinicianId:             primitive.NewObjectID(),
+		UpdatedAt:               updatedAt,
+	}
+}
+
+func expectSafetyEvent(t T, got, want domain.SafetyEvent) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.Kind, want.Kind, "Kind")
+	expectEqual(t, got.MetricId, want.MetricId, "MetricId")
+	expectEqual(t, fmt.Sprint(got.Flags), fmt.Sprint(want.Flags), "Flags")
+	expectEqual(t, got.Escalated, want.Escalated, "Escalated")
+	expectEqual(t, got.Country, want.Country, "Country")
+	expectEqual(t, got.ActorId, want.ActorId, "ActorId")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+}
+
+func expectSafetyOverride(t T, got, want domain.SafetyOverride) {
+	t.Helper()
+	expectEqual(t, got.UserId, want.UserId, "UserId")
+	expectEqual(t, got.LowMoodDays, want.LowMoodDays, "LowMoodDays")
+	expectSameTime(t, got.SuppressEscalationUntil, want.SuppressEscalationUntil, "SuppressEscalationUntil")
+	expectEqual(t, got.ClinicianId, want.ClinicianId, "ClinicianId")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
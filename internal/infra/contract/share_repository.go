@@ -0,0 +1,158 @@
+package contract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShareRepositoryCases returns the cases every infra.ShareRepository must
+// pass. newRepo must return an empty repository each time it is called.
+func ShareRepositoryCases(newRepo func(t T) infra.ShareRepository) []Case {
+	return []Case{
+		{"create_and_get", func(t T) {
+			repo := newRepo(t)
+			share := newShare(primitive.NewObjectID(), now())
+			requireNoError(t, repo.CreateShare(ctx(), share), "CreateShare")
+
+			got, err := repo.GetShareById(ctx(), share.ID)
+			requireNoError(t, err, "GetShareById")
+			expectShare(t, got, share)
+			got, err = repo.GetShareByCodeHash(ctx(), share.CodeHash)
+			requireNoError(t, err, "GetShareByCodeHash")
+			expectShare(t, got, share)
+
+			_, err = repo.GetShareById(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrShareNotFound, "GetShareById for a missing share")
+			_, err = repo.GetShareByCodeHash(ctx(), "missing")
+			requireErrorIs(t, err, infra.ErrShareNotFound, "GetShareByCodeHash for a missing share")
+		}},
+		{"list_by_owner_newest_first", func(t T) {
+			repo := newRepo(t)
+			ownerId := primitive.NewObjectID()
+			oldest := newShare(ownerId, now().Add(-time.Hour))
+			newest := newShare(ownerId, now())
+			for _, share := range []domain.Share{oldest, newest, newShare(primitive.NewObjectID(), now())} {
+				requireNoError(t, repo.CreateShare(ctx(), share), "CreateShare")
+			}
+
+			shares, err := repo.GetSharesByOwnerId(ctx(), ownerId)
+			requireNoError(t, err, "GetSharesByOwnerId")
+			if len(shares) != 2 {
+				t.Fatalf("GetSharesByOwnerId: got %d shares, want 2", len(shares))
+			}
+			expectShare(t, shares[0], newest)
+			expectShare(t, shares[1], oldest)
+
+			shares, err = repo.GetSharesByOwnerId(ctx(), primitive.NewObjectID())
+			requireNoError(t, err, "GetSharesByOwnerId for an owner without shares")
+			expectEqual(t, len(shares), 0, "shares of an owner without shares")
+		}},
+		{"accept_only_once", func(t T) {
+			repo := newRepo(t)
+			share := newShare(primitive.NewObjectID(), now().Add(-time.Hour))
+			requireNoError(t, repo.CreateShare(ctx(), share), "CreateShare")
+
+			acceptedAt := now()
+			requireNoError(t, repo.AcceptShare(ctx(), share.ID, primitive.NilObjectID, acceptedAt), "AcceptShare")
+			err := repo.AcceptShare(ctx(), share.ID, primitive.NewObjectID(), acceptedAt.Add(time.Minute))
+			requireErrorIs(t, err, infra.ErrShareNotFound, "AcceptShare again")
+			err = repo.AcceptShare(ctx(), primitive.NewObjectID(), primitive.NilObjectID, acceptedAt)
+			requireErrorIs(t, err, infra.ErrShareNotFound, "AcceptShare for a missing share")
+
+			got, err := repo.GetShareById(ctx(), share.ID)
+			requireNoError(t, err, "GetShareById")
+			expectSameTime(t, got.AcceptedAt, acceptedAt, "AcceptedAt")
+			expectSameTime(t, got.UpdatedAt, acceptedAt, "UpdatedAt")
+			expectEqual(t, got.AcceptedBy, primitive.NilObjectID, "AcceptedBy")
+			expectEqual(t, got.Status(acceptedAt), domain.ActiveShare, "Status")
+		}},
+		{"accepted_by_clinician", func(t T) {
+			repo := newRepo(t)
+			share := newShare(primitive.NewObjectID(), now().Add(-time.Hour))
+			requireNoError(t, repo.CreateShare(ctx(), share), "CreateShare")
+
+			clinicianId := primitive.NewObjectID()
+			requireNoError(t, repo.AcceptShare(ctx(), share.ID, clinicianId, now()), "AcceptShare")
+
+			got, err := repo.GetShareById(ctx(), share.ID)
+			requireNoError(t, err, "GetShareById")
+			expectEqual(t, got.AcceptedBy, clinicianId, "AcceptedBy")
+			shares, err := repo.GetSharesByOwnerId(ctx(), share.OwnerId)
+			requireNoError(t, err, "GetSharesByOwnerId")
+			if len(shares) != 1 {
+				t.Fatalf("GetSharesByOwnerId: got %d shares, want 1", len(shares))
+			}
+			expectEqual(t, shares[0].AcceptedBy, clinicianId, "AcceptedBy of the listed share")
+		}},
+		{"revoke_keeps_first_revocation", func(t T) {
+			repo := newRepo(t)
+			share := newShare(primitive.NewObjectID(), now().Add(-time.Hour))
+			requireNoError(t, repo.CreateShare(ctx(), share), "CreateShare")
+
+			revokedAt := now()
+			requireNoError(t, repo.RevokeShare(ctx(), share.ID, revokedAt), "RevokeShare")
+			requireNoError(t, repo.RevokeShare(ctx(), share.ID, revokedAt.Add(time.Minute)), "RevokeShare again")
+			err := repo.RevokeShare(ctx(), primitive.NewObjectID(), revokedAt)
+			requireErrorIs(t, err, infra.ErrShareNotFound, "RevokeShare for a missing share")
+
+			got, err := repo.GetShareById(ctx(), share.ID)
+			requireNoError(t, err, "GetShareById")
+			expectSameTime(t, got.RevokedAt, revokedAt, "RevokedAt")
+			expectSameTime(t, got.UpdatedAt, revokedAt, "UpdatedAt")
+			expectEqual(t, got.Status(revokedAt), domain.RevokedShare, "Status")
+
+			err = repo.AcceptShare(ctx(), share.ID, primitive.NilObjectID, revokedAt)
+			requireErrorIs(t, err, infra.ErrShareNotFound, "AcceptShare after it is revoked")
+		}},
+		{"delete_by_owner", func(t T) {
+			repo := newRepo(t)
+			ownerId, other := primitive.NewObjectID(), primitive.NewObjectID()
+			for _, id := range []primitive.ObjectID{ownerId, ownerId, other} {
+				requireNoError(t, repo.CreateShare(ctx(), newShare(id, now())), "CreateShare")
+			}
+
+			requireNoError(t, repo.DeleteSharesByOwnerId(ctx(), ownerId), "DeleteSharesByOwnerId")
+			requireNoError(t, repo.DeleteSharesByOwnerId(ctx(), ownerId), "DeleteSharesByOwnerId again")
+
+			shares, err := repo.GetSharesByOwnerId(ctx(), ownerId)
+			requireNoError(t, err, "GetSharesByOwnerId")
+			expectEqual(t, len(shares), 0, "shares left for the deleted owner")
+			shares, err = repo.GetSharesByOwnerId(ctx(), other)
+			requireNoError(t, err, "GetSharesByOwnerId for another owner")
+			expectEqual(t, len(shares), 1, "shares left for another owner")
+		}},
+	}
+}
+
+func newShare(ownerId primitive.ObjectID, createdAt time.Time) domain.Share {
+	id := primitive.NewObjectID()
+	return domain.Share{
+		ID:        id,
+		OwnerId:   ownerId,
+		Label:     "Dr. Okafor",
+		CodeHash:  "hash-" + id.Hex(),
+		Scopes:    []domain.ShareScope{domain.StatsShareScope, domain.LogsShareScope},
+		ExpiresAt: createdAt.Add(30 * 24 * time.Hour),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func expectShare(t T, got, want domain.Share) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.OwnerId, want.OwnerId, "OwnerId")
+	expectEqual(t, got.Label, want.Label, "Label")
+	expectEqual(t, got.CodeHash, want.CodeHash, "CodeHash")
+	expectEqual(t, fmt.Sprint(got.Scopes), fmt.Sprint(want.Scopes), "Scopes")
+	expectSameTime(t, got.ExpiresAt, want.ExpiresAt, "ExpiresAt")
+	expectSameTime(t, got.AcceptedAt, want.AcceptedAt, "AcceptedAt")
+	expectEqual(t, got.AcceptedBy, want.AcceptedBy, "AcceptedBy")
+	expectSameTime(t, got.RevokedAt, want.RevokedAt, "RevokedAt")
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
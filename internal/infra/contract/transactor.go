@@ -0,0 +1,68 @@
+package contract
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransactorCases returns the cases every infra.Transactor that really rolls
+// back must pass. newStore must return a transactor and a metric repository
+// sharing one empty database.
+func TransactorCases(newStore func(t T) (infra.Transactor, infra.MetricRepository)) []Case {
+	return []Case{
+		{"commits_when_fn_succeeds", func(t T) {
+			transactor, repo := newStore(t)
+			metric := newMetric(primitive.NewObjectID(), now())
+
+			err := transactor.WithinTransaction(ctx(), func(ctx context.Context) error {
+				return repo.CreateMetric(ctx, metric)
+			})
+			requireNoError(t, err, "WithinTransaction")
+
+			got, err := repo.GetMetricById(ctx(), metric.ID)
+			requireNoError(t, err, "GetMetricById")
+			expectMetric(t, got, metric)
+		}},
+		{"rolls_back_when_fn_fails", func(t T) {
+			transactor, repo := newStore(t)
+			metric := newMetric(primitive.NewObjectID(), now())
+			failure := errors.New("failure")
+
+			err := transactor.WithinTransaction(ctx(), func(ctx context.Context) error {
+				if err := repo.CreateMetric(ctx, metric); err != nil {
+					return err
+				}
+				if _, err := repo.GetMetricById(ctx, metric.ID); err != nil {
+					return err
+				}
+				return failure
+			})
+			requireErrorIs(t, err, failure, "WithinTransaction")
+
+			_, err = repo.GetMetricById(ctx(), metric.ID)
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetMetricById after rollback")
+		}},
+		{"nested_calls_join_the_outer_transaction", func(t T) {
+			transactor, repo := newStore(t)
+			metric := newMetric(primitive.NewObjectID(), now())
+			failure := errors.New("failure")
+
+			err := transactor.WithinTransaction(ctx(), func(ctx context.Context) error {
+				err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+					return repo.CreateMetric(ctx, metric)
+				})
+				if err != nil {
+					return err
+				}
+				return failure
+			})
+			requireErrorIs(t, err, failure, "WithinTransaction")
+
+			_, err = repo.GetMetricById(ctx(), metric.ID)
+			requireErrorIs(t, err, infra.ErrMetricNotFound, "GetMetricById after rollback")
+		}},
+	}
+}
@@ -0,0 +1,191 @@
+package contract
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepositoryCases returns the cases every infra.UserRepository must pass.
+// newRepo must return an empty repository each time it is called.
+func UserRepositoryCases(newRepo func(t T) infra.UserRepository) []Case {
+	return []Case{
+		{"create_and_get_by_id_and_email", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+
+			requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+
+			byId, err := repo.GetUserByUserId(ctx(), user.ID)
+			requireNoError(t, err, "GetUserByUserId")
+			expectUser(t, byId, user)
+
+			byEmail, err := repo.GetUserByEmail(ctx(), user.Email)
+			requireNoError(t, err, "GetUserByEmail")
+			expectUser(t, byEmail, user)
+		}},
+		{"missing_user_is_not_found", func(t T) {
+			repo := newRepo(t)
+			requireNoError(t, repo.CreateUser(ctx(), newUser("ada@example.com")), "CreateUser")
+
+			_, err := repo.GetUserByUserId(ctx(), primitive.NewObjectID())
+			requireErrorIs(t, err, infra.ErrUserNotFound, "GetUserByUserId")
+
+			_, err = repo.GetUserByEmail(ctx(), "grace@example.com")
+			requireErrorIs(t, err, infra.ErrUserNotFound, "GetUserByEmail")
+		}},
+		{"create_user_rejects_a_duplicate_email", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+			requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+
+			err := repo.CreateUser(ctx(), newUser("ada@example.com"))
+			requireErrorIs(t, err, infra.ErrDuplicateEmail, "CreateUser")
+
+			got, err := repo.GetUserByEmail(ctx(), user.Email)
+			requireNoError(t, err, "GetUserByEmail")
+			expectUser(t, got, user)
+		}},
+		{"update_user_replaces_every_field", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+			requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+
+			updated := user
+			updated.FirstName = "Grace"
+			updated.LastName = "Hopper"
+			updated.Password = "new-hash"
+			updated.Timezone = "America/New_York"
+			updated.IsEmailVerified = true
+			updated.IsOnBoardingComplete = true
+			updated.LastMetricLog = now().Add(-time.Hour)
+			updated.Reminders = domain.ReminderPreferences{Enabled: true, Time: "21:30", QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+			updated.PushSubscriptions = []domain.PushSubscription{
+				{Endpoint: "https://push.example.com/1", P256dh: "p256dh", Auth: "auth", CreatedAt: now()},
+			}
+			updated.UpdatedAt = now()
+			requireNoError(t, repo.UpdateUser(ctx(), updated), "UpdateUser")
+
+			got, err := repo.GetUserByUserId(ctx(), user.ID)
+			requireNoError(t, err, "GetUserByUserId")
+			expectUser(t, got, updated)
+		}},
+		{"update_missing_user_does_not_create_it", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+
+			requireNoError(t, repo.UpdateUser(ctx(), user), "UpdateUser")
+
+			_, err := repo.GetUserByUserId(ctx(), user.ID)
+			requireErrorIs(t, err, infra.ErrUserNotFound, "GetUserByUserId")
+		}},
+		{"update_last_metric_log_keeps_other_fields", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+			user.Timezone = "Africa/Lagos"
+			user.IsEmailVerified = true
+			requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+
+			before := now()
+			requireNoError(t, repo.UpdateUserLastMetricLog(ctx(), user), "UpdateUserLastMetricLog")
+
+			got, err := repo.GetUserByUserId(ctx(), user.ID)
+			requireNoError(t, err, "GetUserByUserId")
+			if got.LastMetricLog.Before(before) {
+				t.Errorf("LastMetricLog: got %v, want at least %v", got.LastMetricLog, before)
+			}
+			expectEqual(t, got.Timezone, user.Timezone, "Timezone")
+			expectEqual(t, got.IsEmailVerified, user.IsEmailVerified, "IsEmailVerified")
+			expectEqual(t, got.Password, user.Password, "Password")
+		}},
+		{"users_with_reminders_enabled_in_pages", func(t T) {
+			repo := newRepo(t)
+			enabled := []domain.User{}
+			for i := 0; i < 5; i++ {
+				user := newUser(fmt.Sprintf("reminded%d@example.com", i))
+				user.Reminders.Enabled = i != 2
+				requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+				if user.Reminders.Enabled {
+					enabled = append(enabled, user)
+				}
+			}
+			sort.Slice(enabled, func(i, j int) bool { return enabled[i].ID.Hex() < enabled[j].ID.Hex() })
+
+			got := []domain.User{}
+			afterId := primitive.NilObjectID
+			for {
+				page, err := repo.GetUsersWithRemindersEnabled(ctx(), afterId, 3)
+				requireNoError(t, err, "GetUsersWithRemindersEnabled")
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, page...)
+				afterId = page[len(page)-1].ID
+			}
+			expectEqual(t, len(got), len(enabled), "number of users with reminders enabled")
+			for i := range got {
+				if i < len(enabled) {
+					expectUser(t, got[i], enabled[i])
+				}
+			}
+		}},
+		{"delete_user_is_idempotent", func(t T) {
+			repo := newRepo(t)
+			user := newUser("ada@example.com")
+			other := newUser("grace@example.com")
+			requireNoError(t, repo.CreateUser(ctx(), user), "CreateUser")
+			requireNoError(t, repo.CreateUser(ctx(), other), "CreateUser")
+
+			requireNoError(t, repo.DeleteUser(ctx(), user.ID), "DeleteUser")
+			requireNoError(t, repo.DeleteUser(ctx(), user.ID), "DeleteUser again")
+
+			_, err := repo.GetUserByUserId(ctx(), user.ID)
+			requireErrorIs(t, err, infra.ErrUserNotFound, "GetUserByUserId")
+			_, err = repo.GetUserByUserId(ctx(), other.ID)
+			requireNoError(t, err, "GetUserByUserId for another user")
+		}},
+	}
+}
+
+func newUser(email string) domain.User {
+	return domain.User{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Password:  "hash",
+		Timezone:  domain.DefaultTimezone,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+}
+
+func expectUser(t T, got, want domain.User) {
+	t.Helper()
+	expectEqual(t, got.ID, want.ID, "ID")
+	expectEqual(t, got.Email, want.Email, "Email")
+	expectEqual(t, got.FirstName, want.FirstName, "FirstName")
+	expectEqual(t, got.LastName, want.LastName, "LastName")
+	expectEqual(t, got.Password, want.Password, "Password")
+	expectEqual(t, got.Timezone, want.Timezone, "Timezone")
+	expectEqual(t, got.IsEmailVerified, want.IsEmailVerified, "IsEmailVerified")
+	expectEqual(t, got.IsOnBoardingComplete, want.IsOnBoardingComplete, "IsOnBoardingComplete")
+	expectSameTime(t, got.LastMetricLog, want.LastMetricLog, "LastMetricLog")
+	expectEqual(t, got.Reminders, want.Reminders, "Reminders")
+	if len(got.PushSubscriptions) != len(want.PushSubscriptions) {
+		t.Errorf("PushSubscriptions: got %d subscriptions, want %d", len(got.PushSubscriptions), len(want.PushSubscriptions))
+	} else {
+		for i := range got.PushSubscriptions {
+			expectEqual(t, got.PushSubscriptions[i].Endpoint, want.PushSubscriptions[i].Endpoint, "PushSubscriptions Endpoint")
+			expectEqual(t, got.PushSubscriptions[i].P256dh, want.PushSubscriptions[i].P256dh, "PushSubscriptions P256dh")
+			expectEqual(t, got.PushSubscriptions[i].Auth, want.PushSubscriptions[i].Auth, "PushSubscriptions Auth")
+			expectSameTime(t, got.PushSubscriptions[i].CreatedAt, want.PushSubscriptions[i].CreatedAt, "PushSubscriptions CreatedAt")
+		}
+	}
+	expectSameTime(t, got.CreatedAt, want.CreatedAt, "CreatedAt")
+	expectSameTime(t, got.UpdatedAt, want.UpdatedAt, "UpdatedAt")
+}
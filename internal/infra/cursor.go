@@ -0,0 +1,43 @@
+package infra
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetricCursor points at the last metric of a page. Metrics are ordered by
+// CreatedAt and then ID, both descending, so the pair is unique and stable.
+// Journal entries are ordered and paged the same way.
+type MetricCursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+func EncodeMetricCursor(createdAt time.Time, id primitive.ObjectID) string {
+	raw := strconv.FormatInt(createdAt.UnixMilli(), 10) + ":" + id.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeMetricCursor(cursor string) (MetricCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return MetricCursor{}, ErrInvalidCursor
+	}
+	millis, hex, found := strings.Cut(string(raw), ":")
+	if !found {
+		return MetricCursor{}, ErrInvalidCursor
+	}
+	unixMilli, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return MetricCursor{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return MetricCursor{}, ErrInvalidCursor
+	}
+	return MetricCursor{CreatedAt: time.UnixMilli(unixMilli), ID: id}, nil
+}
@@ -0,0 +1,226 @@
+// Package encryption seals sensitive text before the storage backends write
+// it, so a database dump or backup does not expose it.
+//
+// Every document is sealed with its own random data key. The data key is
+// stored next to the document wrapped (encrypted) by a master key, along with
+// the master key's id, so rotating a master key only means wrapping the data
+// keys again.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidKey        = errors.New("encryption key must be 32 bytes encoded in base64")
+	ErrInvalidKeyring    = errors.New("encryption keys must be a comma separated list of id:key")
+	ErrUnknownKey        = errors.New("data key was wrapped by a master key that is not configured")
+	ErrInvalidCiphertext = errors.New("ciphertext is corrupt or was sealed with another key")
+)
+
+const keySize = 32
+
+// Keyring holds the master keys data keys are wrapped with and the key blind
+// indexes are computed with. The current master key wraps new data keys, the
+// others only unwrap the data keys they wrapped before a rotation.
+type Keyring struct {
+	currentKeyId string
+	masterKeys   map[string]cipher.AEAD
+	indexKey     []byte
+}
+
+// ParseKeyring reads master keys such as "2024-01:<base64>,2023-06:<base64>",
+// the first one is current, and a base64 index key. Keys are 32 bytes, from
+// `openssl rand -base64 32` for instance.
+func ParseKeyring(masterKeys, indexKey string) (*Keyring, error) {
+	keys := map[string][]byte{}
+	currentKeyId := ""
+	for _, entry := range strings.Split(masterKeys, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			return nil, ErrInvalidKeyring
+		}
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("encryption key %q is listed twice", id)
+		}
+		key, err := parseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		keys[id] = key
+		if currentKeyId == "" {
+			currentKeyId = id
+		}
+	}
+	parsedIndexKey, err := parseKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("index key: %w", err)
+	}
+	return NewKeyring(currentKeyId, keys, parsedIndexKey)
+}
+
+func NewKeyring(currentKeyId string, masterKeys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := masterKeys[currentKeyId]; !ok {
+		return nil, fmt.Errorf("current encryption key %q: %w", currentKeyId, ErrUnknownKey)
+	}
+	if len(indexKey) != keySize {
+		return nil, ErrInvalidKey
+	}
+	keyring := &Keyring{currentKeyId: currentKeyId, masterKeys: map[string]cipher.AEAD{}, indexKey: indexKey}
+	for id, key := range masterKeys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		keyring.masterKeys[id] = aead
+	}
+	return keyring, nil
+}
+
+// CurrentKeyId is the id of the master key new data keys are wrapped with.
+func (k *Keyring) CurrentKeyId() string {
+	return k.currentKeyId
+}
+
+// NewDataKey returns a random data key wrapped by the current master key.
+func (k *Keyring) NewDataKey() (*DataKey, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := seal(k.masterKeys[k.currentKeyId], key, []byte(k.currentKeyId))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyId: k.currentKeyId, Wrapped: wrapped, aead: aead}, nil
+}
+
+// OpenDataKey unwraps a data key stored with the id of its master key.
+func (k *Keyring) OpenDataKey(keyId string, wrapped []byte) (*DataKey, error) {
+	key, err := k.unwrap(keyId, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyId: keyId, Wrapped: wrapped, aead: aead}, nil
+}
+
+// Rewrap wraps a data key again with the current master key, what it sealed
+// stays as it is.
+func (k *Keyring) Rewrap(keyId string, wrapped []byte) (string, []byte, error) {
+	key, err := k.unwrap(keyId, wrapped)
+	if err != nil {
+		return "", nil, err
+	}
+	rewrapped, err := seal(k.masterKeys[k.currentKeyId], key, []byte(k.currentKeyId))
+	if err != nil {
+		return "", nil, err
+	}
+	return k.currentKeyId, rewrapped, nil
+}
+
+func (k *Keyring) unwrap(keyId string, wrapped []byte) ([]byte, error) {
+	masterKey, ok := k.masterKeys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("encryption key %q: %w", keyId, ErrUnknownKey)
+	}
+	return open(masterKey, wrapped, []byte(keyId))
+}
+
+// BlindIndex returns the keyed hash of term in hex, the same term always gives
+// the same hash. It lets sealed text be looked up by exact value or by whole
+// word without storing it in the clear.
+func (k *Keyring) BlindIndex(term string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(term))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// BlindIndexes is BlindIndex of every term, in order.
+func (k *Keyring) BlindIndexes(terms []string) []string {
+	indexes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		indexes = append(indexes, k.BlindIndex(term))
+	}
+	return indexes
+}
+
+// DataKey seals the fields of one document with AES-256-GCM.
+type DataKey struct {
+	// KeyId is the id of the master key that wrapped the data key.
+	KeyId   string
+	Wrapped []byte
+	aead    cipher.AEAD
+}
+
+// Encrypt seals plaintext under a random nonce. associatedData, such as the id
+// of the document and the name of the field, is authenticated but not stored,
+// so the ciphertext cannot be moved to another document or field.
+func (d *DataKey) Encrypt(plaintext string, associatedData []byte) ([]byte, error) {
+	return seal(d.aead, []byte(plaintext), associatedData)
+}
+
+// Decrypt opens ciphertext made by Encrypt with the same associatedData.
+func (d *DataKey) Decrypt(ciphertext []byte, associatedData []byte) (string, error) {
+	plaintext, err := open(d.aead, ciphertext, associatedData)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func parseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the sealed plaintext.
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, associatedData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
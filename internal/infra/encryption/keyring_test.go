@@ -0,0 +1,170 @@
+package encryption_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+)
+
+func newEncodedKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func parseKeyring(t *testing.T, masterKeys, indexKey string) *encryption.Keyring {
+	t.Helper()
+	keyring, err := encryption.ParseKeyring(masterKeys, indexKey)
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestDataKeyOpensWhatItSealed(t *testing.T) {
+	keyring := parseKeyring(t, "current:"+newEncodedKey(t), newEncodedKey(t))
+	associatedData := []byte("entry id body")
+
+	dataKey, err := keyring.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if dataKey.KeyId != "current" {
+		t.Errorf("KeyId: got %q, want %q", dataKey.KeyId, "current")
+	}
+	sealed, err := dataKey.Encrypt("dear diary", associatedData)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	opened, err := keyring.OpenDataKey(dataKey.KeyId, dataKey.Wrapped)
+	if err != nil {
+		t.Fatalf("OpenDataKey: %v", err)
+	}
+	plaintext, err := opened.Decrypt(sealed, associatedData)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if plaintext != "dear diary" {
+		t.Errorf("Decrypt: got %q, want %q", plaintext, "dear diary")
+	}
+
+	if _, err := opened.Decrypt(sealed, []byte("another entry id body")); !errors.Is(err, encryption.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt with other associated data: got error %v, want %v", err, encryption.ErrInvalidCiphertext)
+	}
+	other, err := keyring.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if _, err := other.Decrypt(sealed, associatedData); !errors.Is(err, encryption.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt with another data key: got error %v, want %v", err, encryption.ErrInvalidCiphertext)
+	}
+}
+
+func TestRewrapUnderTheCurrentMasterKey(t *testing.T) {
+	oldKey, newKey, indexKey := newEncodedKey(t), newEncodedKey(t), newEncodedKey(t)
+	before := parseKeyring(t, "2023-06:"+oldKey, indexKey)
+	dataKey, err := before.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	sealed, err := dataKey.Encrypt("dear diary", nil)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// the new key is listed first once it is rotated in
+	rotated := parseKeyring(t, "2024-01:"+newKey+",2023-06:"+oldKey, indexKey)
+	keyId, wrapped, err := rotated.Rewrap(dataKey.KeyId, dataKey.Wrapped)
+	if err != nil {
+		t.Fatalf("Rewrap: %v", err)
+	}
+	if keyId != "2024-01" {
+		t.Errorf("Rewrap: got key id %q, want %q", keyId, "2024-01")
+	}
+
+	// and the old one can be removed once every data key is wrapped again
+	after := parseKeyring(t, "2024-01:"+newKey, indexKey)
+	opened, err := after.OpenDataKey(keyId, wrapped)
+	if err != nil {
+		t.Fatalf("OpenDataKey: %v", err)
+	}
+	if plaintext, err := opened.Decrypt(sealed, nil); err != nil || plaintext != "dear diary" {
+		t.Errorf("Decrypt after Rewrap: got %q and error %v, want %q", plaintext, err, "dear diary")
+	}
+	if _, err := after.OpenDataKey(dataKey.KeyId, dataKey.Wrapped); !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Errorf("OpenDataKey of a removed master key: got error %v, want %v", err, encryption.ErrUnknownKey)
+	}
+}
+
+func TestUnknownKeyId(t *testing.T) {
+	keyring := parseKeyring(t, "current:"+newEncodedKey(t), newEncodedKey(t))
+	dataKey, err := keyring.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+
+	for _, keyId := range []string{"", "2019-01"} {
+		if _, err := keyring.OpenDataKey(keyId, dataKey.Wrapped); !errors.Is(err, encryption.ErrUnknownKey) {
+			t.Errorf("OpenDataKey(%q): got error %v, want %v", keyId, err, encryption.ErrUnknownKey)
+		}
+		if _, _, err := keyring.Rewrap(keyId, dataKey.Wrapped); !errors.Is(err, encryption.ErrUnknownKey) {
+			t.Errorf("Rewrap(%q): got error %v, want %v", keyId, err, encryption.ErrUnknownKey)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	key, indexKey := newEncodedKey(t), newEncodedKey(t)
+	tests := []struct {
+		name       string
+		masterKeys string
+		indexKey   string
+		wantErr    error
+	}{
+		{"no master keys", "", indexKey, encryption.ErrInvalidKeyring},
+		{"no key id", ":" + key, indexKey, encryption.ErrInvalidKeyring},
+		{"short master key", "current:c2hvcnQ=", indexKey, encryption.ErrInvalidKey},
+		{"master key not in base64", "current:not base64", indexKey, encryption.ErrInvalidKey},
+		{"no index key", "current:" + key, "", encryption.ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if _, err := encryption.ParseKeyring(tt.masterKeys, tt.indexKey); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, err := encryption.ParseKeyring("current:"+key+",current:"+newEncodedKey(t), indexKey); err == nil {
+		t.Errorf("a key id listed twice: got no error")
+	}
+	keyring := parseKeyring(t, " current:"+key+" , old:"+newEncodedKey(t), indexKey)
+	if keyring.CurrentKeyId() != "current" {
+		t.Errorf("CurrentKeyId: got %q, want the first key %q", keyring.CurrentKeyId(), "current")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	indexKey := newEncodedKey(t)
+	keyring := parseKeyring(t, "current:"+newEncodedKey(t), indexKey)
+	rotated := parseKeyring(t, "next:"+newEncodedKey(t), indexKey)
+
+	if keyring.BlindIndex("ada@example.com") != keyring.BlindIndex("ada@example.com") {
+		t.Errorf("BlindIndex differs for the same term")
+	}
+	if keyring.BlindIndex("ada@example.com") == keyring.BlindIndex("bola@example.com") {
+		t.Errorf("BlindIndex is the same for different terms")
+	}
+	if keyring.BlindIndex("ada@example.com") != rotated.BlindIndex("ada@example.com") {
+		t.Errorf("BlindIndex changed with the master keys")
+	}
+	other := parseKeyring(t, "current:"+newEncodedKey(t), newEncodedKey(t))
+	if keyring.BlindIndex("ada@example.com") == other.BlindIndex("ada@example.com") {
+		t.Errorf("BlindIndex is the same under another index key")
+	}
+}
@@ -0,0 +1,13 @@
+// Package memory holds thread-safe in-memory implementations of the infra
+// repositories and cache. They mirror the Mongo and Redis behaviour closely
+// enough to boot the whole app without either, which is handy for demos and
+// end-to-end tests. Nothing survives a restart.
+package memory
+
+import "time"
+
+// storedTime mimics what a round trip through Mongo does to a time.Time,
+// BSON dates only keep milliseconds and are decoded in UTC.
+func storedTime(t time.Time) time.Time {
+	return t.Truncate(time.Millisecond).UTC()
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryAchievementRepository struct {
+	mu           sync.RWMutex
+	achievements map[primitive.ObjectID]domain.Achievement
+}
+
+func NewMemoryAchievementRepo() *MemoryAchievementRepository {
+	return &MemoryAchievementRepository{achievements: map[primitive.ObjectID]domain.Achievement{}}
+}
+
+func (m *MemoryAchievementRepository) CreateAchievement(ctx context.Context, achievement domain.Achievement) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.achievements {
+		if existing.UserId == achievement.UserId && existing.Badge == achievement.Badge {
+			return infra.ErrAchievementExists
+		}
+	}
+	achievement.AwardedAt = storedTime(achievement.AwardedAt)
+	m.achievements[achievement.ID] = achievement
+	return nil
+}
+
+func (m *MemoryAchievementRepository) GetAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Achievement, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	achievements := []domain.Achievement{}
+	for _, achievement := range m.achievements {
+		if achievement.UserId == userId {
+			achievements = append(achievements, achievement)
+		}
+	}
+	sort.Slice(achievements, func(i, j int) bool {
+		if !achievements[i].AwardedAt.Equal(achievements[j].AwardedAt) {
+			return achievements[i].AwardedAt.Before(achievements[j].AwardedAt)
+		}
+		return achievements[i].ID.Hex() < achievements[j].ID.Hex()
+	})
+	return achievements, nil
+}
+
+func (m *MemoryAchievementRepository) DeleteAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, achievement := range m.achievements {
+		if achievement.UserId == userId {
+			delete(m.achievements, id)
+		}
+	}
+	return nil
+}
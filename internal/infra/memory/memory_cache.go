@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+)
+
+var errWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+// sweepEvery is how many writes happen between scans for expired keys, reads
+// already ignore expired keys so this only bounds memory use.
+const sweepEvery = 1000
+
+type cacheEntry struct {
+	value     string
+	set       map[string]struct{}
+	expiresAt time.Time
+}
+
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	writes  int
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]cacheEntry{}}
+}
+
+func (m *MemoryCache) SetOne(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	m.wrote()
+	return nil
+}
+
+func (m *MemoryCache) SetOneIfNotExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(key); ok {
+		return false, nil
+	}
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	m.wrote()
+	return true, nil
+}
+
+func (m *MemoryCache) SetOneIfExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(key); !ok {
+		return false, nil
+	}
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	m.wrote()
+	return true, nil
+}
+
+func (m *MemoryCache) SetOneIfEquals(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.get(key); !ok || existing.set != nil || existing.value != expected {
+		return false, nil
+	}
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	m.wrote()
+	return true, nil
+}
+
+func (m *MemoryCache) GetOne(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.get(key)
+	if !ok {
+		return "", infra.ErrCacheMiss
+	}
+	if entry.set != nil {
+		return "", errWrongType
+	}
+	return entry.value, nil
+}
+
+func (m *MemoryCache) GetAndDeleteOne(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.get(key)
+	if !ok {
+		return "", infra.ErrCacheMiss
+	}
+	if entry.set != nil {
+		return "", errWrongType
+	}
+	delete(m.entries, key)
+	return entry.value, nil
+}
+
+func (m *MemoryCache) DeleteOne(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryCache) AddToSet(ctx context.Context, key, member string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.get(key)
+	if !ok {
+		entry = cacheEntry{set: map[string]struct{}{}}
+	}
+	if entry.set == nil {
+		return errWrongType
+	}
+	entry.set[member] = struct{}{}
+	m.entries[key] = entry
+	m.wrote()
+	return nil
+}
+
+func (m *MemoryCache) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.get(key)
+	if !ok {
+		return []string{}, nil
+	}
+	if entry.set == nil {
+		return nil, errWrongType
+	}
+	members := make([]string, 0, len(entry.set))
+	for member := range entry.set {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (m *MemoryCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.get(key)
+	if !ok {
+		return nil
+	}
+	if entry.set == nil {
+		return errWrongType
+	}
+	delete(entry.set, member)
+	if len(entry.set) == 0 {
+		// redis drops a set once its last member is removed
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// get returns the live entry under key, dropping it if it has expired. The
+// caller must hold mu.
+func (m *MemoryCache) get(key string) (cacheEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if entry.expired(time.Now()) {
+		delete(m.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (m *MemoryCache) wrote() {
+	m.writes++
+	if m.writes%sweepEvery != 0 {
+		return
+	}
+	now := time.Now()
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+}
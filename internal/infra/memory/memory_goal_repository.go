@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryGoalRepository struct {
+	mu          sync.RWMutex
+	goals       map[primitive.ObjectID]domain.Goal
+	completions map[primitive.ObjectID]domain.GoalCompletion
+}
+
+func NewMemoryGoalRepo() *MemoryGoalRepository {
+	return &MemoryGoalRepository{
+		goals:       map[primitive.ObjectID]domain.Goal{},
+		completions: map[primitive.ObjectID]domain.GoalCompletion{},
+	}
+}
+
+func (m *MemoryGoalRepository) CreateGoal(ctx context.Context, goal domain.Goal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.goals[goal.ID] = toStoredGoal(goal)
+	return nil
+}
+
+func (m *MemoryGoalRepository) GetGoalById(ctx context.Context, goalId primitive.ObjectID) (domain.Goal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	goal, ok := m.goals[goalId]
+	if !ok {
+		return domain.Goal{}, infra.ErrGoalNotFound
+	}
+	return goal, nil
+}
+
+func (m *MemoryGoalRepository) GetGoalsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Goal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	goals := []domain.Goal{}
+	for _, goal := range m.goals {
+		if goal.UserId == userId {
+			goals = append(goals, goal)
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool {
+		if !goals[i].CreatedAt.Equal(goals[j].CreatedAt) {
+			return goals[i].CreatedAt.Before(goals[j].CreatedAt)
+		}
+		return goals[i].ID.Hex() < goals[j].ID.Hex()
+	})
+	return goals, nil
+}
+
+func (m *MemoryGoalRepository) UpdateGoal(ctx context.Context, goal domain.Goal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.goals[goal.ID]; !ok {
+		return infra.ErrGoalNotFound
+	}
+	m.goals[goal.ID] = toStoredGoal(goal)
+	return nil
+}
+
+func (m *MemoryGoalRepository) DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.goals[goalId]; !ok {
+		return infra.ErrGoalNotFound
+	}
+	delete(m.goals, goalId)
+	for id, completion := range m.completions {
+		if completion.GoalId == goalId {
+			delete(m.completions, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryGoalRepository) DeleteGoalsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, goal := range m.goals {
+		if goal.UserId == userId {
+			delete(m.goals, id)
+		}
+	}
+	for id, completion := range m.completions {
+		if completion.UserId == userId {
+			delete(m.completions, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryGoalRepository) CreateGoalCompletion(ctx context.Context, completion domain.GoalCompletion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.completions {
+		if existing.GoalId == completion.GoalId && existing.PeriodStart == completion.PeriodStart {
+			return infra.ErrGoalCompletionExists
+		}
+	}
+	completion.CompletedAt = storedTime(completion.CompletedAt)
+	m.completions[completion.ID] = completion
+	return nil
+}
+
+func (m *MemoryGoalRepository) GetGoalCompletionsByUserId(ctx context.Context, userId primitive.ObjectID, since time.Time) ([]domain.GoalCompletion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	completions := []domain.GoalCompletion{}
+	for _, completion := range m.completions {
+		if completion.UserId == userId && completion.CompletedAt.After(since) {
+			completions = append(completions, completion)
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		if !completions[i].CompletedAt.Equal(completions[j].CompletedAt) {
+			return completions[i].CompletedAt.Before(completions[j].CompletedAt)
+		}
+		return completions[i].ID.Hex() < completions[j].ID.Hex()
+	})
+	return completions, nil
+}
+
+func toStoredGoal(goal domain.Goal) domain.Goal {
+	goal.CreatedAt = storedTime(goal.CreatedAt)
+	goal.UpdatedAt = storedTime(goal.UpdatedAt)
+	return goal
+}
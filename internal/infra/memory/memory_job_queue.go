@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryJobQueue keeps jobs in process, they are lost on restart.
+type MemoryJobQueue struct {
+	mu     sync.Mutex
+	queues map[string][]string
+	// ready is closed and replaced whenever a payload is enqueued to wake up
+	// every waiting Dequeue.
+	ready chan struct{}
+}
+
+func NewMemoryJobQueue() *MemoryJobQueue {
+	return &MemoryJobQueue{queues: map[string][]string{}, ready: make(chan struct{})}
+}
+
+func (m *MemoryJobQueue) Enqueue(ctx context.Context, queue, payload string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queues[queue] = append(m.queues[queue], payload)
+	close(m.ready)
+	m.ready = make(chan struct{})
+	return nil
+}
+
+func (m *MemoryJobQueue) Dequeue(ctx context.Context, queue string) (string, error) {
+	for {
+		m.mu.Lock()
+		if payloads := m.queues[queue]; len(payloads) > 0 {
+			payload := payloads[0]
+			if len(payloads) == 1 {
+				delete(m.queues, queue)
+			} else {
+				m.queues[queue] = payloads[1:]
+			}
+			m.mu.Unlock()
+			return payload, nil
+		}
+		ready := m.ready
+		m.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
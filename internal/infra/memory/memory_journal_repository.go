@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryJournalRepository keeps entries in the clear, nothing it holds
+// outlives the process.
+type MemoryJournalRepository struct {
+	mu      sync.RWMutex
+	entries map[primitive.ObjectID]domain.JournalEntry
+}
+
+func NewMemoryJournalRepo() *MemoryJournalRepository {
+	return &MemoryJournalRepository{entries: map[primitive.ObjectID]domain.JournalEntry{}}
+}
+
+func (m *MemoryJournalRepository) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[entry.ID] = toStoredJournalEntry(entry)
+	return nil
+}
+
+func (m *MemoryJournalRepository) GetJournalEntryById(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[entryId]
+	if !ok {
+		return domain.JournalEntry{}, infra.ErrJournalEntryNotFound
+	}
+	return toStoredJournalEntry(entry), nil
+}
+
+func (m *MemoryJournalRepository) GetJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID, filter infra.JournalFilter) (infra.JournalPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from, to := storedTime(filter.From), storedTime(filter.To)
+	searchTerms := domain.SearchTerms(filter.Search)
+	entries := []domain.JournalEntry{}
+	for _, entry := range m.entries {
+		if entry.UserId != userId {
+			continue
+		}
+		if !filter.From.IsZero() && entry.CreatedAt.Before(from) {
+			continue
+		}
+		if !filter.To.IsZero() && !entry.CreatedAt.Before(to) {
+			continue
+		}
+		if filter.Tag != "" && !contains(entry.Tags, filter.Tag) {
+			continue
+		}
+		if !containsAll(entry.SearchTerms(), searchTerms) {
+			continue
+		}
+		entries = append(entries, toStoredJournalEntry(entry))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].ID.Hex() > entries[j].ID.Hex()
+	})
+	total := int64(len(entries))
+
+	if filter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(filter.Cursor)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		start := sort.Search(len(entries), func(i int) bool {
+			if !entries[i].CreatedAt.Equal(cursor.CreatedAt) {
+				return entries[i].CreatedAt.Before(cursor.CreatedAt)
+			}
+			return entries[i].ID.Hex() < cursor.ID.Hex()
+		})
+		entries = entries[start:]
+	}
+
+	page := infra.JournalPage{Entries: []domain.JournalEntry{}, Limit: filter.Limit, Total: total}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		last := entries[len(entries)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	page.Entries = append(page.Entries, entries...)
+	return page, nil
+}
+
+func (m *MemoryJournalRepository) UpdateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[entry.ID]; !ok {
+		return infra.ErrJournalEntryNotFound
+	}
+	m.entries[entry.ID] = toStoredJournalEntry(entry)
+	return nil
+}
+
+func (m *MemoryJournalRepository) DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[entryId]; !ok {
+		return infra.ErrJournalEntryNotFound
+	}
+	delete(m.entries, entryId)
+	return nil
+}
+
+func (m *MemoryJournalRepository) DeleteJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, entry := range m.entries {
+		if entry.UserId == userId {
+			delete(m.entries, id)
+		}
+	}
+	return nil
+}
+
+// toStoredJournalEntry also copies the tags so callers never share them with
+// the map.
+func toStoredJournalEntry(entry domain.JournalEntry) domain.JournalEntry {
+	entry.Tags = append([]string{}, entry.Tags...)
+	entry.CreatedAt = storedTime(entry.CreatedAt)
+	entry.UpdatedAt = storedTime(entry.UpdatedAt)
+	return entry
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values []string, wanted []string) bool {
+	for _, value := range wanted {
+		if !contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryMetricRepository struct {
+	mu      sync.RWMutex
+	metrics map[primitive.ObjectID]domain.Metric
+}
+
+func NewMemoryMetricRepo() *MemoryMetricRepository {
+	return &MemoryMetricRepository{metrics: map[primitive.ObjectID]domain.Metric{}}
+}
+
+func (m *MemoryMetricRepository) CreateMetric(ctx context.Context, metric domain.Metric) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if metric.LocalDate != "" {
+		for _, existing := range m.metrics {
+			if existing.OwnerId == metric.OwnerId && existing.LocalDate == metric.LocalDate {
+				return infra.ErrDailyLogExists
+			}
+		}
+	}
+	m.metrics[metric.ID] = toStoredMetric(metric)
+	return nil
+}
+
+func (m *MemoryMetricRepository) UpdateMetricById(ctx context.Context, metric domain.Metric) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.metrics[metric.ID]; ok {
+		m.metrics[metric.ID] = toStoredMetric(metric)
+	}
+	return nil
+}
+
+func (m *MemoryMetricRepository) GetMetricById(ctx context.Context, metricId primitive.ObjectID) (domain.Metric, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metric, ok := m.metrics[metricId]
+	if !ok {
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metric, nil
+}
+
+func (m *MemoryMetricRepository) DeleteMetricById(ctx context.Context, metricId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.metrics[metricId]; !ok {
+		return infra.ErrMetricNotFound
+	}
+	delete(m.metrics, metricId)
+	return nil
+}
+
+func (m *MemoryMetricRepository) DeleteMetricsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, metric := range m.metrics {
+		if metric.OwnerId == userId {
+			delete(m.metrics, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryMetricRepository) GetMetricDays(ctx context.Context, userId primitive.ObjectID) ([]infra.MetricDay, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metrics := []domain.Metric{}
+	for _, metric := range m.metrics {
+		if metric.OwnerId == userId {
+			metrics = append(metrics, metric)
+		}
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if !metrics[i].CreatedAt.Equal(metrics[j].CreatedAt) {
+			return metrics[i].CreatedAt.Before(metrics[j].CreatedAt)
+		}
+		return metrics[i].ID.Hex() < metrics[j].ID.Hex()
+	})
+
+	days := []infra.MetricDay{}
+	for _, metric := range metrics {
+		days = append(days, infra.MetricDay{MetricId: metric.ID, LocalDate: metric.LocalDate, CreatedAt: metric.CreatedAt})
+	}
+	return days, nil
+}
+
+func (m *MemoryMetricRepository) GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID, location *time.Location) (domain.Metric, error) {
+	startTime, endTime := domain.DayBounds(time.Now(), location)
+
+	metrics := m.findByOwner(userId, startTime, endTime)
+	if len(metrics) == 0 {
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metrics[0], nil
+}
+
+func (m *MemoryMetricRepository) GetMetricsByUserId(ctx context.Context, userId primitive.ObjectID, metricFilter infra.MetricFilter) (infra.MetricPage, error) {
+	metrics := m.findByOwner(userId, metricFilter.From, metricFilter.To)
+	total := int64(len(metrics))
+
+	if metricFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(metricFilter.Cursor)
+		if err != nil {
+			return infra.MetricPage{}, err
+		}
+		start := sort.Search(len(metrics), func(i int) bool {
+			return isAfterCursor(metrics[i], cursor)
+		})
+		metrics = metrics[start:]
+	}
+
+	page := infra.MetricPage{Metrics: []domain.Metric{}, Limit: metricFilter.Limit, Total: total}
+	if metricFilter.Limit > 0 && len(metrics) > metricFilter.Limit {
+		metrics = metrics[:metricFilter.Limit]
+		last := metrics[len(metrics)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	page.Metrics = append(page.Metrics, metrics...)
+
+	return page, nil
+}
+
+func (m *MemoryMetricRepository) GetMetricSummary(ctx context.Context, userId primitive.ObjectID, summaryFilter infra.MetricSummaryFilter) ([]domain.MetricSummaryBucket, error) {
+	if !summaryFilter.Granularity.IsValid() {
+		return nil, fmt.Errorf("unsupported summary granularity %q", summaryFilter.Granularity)
+	}
+	location := summaryFilter.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	metrics := m.findByOwner(userId, summaryFilter.From, summaryFilter.To)
+	return domain.SummarizeMetrics(metrics, summaryFilter.Granularity, location)
+}
+
+// findByOwner returns the user's metrics created in [from, to), newest first
+// and ordered like the Mongo index. A zero from or to leaves that side open.
+func (m *MemoryMetricRepository) findByOwner(userId primitive.ObjectID, from, to time.Time) []domain.Metric {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from, to = storedTime(from), storedTime(to)
+	metrics := []domain.Metric{}
+	for _, metric := range m.metrics {
+		if metric.OwnerId != userId {
+			continue
+		}
+		if !from.IsZero() && metric.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !metric.CreatedAt.Before(to) {
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if !metrics[i].CreatedAt.Equal(metrics[j].CreatedAt) {
+			return metrics[i].CreatedAt.After(metrics[j].CreatedAt)
+		}
+		return metrics[i].ID.Hex() > metrics[j].ID.Hex()
+	})
+	return metrics
+}
+
+func isAfterCursor(metric domain.Metric, cursor infra.MetricCursor) bool {
+	if !metric.CreatedAt.Equal(cursor.CreatedAt) {
+		return metric.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return metric.ID.Hex() < cursor.ID.Hex()
+}
+
+func toStoredMetric(metric domain.Metric) domain.Metric {
+	metric.Themes = append([]domain.Theme{}, metric.Themes...)
+	metric.SafetyFlags = append([]domain.SafetyFlag{}, metric.SafetyFlags...)
+	metric.CreatedAt = storedTime(metric.CreatedAt)
+	metric.UpdatedAt = storedTime(metric.UpdatedAt)
+	return metric
+}
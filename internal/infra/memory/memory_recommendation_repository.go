@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryRecommendationRepository struct {
+	mu              sync.RWMutex
+	recommendations map[primitive.ObjectID]domain.Recommendation
+}
+
+func NewMemoryRecommendationRepo() *MemoryRecommendationRepository {
+	return &MemoryRecommendationRepository{recommendations: map[primitive.ObjectID]domain.Recommendation{}}
+}
+
+func (m *MemoryRecommendationRepository) CreateRecommendation(ctx context.Context, recommendation domain.Recommendation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.recommendations[recommendation.ID] = toStoredRecommendation(recommendation)
+	return nil
+}
+
+func (m *MemoryRecommendationRepository) UpdateRecommendationById(ctx context.Context, recommendation domain.Recommendation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.recommendations[recommendation.ID]; ok {
+		m.recommendations[recommendation.ID] = toStoredRecommendation(recommendation)
+	}
+	return nil
+}
+
+func (m *MemoryRecommendationRepository) GetRecommendationById(ctx context.Context, recommendationId primitive.ObjectID) (domain.Recommendation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	recommendation, ok := m.recommendations[recommendationId]
+	if !ok {
+		return domain.Recommendation{}, infra.ErrRecommendationNotFound
+	}
+	return copyRecommendation(recommendation), nil
+}
+
+func (m *MemoryRecommendationRepository) GetRecommendationByMetricId(ctx context.Context, metricId primitive.ObjectID, metricType string) (domain.Recommendation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, recommendation := range m.recommendations {
+		if recommendation.MetricId == metricId && recommendation.MetricType == metricType {
+			return copyRecommendation(recommendation), nil
+		}
+	}
+	return domain.Recommendation{}, infra.ErrRecommendationNotFound
+}
+
+func (m *MemoryRecommendationRepository) GetRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) ([]domain.Recommendation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wanted := map[primitive.ObjectID]bool{}
+	for _, metricId := range metricIds {
+		wanted[metricId] = true
+	}
+
+	recommendations := []domain.Recommendation{}
+	for _, recommendation := range m.recommendations {
+		if wanted[recommendation.MetricId] {
+			recommendations = append(recommendations, copyRecommendation(recommendation))
+		}
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		if !recommendations[i].CreatedAt.Equal(recommendations[j].CreatedAt) {
+			return recommendations[i].CreatedAt.After(recommendations[j].CreatedAt)
+		}
+		return recommendations[i].ID.Hex() < recommendations[j].ID.Hex()
+	})
+	return recommendations, nil
+}
+
+func (m *MemoryRecommendationRepository) GetPendingRecommendations(ctx context.Context, limit int) ([]domain.Recommendation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	recommendations := []domain.Recommendation{}
+	for _, recommendation := range m.recommendations {
+		if recommendation.Status == domain.RecommendationPending {
+			recommendations = append(recommendations, copyRecommendation(recommendation))
+		}
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		if !recommendations[i].CreatedAt.Equal(recommendations[j].CreatedAt) {
+			return recommendations[i].CreatedAt.Before(recommendations[j].CreatedAt)
+		}
+		return recommendations[i].ID.Hex() < recommendations[j].ID.Hex()
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+func (m *MemoryRecommendationRepository) DeleteRecommendationsByMetricId(ctx context.Context, metricId primitive.ObjectID) error {
+	return m.DeleteRecommendationsByMetricIds(ctx, []primitive.ObjectID{metricId})
+}
+
+func (m *MemoryRecommendationRepository) DeleteRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := map[primitive.ObjectID]bool{}
+	for _, metricId := range metricIds {
+		wanted[metricId] = true
+	}
+	for id, recommendation := range m.recommendations {
+		if wanted[recommendation.MetricId] {
+			delete(m.recommendations, id)
+		}
+	}
+	return nil
+}
+
+func toStoredRecommendation(recommendation domain.Recommendation) domain.Recommendation {
+	recommendation = copyRecommendation(recommendation)
+	recommendation.CreatedAt = storedTime(recommendation.CreatedAt)
+	recommendation.UpdatedAt = storedTime(recommendation.UpdatedAt)
+	return recommendation
+}
+
+// copyRecommendation keeps callers from mutating stored items through the
+// shared slice.
+func copyRecommendation(recommendation domain.Recommendation) domain.Recommendation {
+	recommendation.Items = append([]domain.RecommendationItem{}, recommendation.Items...)
+	return recommendation
+}
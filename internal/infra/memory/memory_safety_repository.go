@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemorySafetyRepository struct {
+	mu        sync.RWMutex
+	events    map[primitive.ObjectID]domain.SafetyEvent
+	overrides map[primitive.ObjectID]domain.SafetyOverride
+}
+
+func NewMemorySafetyRepo() *MemorySafetyRepository {
+	return &MemorySafetyRepository{
+		events:    map[primitive.ObjectID]domain.SafetyEvent{},
+		overrides: map[primitive.ObjectID]domain.SafetyOverride{},
+	}
+}
+
+func (m *MemorySafetyRepository) CreateSafetyEvent(ctx context.Context, event domain.SafetyEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.Flags = append([]domain.SafetyFlag{}, event.Flags...)
+	event.CreatedAt = storedTime(event.CreatedAt)
+	m.events[event.ID] = event
+	return nil
+}
+
+func (m *MemorySafetyRepository) GetSafetyEventsByUserId(ctx context.Context, userId primitive.ObjectID, limit int) ([]domain.SafetyEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []domain.SafetyEvent{}
+	for _, event := range m.events {
+		if event.UserId == userId {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		}
+		return events[i].ID.Hex() > events[j].ID.Hex()
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m *MemorySafetyRepository) SaveSafetyOverride(ctx context.Context, override domain.SafetyOverride) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !override.SuppressEscalationUntil.IsZero() {
+		override.SuppressEscalationUntil = storedTime(override.SuppressEscalationUntil)
+	}
+	override.UpdatedAt = storedTime(override.UpdatedAt)
+	m.overrides[override.UserId] = override
+	return nil
+}
+
+func (m *MemorySafetyRepository) GetSafetyOverride(ctx context.Context, userId primitive.ObjectID) (domain.SafetyOverride, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	override, ok := m.overrides[userId]
+	if !ok {
+		return domain.SafetyOverride{}, infra.ErrSafetyOverrideNotFound
+	}
+	return override, nil
+}
+
+func (m *MemorySafetyRepository) DeleteSafetyOverride(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.overrides[userId]; !ok {
+		return infra.ErrSafetyOverrideNotFound
+	}
+	delete(m.overrides, userId)
+	return nil
+}
+
+func (m *MemorySafetyRepository) DeleteSafetyDataByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.overrides, userId)
+	for id, event := range m.events {
+		if event.UserId == userId {
+			delete(m.events, id)
+		}
+	}
+	return nil
+}
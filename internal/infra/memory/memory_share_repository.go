@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryShareRepository struct {
+	mu     sync.RWMutex
+	shares map[primitive.ObjectID]domain.Share
+}
+
+func NewMemoryShareRepo() *MemoryShareRepository {
+	return &MemoryShareRepository{shares: map[primitive.ObjectID]domain.Share{}}
+}
+
+func (m *MemoryShareRepository) CreateShare(ctx context.Context, share domain.Share) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.shares[share.ID] = toStoredShare(share)
+	return nil
+}
+
+func (m *MemoryShareRepository) GetShareById(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	share, ok := m.shares[shareId]
+	if !ok {
+		return domain.Share{}, infra.ErrShareNotFound
+	}
+	return share, nil
+}
+
+func (m *MemoryShareRepository) GetShareByCodeHash(ctx context.Context, codeHash string) (domain.Share, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, share := range m.shares {
+		if share.CodeHash == codeHash {
+			return share, nil
+		}
+	}
+	return domain.Share{}, infra.ErrShareNotFound
+}
+
+func (m *MemoryShareRepository) GetSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) ([]domain.Share, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shares := []domain.Share{}
+	for _, share := range m.shares {
+		if share.OwnerId == ownerId {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.After(shares[j].CreatedAt)
+		}
+		return shares[i].ID.Hex() > shares[j].ID.Hex()
+	})
+	return shares, nil
+}
+
+func (m *MemoryShareRepository) AcceptShare(ctx context.Context, shareId, acceptedBy primitive.ObjectID, acceptedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	share, ok := m.shares[shareId]
+	if !ok || !share.AcceptedAt.IsZero() || !share.RevokedAt.IsZero() {
+		return infra.ErrShareNotFound
+	}
+	share.AcceptedAt = storedTime(acceptedAt)
+	share.AcceptedBy = acceptedBy
+	share.UpdatedAt = share.AcceptedAt
+	m.shares[shareId] = share
+	return nil
+}
+
+func (m *MemoryShareRepository) RevokeShare(ctx context.Context, shareId primitive.ObjectID, revokedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	share, ok := m.shares[shareId]
+	if !ok {
+		return infra.ErrShareNotFound
+	}
+	if share.RevokedAt.IsZero() {
+		share.RevokedAt = storedTime(revokedAt)
+		share.UpdatedAt = share.RevokedAt
+		m.shares[shareId] = share
+	}
+	return nil
+}
+
+func (m *MemoryShareRepository) DeleteSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, share := range m.shares {
+		if share.OwnerId == ownerId {
+			delete(m.shares, id)
+		}
+	}
+	return nil
+}
+
+func toStoredShare(share domain.Share) domain.Share {
+	share.Scopes = append([]domain.ShareScope{}, share.Scopes...)
+	share.ExpiresAt = storedTime(share.ExpiresAt)
+	share.AcceptedAt = storedTime(share.AcceptedAt)
+	share.RevokedAt = storedTime(share.RevokedAt)
+	share.CreatedAt = storedTime(share.CreatedAt)
+	share.UpdatedAt = storedTime(share.UpdatedAt)
+	return share
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]domain.User
+}
+
+func NewMemoryUserRepo() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[primitive.ObjectID]domain.User{}}
+}
+
+func (m *MemoryUserRepository) CreateUser(ctx context.Context, user domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.Email == user.Email {
+			return infra.ErrDuplicateEmail
+		}
+	}
+	m.users[user.ID] = toStoredUser(user)
+	return nil
+}
+
+func (m *MemoryUserRepository) UpdateUser(ctx context.Context, user domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.ID]; ok {
+		m.users[user.ID] = toStoredUser(user)
+	}
+	return nil
+}
+
+func (m *MemoryUserRepository) UpdateUserLastMetricLog(ctx context.Context, user domain.User) error {
+	updatedUser := user
+	updatedUser.LastMetricLog = time.Now()
+	updatedUser.UpdatedAt = time.Now()
+
+	return m.UpdateUser(ctx, updatedUser)
+}
+
+func (m *MemoryUserRepository) DeleteUser(ctx context.Context, userId primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, userId)
+	return nil
+}
+
+func (m *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			return copyUser(user), nil
+		}
+	}
+	return domain.User{}, infra.ErrUserNotFound
+}
+
+func (m *MemoryUserRepository) GetUserByUserId(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[userId]
+	if !ok {
+		return domain.User{}, infra.ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (m *MemoryUserRepository) GetUsersWithRemindersEnabled(ctx context.Context, afterId primitive.ObjectID, limit int) ([]domain.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := []domain.User{}
+	for _, user := range m.users {
+		if user.Reminders.Enabled && user.ID.Hex() > afterId.Hex() {
+			users = append(users, copyUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID.Hex() < users[j].ID.Hex()
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func toStoredUser(user domain.User) domain.User {
+	user = copyUser(user)
+	user.LastMetricLog = storedTime(user.LastMetricLog)
+	user.CreatedAt = storedTime(user.CreatedAt)
+	user.UpdatedAt = storedTime(user.UpdatedAt)
+	for i := range user.PushSubscriptions {
+		user.PushSubscriptions[i].CreatedAt = storedTime(user.PushSubscriptions[i].CreatedAt)
+	}
+	return user
+}
+
+// copyUser keeps callers from mutating stored push subscriptions through the
+// shared slice.
+func copyUser(user domain.User) domain.User {
+	user.PushSubscriptions = append([]domain.PushSubscription{}, user.PushSubscriptions...)
+	return user
+}
@@ -0,0 +1,182 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"go.uber.org/zap"
+)
+
+// mongoEnvelope is the data key a document's fields are sealed with, wrapped
+// by the master key KeyId names. Documents written before fields were sealed
+// have none and hold those fields as plain strings.
+type mongoEnvelope struct {
+	KeyId   string `bson:"key_id"`
+	DataKey []byte `bson:"data_key"`
+}
+
+func newEnvelope(keyring *encryption.Keyring) (*encryption.DataKey, *mongoEnvelope, error) {
+	dataKey, err := keyring.NewDataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, &mongoEnvelope{KeyId: dataKey.KeyId, DataKey: dataKey.Wrapped}, nil
+}
+
+// openEnvelope returns nil for a document without an envelope.
+func openEnvelope(keyring *encryption.Keyring, envelope *mongoEnvelope) (*encryption.DataKey, error) {
+	if envelope == nil {
+		return nil, nil
+	}
+	return keyring.OpenDataKey(envelope.KeyId, envelope.DataKey)
+}
+
+// sealField encrypts value bound to the document and field it is stored in.
+func sealField(dataKey *encryption.DataKey, documentId primitive.ObjectID, field, value string) (bson.RawValue, error) {
+	sealed, err := dataKey.Encrypt(value, fieldAssociatedData(documentId, field))
+	if err != nil {
+		return bson.RawValue{}, fmt.Errorf("failed to encrypt %s: %w", field, err)
+	}
+	return bson.RawValue{Type: bsontype.Binary, Value: bsoncore.AppendBinary(nil, bsontype.BinaryGeneric, sealed)}, nil
+}
+
+// openField decrypts a field sealed by sealField. A string is a field written
+// before fields were sealed and is returned as it is.
+func openField(dataKey *encryption.DataKey, documentId primitive.ObjectID, field string, value bson.RawValue) (string, error) {
+	switch value.Type {
+	case bsontype.String:
+		return value.StringValue(), nil
+	case bsontype.Binary:
+		if dataKey == nil {
+			return "", fmt.Errorf("%s of %s is sealed but the document has no data key", field, documentId.Hex())
+		}
+		_, sealed := value.Binary()
+		plaintext, err := dataKey.Decrypt(sealed, fieldAssociatedData(documentId, field))
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt %s of %s: %w", field, documentId.Hex(), err)
+		}
+		return plaintext, nil
+	case 0, bsontype.Null:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%s of %s has unexpected type %s", field, documentId.Hex(), value.Type)
+	}
+}
+
+func fieldAssociatedData(documentId primitive.ObjectID, field string) []byte {
+	return append(documentId[:], field...)
+}
+
+// Reencrypt brings every document with sealed fields under the keyring's
+// current master key. Data keys wrapped by an older master key are wrapped
+// again, documents from before fields were sealed are sealed. It returns how
+// many documents of each collection it changed and is safe to run again.
+func Reencrypt(ctx context.Context, db *mongo.Database, keyring *encryption.Keyring, logger *zap.Logger) (map[string]int64, error) {
+	userRepo, err := NewMongoUserRepo(ctx, db, keyring, logger)
+	if err != nil {
+		return nil, err
+	}
+	metricRepo, err := NewMongoMetricRepo(ctx, db, keyring, logger)
+	if err != nil {
+		return nil, err
+	}
+	journalRepo, err := NewMongoJournalRepo(ctx, db, keyring, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]int64{}
+	changed["users"], err = reencryptCollection(ctx, userRepo.users, keyring,
+		func(user mongoUser) (primitive.ObjectID, *mongoEnvelope) { return user.ObjectID, user.Encryption },
+		func(user mongoUser) (any, error) {
+			domainUser, err := userRepo.toDomainUser(user)
+			if err != nil {
+				return nil, err
+			}
+			return userRepo.toMongoUser(domainUser)
+		},
+	)
+	if err != nil {
+		return changed, err
+	}
+	changed["metrics"], err = reencryptCollection(ctx, metricRepo.metrics, keyring,
+		func(metric mongoMetric) (primitive.ObjectID, *mongoEnvelope) {
+			return metric.ObjectID, metric.Encryption
+		},
+		func(metric mongoMetric) (any, error) {
+			domainMetric, err := metricRepo.toDomainMetric(metric)
+			if err != nil {
+				return nil, err
+			}
+			return metricRepo.toMongoMetric(domainMetric)
+		},
+	)
+	if err != nil {
+		return changed, err
+	}
+	changed["journal_entries"], err = reencryptCollection(ctx, journalRepo.entries, keyring,
+		func(entry mongoJournalEntry) (primitive.ObjectID, *mongoEnvelope) {
+			return entry.ObjectID, entry.Encryption
+		},
+		func(entry mongoJournalEntry) (any, error) {
+			return nil, fmt.Errorf("journal entry %s has no data key", entry.ObjectID.Hex())
+		},
+	)
+	return changed, err
+}
+
+// reencryptCollection goes through the documents of collection that are not
+// under the current master key. Documents with an envelope get their data key
+// wrapped again, the others are replaced by the fields seal returns.
+func reencryptCollection[T any](ctx context.Context, collection *mongo.Collection, keyring *encryption.Keyring, envelope func(T) (primitive.ObjectID, *mongoEnvelope), seal func(T) (any, error)) (int64, error) {
+	cursor, err := collection.Find(ctx, bson.M{"encryption.key_id": bson.M{"$ne": keyring.CurrentKeyId()}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var changed int64
+	for cursor.Next(ctx) {
+		var document T
+		if err := cursor.Decode(&document); err != nil {
+			return changed, err
+		}
+		id, existing := envelope(document)
+
+		// the filters skip a document written again since it was read.
+		var result *mongo.UpdateResult
+		if existing != nil {
+			keyId, wrapped, err := keyring.Rewrap(existing.KeyId, existing.DataKey)
+			if err != nil {
+				return changed, fmt.Errorf("failed to wrap the data key of %s again: %w", id.Hex(), err)
+			}
+			result, err = collection.UpdateOne(ctx,
+				bson.M{"_id": id, "encryption.data_key": existing.DataKey},
+				bson.M{"$set": bson.M{"encryption": mongoEnvelope{KeyId: keyId, DataKey: wrapped}}},
+			)
+			if err != nil {
+				return changed, err
+			}
+		} else {
+			sealed, err := seal(document)
+			if err != nil {
+				return changed, err
+			}
+			result, err = collection.UpdateOne(ctx,
+				bson.M{"_id": id, "encryption": bson.M{"$exists": false}},
+				bson.M{"$set": sealed},
+			)
+			if err != nil {
+				return changed, err
+			}
+		}
+		changed += result.ModifiedCount
+	}
+	return changed, cursor.Err()
+}
@@ -0,0 +1,391 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var ErrMigrationInProgress = errors.New("another migration is already running")
+
+// migrationLockTTL bounds how long a crashed runner can hold the lock.
+var migrationLockTTL = 10 * time.Minute
+
+// Migration is one versioned change to the database, index changes and data
+// backfills alike. Up must be safe to run again if it fails half way.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Migrations lists every migration, new ones go at the end with the next
+// version number.
+var Migrations = []Migration{
+	{1, "unique index on users.email", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email").SetUnique(true),
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("users.email has duplicates, merge or delete them and run the migration again: %w", err)
+		}
+		return err
+	}},
+	{2, "index on metrics owner_id and created_at", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("metrics").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("owner_id_created_at_id"),
+		})
+		if err != nil {
+			return err
+		}
+		// replaced by the index above, it used to be created by NewMongoMetricRepo.
+		_, err = db.Collection("metrics").Indexes().DropOne(ctx, "owner_id_created_at")
+		if isIndexNotFound(err) {
+			return nil
+		}
+		return err
+	}},
+	{3, "index on recommendations metric_id and metric_type", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("recommendations").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "metric_id", Value: 1}, {Key: "metric_type", Value: 1}},
+			Options: options.Index().SetName("metric_id_metric_type"),
+		})
+		return err
+	}},
+	{4, "backfill users.timezone", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("users").UpdateMany(ctx,
+			bson.M{"$or": bson.A{bson.M{"timezone": bson.M{"$exists": false}}, bson.M{"timezone": ""}}},
+			bson.M{"$set": bson.M{"timezone": domain.DefaultTimezone}},
+		)
+		return err
+	}},
+	{5, "backfill metrics.local_date and make it unique per user", func(ctx context.Context, db *mongo.Database) error {
+		if err := backfillMetricLocalDates(ctx, db); err != nil {
+			return err
+		}
+		_, err := db.Collection("metrics").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "local_date", Value: 1}},
+			Options: options.Index().SetName("owner_id_local_date").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"local_date": bson.M{"$exists": true}}),
+		})
+		return err
+	}},
+	{6, "index on pending recommendations", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("recommendations").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("status_pending_created_at").
+				SetPartialFilterExpression(bson.M{"status": domain.RecommendationPending}),
+		})
+		return err
+	}},
+	{7, "index on users with reminders enabled", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "reminders.enabled", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("reminders_enabled_id"),
+		})
+		return err
+	}},
+	{8, "unique index on achievements user_id and badge", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("achievements").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "badge", Value: 1}},
+			Options: options.Index().SetName("user_id_badge").SetUnique(true),
+		})
+		return err
+	}},
+	{9, "index on goals user_id and unique index on goal_completions goal_id and period_start", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("goals").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("user_id_created_at"),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = db.Collection("goal_completions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "goal_id", Value: 1}, {Key: "period_start", Value: 1}},
+				Options: options.Index().SetName("goal_id_period_start").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "completed_at", Value: 1}},
+				Options: options.Index().SetName("user_id_completed_at"),
+			},
+		})
+		return err
+	}},
+	{10, "indexes on journal_entries including a text index for search", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("journal_entries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("user_id_created_at_id"),
+			},
+			{
+				// search terms are blind indexes, stemming or stop words
+				// would only mangle them
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "search_terms", Value: "text"}},
+				Options: options.Index().SetName("user_id_search_terms_text").SetDefaultLanguage("none"),
+			},
+		})
+		return err
+	}},
+	{11, "unique index on users.email_index", func(ctx context.Context, db *mongo.Database) error {
+		// emails are sealed, they are unique and looked up through their
+		// blind index. Users not sealed yet are still covered by the email
+		// index.
+		_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "email_index", Value: 1}},
+			Options: options.Index().SetName("email_index").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email_index": bson.M{"$exists": true}}),
+		})
+		return err
+	}},
+	{12, "index on safety_events user_id and created_at", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("safety_events").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("user_id_created_at_id"),
+		})
+		return err
+	}},
+	{13, "indexes on shares code_hash, and owner_id and created_at", func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("shares").Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "code_hash", Value: 1}},
+				Options: options.Index().SetName("code_hash").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("owner_id_created_at_id"),
+			},
+		})
+		return err
+	}},
+	{14, "backfill local_date of daily logs saved by onboarding", func(ctx context.Context, db *mongo.Database) error {
+		// onboarding saved its daily log without a local_date until it was
+		// fixed, so those logs stayed out of the unique index
+		return backfillMetricLocalDates(ctx, db)
+	}},
+}
+
+// backfillMetricLocalDates sets local_date on metrics created before it was
+// stored, using the owner's current timezone. When a user already has more than
+// one log on a day only the oldest gets a local_date, the others stay out of
+// the unique index.
+func backfillMetricLocalDates(ctx context.Context, db *mongo.Database) error {
+	locations := map[primitive.ObjectID]*time.Location{}
+	userCursor, err := db.Collection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"timezone": 1}))
+	if err != nil {
+		return err
+	}
+	for userCursor.Next(ctx) {
+		var user struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Timezone string             `bson:"timezone"`
+		}
+		if err := userCursor.Decode(&user); err != nil {
+			userCursor.Close(ctx)
+			return err
+		}
+		locations[user.ID] = domain.User{Timezone: user.Timezone}.Location()
+	}
+	userCursor.Close(ctx)
+	if err := userCursor.Err(); err != nil {
+		return err
+	}
+
+	metrics := db.Collection("metrics")
+	cursor, err := metrics.Find(ctx, bson.M{}, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"owner_id": 1, "created_at": 1, "local_date": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	type ownerDay struct {
+		ownerId primitive.ObjectID
+		day     string
+	}
+	type backfill struct {
+		id  primitive.ObjectID
+		key ownerDay
+	}
+	seen := map[ownerDay]bool{}
+	pending := []backfill{}
+	for cursor.Next(ctx) {
+		var metric struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			OwnerId   primitive.ObjectID `bson:"owner_id"`
+			LocalDate string             `bson:"local_date"`
+			CreatedAt time.Time          `bson:"created_at"`
+		}
+		if err := cursor.Decode(&metric); err != nil {
+			return err
+		}
+		if metric.LocalDate != "" {
+			seen[ownerDay{metric.OwnerId, metric.LocalDate}] = true
+			continue
+		}
+		location, ok := locations[metric.OwnerId]
+		if !ok {
+			location = time.UTC
+		}
+		pending = append(pending, backfill{metric.ID, ownerDay{metric.OwnerId, domain.LocalDate(metric.CreatedAt, location)}})
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, metric := range pending {
+		if seen[metric.key] {
+			continue
+		}
+		seen[metric.key] = true
+		_, err := metrics.UpdateByID(ctx, metric.id, bson.M{"$set": bson.M{"local_date": metric.key.day}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+// Migrate applies every migration in Migrations that is not yet recorded in
+// the schema_migrations collection, in version order.
+func Migrate(ctx context.Context, db *mongo.Database, logger *zap.Logger) error {
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sortedMigrations() {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		logger.Info("applying migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+		if err := migration.Up(ctx, db); err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		_, err := db.Collection("schema_migrations").InsertOne(ctx, bson.M{
+			"_id":         migration.Version,
+			"description": migration.Description,
+			"applied_at":  time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+// PendingMigrations returns the migrations Migrate would apply.
+func PendingMigrations(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, migration := range sortedMigrations() {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// AppliedMigrations returns the migrations recorded in schema_migrations,
+// oldest version first.
+func AppliedMigrations(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []MigrationStatus{}
+	for _, status := range applied {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+func appliedMigrations(ctx context.Context, db *mongo.Database) (map[int]MigrationStatus, error) {
+	cursor, err := db.Collection("schema_migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	applied := map[int]MigrationStatus{}
+	for cursor.Next(ctx) {
+		var record struct {
+			Version     int       `bson:"_id"`
+			Description string    `bson:"description"`
+			AppliedAt   time.Time `bson:"applied_at"`
+		}
+		if err := cursor.Decode(&record); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied[record.Version] = MigrationStatus{record.Version, record.Description, record.AppliedAt}
+	}
+	return applied, cursor.Err()
+}
+
+// lockMigrations keeps two instances starting at the same time from applying
+// the same migration twice. A lock older than migrationLockTTL is taken over.
+func lockMigrations(ctx context.Context, db *mongo.Database) (func(), error) {
+	locks := db.Collection("schema_migrations_lock")
+	now := time.Now()
+
+	_, err := locks.UpdateOne(ctx,
+		bson.M{"_id": "lock", "locked_at": bson.M{"$lt": now.Add(-migrationLockTTL)}},
+		bson.M{"$set": bson.M{"locked_at": now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrMigrationInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeoutDuration)
+		defer cancel()
+		locks.DeleteOne(ctx, bson.M{"_id": "lock"})
+	}, nil
+}
+
+func sortedMigrations() []Migration {
+	migrations := append([]Migration{}, Migrations...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+func isIndexNotFound(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound"
+}
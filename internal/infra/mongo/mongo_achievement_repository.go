@@ -0,0 +1,105 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MongoAchievementRepository struct {
+	achievements *mongo.Collection
+	logger       *zap.Logger
+}
+
+func NewMongoAchievementRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoAchievementRepository, error) {
+	achievementsCollection := mongoDatabase.Collection("achievements")
+
+	return &MongoAchievementRepository{achievements: achievementsCollection, logger: logger}, nil
+}
+
+func (m *MongoAchievementRepository) CreateAchievement(ctx context.Context, achievement domain.Achievement) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.achievements.InsertOne(ctx, toMongoAchievement(achievement))
+	if mongo.IsDuplicateKeyError(err) {
+		return infra.ErrAchievementExists
+	}
+	if err != nil {
+		m.logger.Error("failed to persist achievement", zap.Error(err))
+		return fmt.Errorf("failed to persist achievement: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoAchievementRepository) GetAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Achievement, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "awarded_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := m.achievements.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		m.logger.Error("failed to find achievements by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find achievements by user id: %w", err)
+	}
+
+	mongoAchievements := []mongoAchievement{}
+	if err := cursor.All(ctx, &mongoAchievements); err != nil {
+		m.logger.Error("failed to decode achievements", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode achievements: %w", err)
+	}
+
+	achievements := []domain.Achievement{}
+	for _, mongoAchievement := range mongoAchievements {
+		achievements = append(achievements, toDomainAchievement(mongoAchievement))
+	}
+	return achievements, nil
+}
+
+func (m *MongoAchievementRepository) DeleteAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.achievements.DeleteMany(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete achievements by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete achievements by user id: %w", err)
+	}
+	return nil
+}
+
+type mongoAchievement struct {
+	ObjectID  primitive.ObjectID `bson:"_id"`
+	UserId    primitive.ObjectID `bson:"user_id"`
+	Badge     string             `bson:"badge"`
+	MetricId  primitive.ObjectID `bson:"metric_id"`
+	AwardedAt time.Time          `bson:"awarded_at"`
+}
+
+func toMongoAchievement(achievement domain.Achievement) mongoAchievement {
+	return mongoAchievement{
+		ObjectID:  achievement.ID,
+		UserId:    achievement.UserId,
+		Badge:     string(achievement.Badge),
+		MetricId:  achievement.MetricId,
+		AwardedAt: achievement.AwardedAt,
+	}
+}
+
+func toDomainAchievement(m mongoAchievement) domain.Achievement {
+	return domain.Achievement{
+		ID:        m.ObjectID,
+		UserId:    m.UserId,
+		Badge:     domain.BadgeId(m.Badge),
+		MetricId:  m.MetricId,
+		AwardedAt: m.AwardedAt,
+	}
+}
@@ -0,0 +1,237 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MongoGoalRepository struct {
+	goals       *mongo.Collection
+	completions *mongo.Collection
+	logger      *zap.Logger
+}
+
+func NewMongoGoalRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoGoalRepository, error) {
+	goalsCollection := mongoDatabase.Collection("goals")
+	completionsCollection := mongoDatabase.Collection("goal_completions")
+
+	return &MongoGoalRepository{goals: goalsCollection, completions: completionsCollection, logger: logger}, nil
+}
+
+func (m *MongoGoalRepository) CreateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.goals.InsertOne(ctx, toMongoGoal(goal))
+	if err != nil {
+		m.logger.Error("failed to persist goal", zap.Error(err))
+		return fmt.Errorf("failed to persist goal: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoGoalRepository) GetGoalById(ctx context.Context, goalId primitive.ObjectID) (domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoGoal := mongoGoal{}
+	err := m.goals.FindOne(ctx, bson.M{"_id": goalId}).Decode(&mongoGoal)
+	if err != nil {
+		m.logger.Error("failed to find goal by id", zap.Error(err))
+		return domain.Goal{}, infra.ErrGoalNotFound
+	}
+	return toDomainGoal(mongoGoal), nil
+}
+
+func (m *MongoGoalRepository) GetGoalsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := m.goals.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		m.logger.Error("failed to find goals by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goals by user id: %w", err)
+	}
+
+	mongoGoals := []mongoGoal{}
+	if err := cursor.All(ctx, &mongoGoals); err != nil {
+		m.logger.Error("failed to decode goals", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode goals: %w", err)
+	}
+
+	goals := []domain.Goal{}
+	for _, mongoGoal := range mongoGoals {
+		goals = append(goals, toDomainGoal(mongoGoal))
+	}
+	return goals, nil
+}
+
+func (m *MongoGoalRepository) UpdateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.goals.UpdateOne(ctx, bson.M{"_id": goal.ID}, bson.M{"$set": toMongoGoal(goal)})
+	if err != nil {
+		m.logger.Error("failed to update goal", zap.Error(err))
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (m *MongoGoalRepository) DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.completions.DeleteMany(ctx, bson.M{"goal_id": goalId})
+	if err != nil {
+		m.logger.Error("failed to delete goal completions", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions: %w", err)
+	}
+	result, err := m.goals.DeleteOne(ctx, bson.M{"_id": goalId})
+	if err != nil {
+		m.logger.Error("failed to delete goal", zap.Error(err))
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (m *MongoGoalRepository) DeleteGoalsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.completions.DeleteMany(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete goal completions by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions by user id: %w", err)
+	}
+	_, err = m.goals.DeleteMany(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete goals by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goals by user id: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoGoalRepository) CreateGoalCompletion(ctx context.Context, completion domain.GoalCompletion) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.completions.InsertOne(ctx, toMongoGoalCompletion(completion))
+	if mongo.IsDuplicateKeyError(err) {
+		return infra.ErrGoalCompletionExists
+	}
+	if err != nil {
+		m.logger.Error("failed to persist goal completion", zap.Error(err))
+		return fmt.Errorf("failed to persist goal completion: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoGoalRepository) GetGoalCompletionsByUserId(ctx context.Context, userId primitive.ObjectID, since time.Time) ([]domain.GoalCompletion, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	filter := bson.M{"user_id": userId, "completed_at": bson.M{"$gt": since}}
+	opts := options.Find().SetSort(bson.D{{Key: "completed_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := m.completions.Find(ctx, filter, opts)
+	if err != nil {
+		m.logger.Error("failed to find goal completions by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goal completions by user id: %w", err)
+	}
+
+	mongoCompletions := []mongoGoalCompletion{}
+	if err := cursor.All(ctx, &mongoCompletions); err != nil {
+		m.logger.Error("failed to decode goal completions", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode goal completions: %w", err)
+	}
+
+	completions := []domain.GoalCompletion{}
+	for _, mongoCompletion := range mongoCompletions {
+		completions = append(completions, toDomainGoalCompletion(mongoCompletion))
+	}
+	return completions, nil
+}
+
+type mongoGoal struct {
+	ObjectID  primitive.ObjectID `bson:"_id"`
+	UserId    primitive.ObjectID `bson:"user_id"`
+	Kind      string             `bson:"kind"`
+	Period    string             `bson:"period"`
+	Target    int                `bson:"target"`
+	Threshold string             `bson:"threshold,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func toMongoGoal(goal domain.Goal) mongoGoal {
+	return mongoGoal{
+		ObjectID:  goal.ID,
+		UserId:    goal.UserId,
+		Kind:      string(goal.Kind),
+		Period:    string(goal.Period),
+		Target:    goal.Target,
+		Threshold: goal.Threshold,
+		CreatedAt: goal.CreatedAt,
+		UpdatedAt: goal.UpdatedAt,
+	}
+}
+
+func toDomainGoal(m mongoGoal) domain.Goal {
+	return domain.Goal{
+		ID:        m.ObjectID,
+		UserId:    m.UserId,
+		Kind:      domain.GoalKind(m.Kind),
+		Period:    domain.GoalPeriod(m.Period),
+		Target:    m.Target,
+		Threshold: m.Threshold,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+type mongoGoalCompletion struct {
+	ObjectID    primitive.ObjectID `bson:"_id"`
+	GoalId      primitive.ObjectID `bson:"goal_id"`
+	UserId      primitive.ObjectID `bson:"user_id"`
+	PeriodStart string             `bson:"period_start"`
+	Value       int                `bson:"value"`
+	CompletedAt time.Time          `bson:"completed_at"`
+}
+
+func toMongoGoalCompletion(completion domain.GoalCompletion) mongoGoalCompletion {
+	return mongoGoalCompletion{
+		ObjectID:    completion.ID,
+		GoalId:      completion.GoalId,
+		UserId:      completion.UserId,
+		PeriodStart: completion.PeriodStart,
+		Value:       completion.Value,
+		CompletedAt: completion.CompletedAt,
+	}
+}
+
+func toDomainGoalCompletion(m mongoGoalCompletion) domain.GoalCompletion {
+	return domain.GoalCompletion{
+		ID:          m.ObjectID,
+		GoalId:      m.GoalId,
+		UserId:      m.UserId,
+		PeriodStart: m.PeriodStart,
+		Value:       m.Value,
+		CompletedAt: m.CompletedAt,
+	}
+}
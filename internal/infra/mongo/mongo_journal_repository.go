@@ -0,0 +1,260 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// MongoJournalRepository seals entry bodies. Entries are searched through a
+// text index on the blind indexes of their search terms.
+type MongoJournalRepository struct {
+	entries *mongo.Collection
+	keyring *encryption.Keyring
+	logger  *zap.Logger
+}
+
+func NewMongoJournalRepo(ctx context.Context, mongoDatabase *mongo.Database, keyring *encryption.Keyring, logger *zap.Logger) (*MongoJournalRepository, error) {
+	if keyring == nil {
+		return nil, errors.New("failed to initialize mongo journal repo, keyring is nil")
+	}
+	entriesCollection := mongoDatabase.Collection("journal_entries")
+
+	return &MongoJournalRepository{entries: entriesCollection, keyring: keyring, logger: logger}, nil
+}
+
+func (m *MongoJournalRepository) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoEntry, err := m.toMongoJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	_, err = m.entries.InsertOne(ctx, mongoEntry)
+	if err != nil {
+		m.logger.Error("failed to persist journal entry", zap.Error(err))
+		return fmt.Errorf("failed to persist journal entry: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoJournalRepository) GetJournalEntryById(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoEntry := mongoJournalEntry{}
+	err := m.entries.FindOne(ctx, bson.M{"_id": entryId}).Decode(&mongoEntry)
+	if err != nil {
+		m.logger.Error("failed to find journal entry by id", zap.Error(err))
+		return domain.JournalEntry{}, infra.ErrJournalEntryNotFound
+	}
+	return m.toDomainJournalEntry(mongoEntry)
+}
+
+func (m *MongoJournalRepository) GetJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID, journalFilter infra.JournalFilter) (infra.JournalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	filter := bson.M{"user_id": userId}
+	createdAtFilter := bson.M{}
+	if !journalFilter.From.IsZero() {
+		createdAtFilter["$gte"] = primitive.NewDateTimeFromTime(journalFilter.From)
+	}
+	if !journalFilter.To.IsZero() {
+		createdAtFilter["$lt"] = primitive.NewDateTimeFromTime(journalFilter.To)
+	}
+	if len(createdAtFilter) > 0 {
+		filter["created_at"] = createdAtFilter
+	}
+	if journalFilter.Tag != "" {
+		filter["tags"] = journalFilter.Tag
+	}
+	if terms := domain.SearchTerms(journalFilter.Search); len(terms) > 0 {
+		// every term quoted so entries need all of them, not any
+		phrases := []string{}
+		for _, index := range m.keyring.BlindIndexes(terms) {
+			phrases = append(phrases, `"`+index+`"`)
+		}
+		filter["$text"] = bson.M{"$search": strings.Join(phrases, " ")}
+	}
+
+	total, err := m.entries.CountDocuments(ctx, filter)
+	if err != nil {
+		m.logger.Error("failed to count journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+
+	pageFilter := filter
+	if journalFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(journalFilter.Cursor)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		cursorCreatedAt := primitive.NewDateTimeFromTime(cursor.CreatedAt)
+		pageFilter = bson.M{
+			"$and": bson.A{
+				filter,
+				bson.M{"$or": bson.A{
+					bson.M{"created_at": bson.M{"$lt": cursorCreatedAt}},
+					bson.M{"created_at": cursorCreatedAt, "_id": bson.M{"$lt": cursor.ID}},
+				}},
+			},
+		}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if journalFilter.Limit > 0 {
+		findOptions.SetLimit(int64(journalFilter.Limit) + 1)
+	}
+
+	cursor, err := m.entries.Find(ctx, pageFilter, findOptions)
+	if err != nil {
+		m.logger.Error("failed retrieve journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+	defer cursor.Close(ctx)
+
+	mongoEntries := []mongoJournalEntry{}
+	if err := cursor.All(ctx, &mongoEntries); err != nil {
+		m.logger.Error("failed to decode journal entries", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+
+	page := infra.JournalPage{Entries: []domain.JournalEntry{}, Limit: journalFilter.Limit, Total: total}
+	if journalFilter.Limit > 0 && len(mongoEntries) > journalFilter.Limit {
+		mongoEntries = mongoEntries[:journalFilter.Limit]
+		last := mongoEntries[len(mongoEntries)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ObjectID)
+	}
+	for _, mongoEntry := range mongoEntries {
+		entry, err := m.toDomainJournalEntry(mongoEntry)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+func (m *MongoJournalRepository) UpdateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoEntry, err := m.toMongoJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	result, err := m.entries.ReplaceOne(ctx, bson.M{"_id": entry.ID}, mongoEntry)
+	if err != nil {
+		m.logger.Error("failed to update journal entry", zap.Error(err))
+		return fmt.Errorf("failed to update journal entry: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (m *MongoJournalRepository) DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.entries.DeleteOne(ctx, bson.M{"_id": entryId})
+	if err != nil {
+		m.logger.Error("failed to delete journal entry", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (m *MongoJournalRepository) DeleteJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.entries.DeleteMany(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete journal entries by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entries by user id: %w", err)
+	}
+	return nil
+}
+
+type mongoJournalEntry struct {
+	ObjectID primitive.ObjectID  `bson:"_id"`
+	UserId   primitive.ObjectID  `bson:"user_id"`
+	Title    string              `bson:"title"`
+	Body     bson.RawValue       `bson:"body"`
+	Tags     []string            `bson:"tags"`
+	MetricId *primitive.ObjectID `bson:"metric_id,omitempty"`
+	// SearchTerms are the blind indexes of the entry's search terms.
+	SearchTerms []string       `bson:"search_terms"`
+	Encryption  *mongoEnvelope `bson:"encryption"`
+	CreatedAt   time.Time      `bson:"created_at"`
+	UpdatedAt   time.Time      `bson:"updated_at"`
+}
+
+func (m *MongoJournalRepository) toMongoJournalEntry(entry domain.JournalEntry) (mongoJournalEntry, error) {
+	dataKey, envelope, err := newEnvelope(m.keyring)
+	if err != nil {
+		return mongoJournalEntry{}, err
+	}
+	body, err := sealField(dataKey, entry.ID, "body", entry.Body)
+	if err != nil {
+		return mongoJournalEntry{}, err
+	}
+	mongoEntry := mongoJournalEntry{
+		ObjectID:    entry.ID,
+		UserId:      entry.UserId,
+		Title:       entry.Title,
+		Body:        body,
+		Tags:        append([]string{}, entry.Tags...),
+		SearchTerms: m.keyring.BlindIndexes(entry.SearchTerms()),
+		Encryption:  envelope,
+		CreatedAt:   entry.CreatedAt,
+		UpdatedAt:   entry.UpdatedAt,
+	}
+	if entry.HasMetric() {
+		metricId := entry.MetricId
+		mongoEntry.MetricId = &metricId
+	}
+	return mongoEntry, nil
+}
+
+func (m *MongoJournalRepository) toDomainJournalEntry(mongoEntry mongoJournalEntry) (domain.JournalEntry, error) {
+	dataKey, err := openEnvelope(m.keyring, mongoEntry.Encryption)
+	if err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to open the data key of journal entry %s: %w", mongoEntry.ObjectID.Hex(), err)
+	}
+	body, err := openField(dataKey, mongoEntry.ObjectID, "body", mongoEntry.Body)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	entry := domain.JournalEntry{
+		ID:        mongoEntry.ObjectID,
+		UserId:    mongoEntry.UserId,
+		Title:     mongoEntry.Title,
+		Body:      body,
+		Tags:      append([]string{}, mongoEntry.Tags...),
+		CreatedAt: mongoEntry.CreatedAt,
+		UpdatedAt: mongoEntry.UpdatedAt,
+	}
+	if mongoEntry.MetricId != nil {
+		entry.MetricId = *mongoEntry.MetricId
+	}
+	return entry, nil
+}
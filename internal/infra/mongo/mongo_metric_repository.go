@@ -2,36 +2,67 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoMetricRepository seals the feeling of every metric.
 type MongoMetricRepository struct {
 	metrics *mongo.Collection
+	keyring *encryption.Keyring
 	logger  *zap.Logger
 }
 
-func NewMongoMetricRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoMetricRepository, error) {
+func NewMongoMetricRepo(ctx context.Context, mongoDatabase *mongo.Database, keyring *encryption.Keyring, logger *zap.Logger) (*MongoMetricRepository, error) {
+	if keyring == nil {
+		return nil, errors.New("failed to initialize mongo metric repo, keyring is nil")
+	}
 	metricsCollection := mongoDatabase.Collection("metrics")
 
-	return &MongoMetricRepository{metrics: metricsCollection, logger: logger}, nil
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	// CreateMetric relies on this index to refuse a second log for a day, so
+	// it is ensured here too instead of trusting that migration 5 has run.
+	// Metrics saved before local_date existed are left out until the
+	// migration backfills them.
+	_, err := metricsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "local_date", Value: 1}},
+		Options: options.Index().SetName("owner_id_local_date").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"local_date": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics owner_id local_date index: %w", err)
+	}
+
+	return &MongoMetricRepository{metrics: metricsCollection, keyring: keyring, logger: logger}, nil
 }
 
 func (m *MongoMetricRepository) CreateMetric(ctx context.Context, metric domain.Metric) error {
 	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
 	defer cancel()
 
-	mongoMetric := toMongoMetric(metric)
+	mongoMetric, err := m.toMongoMetric(metric)
+	if err != nil {
+		return err
+	}
 
-	_, err := m.metrics.InsertOne(ctx, mongoMetric)
+	_, err = m.metrics.InsertOne(ctx, mongoMetric)
+	if mongo.IsDuplicateKeyError(err) {
+		return infra.ErrDailyLogExists
+	}
 	if err != nil {
 		m.logger.Error("failed to persist metric: %w", zap.Error(err))
 		return fmt.Errorf("failed to persist metric: %w", err)
@@ -43,13 +74,16 @@ func (m *MongoMetricRepository) UpdateMetricById(ctx context.Context, metric dom
 	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
 	defer cancel()
 
-	mongoMetric := toMongoMetric(metric)
+	mongoMetric, err := m.toMongoMetric(metric)
+	if err != nil {
+		return err
+	}
 
 	filter := bson.M{"_id": metric.ID}
 	updatedDoc := bson.M{
 		"$set": mongoMetric,
 	}
-	_, err := m.metrics.UpdateOne(ctx, filter, updatedDoc)
+	_, err = m.metrics.UpdateOne(ctx, filter, updatedDoc)
 	if err != nil {
 		m.logger.Error("failed to update metric: %w", zap.Error(err))
 		return fmt.Errorf("failed to update metric: %w", err)
@@ -71,15 +105,72 @@ func (m *MongoMetricRepository) GetMetricById(ctx context.Context, metricId prim
 		m.logger.Error("failed to find metric by id: %w", zap.Error(err))
 		return domain.Metric{}, infra.ErrMetricNotFound
 	}
-	return toDomainMetric(mongoMetric), nil
+	return m.toDomainMetric(mongoMetric)
+}
+
+func (m *MongoMetricRepository) DeleteMetricById(ctx context.Context, metricId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.metrics.DeleteOne(ctx, bson.M{"_id": metricId})
+	if err != nil {
+		m.logger.Error("failed to delete metric", zap.Error(err))
+		return fmt.Errorf("failed to delete metric: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return infra.ErrMetricNotFound
+	}
+	return nil
+}
+
+func (m *MongoMetricRepository) DeleteMetricsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.metrics.DeleteMany(ctx, bson.M{"owner_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete metrics by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete metrics by user id: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoMetricRepository) GetMetricDays(ctx context.Context, userId primitive.ObjectID) ([]infra.MetricDay, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"_id": 1, "local_date": 1, "created_at": 1})
+	cursor, err := m.metrics.Find(ctx, bson.M{"owner_id": userId}, opts)
+	if err != nil {
+		m.logger.Error("failed to find metric days", zap.Error(err))
+		return nil, fmt.Errorf("failed to find metric days: %w", err)
+	}
+
+	rows := []struct {
+		ObjectID  primitive.ObjectID `bson:"_id"`
+		LocalDate string             `bson:"local_date"`
+		CreatedAt time.Time          `bson:"created_at"`
+	}{}
+	if err := cursor.All(ctx, &rows); err != nil {
+		m.logger.Error("failed to decode metric days", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode metric days: %w", err)
+	}
+
+	days := []infra.MetricDay{}
+	for _, row := range rows {
+		days = append(days, infra.MetricDay{MetricId: row.ObjectID, LocalDate: row.LocalDate, CreatedAt: row.CreatedAt})
+	}
+	return days, nil
 }
 
-func (m *MongoMetricRepository) GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID) (domain.Metric, error) {
+func (m *MongoMetricRepository) GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID, location *time.Location) (domain.Metric, error) {
 	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
 	defer cancel()
 
 	mongoMetric := mongoMetric{}
-	startTime, endTime := getDayBounds()
+	startTime, endTime := domain.DayBounds(time.Now(), location)
 
 	filter := bson.M{
 		"owner_id": userId,
@@ -94,49 +185,164 @@ func (m *MongoMetricRepository) GetUserTodayLogIfExists(ctx context.Context, use
 		m.logger.Error("failed to find mongo metric for today: %w", zap.Error(err))
 		return domain.Metric{}, infra.ErrMetricNotFound
 	}
-	return toDomainMetric(mongoMetric), nil
+	return m.toDomainMetric(mongoMetric)
 }
 
-func getDayBounds() (time.Time, time.Time) {
-	now := time.Now()
-	year, month, day := now.Date()
+func (m *MongoMetricRepository) GetMetricsByUserId(ctx context.Context, userId primitive.ObjectID, metricFilter infra.MetricFilter) (infra.MetricPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
 
-	earliest := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	filter := bson.M{"owner_id": userId}
+	createdAtFilter := bson.M{}
+	if !metricFilter.From.IsZero() {
+		createdAtFilter["$gte"] = primitive.NewDateTimeFromTime(metricFilter.From)
+	}
+	if !metricFilter.To.IsZero() {
+		createdAtFilter["$lt"] = primitive.NewDateTimeFromTime(metricFilter.To)
+	}
+	if len(createdAtFilter) > 0 {
+		filter["created_at"] = createdAtFilter
+	}
 
-	latest := time.Date(year, month, day, 23, 59, 59, int(time.Second-time.Nanosecond), now.Location())
+	total, err := m.metrics.CountDocuments(ctx, filter)
+	if err != nil {
+		m.logger.Error("failed to count metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
 
-	return earliest, latest
-}
+	pageFilter := filter
+	if metricFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(metricFilter.Cursor)
+		if err != nil {
+			return infra.MetricPage{}, err
+		}
+		cursorCreatedAt := primitive.NewDateTimeFromTime(cursor.CreatedAt)
+		pageFilter = bson.M{
+			"$and": bson.A{
+				filter,
+				bson.M{"$or": bson.A{
+					bson.M{"created_at": bson.M{"$lt": cursorCreatedAt}},
+					bson.M{"created_at": cursorCreatedAt, "_id": bson.M{"$lt": cursor.ID}},
+				}},
+			},
+		}
+	}
 
-func (m *MongoMetricRepository) GetRecentMetricsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Metric, error) {
-	// TODO:TODO: I think this method has issues
-	mongoMetrics := []*mongoMetric{}
-	filter := bson.M{"owner_id": userId}
-	cursor, err := m.metrics.Find(ctx, filter)
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if metricFilter.Limit > 0 {
+		findOptions.SetLimit(int64(metricFilter.Limit) + 1)
+	}
+
+	cursor, err := m.metrics.Find(ctx, pageFilter, findOptions)
 	if err != nil {
-		m.logger.Error("failed retrieve recent metrics by user id: %w", zap.Error(err))
-		return []domain.Metric{}, err
+		m.logger.Error("failed retrieve metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
 	}
-	for cursor.Next(ctx) {
-		var mm mongoMetric
-		err := cursor.Decode(&mm)
+	defer cursor.Close(ctx)
+
+	mongoMetrics := []mongoMetric{}
+	if err := cursor.All(ctx, &mongoMetrics); err != nil {
+		m.logger.Error("failed to decode metric in list of metrics : %w", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
+
+	page := infra.MetricPage{Metrics: []domain.Metric{}, Limit: metricFilter.Limit, Total: total}
+	if metricFilter.Limit > 0 && len(mongoMetrics) > metricFilter.Limit {
+		mongoMetrics = mongoMetrics[:metricFilter.Limit]
+		last := mongoMetrics[len(mongoMetrics)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ObjectID)
+	}
+	for _, element := range mongoMetrics {
+		metric, err := m.toDomainMetric(element)
 		if err != nil {
-			m.logger.Error("failed to decode metric in list of metrics : %w", zap.Error(err))
-			return []domain.Metric{}, err
+			return infra.MetricPage{}, err
 		}
-		mongoMetrics = append(mongoMetrics, &mm)
+		page.Metrics = append(page.Metrics, metric)
+	}
+
+	return page, nil
+}
+
+func (m *MongoMetricRepository) GetMetricSummary(ctx context.Context, userId primitive.ObjectID, summaryFilter infra.MetricSummaryFilter) ([]domain.MetricSummaryBucket, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	if !summaryFilter.Granularity.IsValid() {
+		return nil, fmt.Errorf("unsupported summary granularity %q", summaryFilter.Granularity)
 	}
-	if err := cursor.Err(); err != nil {
-		return []domain.Metric{}, err
+	location := summaryFilter.Location
+	if location == nil {
+		location = time.UTC
 	}
-	cursor.Close(ctx)
 
-	result := []domain.Metric{}
-	for _, element := range mongoMetrics {
-		result = append(result, toDomainMetric(*element))
+	filter := bson.M{"owner_id": userId}
+	createdAtFilter := bson.M{}
+	if !summaryFilter.From.IsZero() {
+		createdAtFilter["$gte"] = primitive.NewDateTimeFromTime(summaryFilter.From)
+	}
+	if !summaryFilter.To.IsZero() {
+		createdAtFilter["$lt"] = primitive.NewDateTimeFromTime(summaryFilter.To)
+	}
+	if len(createdAtFilter) > 0 {
+		filter["created_at"] = createdAtFilter
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format": "%Y-%m-%d", "date": "$created_at", "timezone": location.String(),
+			}},
+			"count":            bson.M{"$sum": 1},
+			"sum_score":        bson.M{"$sum": "$stress_less_score"},
+			"sum_stress_level": bson.M{"$sum": "$stress_level"},
+			"moods":            bson.M{"$push": "$mood"},
+			"sleep_qualities":  bson.M{"$push": "$sleep_quality"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := m.metrics.Aggregate(ctx, pipeline)
+	if err != nil {
+		m.logger.Error("failed to aggregate metric summary", zap.Error(err))
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rows := []mongoMetricSummaryDay{}
+	if err := cursor.All(ctx, &rows); err != nil {
+		m.logger.Error("failed to decode metric summary", zap.Error(err))
+		return nil, err
 	}
 
-	return result, nil
+	days := []domain.MetricDayTotals{}
+	for _, row := range rows {
+		day := domain.MetricDayTotals{
+			Date:                     row.Day,
+			MetricCount:              row.Count,
+			SumStressLessScore:       row.SumScore,
+			SumStressLevel:           row.SumStressLevel,
+			MoodDistribution:         map[domain.Mood]int{},
+			SleepQualityDistribution: map[domain.SleepQuality]int{},
+		}
+		for _, mood := range row.Moods {
+			day.MoodDistribution[mood]++
+		}
+		for _, sleepQuality := range row.SleepQualities {
+			day.SleepQualityDistribution[sleepQuality]++
+		}
+		days = append(days, day)
+	}
+	return domain.SummarizeMetricDays(days, summaryFilter.Granularity, location)
+}
+
+type mongoMetricSummaryDay struct {
+	Day            string                `bson:"_id"`
+	Count          int                   `bson:"count"`
+	SumScore       float64               `bson:"sum_score"`
+	SumStressLevel float64               `bson:"sum_stress_level"`
+	Moods          []domain.Mood         `bson:"moods"`
+	SleepQualities []domain.SleepQuality `bson:"sleep_qualities"`
 }
 
 type mongoMetric struct {
@@ -145,13 +351,40 @@ type mongoMetric struct {
 	StressLevel     int                 `bson:"stress_level"`
 	Mood            domain.Mood         `bson:"mood"`
 	SleepQuality    domain.SleepQuality `bson:"sleep_quality"`
-	Feeling         string              `bson:"feeling"`
-	StressLessScore int                 `bson:"stress_less_score"`
-	CreatedAt       time.Time           `bson:"created_at"`
-	UpdatedAt       time.Time           `bson:"updated_at"`
+	Feeling         bson.RawValue       `bson:"feeling"`
+	FeelingPolarity float64             `bson:"feeling_polarity"`
+	// Themes are sealed along with the feeling they come from, as a comma
+	// separated list.
+	Themes          bson.RawValue  `bson:"themes"`
+	SafetyFlags     []string       `bson:"safety_flags"`
+	StressLessScore int            `bson:"stress_less_score"`
+	LocalDate       string         `bson:"local_date,omitempty"`
+	Encryption      *mongoEnvelope `bson:"encryption,omitempty"`
+	CreatedAt       time.Time      `bson:"created_at"`
+	UpdatedAt       time.Time      `bson:"updated_at"`
 }
 
-func toMongoMetric(metric domain.Metric) mongoMetric {
+func (m *MongoMetricRepository) toMongoMetric(metric domain.Metric) (mongoMetric, error) {
+	dataKey, envelope, err := newEnvelope(m.keyring)
+	if err != nil {
+		return mongoMetric{}, err
+	}
+	feeling, err := sealField(dataKey, metric.ID, "feeling", metric.Feeling)
+	if err != nil {
+		return mongoMetric{}, err
+	}
+	themeNames := []string{}
+	for _, theme := range metric.Themes {
+		themeNames = append(themeNames, string(theme))
+	}
+	themes, err := sealField(dataKey, metric.ID, "themes", strings.Join(themeNames, ","))
+	if err != nil {
+		return mongoMetric{}, err
+	}
+	safetyFlags := []string{}
+	for _, flag := range metric.SafetyFlags {
+		safetyFlags = append(safetyFlags, string(flag))
+	}
 	return mongoMetric{
 		ObjectID:        metric.ID,
 		OwnerId:         metric.OwnerId,
@@ -159,22 +392,54 @@ func toMongoMetric(metric domain.Metric) mongoMetric {
 		StressLessScore: metric.StressLessScore,
 		SleepQuality:    metric.SleepQuality,
 		Mood:            metric.Mood,
-		Feeling:         metric.Feeling,
+		Feeling:         feeling,
+		FeelingPolarity: metric.FeelingPolarity,
+		Themes:          themes,
+		SafetyFlags:     safetyFlags,
+		LocalDate:       metric.LocalDate,
+		Encryption:      envelope,
 		CreatedAt:       metric.CreatedAt,
 		UpdatedAt:       metric.UpdatedAt,
-	}
+	}, nil
 }
 
-func toDomainMetric(m mongoMetric) domain.Metric {
-	return domain.Metric{
-		ID:              m.ObjectID,
-		OwnerId:         m.OwnerId,
-		StressLevel:     m.StressLevel,
-		StressLessScore: m.StressLessScore,
-		SleepQuality:    m.SleepQuality,
-		Mood:            m.Mood,
-		Feeling:         m.Feeling,
-		CreatedAt:       m.CreatedAt,
-		UpdatedAt:       m.UpdatedAt,
+func (m *MongoMetricRepository) toDomainMetric(mm mongoMetric) (domain.Metric, error) {
+	dataKey, err := openEnvelope(m.keyring, mm.Encryption)
+	if err != nil {
+		return domain.Metric{}, fmt.Errorf("failed to open the data key of metric %s: %w", mm.ObjectID.Hex(), err)
+	}
+	feeling, err := openField(dataKey, mm.ObjectID, "feeling", mm.Feeling)
+	if err != nil {
+		return domain.Metric{}, err
 	}
+	// metrics from before feelings were analyzed have no themes
+	themeNames, err := openField(dataKey, mm.ObjectID, "themes", mm.Themes)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	themes := []domain.Theme{}
+	if themeNames != "" {
+		for _, theme := range strings.Split(themeNames, ",") {
+			themes = append(themes, domain.Theme(theme))
+		}
+	}
+	safetyFlags := []domain.SafetyFlag{}
+	for _, flag := range mm.SafetyFlags {
+		safetyFlags = append(safetyFlags, domain.SafetyFlag(flag))
+	}
+	return domain.Metric{
+		ID:              mm.ObjectID,
+		OwnerId:         mm.OwnerId,
+		StressLevel:     mm.StressLevel,
+		StressLessScore: mm.StressLessScore,
+		SleepQuality:    mm.SleepQuality,
+		Mood:            mm.Mood,
+		Feeling:         feeling,
+		FeelingPolarity: mm.FeelingPolarity,
+		Themes:          themes,
+		SafetyFlags:     safetyFlags,
+		LocalDate:       mm.LocalDate,
+		CreatedAt:       mm.CreatedAt,
+		UpdatedAt:       mm.UpdatedAt,
+	}, nil
 }
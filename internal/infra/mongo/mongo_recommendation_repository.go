@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -91,6 +92,90 @@ func (m *MongoRecommendationRepository) GetRecommendationByMetricId(ctx context.
 	return toDomainRecommendation(mongoRecommendation), nil
 }
 
+func (m *MongoRecommendationRepository) GetRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) ([]domain.Recommendation, error) {
+	if len(metricIds) == 0 {
+		return []domain.Recommendation{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	filter := bson.M{"metric_id": bson.M{"$in": metricIds}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+
+	cursor, err := m.recommendations.Find(ctx, filter, opts)
+	if err != nil {
+		m.logger.Error("failed to find recommendations by metric ids", zap.Error(err))
+		return nil, fmt.Errorf("failed to find recommendations by metric ids: %w", err)
+	}
+
+	mongoRecommendations := []mongoRecommendation{}
+	if err := cursor.All(ctx, &mongoRecommendations); err != nil {
+		m.logger.Error("failed to decode recommendations", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode recommendations: %w", err)
+	}
+
+	recommendations := []domain.Recommendation{}
+	for _, mongoRecommendation := range mongoRecommendations {
+		recommendations = append(recommendations, toDomainRecommendation(mongoRecommendation))
+	}
+	return recommendations, nil
+}
+
+func (m *MongoRecommendationRepository) GetPendingRecommendations(ctx context.Context, limit int) ([]domain.Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	filter := bson.M{"status": domain.RecommendationPending}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := m.recommendations.Find(ctx, filter, opts)
+	if err != nil {
+		m.logger.Error("failed to find pending recommendations", zap.Error(err))
+		return nil, fmt.Errorf("failed to find pending recommendations: %w", err)
+	}
+
+	mongoRecommendations := []mongoRecommendation{}
+	if err := cursor.All(ctx, &mongoRecommendations); err != nil {
+		m.logger.Error("failed to decode recommendations", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode recommendations: %w", err)
+	}
+
+	recommendations := []domain.Recommendation{}
+	for _, mongoRecommendation := range mongoRecommendations {
+		recommendations = append(recommendations, toDomainRecommendation(mongoRecommendation))
+	}
+	return recommendations, nil
+}
+
+func (m *MongoRecommendationRepository) DeleteRecommendationsByMetricId(ctx context.Context, metricId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.recommendations.DeleteMany(ctx, bson.M{"metric_id": metricId})
+	if err != nil {
+		m.logger.Error("failed to delete recommendations by metric id", zap.Error(err))
+		return fmt.Errorf("failed to delete recommendations by metric id: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoRecommendationRepository) DeleteRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) error {
+	if len(metricIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.recommendations.DeleteMany(ctx, bson.M{"metric_id": bson.M{"$in": metricIds}})
+	if err != nil {
+		m.logger.Error("failed to delete recommendations by metric ids", zap.Error(err))
+		return fmt.Errorf("failed to delete recommendations by metric ids: %w", err)
+	}
+	return nil
+}
+
 type mongoRecommedationItem struct {
 	Index    int    `bson:"index"`
 	Heading  string `bson:"heading"`
@@ -98,11 +183,14 @@ type mongoRecommedationItem struct {
 	ImageUrl string `bson:"image_url"`
 }
 
+// Status is missing on recommendations saved before generation moved to the
+// background, they were always ready.
 type mongoRecommendation struct {
 	ObjectID   primitive.ObjectID       `bson:"_id"`
 	MetricId   primitive.ObjectID       `bson:"metric_id"`
 	MetricType string                   `bson:"metric_type"`
 	Items      []mongoRecommedationItem `bson:"items"`
+	Status     string                   `bson:"status,omitempty"`
 	CreatedAt  time.Time                `bson:"created_at"`
 	UpdatedAt  time.Time                `bson:"updated_at"`
 }
@@ -117,6 +205,7 @@ func toMongoRecommendation(recommendation domain.Recommendation) mongoRecommenda
 		MetricId:   recommendation.MetricId,
 		MetricType: recommendation.MetricType,
 		Items:      items,
+		Status:     string(recommendation.Status),
 		CreatedAt:  recommendation.CreatedAt,
 		UpdatedAt:  recommendation.UpdatedAt,
 	}
@@ -145,11 +234,16 @@ func toDomainRecommendation(m mongoRecommendation) domain.Recommendation {
 	for _, item := range m.Items {
 		items = append(items, toDomainRecommendationItem(item))
 	}
+	status := domain.RecommendationStatus(m.Status)
+	if status == "" {
+		status = domain.RecommendationReady
+	}
 	return domain.Recommendation{
 		ID:         m.ObjectID,
 		MetricId:   m.MetricId,
 		MetricType: m.MetricType,
 		Items:      items,
+		Status:     status,
 		CreatedAt:  m.CreatedAt,
 		UpdatedAt:  m.UpdatedAt,
 	}
@@ -0,0 +1,224 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// MongoSafetyRepository keeps a user's override in a document whose _id is
+// the user's id.
+type MongoSafetyRepository struct {
+	events    *mongo.Collection
+	overrides *mongo.Collection
+	logger    *zap.Logger
+}
+
+func NewMongoSafetyRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoSafetyRepository, error) {
+	eventsCollection := mongoDatabase.Collection("safety_events")
+	overridesCollection := mongoDatabase.Collection("safety_overrides")
+
+	return &MongoSafetyRepository{events: eventsCollection, overrides: overridesCollection, logger: logger}, nil
+}
+
+func (m *MongoSafetyRepository) CreateSafetyEvent(ctx context.Context, event domain.SafetyEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.events.InsertOne(ctx, toMongoSafetyEvent(event))
+	if err != nil {
+		m.logger.Error("failed to persist safety event", zap.Error(err))
+		return fmt.Errorf("failed to persist safety event: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoSafetyRepository) GetSafetyEventsByUserId(ctx context.Context, userId primitive.ObjectID, limit int) ([]domain.SafetyEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := m.events.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		m.logger.Error("failed to find safety events by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find safety events by user id: %w", err)
+	}
+
+	mongoEvents := []mongoSafetyEvent{}
+	if err := cursor.All(ctx, &mongoEvents); err != nil {
+		m.logger.Error("failed to decode safety events", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode safety events: %w", err)
+	}
+
+	events := []domain.SafetyEvent{}
+	for _, mongoEvent := range mongoEvents {
+		events = append(events, toDomainSafetyEvent(mongoEvent))
+	}
+	return events, nil
+}
+
+func (m *MongoSafetyRepository) SaveSafetyOverride(ctx context.Context, override domain.SafetyOverride) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.overrides.ReplaceOne(ctx, bson.M{"_id": override.UserId}, toMongoSafetyOverride(override), options.Replace().SetUpsert(true))
+	if err != nil {
+		m.logger.Error("failed to save safety override", zap.Error(err))
+		return fmt.Errorf("failed to save safety override: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoSafetyRepository) GetSafetyOverride(ctx context.Context, userId primitive.ObjectID) (domain.SafetyOverride, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoOverride := mongoSafetyOverride{}
+	err := m.overrides.FindOne(ctx, bson.M{"_id": userId}).Decode(&mongoOverride)
+	if err == mongo.ErrNoDocuments {
+		return domain.SafetyOverride{}, infra.ErrSafetyOverrideNotFound
+	}
+	if err != nil {
+		m.logger.Error("failed to find safety override", zap.Error(err))
+		return domain.SafetyOverride{}, fmt.Errorf("failed to find safety override: %w", err)
+	}
+	return toDomainSafetyOverride(mongoOverride), nil
+}
+
+func (m *MongoSafetyRepository) DeleteSafetyOverride(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.overrides.DeleteOne(ctx, bson.M{"_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete safety override", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return infra.ErrSafetyOverrideNotFound
+	}
+	return nil
+}
+
+func (m *MongoSafetyRepository) DeleteSafetyDataByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.overrides.DeleteOne(ctx, bson.M{"_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete safety override by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override by user id: %w", err)
+	}
+	_, err = m.events.DeleteMany(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete safety events by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety events by user id: %w", err)
+	}
+	return nil
+}
+
+type mongoSafetyEvent struct {
+	ObjectID  primitive.ObjectID  `bson:"_id"`
+	UserId    primitive.ObjectID  `bson:"user_id"`
+	Kind      string              `bson:"kind"`
+	MetricId  *primitive.ObjectID `bson:"metric_id,omitempty"`
+	Flags     []string            `bson:"flags"`
+	Escalated bool                `bson:"escalated"`
+	Country   string              `bson:"country,omitempty"`
+	ActorId   *primitive.ObjectID `bson:"actor_id,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+func toMongoSafetyEvent(event domain.SafetyEvent) mongoSafetyEvent {
+	flags := []string{}
+	for _, flag := range event.Flags {
+		flags = append(flags, string(flag))
+	}
+	mongoEvent := mongoSafetyEvent{
+		ObjectID:  event.ID,
+		UserId:    event.UserId,
+		Kind:      string(event.Kind),
+		Flags:     flags,
+		Escalated: event.Escalated,
+		Country:   event.Country,
+		CreatedAt: event.CreatedAt,
+	}
+	if !event.MetricId.IsZero() {
+		metricId := event.MetricId
+		mongoEvent.MetricId = &metricId
+	}
+	if !event.ActorId.IsZero() {
+		actorId := event.ActorId
+		mongoEvent.ActorId = &actorId
+	}
+	return mongoEvent
+}
+
+func toDomainSafetyEvent(m mongoSafetyEvent) domain.SafetyEvent {
+	flags := []domain.SafetyFlag{}
+	for _, flag := range m.Flags {
+		flags = append(flags, domain.SafetyFlag(flag))
+	}
+	event := domain.SafetyEvent{
+		ID:        m.ObjectID,
+		UserId:    m.UserId,
+		Kind:      domain.SafetyEventKind(m.Kind),
+		Flags:     flags,
+		Escalated: m.Escalated,
+		Country:   m.Country,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.MetricId != nil {
+		event.MetricId = *m.MetricId
+	}
+	if m.ActorId != nil {
+		event.ActorId = *m.ActorId
+	}
+	return event
+}
+
+type mongoSafetyOverride struct {
+	UserId                  primitive.ObjectID `bson:"_id"`
+	LowMoodDays             int                `bson:"low_mood_days"`
+	SuppressEscalationUntil *time.Time         `bson:"suppress_escalation_until,omitempty"`
+	ClinicianId             primitive.ObjectID `bson:"clinician_id"`
+	UpdatedAt               time.Time          `bson:"updated_at"`
+}
+
+func toMongoSafetyOverride(override domain.SafetyOverride) mongoSafetyOverride {
+	mongoOverride := mongoSafetyOverride{
+		UserId:      override.UserId,
+		LowMoodDays: override.LowMoodDays,
+		ClinicianId: override.ClinicianId,
+		UpdatedAt:   override.UpdatedAt,
+	}
+	if !override.SuppressEscalationUntil.IsZero() {
+		suppressUntil := override.SuppressEscalationUntil
+		mongoOverride.SuppressEscalationUntil = &suppressUntil
+	}
+	return mongoOverride
+}
+
+func toDomainSafetyOverride(m mongoSafetyOverride) domain.SafetyOverride {
+	override := domain.SafetyOverride{
+		UserId:      m.UserId,
+		LowMoodDays: m.LowMoodDays,
+		ClinicianId: m.ClinicianId,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if m.SuppressEscalationUntil != nil {
+		override.SuppressEscalationUntil = *m.SuppressEscalationUntil
+	}
+	return override
+}
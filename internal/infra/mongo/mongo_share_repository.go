@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MongoShareRepository struct {
+	collection *mongo.Collection
+	logger     *zap.Logger
+}
+
+func NewMongoShareRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoShareRepository, error) {
+	collection := mongoDatabase.Collection("shares")
+
+	return &MongoShareRepository{collection: collection, logger: logger}, nil
+}
+
+func (m *MongoShareRepository) CreateShare(ctx context.Context, share domain.Share) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.collection.InsertOne(ctx, toMongoShare(share))
+	if err != nil {
+		m.logger.Error("failed to persist share", zap.Error(err))
+		return fmt.Errorf("failed to persist share: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoShareRepository) GetShareById(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error) {
+	return m.getShare(ctx, bson.M{"_id": shareId})
+}
+
+func (m *MongoShareRepository) GetShareByCodeHash(ctx context.Context, codeHash string) (domain.Share, error) {
+	return m.getShare(ctx, bson.M{"code_hash": codeHash})
+}
+
+func (m *MongoShareRepository) getShare(ctx context.Context, filter bson.M) (domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	mongoShare := mongoShare{}
+	err := m.collection.FindOne(ctx, filter).Decode(&mongoShare)
+	if err == mongo.ErrNoDocuments {
+		return domain.Share{}, infra.ErrShareNotFound
+	}
+	if err != nil {
+		m.logger.Error("failed to find share", zap.Error(err))
+		return domain.Share{}, fmt.Errorf("failed to find share: %w", err)
+	}
+	return toDomainShare(mongoShare), nil
+}
+
+func (m *MongoShareRepository) GetSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) ([]domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := m.collection.Find(ctx, bson.M{"owner_id": ownerId}, opts)
+	if err != nil {
+		m.logger.Error("failed to find shares by owner id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find shares by owner id: %w", err)
+	}
+
+	mongoShares := []mongoShare{}
+	if err := cursor.All(ctx, &mongoShares); err != nil {
+		m.logger.Error("failed to decode shares", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode shares: %w", err)
+	}
+
+	shares := []domain.Share{}
+	for _, mongoShare := range mongoShares {
+		shares = append(shares, toDomainShare(mongoShare))
+	}
+	return shares, nil
+}
+
+func (m *MongoShareRepository) AcceptShare(ctx context.Context, shareId, acceptedBy primitive.ObjectID, acceptedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.collection.UpdateOne(ctx,
+		bson.M{"_id": shareId, "accepted_at": bson.M{"$exists": false}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": acceptedShareUpdate(acceptedBy, acceptedAt)},
+	)
+	if err != nil {
+		m.logger.Error("failed to accept share", zap.Error(err))
+		return fmt.Errorf("failed to accept share: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func acceptedShareUpdate(acceptedBy primitive.ObjectID, acceptedAt time.Time) bson.M {
+	update := bson.M{"accepted_at": acceptedAt, "updated_at": acceptedAt}
+	if !acceptedBy.IsZero() {
+		update["accepted_by"] = acceptedBy
+	}
+	return update
+}
+
+func (m *MongoShareRepository) RevokeShare(ctx context.Context, shareId primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := m.collection.UpdateOne(ctx,
+		bson.M{"_id": shareId, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": revokedAt, "updated_at": revokedAt}},
+	)
+	if err != nil {
+		m.logger.Error("failed to revoke share", zap.Error(err))
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// already revoked shares keep the time they were first revoked at
+	count, err := m.collection.CountDocuments(ctx, bson.M{"_id": shareId})
+	if err != nil {
+		m.logger.Error("failed to find share", zap.Error(err))
+		return fmt.Errorf("failed to find share: %w", err)
+	}
+	if count == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func (m *MongoShareRepository) DeleteSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.collection.DeleteMany(ctx, bson.M{"owner_id": ownerId})
+	if err != nil {
+		m.logger.Error("failed to delete shares by owner id", zap.Error(err))
+		return fmt.Errorf("failed to delete shares by owner id: %w", err)
+	}
+	return nil
+}
+
+type mongoShare struct {
+	ObjectID   primitive.ObjectID `bson:"_id"`
+	OwnerId    primitive.ObjectID `bson:"owner_id"`
+	Label      string             `bson:"label"`
+	CodeHash   string             `bson:"code_hash"`
+	Scopes     []string           `bson:"scopes"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	AcceptedAt *time.Time         `bson:"accepted_at,omitempty"`
+	AcceptedBy primitive.ObjectID `bson:"accepted_by,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func toMongoShare(share domain.Share) mongoShare {
+	scopes := []string{}
+	for _, scope := range share.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	mongoShare := mongoShare{
+		ObjectID:   share.ID,
+		OwnerId:    share.OwnerId,
+		Label:      share.Label,
+		CodeHash:   share.CodeHash,
+		Scopes:     scopes,
+		ExpiresAt:  share.ExpiresAt,
+		AcceptedBy: share.AcceptedBy,
+		CreatedAt:  share.CreatedAt,
+		UpdatedAt:  share.UpdatedAt,
+	}
+	if !share.AcceptedAt.IsZero() {
+		acceptedAt := share.AcceptedAt
+		mongoShare.AcceptedAt = &acceptedAt
+	}
+	if !share.RevokedAt.IsZero() {
+		revokedAt := share.RevokedAt
+		mongoShare.RevokedAt = &revokedAt
+	}
+	return mongoShare
+}
+
+func toDomainShare(m mongoShare) domain.Share {
+	scopes := []domain.ShareScope{}
+	for _, scope := range m.Scopes {
+		scopes = append(scopes, domain.ShareScope(scope))
+	}
+	share := domain.Share{
+		ID:         m.ObjectID,
+		OwnerId:    m.OwnerId,
+		Label:      m.Label,
+		CodeHash:   m.CodeHash,
+		Scopes:     scopes,
+		ExpiresAt:  m.ExpiresAt,
+		AcceptedBy: m.AcceptedBy,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+	if m.AcceptedAt != nil {
+		share.AcceptedAt = *m.AcceptedAt
+	}
+	if m.RevokedAt != nil {
+		share.RevokedAt = *m.RevokedAt
+	}
+	return share
+}
@@ -0,0 +1,56 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// MongoTransactor runs work in a session transaction. Transactions need a
+// replica set, against a standalone mongod the work runs without one and the
+// unique indexes are the only guard.
+type MongoTransactor struct {
+	client       *mongo.Client
+	transactions bool
+	logger       *zap.Logger
+}
+
+func NewMongoTransactor(ctx context.Context, client *mongo.Client, logger *zap.Logger) (*MongoTransactor, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mongo topology: %w", err)
+	}
+	// mongos answers with msg isdbgrid and supports transactions too
+	transactions := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !transactions {
+		logger.Warn("mongo is not a replica set, writes will not run in transactions")
+	}
+	return &MongoTransactor{client: client, transactions: transactions, logger: logger}, nil
+}
+
+func (m *MongoTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !m.transactions || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := m.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start mongo session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
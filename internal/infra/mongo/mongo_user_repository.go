@@ -2,37 +2,52 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 var contextTimeoutDuration = 5 * time.Second
 
+// MongoUserRepository seals names and emails, users are found by email
+// through its blind index.
 type MongoUserRepository struct {
-	users  *mongo.Collection
-	logger *zap.Logger
+	users   *mongo.Collection
+	keyring *encryption.Keyring
+	logger  *zap.Logger
 }
 
-func NewMongoUserRepo(ctx context.Context, mongoDatabase *mongo.Database, logger *zap.Logger) (*MongoUserRepository, error) {
+func NewMongoUserRepo(ctx context.Context, mongoDatabase *mongo.Database, keyring *encryption.Keyring, logger *zap.Logger) (*MongoUserRepository, error) {
+	if keyring == nil {
+		return nil, errors.New("failed to initialize mongo user repo, keyring is nil")
+	}
 	userCollection := mongoDatabase.Collection("users")
 
-	return &MongoUserRepository{users: userCollection, logger: logger}, nil
+	return &MongoUserRepository{users: userCollection, keyring: keyring, logger: logger}, nil
 }
 
 func (m *MongoUserRepository) CreateUser(ctx context.Context, user domain.User) error {
 	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
 	defer cancel()
 
-	mongoUser := toMongoUser(user)
+	mongoUser, err := m.toMongoUser(user)
+	if err != nil {
+		return err
+	}
 
-	_, err := m.users.InsertOne(ctx, mongoUser)
+	_, err = m.users.InsertOne(ctx, mongoUser)
+	if mongo.IsDuplicateKeyError(err) {
+		return infra.ErrDuplicateEmail
+	}
 	if err != nil {
 		m.logger.Error("failed to persist user: %w", zap.Error(err))
 		return fmt.Errorf("failed to persist user: %w", err)
@@ -44,13 +59,16 @@ func (m *MongoUserRepository) UpdateUser(ctx context.Context, user domain.User)
 	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
 	defer cancel()
 
-	mongoUser := toMongoUser(user)
+	mongoUser, err := m.toMongoUser(user)
+	if err != nil {
+		return err
+	}
 
 	filter := bson.M{"_id": user.ID}
 	updatedDoc := bson.M{
 		"$set": mongoUser,
 	}
-	_, err := m.users.UpdateOne(ctx, filter, updatedDoc)
+	_, err = m.users.UpdateOne(ctx, filter, updatedDoc)
 	if err != nil {
 		m.logger.Error("failed to update user: %w", zap.Error(err))
 		return fmt.Errorf("failed to update user: %w", err)
@@ -59,28 +77,38 @@ func (m *MongoUserRepository) UpdateUser(ctx context.Context, user domain.User)
 }
 
 func (m *MongoUserRepository) UpdateUserLastMetricLog(ctx context.Context, user domain.User) error {
-	updatedUser := domain.User{
-		ID:                   user.ID,
-		Email:                user.Email,
-		FirstName:            user.FirstName,
-		LastName:             user.LastName,
-		Password:             user.Password,
-		IsOnBoardingComplete: user.IsOnBoardingComplete,
-		LastMetricLog:        time.Now(),
-		UpdatedAt:            time.Now(),
-	}
+	updatedUser := user
+	updatedUser.LastMetricLog = time.Now()
+	updatedUser.UpdatedAt = time.Now()
 
 	return m.UpdateUser(ctx, updatedUser)
 }
 
+func (m *MongoUserRepository) DeleteUser(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := m.users.DeleteOne(ctx, bson.M{"_id": userId})
+	if err != nil {
+		m.logger.Error("failed to delete user", zap.Error(err))
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
 func (m *MongoUserRepository) GetUserByEmail(ctx context.Context, userEmail string) (domain.User, error) {
 	user := mongoUser{}
-	err := m.users.FindOne(ctx, bson.M{"email": userEmail}).Decode(&user)
+	// users written before emails were sealed still hold them in the clear.
+	filter := bson.M{"$or": bson.A{
+		bson.M{"email_index": m.keyring.BlindIndex(userEmail)},
+		bson.M{"email": userEmail},
+	}}
+	err := m.users.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		m.logger.Error("failed retrieve user by email: %w", zap.Error(err))
 		return domain.User{}, infra.ErrUserNotFound
 	}
-	return toDomainUser(user), nil
+	return m.toDomainUser(user)
 }
 
 func (m *MongoUserRepository) GetUserByUserId(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
@@ -91,45 +119,153 @@ func (m *MongoUserRepository) GetUserByUserId(ctx context.Context, userId primit
 		return domain.User{}, infra.ErrUserNotFound
 	}
 
-	return toDomainUser(user), nil
+	return m.toDomainUser(user)
+}
+
+func (m *MongoUserRepository) GetUsersWithRemindersEnabled(ctx context.Context, afterId primitive.ObjectID, limit int) ([]domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	filter := bson.M{"reminders.enabled": true, "_id": bson.M{"$gt": afterId}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := m.users.Find(ctx, filter, opts)
+	if err != nil {
+		m.logger.Error("failed to find users with reminders enabled", zap.Error(err))
+		return nil, fmt.Errorf("failed to find users with reminders enabled: %w", err)
+	}
+
+	mongoUsers := []mongoUser{}
+	if err := cursor.All(ctx, &mongoUsers); err != nil {
+		m.logger.Error("failed to decode users", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+
+	users := []domain.User{}
+	for _, mongoUser := range mongoUsers {
+		user, err := m.toDomainUser(mongoUser)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+type mongoReminderPreferences struct {
+	Enabled         bool   `bson:"enabled"`
+	Time            string `bson:"time"`
+	QuietHoursStart string `bson:"quiet_hours_start"`
+	QuietHoursEnd   string `bson:"quiet_hours_end"`
+}
+
+type mongoPushSubscription struct {
+	Endpoint  string    `bson:"endpoint"`
+	P256dh    string    `bson:"p256dh"`
+	Auth      string    `bson:"auth"`
+	CreatedAt time.Time `bson:"created_at"`
 }
 
 type mongoUser struct {
-	ObjectID            primitive.ObjectID `bson:"_id"`
-	Email               string             `bson:"email"`
-	FirstName           string             `bson:"first_name"`
-	LastName            string             `bson:"last_name"`
-	Password            string             `bson:"password"`
-	IsOnBoardinComplete bool               `bson:"is_onboarding_complete"`
-	LastMetricLog       time.Time          `bson:"last_metric_log"`
-	CreatedAt           time.Time          `bson:"created_at"`
-	UpdatedAt           time.Time          `bson:"updated_at"`
-}
-
-func toMongoUser(user domain.User) mongoUser {
+	ObjectID            primitive.ObjectID       `bson:"_id"`
+	Email               bson.RawValue            `bson:"email"`
+	EmailIndex          string                   `bson:"email_index,omitempty"`
+	FirstName           bson.RawValue            `bson:"first_name"`
+	LastName            bson.RawValue            `bson:"last_name"`
+	Password            string                   `bson:"password"`
+	Timezone            string                   `bson:"timezone"`
+	IsEmailVerified     bool                     `bson:"is_email_verified"`
+	IsOnBoardinComplete bool                     `bson:"is_onboarding_complete"`
+	LastMetricLog       time.Time                `bson:"last_metric_log"`
+	Reminders           mongoReminderPreferences `bson:"reminders"`
+	PushSubscriptions   []mongoPushSubscription  `bson:"push_subscriptions"`
+	Encryption          *mongoEnvelope           `bson:"encryption,omitempty"`
+	CreatedAt           time.Time                `bson:"created_at"`
+	UpdatedAt           time.Time                `bson:"updated_at"`
+}
+
+func (m *MongoUserRepository) toMongoUser(user domain.User) (mongoUser, error) {
+	dataKey, envelope, err := newEnvelope(m.keyring)
+	if err != nil {
+		return mongoUser{}, err
+	}
+	email, err := sealField(dataKey, user.ID, "email", user.Email)
+	if err != nil {
+		return mongoUser{}, err
+	}
+	firstName, err := sealField(dataKey, user.ID, "first_name", user.FirstName)
+	if err != nil {
+		return mongoUser{}, err
+	}
+	lastName, err := sealField(dataKey, user.ID, "last_name", user.LastName)
+	if err != nil {
+		return mongoUser{}, err
+	}
 	return mongoUser{
 		ObjectID:            user.ID,
-		Email:               user.Email,
-		FirstName:           user.FirstName,
-		LastName:            user.LastName,
+		Email:               email,
+		EmailIndex:          m.keyring.BlindIndex(user.Email),
+		FirstName:           firstName,
+		LastName:            lastName,
 		Password:            user.Password,
+		Timezone:            user.Timezone,
+		IsEmailVerified:     user.IsEmailVerified,
 		IsOnBoardinComplete: user.IsOnBoardingComplete,
 		LastMetricLog:       user.LastMetricLog,
+		Reminders:           mongoReminderPreferences(user.Reminders),
+		PushSubscriptions:   toMongoPushSubscriptions(user.PushSubscriptions),
+		Encryption:          envelope,
 		CreatedAt:           user.CreatedAt,
 		UpdatedAt:           user.UpdatedAt,
-	}
+	}, nil
 }
 
-func toDomainUser(m mongoUser) domain.User {
+func (m *MongoUserRepository) toDomainUser(u mongoUser) (domain.User, error) {
+	dataKey, err := openEnvelope(m.keyring, u.Encryption)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("failed to open the data key of user %s: %w", u.ObjectID.Hex(), err)
+	}
+	email, err := openField(dataKey, u.ObjectID, "email", u.Email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	firstName, err := openField(dataKey, u.ObjectID, "first_name", u.FirstName)
+	if err != nil {
+		return domain.User{}, err
+	}
+	lastName, err := openField(dataKey, u.ObjectID, "last_name", u.LastName)
+	if err != nil {
+		return domain.User{}, err
+	}
 	return domain.User{
-		ID:                   m.ObjectID,
-		Email:                m.Email,
-		FirstName:            m.FirstName,
-		LastName:             m.LastName,
-		Password:             m.Password,
-		LastMetricLog:        m.LastMetricLog,
-		IsOnBoardingComplete: m.IsOnBoardinComplete,
-		CreatedAt:            m.CreatedAt,
-		UpdatedAt:            m.UpdatedAt,
+		ID:                   u.ObjectID,
+		Email:                email,
+		FirstName:            firstName,
+		LastName:             lastName,
+		Password:             u.Password,
+		Timezone:             u.Timezone,
+		IsEmailVerified:      u.IsEmailVerified,
+		LastMetricLog:        u.LastMetricLog,
+		IsOnBoardingComplete: u.IsOnBoardinComplete,
+		Reminders:            domain.ReminderPreferences(u.Reminders),
+		PushSubscriptions:    toDomainPushSubscriptions(u.PushSubscriptions),
+		CreatedAt:            u.CreatedAt,
+		UpdatedAt:            u.UpdatedAt,
+	}, nil
+}
+
+func toMongoPushSubscriptions(subscriptions []domain.PushSubscription) []mongoPushSubscription {
+	mongoSubscriptions := []mongoPushSubscription{}
+	for _, subscription := range subscriptions {
+		mongoSubscriptions = append(mongoSubscriptions, mongoPushSubscription(subscription))
+	}
+	return mongoSubscriptions
+}
+
+func toDomainPushSubscriptions(mongoSubscriptions []mongoPushSubscription) []domain.PushSubscription {
+	subscriptions := []domain.PushSubscription{}
+	for _, subscription := range mongoSubscriptions {
+		subscriptions = append(subscriptions, domain.PushSubscription(subscription))
 	}
+	return subscriptions
 }
@@ -0,0 +1,51 @@
+-- ids are the 24 character hex form of a primitive.ObjectID so they stay
+-- interchangeable with the mongo backend. The "C" collation makes them sort
+-- like ObjectIDs do, which metric cursors rely on.
+CREATE TABLE users (
+    id                     CHAR(24) COLLATE "C" PRIMARY KEY,
+    email                  TEXT                 NOT NULL,
+    first_name             TEXT                 NOT NULL DEFAULT '',
+    last_name              TEXT                 NOT NULL DEFAULT '',
+    password               TEXT                 NOT NULL DEFAULT '',
+    timezone               TEXT                 NOT NULL DEFAULT '',
+    is_email_verified      BOOLEAN              NOT NULL DEFAULT FALSE,
+    is_onboarding_complete BOOLEAN              NOT NULL DEFAULT FALSE,
+    last_metric_log        TIMESTAMPTZ,
+    created_at             TIMESTAMPTZ          NOT NULL,
+    updated_at             TIMESTAMPTZ          NOT NULL
+);
+
+CREATE UNIQUE INDEX users_email ON users (email);
+
+CREATE TABLE metrics (
+    id                CHAR(24) COLLATE "C" PRIMARY KEY,
+    owner_id          CHAR(24) COLLATE "C" NOT NULL,
+    stress_level      INTEGER              NOT NULL,
+    mood              TEXT                 NOT NULL,
+    sleep_quality     TEXT                 NOT NULL,
+    feeling           TEXT                 NOT NULL DEFAULT '',
+    stress_less_score INTEGER              NOT NULL,
+    created_at        TIMESTAMPTZ          NOT NULL,
+    updated_at        TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX metrics_owner_id_created_at ON metrics (owner_id, created_at DESC, id DESC);
+
+CREATE TABLE recommendations (
+    id          CHAR(24) COLLATE "C" PRIMARY KEY,
+    metric_id   CHAR(24) COLLATE "C" NOT NULL,
+    metric_type TEXT                 NOT NULL,
+    created_at  TIMESTAMPTZ          NOT NULL,
+    updated_at  TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX recommendations_metric_id_metric_type ON recommendations (metric_id, metric_type);
+
+CREATE TABLE recommendation_items (
+    recommendation_id CHAR(24) COLLATE "C" NOT NULL REFERENCES recommendations (id) ON DELETE CASCADE,
+    position          INTEGER              NOT NULL,
+    heading           TEXT                 NOT NULL,
+    text              TEXT                 NOT NULL,
+    image_url         TEXT                 NOT NULL DEFAULT '',
+    PRIMARY KEY (recommendation_id, position)
+);
@@ -0,0 +1,18 @@
+ALTER TABLE metrics ADD COLUMN local_date TEXT;
+
+-- backfill from the owner's timezone. When a user already has more than one
+-- log on a day only the oldest gets a local_date, the others stay out of the
+-- unique index.
+UPDATE metrics SET local_date = days.local_date
+FROM (
+    SELECT id, local_date,
+           row_number() OVER (PARTITION BY owner_id, local_date ORDER BY created_at, id) AS n
+    FROM (
+        SELECT metrics.id, metrics.owner_id, metrics.created_at,
+               to_char(metrics.created_at AT TIME ZONE COALESCE(NULLIF(users.timezone, ''), 'UTC'), 'YYYY-MM-DD') AS local_date
+        FROM metrics LEFT JOIN users ON users.id = metrics.owner_id
+    ) AS localised
+) AS days
+WHERE metrics.id = days.id AND days.n = 1;
+
+CREATE UNIQUE INDEX metrics_owner_id_local_date ON metrics (owner_id, local_date);
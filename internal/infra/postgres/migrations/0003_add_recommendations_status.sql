@@ -0,0 +1,5 @@
+-- recommendations saved before generation moved to the background were always
+-- ready.
+ALTER TABLE recommendations ADD COLUMN status TEXT NOT NULL DEFAULT 'ready';
+
+CREATE INDEX recommendations_pending ON recommendations (created_at, id) WHERE status = 'pending';
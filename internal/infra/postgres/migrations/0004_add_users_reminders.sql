@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN reminders_enabled  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN reminder_time      TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN quiet_hours_start  TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN quiet_hours_end    TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN push_subscriptions JSONB   NOT NULL DEFAULT '[]';
+
+CREATE INDEX users_reminders_enabled ON users (id) WHERE reminders_enabled;
@@ -0,0 +1,9 @@
+CREATE TABLE achievements (
+    id         CHAR(24) COLLATE "C" PRIMARY KEY,
+    user_id    CHAR(24) COLLATE "C" NOT NULL,
+    badge      TEXT                 NOT NULL,
+    metric_id  CHAR(24) COLLATE "C" NOT NULL,
+    awarded_at TIMESTAMPTZ          NOT NULL
+);
+
+CREATE UNIQUE INDEX achievements_user_id_badge ON achievements (user_id, badge);
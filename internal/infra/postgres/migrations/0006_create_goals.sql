@@ -0,0 +1,24 @@
+CREATE TABLE goals (
+    id         CHAR(24) COLLATE "C" PRIMARY KEY,
+    user_id    CHAR(24) COLLATE "C" NOT NULL,
+    kind       TEXT                 NOT NULL,
+    period     TEXT                 NOT NULL,
+    target     INTEGER              NOT NULL,
+    threshold  TEXT                 NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ          NOT NULL,
+    updated_at TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX goals_user_id_created_at ON goals (user_id, created_at, id);
+
+CREATE TABLE goal_completions (
+    id           CHAR(24) COLLATE "C" PRIMARY KEY,
+    goal_id      CHAR(24) COLLATE "C" NOT NULL,
+    user_id      CHAR(24) COLLATE "C" NOT NULL,
+    period_start TEXT                 NOT NULL,
+    value        INTEGER              NOT NULL,
+    completed_at TIMESTAMPTZ          NOT NULL
+);
+
+CREATE UNIQUE INDEX goal_completions_goal_id_period_start ON goal_completions (goal_id, period_start);
+CREATE INDEX goal_completions_user_id_completed_at ON goal_completions (user_id, completed_at, id);
@@ -0,0 +1,22 @@
+-- body is encrypted, search_terms holds the blind indexes entries are
+-- searched by.
+CREATE TABLE journal_entries (
+    id         CHAR(24) COLLATE "C" PRIMARY KEY,
+    user_id    CHAR(24) COLLATE "C" NOT NULL,
+    title      TEXT                 NOT NULL,
+    body       BYTEA                NOT NULL,
+    tags       TEXT[]               NOT NULL DEFAULT '{}',
+    metric_id  CHAR(24) COLLATE "C",
+    created_at TIMESTAMPTZ          NOT NULL,
+    updated_at TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX journal_entries_user_id_created_at ON journal_entries (user_id, created_at DESC, id DESC);
+
+CREATE TABLE journal_search_terms (
+    entry_id CHAR(24) COLLATE "C" NOT NULL REFERENCES journal_entries (id) ON DELETE CASCADE,
+    term     TEXT                 NOT NULL,
+    PRIMARY KEY (entry_id, term)
+);
+
+CREATE INDEX journal_search_terms_term ON journal_search_terms (term, entry_id);
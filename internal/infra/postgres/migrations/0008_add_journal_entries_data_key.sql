@@ -0,0 +1,7 @@
+-- every entry body is sealed with its own data key, wrapped by the master key
+-- key_id names.
+ALTER TABLE journal_entries
+    ADD COLUMN key_id   TEXT  NOT NULL DEFAULT '',
+    ADD COLUMN data_key BYTEA NOT NULL DEFAULT '';
+
+CREATE INDEX journal_entries_key_id ON journal_entries (key_id);
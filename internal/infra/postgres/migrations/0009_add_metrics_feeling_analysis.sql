@@ -0,0 +1,4 @@
+-- older logs keep a neutral polarity and no themes.
+ALTER TABLE metrics
+    ADD COLUMN feeling_polarity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN themes           TEXT[]           NOT NULL DEFAULT '{}';
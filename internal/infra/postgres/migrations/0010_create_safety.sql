@@ -0,0 +1,25 @@
+ALTER TABLE metrics ADD COLUMN safety_flags TEXT[] NOT NULL DEFAULT '{}';
+
+-- the audit trail of flagged logs and clinician overrides. metric_id is set
+-- on flagged logs and actor_id on overrides.
+CREATE TABLE safety_events (
+    id         CHAR(24) COLLATE "C" PRIMARY KEY,
+    user_id    CHAR(24) COLLATE "C" NOT NULL,
+    kind       TEXT                 NOT NULL,
+    metric_id  CHAR(24) COLLATE "C",
+    flags      TEXT[]               NOT NULL DEFAULT '{}',
+    escalated  BOOLEAN              NOT NULL DEFAULT FALSE,
+    country    TEXT                 NOT NULL DEFAULT '',
+    actor_id   CHAR(24) COLLATE "C",
+    created_at TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX safety_events_user_id_created_at ON safety_events (user_id, created_at DESC, id DESC);
+
+CREATE TABLE safety_overrides (
+    user_id                   CHAR(24) COLLATE "C" PRIMARY KEY,
+    low_mood_days             INTEGER              NOT NULL DEFAULT 0,
+    suppress_escalation_until TIMESTAMPTZ,
+    clinician_id              CHAR(24) COLLATE "C" NOT NULL,
+    updated_at                TIMESTAMPTZ          NOT NULL
+);
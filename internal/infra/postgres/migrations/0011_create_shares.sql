@@ -0,0 +1,15 @@
+-- accepted_at and revoked_at are NULL until the share is accepted or revoked.
+CREATE TABLE shares (
+    id          CHAR(24) COLLATE "C" PRIMARY KEY,
+    owner_id    CHAR(24) COLLATE "C" NOT NULL,
+    label       TEXT                 NOT NULL DEFAULT '',
+    code_hash   TEXT                 NOT NULL UNIQUE,
+    scopes      TEXT[]               NOT NULL DEFAULT '{}',
+    expires_at  TIMESTAMPTZ          NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ          NOT NULL,
+    updated_at  TIMESTAMPTZ          NOT NULL
+);
+
+CREATE INDEX shares_owner_id_created_at ON shares (owner_id, created_at DESC, id DESC);
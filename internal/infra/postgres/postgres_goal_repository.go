@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresGoalRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresGoalRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresGoalRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres goal repo, db is nil")
+	}
+	return &PostgresGoalRepository{db: db, logger: logger}, nil
+}
+
+const (
+	goalColumns           = `id, user_id, kind, period, target, threshold, created_at, updated_at`
+	goalCompletionColumns = `id, goal_id, user_id, period_start, value, completed_at`
+)
+
+func (p *PostgresGoalRepository) CreateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresGoal(goal)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO goals (`+goalColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		row.ID, row.UserId, row.Kind, row.Period, row.Target, row.Threshold, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to persist goal", zap.Error(err))
+		return fmt.Errorf("failed to persist goal: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresGoalRepository) GetGoalById(ctx context.Context, goalId primitive.ObjectID) (domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	goal, err := scanGoal(conn(ctx, p.db).QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE id = $1`, goalId.Hex()))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find goal by id", zap.Error(err))
+		}
+		return domain.Goal{}, infra.ErrGoalNotFound
+	}
+	return goal, nil
+}
+
+func (p *PostgresGoalRepository) GetGoalsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE user_id = $1 ORDER BY created_at, id`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to find goals by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goals by user id: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []domain.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+func (p *PostgresGoalRepository) UpdateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresGoal(goal)
+	result, err := conn(ctx, p.db).ExecContext(ctx, `UPDATE goals SET
+		user_id = $2, kind = $3, period = $4, target = $5, threshold = $6, created_at = $7, updated_at = $8
+		WHERE id = $1`,
+		row.ID, row.UserId, row.Kind, row.Period, row.Target, row.Threshold, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to update goal", zap.Error(err))
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (p *PostgresGoalRepository) DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM goal_completions WHERE goal_id = $1`, goalId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete goal completions", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions: %w", err)
+	}
+	result, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM goals WHERE id = $1`, goalId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete goal", zap.Error(err))
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (p *PostgresGoalRepository) DeleteGoalsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM goal_completions WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete goal completions by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions by user id: %w", err)
+	}
+	_, err = conn(ctx, p.db).ExecContext(ctx, `DELETE FROM goals WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete goals by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goals by user id: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresGoalRepository) CreateGoalCompletion(ctx context.Context, completion domain.GoalCompletion) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresGoalCompletion(completion)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO goal_completions (`+goalCompletionColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		row.ID, row.GoalId, row.UserId, row.PeriodStart, row.Value, row.CompletedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrGoalCompletionExists
+	}
+	if err != nil {
+		p.logger.Error("failed to persist goal completion", zap.Error(err))
+		return fmt.Errorf("failed to persist goal completion: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresGoalRepository) GetGoalCompletionsByUserId(ctx context.Context, userId primitive.ObjectID, since time.Time) ([]domain.GoalCompletion, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT `+goalCompletionColumns+` FROM goal_completions
+		WHERE user_id = $1 AND completed_at > $2 ORDER BY completed_at, id`, userId.Hex(), storedTime(since))
+	if err != nil {
+		p.logger.Error("failed to find goal completions by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goal completions by user id: %w", err)
+	}
+	defer rows.Close()
+
+	completions := []domain.GoalCompletion{}
+	for rows.Next() {
+		row := postgresGoalCompletion{}
+		if err := rows.Scan(&row.ID, &row.GoalId, &row.UserId, &row.PeriodStart, &row.Value, &row.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal completion: %w", err)
+		}
+		completion, err := toDomainGoalCompletion(row)
+		if err != nil {
+			return nil, err
+		}
+		completions = append(completions, completion)
+	}
+	return completions, rows.Err()
+}
+
+func scanGoal(row rowScanner) (domain.Goal, error) {
+	g := postgresGoal{}
+	err := row.Scan(&g.ID, &g.UserId, &g.Kind, &g.Period, &g.Target, &g.Threshold, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	return toDomainGoal(g)
+}
+
+type postgresGoal struct {
+	ID        string
+	UserId    string
+	Kind      string
+	Period    string
+	Target    int
+	Threshold string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func toPostgresGoal(goal domain.Goal) postgresGoal {
+	return postgresGoal{
+		ID:        goal.ID.Hex(),
+		UserId:    goal.UserId.Hex(),
+		Kind:      string(goal.Kind),
+		Period:    string(goal.Period),
+		Target:    goal.Target,
+		Threshold: goal.Threshold,
+		CreatedAt: storedTime(goal.CreatedAt),
+		UpdatedAt: storedTime(goal.UpdatedAt),
+	}
+}
+
+func toDomainGoal(row postgresGoal) (domain.Goal, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	return domain.Goal{
+		ID:        id,
+		UserId:    userId,
+		Kind:      domain.GoalKind(row.Kind),
+		Period:    domain.GoalPeriod(row.Period),
+		Target:    row.Target,
+		Threshold: row.Threshold,
+		CreatedAt: row.CreatedAt.UTC(),
+		UpdatedAt: row.UpdatedAt.UTC(),
+	}, nil
+}
+
+type postgresGoalCompletion struct {
+	ID          string
+	GoalId      string
+	UserId      string
+	PeriodStart string
+	Value       int
+	CompletedAt time.Time
+}
+
+func toPostgresGoalCompletion(completion domain.GoalCompletion) postgresGoalCompletion {
+	return postgresGoalCompletion{
+		ID:          completion.ID.Hex(),
+		GoalId:      completion.GoalId.Hex(),
+		UserId:      completion.UserId.Hex(),
+		PeriodStart: completion.PeriodStart,
+		Value:       completion.Value,
+		CompletedAt: storedTime(completion.CompletedAt),
+	}
+}
+
+func toDomainGoalCompletion(row postgresGoalCompletion) (domain.GoalCompletion, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	goalId, err := toObjectID(row.GoalId)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	return domain.GoalCompletion{
+		ID:          id,
+		GoalId:      goalId,
+		UserId:      userId,
+		PeriodStart: row.PeriodStart,
+		Value:       row.Value,
+		CompletedAt: row.CompletedAt.UTC(),
+	}, nil
+}
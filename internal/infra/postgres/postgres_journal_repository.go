@@ -0,0 +1,317 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// PostgresJournalRepository seals entry bodies. Entries are
+// searched through the blind indexes of their search terms.
+type PostgresJournalRepository struct {
+	db      *sql.DB
+	keyring *encryption.Keyring
+	logger  *zap.Logger
+}
+
+func NewPostgresJournalRepo(ctx context.Context, db *sql.DB, keyring *encryption.Keyring, logger *zap.Logger) (*PostgresJournalRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres journal repo, db is nil")
+	}
+	if keyring == nil {
+		return nil, errors.New("failed to initialize postgres journal repo, keyring is nil")
+	}
+	return &PostgresJournalRepository{db: db, keyring: keyring, logger: logger}, nil
+}
+
+const journalEntryColumns = `id, user_id, title, body, tags, metric_id, key_id, data_key, created_at, updated_at`
+
+func (p *PostgresJournalRepository) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row, err := p.toPostgresJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	err = withinTx(ctx, p.db, func(ctx context.Context) error {
+		tx := conn(ctx, p.db)
+		_, err := tx.ExecContext(ctx, `INSERT INTO journal_entries (`+journalEntryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			row.ID, row.UserId, row.Title, row.Body, pq.Array(row.Tags), row.MetricId, row.KeyId, row.DataKey, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return insertJournalSearchTerms(ctx, tx, row.ID, row.SearchTerms)
+	})
+	if err != nil {
+		p.logger.Error("failed to persist journal entry", zap.Error(err))
+		return fmt.Errorf("failed to persist journal entry: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresJournalRepository) GetJournalEntryById(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := postgresJournalEntry{}
+	err := scanJournalEntry(conn(ctx, p.db).QueryRowContext(ctx, `SELECT `+journalEntryColumns+` FROM journal_entries WHERE id = $1`, entryId.Hex()), &row)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find journal entry by id", zap.Error(err))
+		}
+		return domain.JournalEntry{}, infra.ErrJournalEntryNotFound
+	}
+	return p.toDomainJournalEntry(row)
+}
+
+func (p *PostgresJournalRepository) GetJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID, journalFilter infra.JournalFilter) (infra.JournalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	conditions := []string{"user_id = $1"}
+	args := []any{userId.Hex()}
+	if !journalFilter.From.IsZero() {
+		args = append(args, storedTime(journalFilter.From))
+		conditions = append(conditions, "created_at >= "+placeholder(len(args)))
+	}
+	if !journalFilter.To.IsZero() {
+		args = append(args, storedTime(journalFilter.To))
+		conditions = append(conditions, "created_at < "+placeholder(len(args)))
+	}
+	if journalFilter.Tag != "" {
+		args = append(args, journalFilter.Tag)
+		conditions = append(conditions, placeholder(len(args))+" = ANY(tags)")
+	}
+	if terms := domain.SearchTerms(journalFilter.Search); len(terms) > 0 {
+		args = append(args, pq.Array(p.keyring.BlindIndexes(terms)))
+		conditions = append(conditions, `id IN (SELECT entry_id FROM journal_search_terms WHERE term = ANY(`+placeholder(len(args))+`)
+			GROUP BY entry_id HAVING count(*) = `+strconv.Itoa(len(terms))+`)`)
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	err := conn(ctx, p.db).QueryRowContext(ctx, `SELECT count(*) FROM journal_entries WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		p.logger.Error("failed to count journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+
+	if journalFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(journalFilter.Cursor)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		args = append(args, storedTime(cursor.CreatedAt), cursor.ID.Hex())
+		createdAt, id := placeholder(len(args)-1), placeholder(len(args))
+		where += " AND (created_at < " + createdAt + " OR (created_at = " + createdAt + " AND id < " + id + "))"
+	}
+
+	query := `SELECT ` + journalEntryColumns + ` FROM journal_entries WHERE ` + where + ` ORDER BY created_at DESC, id DESC`
+	if journalFilter.Limit > 0 {
+		args = append(args, journalFilter.Limit+1)
+		query += " LIMIT " + placeholder(len(args))
+	}
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		p.logger.Error("failed retrieve journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+	defer rows.Close()
+
+	entries := []domain.JournalEntry{}
+	for rows.Next() {
+		row := postgresJournalEntry{}
+		if err := scanJournalEntry(rows, &row); err != nil {
+			return infra.JournalPage{}, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		entry, err := p.toDomainJournalEntry(row)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return infra.JournalPage{}, err
+	}
+
+	page := infra.JournalPage{Entries: entries, Limit: journalFilter.Limit, Total: total}
+	if journalFilter.Limit > 0 && len(entries) > journalFilter.Limit {
+		page.Entries = entries[:journalFilter.Limit]
+		last := page.Entries[len(page.Entries)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func (p *PostgresJournalRepository) UpdateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row, err := p.toPostgresJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	found := false
+	err = withinTx(ctx, p.db, func(ctx context.Context) error {
+		tx := conn(ctx, p.db)
+		result, err := tx.ExecContext(ctx, `UPDATE journal_entries SET
+			user_id = $2, title = $3, body = $4, tags = $5, metric_id = $6, key_id = $7, data_key = $8, created_at = $9, updated_at = $10
+			WHERE id = $1`,
+			row.ID, row.UserId, row.Title, row.Body, pq.Array(row.Tags), row.MetricId, row.KeyId, row.DataKey, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil || updated == 0 {
+			return err
+		}
+		found = true
+		if _, err := tx.ExecContext(ctx, `DELETE FROM journal_search_terms WHERE entry_id = $1`, row.ID); err != nil {
+			return err
+		}
+		return insertJournalSearchTerms(ctx, tx, row.ID, row.SearchTerms)
+	})
+	if err != nil {
+		p.logger.Error("failed to update journal entry", zap.Error(err))
+		return fmt.Errorf("failed to update journal entry: %w", err)
+	}
+	if !found {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (p *PostgresJournalRepository) DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM journal_entries WHERE id = $1`, entryId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete journal entry", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (p *PostgresJournalRepository) DeleteJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM journal_entries WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete journal entries by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entries by user id: %w", err)
+	}
+	return nil
+}
+
+func insertJournalSearchTerms(ctx context.Context, tx querier, entryId string, terms []string) error {
+	if len(terms) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO journal_search_terms (entry_id, term) SELECT $1, unnest($2::text[])`, entryId, pq.Array(terms))
+	return err
+}
+
+func scanJournalEntry(row rowScanner, entry *postgresJournalEntry) error {
+	return row.Scan(&entry.ID, &entry.UserId, &entry.Title, &entry.Body, pq.Array(&entry.Tags), &entry.MetricId, &entry.KeyId, &entry.DataKey, &entry.CreatedAt, &entry.UpdatedAt)
+}
+
+type postgresJournalEntry struct {
+	ID          string
+	UserId      string
+	Title       string
+	Body        []byte
+	Tags        []string
+	MetricId    sql.NullString
+	KeyId       string
+	DataKey     []byte
+	SearchTerms []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (p *PostgresJournalRepository) toPostgresJournalEntry(entry domain.JournalEntry) (postgresJournalEntry, error) {
+	dataKey, err := p.keyring.NewDataKey()
+	if err != nil {
+		return postgresJournalEntry{}, err
+	}
+	body, err := dataKey.Encrypt(entry.Body, entry.ID[:])
+	if err != nil {
+		return postgresJournalEntry{}, fmt.Errorf("failed to encrypt journal entry: %w", err)
+	}
+	row := postgresJournalEntry{
+		ID:          entry.ID.Hex(),
+		UserId:      entry.UserId.Hex(),
+		Title:       entry.Title,
+		Body:        body,
+		KeyId:       dataKey.KeyId,
+		DataKey:     dataKey.Wrapped,
+		Tags:        append([]string{}, entry.Tags...),
+		SearchTerms: p.keyring.BlindIndexes(entry.SearchTerms()),
+		CreatedAt:   storedTime(entry.CreatedAt),
+		UpdatedAt:   storedTime(entry.UpdatedAt),
+	}
+	if entry.HasMetric() {
+		row.MetricId = sql.NullString{String: entry.MetricId.Hex(), Valid: true}
+	}
+	return row, nil
+}
+
+func (p *PostgresJournalRepository) toDomainJournalEntry(row postgresJournalEntry) (domain.JournalEntry, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	dataKey, err := p.keyring.OpenDataKey(row.KeyId, row.DataKey)
+	if err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to open the data key of journal entry %s: %w", row.ID, err)
+	}
+	body, err := dataKey.Decrypt(row.Body, id[:])
+	if err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to decrypt journal entry %s: %w", row.ID, err)
+	}
+	entry := domain.JournalEntry{
+		ID:        id,
+		UserId:    userId,
+		Title:     row.Title,
+		Body:      body,
+		Tags:      append([]string{}, row.Tags...),
+		CreatedAt: row.CreatedAt.UTC(),
+		UpdatedAt: row.UpdatedAt.UTC(),
+	}
+	if row.MetricId.Valid {
+		metricId, err := toObjectID(row.MetricId.String)
+		if err != nil {
+			return domain.JournalEntry{}, err
+		}
+		entry.MetricId = metricId
+	}
+	return entry, nil
+}
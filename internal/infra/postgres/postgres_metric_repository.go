@@ -0,0 +1,375 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresMetricRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresMetricRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresMetricRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres metric repo, db is nil")
+	}
+	return &PostgresMetricRepository{db: db, logger: logger}, nil
+}
+
+const metricColumns = `id, owner_id, stress_level, mood, sleep_quality, feeling, feeling_polarity, themes, safety_flags, stress_less_score, local_date, created_at, updated_at`
+
+func (p *PostgresMetricRepository) CreateMetric(ctx context.Context, metric domain.Metric) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresMetric(metric)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO metrics (`+metricColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		row.ID, row.OwnerId, row.StressLevel, row.Mood, row.SleepQuality, row.Feeling, row.FeelingPolarity, pq.Array(row.Themes), pq.Array(row.SafetyFlags), row.StressLessScore, row.LocalDate, row.CreatedAt, row.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrDailyLogExists
+	}
+	if err != nil {
+		p.logger.Error("failed to persist metric", zap.Error(err))
+		return fmt.Errorf("failed to persist metric: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresMetricRepository) UpdateMetricById(ctx context.Context, metric domain.Metric) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresMetric(metric)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `UPDATE metrics SET
+		owner_id = $2, stress_level = $3, mood = $4, sleep_quality = $5, feeling = $6, feeling_polarity = $7, themes = $8,
+		safety_flags = $9, stress_less_score = $10, local_date = $11, created_at = $12, updated_at = $13
+		WHERE id = $1`,
+		row.ID, row.OwnerId, row.StressLevel, row.Mood, row.SleepQuality, row.Feeling, row.FeelingPolarity, pq.Array(row.Themes), pq.Array(row.SafetyFlags), row.StressLessScore, row.LocalDate, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to update metric", zap.Error(err))
+		return fmt.Errorf("failed to update metric: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresMetricRepository) GetMetricById(ctx context.Context, metricId primitive.ObjectID) (domain.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	metric, err := scanMetric(conn(ctx, p.db).QueryRowContext(ctx, `SELECT `+metricColumns+` FROM metrics WHERE id = $1`, metricId.Hex()))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find metric by id", zap.Error(err))
+		}
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metric, nil
+}
+
+func (p *PostgresMetricRepository) DeleteMetricById(ctx context.Context, metricId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM metrics WHERE id = $1`, metricId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete metric", zap.Error(err))
+		return fmt.Errorf("failed to delete metric: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrMetricNotFound
+	}
+	return nil
+}
+
+func (p *PostgresMetricRepository) DeleteMetricsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM metrics WHERE owner_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete metrics by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete metrics by user id: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresMetricRepository) GetMetricDays(ctx context.Context, userId primitive.ObjectID) ([]infra.MetricDay, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT id, COALESCE(local_date, ''), created_at FROM metrics WHERE owner_id = $1 ORDER BY created_at, id`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to find metric days", zap.Error(err))
+		return nil, fmt.Errorf("failed to find metric days: %w", err)
+	}
+	defer rows.Close()
+
+	days := []infra.MetricDay{}
+	for rows.Next() {
+		var id string
+		day := infra.MetricDay{}
+		if err := rows.Scan(&id, &day.LocalDate, &day.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan metric day: %w", err)
+		}
+		if day.MetricId, err = toObjectID(id); err != nil {
+			return nil, err
+		}
+		day.CreatedAt = day.CreatedAt.UTC()
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+func (p *PostgresMetricRepository) GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID, location *time.Location) (domain.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	startTime, endTime := domain.DayBounds(time.Now(), location)
+
+	metric, err := scanMetric(conn(ctx, p.db).QueryRowContext(ctx,
+		`SELECT `+metricColumns+` FROM metrics WHERE owner_id = $1 AND created_at >= $2 AND created_at < $3 LIMIT 1`,
+		userId.Hex(), storedTime(startTime), storedTime(endTime),
+	))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find metric for today", zap.Error(err))
+		}
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metric, nil
+}
+
+func (p *PostgresMetricRepository) GetMetricsByUserId(ctx context.Context, userId primitive.ObjectID, metricFilter infra.MetricFilter) (infra.MetricPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	where, args := metricRangeFilter(userId, metricFilter.From, metricFilter.To)
+
+	var total int64
+	err := conn(ctx, p.db).QueryRowContext(ctx, `SELECT count(*) FROM metrics WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		p.logger.Error("failed to count metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
+
+	if metricFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(metricFilter.Cursor)
+		if err != nil {
+			return infra.MetricPage{}, err
+		}
+		args = append(args, storedTime(cursor.CreatedAt), cursor.ID.Hex())
+		createdAt, id := placeholder(len(args)-1), placeholder(len(args))
+		where += " AND (created_at < " + createdAt + " OR (created_at = " + createdAt + " AND id < " + id + "))"
+	}
+
+	query := `SELECT ` + metricColumns + ` FROM metrics WHERE ` + where + ` ORDER BY created_at DESC, id DESC`
+	if metricFilter.Limit > 0 {
+		args = append(args, metricFilter.Limit+1)
+		query += " LIMIT " + placeholder(len(args))
+	}
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		p.logger.Error("failed retrieve metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
+	defer rows.Close()
+
+	metrics := []domain.Metric{}
+	for rows.Next() {
+		metric, err := scanMetric(rows)
+		if err != nil {
+			return infra.MetricPage{}, err
+		}
+		metrics = append(metrics, metric)
+	}
+	if err := rows.Err(); err != nil {
+		return infra.MetricPage{}, err
+	}
+
+	page := infra.MetricPage{Metrics: metrics, Limit: metricFilter.Limit, Total: total}
+	if metricFilter.Limit > 0 && len(metrics) > metricFilter.Limit {
+		page.Metrics = metrics[:metricFilter.Limit]
+		last := page.Metrics[len(page.Metrics)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func (p *PostgresMetricRepository) GetMetricSummary(ctx context.Context, userId primitive.ObjectID, summaryFilter infra.MetricSummaryFilter) ([]domain.MetricSummaryBucket, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	if !summaryFilter.Granularity.IsValid() {
+		return nil, fmt.Errorf("unsupported summary granularity %q", summaryFilter.Granularity)
+	}
+	location := summaryFilter.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	where, args := metricRangeFilter(userId, summaryFilter.From, summaryFilter.To)
+	args = append(args, location.String())
+	day := "to_char(created_at AT TIME ZONE " + placeholder(len(args)) + ", 'YYYY-MM-DD')"
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT `+day+` AS day, count(*), sum(stress_less_score), sum(stress_level),
+		array_agg(mood), array_agg(sleep_quality)
+		FROM metrics WHERE `+where+` GROUP BY day ORDER BY day`, args...)
+	if err != nil {
+		p.logger.Error("failed to aggregate metric summary", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []domain.MetricDayTotals{}
+	for rows.Next() {
+		day := domain.MetricDayTotals{
+			MoodDistribution:         map[domain.Mood]int{},
+			SleepQualityDistribution: map[domain.SleepQuality]int{},
+		}
+		var moods, sleepQualities []string
+		err := rows.Scan(&day.Date, &day.MetricCount, &day.SumStressLessScore, &day.SumStressLevel, pq.Array(&moods), pq.Array(&sleepQualities))
+		if err != nil {
+			return nil, err
+		}
+		for _, mood := range moods {
+			day.MoodDistribution[domain.Mood(mood)]++
+		}
+		for _, sleepQuality := range sleepQualities {
+			day.SleepQualityDistribution[domain.SleepQuality(sleepQuality)]++
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return domain.SummarizeMetricDays(days, summaryFilter.Granularity, location)
+}
+
+// metricRangeFilter builds the where clause shared by the metric listings,
+// From is inclusive and To exclusive.
+func metricRangeFilter(userId primitive.ObjectID, from, to time.Time) (string, []any) {
+	conditions := []string{"owner_id = $1"}
+	args := []any{userId.Hex()}
+	if !from.IsZero() {
+		args = append(args, storedTime(from))
+		conditions = append(conditions, "created_at >= "+placeholder(len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, storedTime(to))
+		conditions = append(conditions, "created_at < "+placeholder(len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMetric(row rowScanner) (domain.Metric, error) {
+	m := postgresMetric{}
+	err := row.Scan(&m.ID, &m.OwnerId, &m.StressLevel, &m.Mood, &m.SleepQuality, &m.Feeling, &m.FeelingPolarity, pq.Array(&m.Themes), pq.Array(&m.SafetyFlags), &m.StressLessScore, &m.LocalDate, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	return toDomainMetric(m)
+}
+
+type postgresMetric struct {
+	ID              string
+	OwnerId         string
+	StressLevel     int
+	Mood            string
+	SleepQuality    string
+	Feeling         string
+	FeelingPolarity float64
+	Themes          []string
+	SafetyFlags     []string
+	StressLessScore int
+	LocalDate       sql.NullString
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func toPostgresMetric(metric domain.Metric) postgresMetric {
+	themes := []string{}
+	for _, theme := range metric.Themes {
+		themes = append(themes, string(theme))
+	}
+	safetyFlags := []string{}
+	for _, flag := range metric.SafetyFlags {
+		safetyFlags = append(safetyFlags, string(flag))
+	}
+	return postgresMetric{
+		ID:              metric.ID.Hex(),
+		OwnerId:         metric.OwnerId.Hex(),
+		StressLevel:     metric.StressLevel,
+		Mood:            string(metric.Mood),
+		SleepQuality:    string(metric.SleepQuality),
+		Feeling:         metric.Feeling,
+		FeelingPolarity: metric.FeelingPolarity,
+		Themes:          themes,
+		SafetyFlags:     safetyFlags,
+		StressLessScore: metric.StressLessScore,
+		LocalDate:       sql.NullString{String: metric.LocalDate, Valid: metric.LocalDate != ""},
+		CreatedAt:       storedTime(metric.CreatedAt),
+		UpdatedAt:       storedTime(metric.UpdatedAt),
+	}
+}
+
+func toDomainMetric(m postgresMetric) (domain.Metric, error) {
+	id, err := toObjectID(m.ID)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	ownerId, err := toObjectID(m.OwnerId)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	themes := []domain.Theme{}
+	for _, theme := range m.Themes {
+		themes = append(themes, domain.Theme(theme))
+	}
+	safetyFlags := []domain.SafetyFlag{}
+	for _, flag := range m.SafetyFlags {
+		safetyFlags = append(safetyFlags, domain.SafetyFlag(flag))
+	}
+	return domain.Metric{
+		ID:              id,
+		OwnerId:         ownerId,
+		StressLevel:     m.StressLevel,
+		Mood:            domain.Mood(m.Mood),
+		SleepQuality:    domain.SleepQuality(m.SleepQuality),
+		Feeling:         m.Feeling,
+		FeelingPolarity: m.FeelingPolarity,
+		Themes:          themes,
+		SafetyFlags:     safetyFlags,
+		StressLessScore: m.StressLessScore,
+		LocalDate:       m.LocalDate.String,
+		CreatedAt:       m.CreatedAt.UTC(),
+		UpdatedAt:       m.UpdatedAt.UTC(),
+	}, nil
+}
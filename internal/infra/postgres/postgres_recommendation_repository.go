@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresRecommendationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresRecommendationRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresRecommendationRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres recommendation repo, db is nil")
+	}
+	return &PostgresRecommendationRepository{db: db, logger: logger}, nil
+}
+
+const recommendationColumns = `id, metric_id, metric_type, status, created_at, updated_at`
+
+func (p *PostgresRecommendationRepository) CreateRecommendation(ctx context.Context, recommendation domain.Recommendation) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	err := withinTx(ctx, p.db, func(ctx context.Context) error {
+		tx := conn(ctx, p.db)
+		row := toPostgresRecommendation(recommendation)
+		_, err := tx.ExecContext(ctx, `INSERT INTO recommendations (`+recommendationColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+			row.ID, row.MetricId, row.MetricType, row.Status, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return insertRecommendationItems(ctx, tx, row.ID, recommendation.Items)
+	})
+	if err != nil {
+		p.logger.Error("failed to persist recommendation", zap.Error(err))
+		return fmt.Errorf("failed to persist recommendation: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRecommendationRepository) UpdateRecommendationById(ctx context.Context, recommendation domain.Recommendation) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	err := withinTx(ctx, p.db, func(ctx context.Context) error {
+		tx := conn(ctx, p.db)
+		row := toPostgresRecommendation(recommendation)
+		result, err := tx.ExecContext(ctx, `UPDATE recommendations SET
+			metric_id = $2, metric_type = $3, status = $4, created_at = $5, updated_at = $6
+			WHERE id = $1`,
+			row.ID, row.MetricId, row.MetricType, row.Status, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil || updated == 0 {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recommendation_items WHERE recommendation_id = $1`, row.ID); err != nil {
+			return err
+		}
+		return insertRecommendationItems(ctx, tx, row.ID, recommendation.Items)
+	})
+	if err != nil {
+		p.logger.Error("failed to update recommendation", zap.Error(err))
+		return fmt.Errorf("failed to update recommendation: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRecommendationRepository) GetRecommendationById(ctx context.Context, recommendationId primitive.ObjectID) (domain.Recommendation, error) {
+	return p.getRecommendation(ctx, `SELECT `+recommendationColumns+` FROM recommendations WHERE id = $1`, recommendationId.Hex())
+}
+
+func (p *PostgresRecommendationRepository) GetRecommendationByMetricId(ctx context.Context, metricId primitive.ObjectID, metricType string) (domain.Recommendation, error) {
+	return p.getRecommendation(ctx, `SELECT `+recommendationColumns+` FROM recommendations WHERE metric_id = $1 AND metric_type = $2 LIMIT 1`, metricId.Hex(), metricType)
+}
+
+func (p *PostgresRecommendationRepository) GetRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) ([]domain.Recommendation, error) {
+	if len(metricIds) == 0 {
+		return []domain.Recommendation{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	recommendations, err := p.listRecommendations(ctx,
+		`SELECT `+recommendationColumns+` FROM recommendations WHERE metric_id = ANY($1) ORDER BY created_at DESC, id`,
+		pq.Array(toHexIds(metricIds)),
+	)
+	if err != nil {
+		p.logger.Error("failed to find recommendations by metric ids", zap.Error(err))
+		return nil, fmt.Errorf("failed to find recommendations by metric ids: %w", err)
+	}
+	return recommendations, nil
+}
+
+func (p *PostgresRecommendationRepository) GetPendingRecommendations(ctx context.Context, limit int) ([]domain.Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	recommendations, err := p.listRecommendations(ctx,
+		`SELECT `+recommendationColumns+` FROM recommendations WHERE status = $1 ORDER BY created_at, id LIMIT $2`,
+		string(domain.RecommendationPending), limit,
+	)
+	if err != nil {
+		p.logger.Error("failed to find pending recommendations", zap.Error(err))
+		return nil, fmt.Errorf("failed to find pending recommendations: %w", err)
+	}
+	return recommendations, nil
+}
+
+func (p *PostgresRecommendationRepository) DeleteRecommendationsByMetricId(ctx context.Context, metricId primitive.ObjectID) error {
+	return p.DeleteRecommendationsByMetricIds(ctx, []primitive.ObjectID{metricId})
+}
+
+func (p *PostgresRecommendationRepository) DeleteRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) error {
+	if len(metricIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM recommendations WHERE metric_id = ANY($1)`, pq.Array(toHexIds(metricIds)))
+	if err != nil {
+		p.logger.Error("failed to delete recommendations by metric ids", zap.Error(err))
+		return fmt.Errorf("failed to delete recommendations by metric ids: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresRecommendationRepository) getRecommendation(ctx context.Context, query string, args ...any) (domain.Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	recommendations, err := p.listRecommendations(ctx, query, args...)
+	if err != nil {
+		p.logger.Error("failed to find recommendation", zap.Error(err))
+		return domain.Recommendation{}, infra.ErrRecommendationNotFound
+	}
+	if len(recommendations) == 0 {
+		return domain.Recommendation{}, infra.ErrRecommendationNotFound
+	}
+	return recommendations[0], nil
+}
+
+// listRecommendations runs a query selecting recommendationColumns and loads
+// the items of every row with one more query.
+func (p *PostgresRecommendationRepository) listRecommendations(ctx context.Context, query string, args ...any) ([]domain.Recommendation, error) {
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := []domain.Recommendation{}
+	byId := map[string]int{}
+	for rows.Next() {
+		row := postgresRecommendation{}
+		if err := rows.Scan(&row.ID, &row.MetricId, &row.MetricType, &row.Status, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		recommendation, err := toDomainRecommendation(row)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		byId[row.ID] = len(recommendations)
+		recommendations = append(recommendations, recommendation)
+	}
+	// inside a transaction both queries share one connection, the rows have
+	// to be released before the items can be queried.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	ids := make([]string, 0, len(byId))
+	for id := range byId {
+		ids = append(ids, id)
+	}
+	itemRows, err := conn(ctx, p.db).QueryContext(ctx,
+		`SELECT recommendation_id, position, heading, text, image_url FROM recommendation_items
+		WHERE recommendation_id = ANY($1) ORDER BY recommendation_id, position`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var recommendationId string
+		item := domain.RecommendationItem{}
+		if err := itemRows.Scan(&recommendationId, &item.Index, &item.Heading, &item.Text, &item.ImageUrl); err != nil {
+			return nil, err
+		}
+		i := byId[recommendationId]
+		recommendations[i].Items = append(recommendations[i].Items, item)
+	}
+	return recommendations, itemRows.Err()
+}
+
+func insertRecommendationItems(ctx context.Context, tx querier, recommendationId string, items []domain.RecommendationItem) error {
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, `INSERT INTO recommendation_items (recommendation_id, position, heading, text, image_url) VALUES ($1, $2, $3, $4, $5)`,
+			recommendationId, item.Index, item.Heading, item.Text, item.ImageUrl,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toHexIds(ids []primitive.ObjectID) []string {
+	hexIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexIds = append(hexIds, id.Hex())
+	}
+	return hexIds
+}
+
+type postgresRecommendation struct {
+	ID         string
+	MetricId   string
+	MetricType string
+	Status     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func toPostgresRecommendation(recommendation domain.Recommendation) postgresRecommendation {
+	return postgresRecommendation{
+		ID:         recommendation.ID.Hex(),
+		MetricId:   recommendation.MetricId.Hex(),
+		MetricType: recommendation.MetricType,
+		Status:     string(recommendation.Status),
+		CreatedAt:  storedTime(recommendation.CreatedAt),
+		UpdatedAt:  storedTime(recommendation.UpdatedAt),
+	}
+}
+
+func toDomainRecommendation(row postgresRecommendation) (domain.Recommendation, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	metricId, err := toObjectID(row.MetricId)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return domain.Recommendation{
+		ID:         id,
+		MetricId:   metricId,
+		MetricType: row.MetricType,
+		Items:      []domain.RecommendationItem{},
+		Status:     domain.RecommendationStatus(row.Status),
+		CreatedAt:  row.CreatedAt.UTC(),
+		UpdatedAt:  row.UpdatedAt.UTC(),
+	}, nil
+}
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresSafetyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresSafetyRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresSafetyRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres safety repo, db is nil")
+	}
+	return &PostgresSafetyRepository{db: db, logger: logger}, nil
+}
+
+const (
+	safetyEventColumns    = `id, user_id, kind, metric_id, flags, escalated, country, actor_id, created_at`
+	safetyOverrideColumns = `user_id, low_mood_days, suppress_escalation_until, clinician_id, updated_at`
+)
+
+func (p *PostgresSafetyRepository) CreateSafetyEvent(ctx context.Context, event domain.SafetyEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresSafetyEvent(event)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO safety_events (`+safetyEventColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		row.ID, row.UserId, row.Kind, row.MetricId, pq.Array(row.Flags), row.Escalated, row.Country, row.ActorId, row.CreatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to persist safety event", zap.Error(err))
+		return fmt.Errorf("failed to persist safety event: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresSafetyRepository) GetSafetyEventsByUserId(ctx context.Context, userId primitive.ObjectID, limit int) ([]domain.SafetyEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	query := `SELECT ` + safetyEventColumns + ` FROM safety_events WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+	args := []any{userId.Hex()}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		p.logger.Error("failed to find safety events by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find safety events by user id: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.SafetyEvent{}
+	for rows.Next() {
+		row := postgresSafetyEvent{}
+		err := rows.Scan(&row.ID, &row.UserId, &row.Kind, &row.MetricId, pq.Array(&row.Flags), &row.Escalated, &row.Country, &row.ActorId, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan safety event: %w", err)
+		}
+		event, err := toDomainSafetyEvent(row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (p *PostgresSafetyRepository) SaveSafetyOverride(ctx context.Context, override domain.SafetyOverride) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresSafetyOverride(override)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO safety_overrides (`+safetyOverrideColumns+`) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+		low_mood_days = excluded.low_mood_days, suppress_escalation_until = excluded.suppress_escalation_until,
+		clinician_id = excluded.clinician_id, updated_at = excluded.updated_at`,
+		row.UserId, row.LowMoodDays, row.SuppressEscalationUntil, row.ClinicianId, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to save safety override", zap.Error(err))
+		return fmt.Errorf("failed to save safety override: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresSafetyRepository) GetSafetyOverride(ctx context.Context, userId primitive.ObjectID) (domain.SafetyOverride, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := postgresSafetyOverride{}
+	err := conn(ctx, p.db).QueryRowContext(ctx, `SELECT `+safetyOverrideColumns+` FROM safety_overrides WHERE user_id = $1`, userId.Hex()).
+		Scan(&row.UserId, &row.LowMoodDays, &row.SuppressEscalationUntil, &row.ClinicianId, &row.UpdatedAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find safety override", zap.Error(err))
+			return domain.SafetyOverride{}, fmt.Errorf("failed to find safety override: %w", err)
+		}
+		return domain.SafetyOverride{}, infra.ErrSafetyOverrideNotFound
+	}
+	return toDomainSafetyOverride(row)
+}
+
+func (p *PostgresSafetyRepository) DeleteSafetyOverride(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM safety_overrides WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete safety override", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrSafetyOverrideNotFound
+	}
+	return nil
+}
+
+func (p *PostgresSafetyRepository) DeleteSafetyDataByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM safety_overrides WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete safety override by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override by user id: %w", err)
+	}
+	_, err = conn(ctx, p.db).ExecContext(ctx, `DELETE FROM safety_events WHERE user_id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete safety events by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety events by user id: %w", err)
+	}
+	return nil
+}
+
+type postgresSafetyEvent struct {
+	ID        string
+	UserId    string
+	Kind      string
+	MetricId  sql.NullString
+	Flags     []string
+	Escalated bool
+	Country   string
+	ActorId   sql.NullString
+	CreatedAt time.Time
+}
+
+func toPostgresSafetyEvent(event domain.SafetyEvent) postgresSafetyEvent {
+	flags := []string{}
+	for _, flag := range event.Flags {
+		flags = append(flags, string(flag))
+	}
+	return postgresSafetyEvent{
+		ID:        event.ID.Hex(),
+		UserId:    event.UserId.Hex(),
+		Kind:      string(event.Kind),
+		MetricId:  nullObjectID(event.MetricId),
+		Flags:     flags,
+		Escalated: event.Escalated,
+		Country:   event.Country,
+		ActorId:   nullObjectID(event.ActorId),
+		CreatedAt: storedTime(event.CreatedAt),
+	}
+}
+
+func toDomainSafetyEvent(row postgresSafetyEvent) (domain.SafetyEvent, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	metricId, err := fromNullObjectID(row.MetricId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	actorId, err := fromNullObjectID(row.ActorId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	flags := []domain.SafetyFlag{}
+	for _, flag := range row.Flags {
+		flags = append(flags, domain.SafetyFlag(flag))
+	}
+	return domain.SafetyEvent{
+		ID:        id,
+		UserId:    userId,
+		Kind:      domain.SafetyEventKind(row.Kind),
+		MetricId:  metricId,
+		Flags:     flags,
+		Escalated: row.Escalated,
+		Country:   row.Country,
+		ActorId:   actorId,
+		CreatedAt: row.CreatedAt.UTC(),
+	}, nil
+}
+
+type postgresSafetyOverride struct {
+	UserId                  string
+	LowMoodDays             int
+	SuppressEscalationUntil sql.NullTime
+	ClinicianId             string
+	UpdatedAt               time.Time
+}
+
+func toPostgresSafetyOverride(override domain.SafetyOverride) postgresSafetyOverride {
+	return postgresSafetyOverride{
+		UserId:                  override.UserId.Hex(),
+		LowMoodDays:             override.LowMoodDays,
+		SuppressEscalationUntil: sql.NullTime{Time: storedTime(override.SuppressEscalationUntil), Valid: !override.SuppressEscalationUntil.IsZero()},
+		ClinicianId:             override.ClinicianId.Hex(),
+		UpdatedAt:               storedTime(override.UpdatedAt),
+	}
+}
+
+func toDomainSafetyOverride(row postgresSafetyOverride) (domain.SafetyOverride, error) {
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+	clinicianId, err := toObjectID(row.ClinicianId)
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+	override := domain.SafetyOverride{
+		UserId:      userId,
+		LowMoodDays: row.LowMoodDays,
+		ClinicianId: clinicianId,
+		UpdatedAt:   row.UpdatedAt.UTC(),
+	}
+	if row.SuppressEscalationUntil.Valid {
+		override.SuppressEscalationUntil = row.SuppressEscalationUntil.Time.UTC()
+	}
+	return override, nil
+}
+
+// nullObjectID stores a zero id as NULL.
+func nullObjectID(id primitive.ObjectID) sql.NullString {
+	return sql.NullString{String: id.Hex(), Valid: !id.IsZero()}
+}
+
+func fromNullObjectID(id sql.NullString) (primitive.ObjectID, error) {
+	if !id.Valid {
+		return primitive.NilObjectID, nil
+	}
+	return toObjectID(id.String)
+}
@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresShareRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresShareRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresShareRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres share repo, db is nil")
+	}
+	return &PostgresShareRepository{db: db, logger: logger}, nil
+}
+
+const shareColumns = `id, owner_id, label, code_hash, scopes, expires_at, accepted_at, accepted_by, revoked_at, created_at, updated_at`
+
+func (p *PostgresShareRepository) CreateShare(ctx context.Context, share domain.Share) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresShare(share)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO shares (`+shareColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		row.ID, row.OwnerId, row.Label, row.CodeHash, pq.Array(row.Scopes), row.ExpiresAt, row.AcceptedAt, row.AcceptedBy, row.RevokedAt, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to persist share", zap.Error(err))
+		return fmt.Errorf("failed to persist share: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresShareRepository) GetShareById(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error) {
+	return p.getShare(ctx, `id = $1`, shareId.Hex())
+}
+
+func (p *PostgresShareRepository) GetShareByCodeHash(ctx context.Context, codeHash string) (domain.Share, error) {
+	return p.getShare(ctx, `code_hash = $1`, codeHash)
+}
+
+func (p *PostgresShareRepository) getShare(ctx context.Context, where string, arg any) (domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := postgresShare{}
+	err := scanShare(conn(ctx, p.db).QueryRowContext(ctx, `SELECT `+shareColumns+` FROM shares WHERE `+where, arg), &row)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to find share", zap.Error(err))
+			return domain.Share{}, fmt.Errorf("failed to find share: %w", err)
+		}
+		return domain.Share{}, infra.ErrShareNotFound
+	}
+	return toDomainShare(row)
+}
+
+func (p *PostgresShareRepository) GetSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) ([]domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT `+shareColumns+` FROM shares WHERE owner_id = $1 ORDER BY created_at DESC, id DESC`, ownerId.Hex())
+	if err != nil {
+		p.logger.Error("failed to find shares by owner id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find shares by owner id: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []domain.Share{}
+	for rows.Next() {
+		row := postgresShare{}
+		if err := scanShare(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		share, err := toDomainShare(row)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (p *PostgresShareRepository) AcceptShare(ctx context.Context, shareId, acceptedBy primitive.ObjectID, acceptedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, p.db).ExecContext(ctx, `UPDATE shares SET accepted_at = $2, accepted_by = $3, updated_at = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		shareId.Hex(), storedTime(acceptedAt), nullObjectID(acceptedBy),
+	)
+	if err != nil {
+		p.logger.Error("failed to accept share", zap.Error(err))
+		return fmt.Errorf("failed to accept share: %w", err)
+	}
+	accepted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if accepted == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func (p *PostgresShareRepository) RevokeShare(ctx context.Context, shareId primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, p.db).ExecContext(ctx, `UPDATE shares SET
+		updated_at = CASE WHEN revoked_at IS NULL THEN $2 ELSE updated_at END, revoked_at = COALESCE(revoked_at, $2)
+		WHERE id = $1`,
+		shareId.Hex(), storedTime(revokedAt),
+	)
+	if err != nil {
+		p.logger.Error("failed to revoke share", zap.Error(err))
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func (p *PostgresShareRepository) DeleteSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM shares WHERE owner_id = $1`, ownerId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete shares by owner id", zap.Error(err))
+		return fmt.Errorf("failed to delete shares by owner id: %w", err)
+	}
+	return nil
+}
+
+type postgresShare struct {
+	ID         string
+	OwnerId    string
+	Label      string
+	CodeHash   string
+	Scopes     []string
+	ExpiresAt  time.Time
+	AcceptedAt sql.NullTime
+	AcceptedBy sql.NullString
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func scanShare(row rowScanner, share *postgresShare) error {
+	return row.Scan(&share.ID, &share.OwnerId, &share.Label, &share.CodeHash, pq.Array(&share.Scopes), &share.ExpiresAt,
+		&share.AcceptedAt, &share.AcceptedBy, &share.RevokedAt, &share.CreatedAt, &share.UpdatedAt)
+}
+
+func toPostgresShare(share domain.Share) postgresShare {
+	scopes := []string{}
+	for _, scope := range share.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return postgresShare{
+		ID:         share.ID.Hex(),
+		OwnerId:    share.OwnerId.Hex(),
+		Label:      share.Label,
+		CodeHash:   share.CodeHash,
+		Scopes:     scopes,
+		ExpiresAt:  storedTime(share.ExpiresAt),
+		AcceptedAt: sql.NullTime{Time: storedTime(share.AcceptedAt), Valid: !share.AcceptedAt.IsZero()},
+		AcceptedBy: nullObjectID(share.AcceptedBy),
+		RevokedAt:  sql.NullTime{Time: storedTime(share.RevokedAt), Valid: !share.RevokedAt.IsZero()},
+		CreatedAt:  storedTime(share.CreatedAt),
+		UpdatedAt:  storedTime(share.UpdatedAt),
+	}
+}
+
+func toDomainShare(row postgresShare) (domain.Share, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	ownerId, err := toObjectID(row.OwnerId)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	acceptedBy, err := fromNullObjectID(row.AcceptedBy)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	scopes := []domain.ShareScope{}
+	for _, scope := range row.Scopes {
+		scopes = append(scopes, domain.ShareScope(scope))
+	}
+	share := domain.Share{
+		ID:         id,
+		OwnerId:    ownerId,
+		Label:      row.Label,
+		CodeHash:   row.CodeHash,
+		Scopes:     scopes,
+		AcceptedBy: acceptedBy,
+		ExpiresAt:  row.ExpiresAt.UTC(),
+		CreatedAt:  row.CreatedAt.UTC(),
+		UpdatedAt:  row.UpdatedAt.UTC(),
+	}
+	if row.AcceptedAt.Valid {
+		share.AcceptedAt = row.AcceptedAt.Time.UTC()
+	}
+	if row.RevokedAt.Valid {
+		share.RevokedAt = row.RevokedAt.Time.UTC()
+	}
+	return share, nil
+}
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostgresUserRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresUserRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*PostgresUserRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize postgres user repo, db is nil")
+	}
+	return &PostgresUserRepository{db: db, logger: logger}, nil
+}
+
+const userColumns = `id, email, first_name, last_name, password, timezone, is_email_verified, is_onboarding_complete, last_metric_log,
+	reminders_enabled, reminder_time, quiet_hours_start, quiet_hours_end, push_subscriptions, created_at, updated_at`
+
+func (p *PostgresUserRepository) CreateUser(ctx context.Context, user domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresUser(user)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		row.ID, row.Email, row.FirstName, row.LastName, row.Password, row.Timezone,
+		row.IsEmailVerified, row.IsOnBoardingComplete, row.LastMetricLog,
+		row.RemindersEnabled, row.ReminderTime, row.QuietHoursStart, row.QuietHoursEnd, row.PushSubscriptions,
+		row.CreatedAt, row.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrDuplicateEmail
+	}
+	if err != nil {
+		p.logger.Error("failed to persist user", zap.Error(err))
+		return fmt.Errorf("failed to persist user: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresUserRepository) UpdateUser(ctx context.Context, user domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toPostgresUser(user)
+	_, err := conn(ctx, p.db).ExecContext(ctx, `UPDATE users SET
+		email = $2, first_name = $3, last_name = $4, password = $5, timezone = $6,
+		is_email_verified = $7, is_onboarding_complete = $8, last_metric_log = $9,
+		reminders_enabled = $10, reminder_time = $11, quiet_hours_start = $12, quiet_hours_end = $13,
+		push_subscriptions = $14, created_at = $15, updated_at = $16
+		WHERE id = $1`,
+		row.ID, row.Email, row.FirstName, row.LastName, row.Password, row.Timezone,
+		row.IsEmailVerified, row.IsOnBoardingComplete, row.LastMetricLog,
+		row.RemindersEnabled, row.ReminderTime, row.QuietHoursStart, row.QuietHoursEnd, row.PushSubscriptions,
+		row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		p.logger.Error("failed to update user", zap.Error(err))
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresUserRepository) UpdateUserLastMetricLog(ctx context.Context, user domain.User) error {
+	updatedUser := user
+	updatedUser.LastMetricLog = time.Now()
+	updatedUser.UpdatedAt = time.Now()
+
+	return p.UpdateUser(ctx, updatedUser)
+}
+
+func (p *PostgresUserRepository) DeleteUser(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, p.db).ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userId.Hex())
+	if err != nil {
+		p.logger.Error("failed to delete user", zap.Error(err))
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	return p.getUser(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email)
+}
+
+func (p *PostgresUserRepository) GetUserByUserId(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
+	return p.getUser(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userId.Hex())
+}
+
+func (p *PostgresUserRepository) GetUsersWithRemindersEnabled(ctx context.Context, afterId primitive.ObjectID, limit int) ([]domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	// the zero ObjectID is all zeros in hex, so it sorts before every id
+	rows, err := conn(ctx, p.db).QueryContext(ctx, `SELECT `+userColumns+` FROM users WHERE reminders_enabled AND id > $1 ORDER BY id LIMIT $2`, afterId.Hex(), limit)
+	if err != nil {
+		p.logger.Error("failed to find users with reminders enabled", zap.Error(err))
+		return nil, fmt.Errorf("failed to find users with reminders enabled: %w", err)
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		row := postgresUser{}
+		if err := rows.Scan(row.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user, err := toDomainUser(row)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (p *PostgresUserRepository) getUser(ctx context.Context, query string, arg any) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := postgresUser{}
+	err := conn(ctx, p.db).QueryRowContext(ctx, query, arg).Scan(row.fields()...)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Error("failed to retrieve user", zap.Error(err))
+		}
+		return domain.User{}, infra.ErrUserNotFound
+	}
+	return toDomainUser(row)
+}
+
+// PushSubscriptions is a JSON array of pushSubscriptionJSON.
+type postgresUser struct {
+	ID                   string
+	Email                string
+	FirstName            string
+	LastName             string
+	Password             string
+	Timezone             string
+	IsEmailVerified      bool
+	IsOnBoardingComplete bool
+	LastMetricLog        sql.NullTime
+	RemindersEnabled     bool
+	ReminderTime         string
+	QuietHoursStart      string
+	QuietHoursEnd        string
+	PushSubscriptions    string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// fields are the scan targets for userColumns, in order.
+func (row *postgresUser) fields() []any {
+	return []any{
+		&row.ID, &row.Email, &row.FirstName, &row.LastName, &row.Password, &row.Timezone,
+		&row.IsEmailVerified, &row.IsOnBoardingComplete, &row.LastMetricLog,
+		&row.RemindersEnabled, &row.ReminderTime, &row.QuietHoursStart, &row.QuietHoursEnd, &row.PushSubscriptions,
+		&row.CreatedAt, &row.UpdatedAt,
+	}
+}
+
+func toPostgresUser(user domain.User) postgresUser {
+	return postgresUser{
+		ID:                   user.ID.Hex(),
+		Email:                user.Email,
+		FirstName:            user.FirstName,
+		LastName:             user.LastName,
+		Password:             user.Password,
+		Timezone:             user.Timezone,
+		IsEmailVerified:      user.IsEmailVerified,
+		IsOnBoardingComplete: user.IsOnBoardingComplete,
+		LastMetricLog:        sql.NullTime{Time: storedTime(user.LastMetricLog), Valid: !user.LastMetricLog.IsZero()},
+		RemindersEnabled:     user.Reminders.Enabled,
+		ReminderTime:         user.Reminders.Time,
+		QuietHoursStart:      user.Reminders.QuietHoursStart,
+		QuietHoursEnd:        user.Reminders.QuietHoursEnd,
+		PushSubscriptions:    encodePushSubscriptions(user.PushSubscriptions),
+		CreatedAt:            storedTime(user.CreatedAt),
+		UpdatedAt:            storedTime(user.UpdatedAt),
+	}
+}
+
+func toDomainUser(row postgresUser) (domain.User, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user := domain.User{
+		ID:                   id,
+		Email:                row.Email,
+		FirstName:            row.FirstName,
+		LastName:             row.LastName,
+		Password:             row.Password,
+		Timezone:             row.Timezone,
+		IsEmailVerified:      row.IsEmailVerified,
+		IsOnBoardingComplete: row.IsOnBoardingComplete,
+		Reminders: domain.ReminderPreferences{
+			Enabled:         row.RemindersEnabled,
+			Time:            row.ReminderTime,
+			QuietHoursStart: row.QuietHoursStart,
+			QuietHoursEnd:   row.QuietHoursEnd,
+		},
+		CreatedAt: row.CreatedAt.UTC(),
+		UpdatedAt: row.UpdatedAt.UTC(),
+	}
+	if row.LastMetricLog.Valid {
+		user.LastMetricLog = row.LastMetricLog.Time.UTC()
+	}
+	subscriptions, err := decodePushSubscriptions(row.PushSubscriptions)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user.PushSubscriptions = subscriptions
+	return user, nil
+}
+
+type pushSubscriptionJSON struct {
+	Endpoint  string `json:"endpoint"`
+	P256dh    string `json:"p256dh"`
+	Auth      string `json:"auth"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func encodePushSubscriptions(subscriptions []domain.PushSubscription) string {
+	rows := []pushSubscriptionJSON{}
+	for _, subscription := range subscriptions {
+		rows = append(rows, pushSubscriptionJSON{
+			Endpoint:  subscription.Endpoint,
+			P256dh:    subscription.P256dh,
+			Auth:      subscription.Auth,
+			CreatedAt: subscription.CreatedAt.UnixMilli(),
+		})
+	}
+	encoded, _ := json.Marshal(rows)
+	return string(encoded)
+}
+
+func decodePushSubscriptions(encoded string) ([]domain.PushSubscription, error) {
+	rows := []pushSubscriptionJSON{}
+	if err := json.Unmarshal([]byte(encoded), &rows); err != nil {
+		return nil, fmt.Errorf("invalid push_subscriptions: %w", err)
+	}
+	subscriptions := []domain.PushSubscription{}
+	for _, row := range rows {
+		subscriptions = append(subscriptions, domain.PushSubscription{
+			Endpoint:  row.Endpoint,
+			P256dh:    row.P256dh,
+			Auth:      row.Auth,
+			CreatedAt: time.UnixMilli(row.CreatedAt).UTC(),
+		})
+	}
+	return subscriptions, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
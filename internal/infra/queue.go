@@ -0,0 +1,12 @@
+package infra
+
+import "context"
+
+// JobQueue hands payloads to background workers in the order they were
+// enqueued. Each payload is delivered to a single Dequeue call.
+type JobQueue interface {
+	Enqueue(ctx context.Context, queue, payload string) error
+	// Dequeue blocks until a payload is available on queue or ctx is done, in
+	// which case it returns ctx.Err().
+	Dequeue(ctx context.Context, queue string) (string, error)
+}
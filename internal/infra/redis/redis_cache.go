@@ -2,11 +2,13 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
 	"go.uber.org/zap"
 )
 
@@ -15,8 +17,6 @@ type RedisCache struct {
 	logger *zap.Logger
 }
 
-var ttl = time.Minute * 30
-
 func New(ctx context.Context, configurations *config.Configurations, logger *zap.Logger) (*RedisCache, error) {
 	opt, err := redis.ParseURL(configurations.CacheAddress)
 	if err != nil {
@@ -34,7 +34,7 @@ func New(ctx context.Context, configurations *config.Configurations, logger *zap
 	}, nil
 }
 
-func (r *RedisCache) SetOne(ctx context.Context, key, value string) error {
+func (r *RedisCache) SetOne(ctx context.Context, key, value string, ttl time.Duration) error {
 	_, err := r.Client.Set(ctx, key, value, ttl).Result()
 	if err != nil {
 		return fmt.Errorf("Error setting value in cache: %w", err)
@@ -42,14 +42,65 @@ func (r *RedisCache) SetOne(ctx context.Context, key, value string) error {
 	return nil
 }
 
+func (r *RedisCache) SetOneIfNotExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ok, err := r.Client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("Error setting value in cache: %w", err)
+	}
+	return ok, nil
+}
+
+func (r *RedisCache) SetOneIfExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ok, err := r.Client.SetXX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("Error setting value in cache: %w", err)
+	}
+	return ok, nil
+}
+
+// setIfEqualsScript compares and sets in one step on the server.
+var setIfEqualsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+func (r *RedisCache) SetOneIfEquals(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	set, err := setIfEqualsScript.Run(ctx, r.Client, []string{key}, expected, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("Error setting value in cache: %w", err)
+	}
+	return set == 1, nil
+}
+
 func (r *RedisCache) GetOne(ctx context.Context, key string) (string, error) {
 	result, err := r.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", infra.ErrCacheMiss
+	}
 	if err != nil {
 		return "", fmt.Errorf("Error getting value from cache: %w", err)
 	}
 	return result, nil
 }
 
+func (r *RedisCache) GetAndDeleteOne(ctx context.Context, key string) (string, error) {
+	result, err := r.Client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", infra.ErrCacheMiss
+	}
+	if err != nil {
+		return "", fmt.Errorf("Error getting and deleting value from cache: %w", err)
+	}
+	return result, nil
+}
+
 func (r *RedisCache) DeleteOne(ctx context.Context, key string) error {
 	_, err := r.Client.Del(ctx, key).Result()
 	if err != nil {
@@ -58,6 +109,30 @@ func (r *RedisCache) DeleteOne(ctx context.Context, key string) error {
 	return nil
 }
 
+func (r *RedisCache) AddToSet(ctx context.Context, key, member string) error {
+	_, err := r.Client.SAdd(ctx, key, member).Result()
+	if err != nil {
+		return fmt.Errorf("Error adding member to set in cache: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisCache) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := r.Client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting set members from cache: %w", err)
+	}
+	return members, nil
+}
+
+func (r *RedisCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	_, err := r.Client.SRem(ctx, key, member).Result()
+	if err != nil {
+		return fmt.Errorf("Error removing member from set in cache: %w", err)
+	}
+	return nil
+}
+
 func (r *RedisCache) Ping(ctx context.Context) error {
 	if err := r.Client.Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("Failed to Ping Redis Cache: %v", err)
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// dequeuePollInterval bounds how long a blocked Dequeue takes to notice that
+// its context is done.
+const dequeuePollInterval = time.Second
+
+// RedisJobQueue keeps each queue in a redis list so jobs survive a restart and
+// are shared by every instance.
+type RedisJobQueue struct {
+	client *redis.Client
+}
+
+func NewRedisJobQueue(client *redis.Client) (*RedisJobQueue, error) {
+	if client == nil {
+		return nil, errors.New("failed to initialize redis job queue, client is nil")
+	}
+	return &RedisJobQueue{client: client}, nil
+}
+
+func (r *RedisJobQueue) Enqueue(ctx context.Context, queue, payload string) error {
+	if err := r.client.LPush(ctx, queueKey(queue), payload).Err(); err != nil {
+		return fmt.Errorf("Error enqueueing job: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisJobQueue) Dequeue(ctx context.Context, queue string) (string, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		result, err := r.client.BRPop(ctx, dequeuePollInterval, queueKey(queue)).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("Error dequeueing job: %w", err)
+		}
+		// BRPOP replies with the key and the value
+		return result[1], nil
+	}
+}
+
+func queueKey(queue string) string {
+	return "queue:" + queue
+}
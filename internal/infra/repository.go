@@ -3,6 +3,7 @@ package infra
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,24 +11,84 @@ import (
 
 var (
 	ErrUserNotFound           = errors.New("user not found")
+	ErrDuplicateEmail         = errors.New("email already in use")
 	ErrMetricNotFound         = errors.New("metric not found")
 	ErrRecommendationNotFound = errors.New("recommendation not found")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+	ErrDailyLogExists         = errors.New("user already has a log for this day")
+	ErrAchievementExists      = errors.New("user already has this badge")
+	ErrGoalNotFound           = errors.New("goal not found")
+	ErrGoalCompletionExists   = errors.New("goal already completed for this period")
+	ErrJournalEntryNotFound   = errors.New("journal entry not found")
+	ErrSafetyOverrideNotFound = errors.New("safety override not found")
+	ErrShareNotFound          = errors.New("share not found")
 )
 
+// Transactor runs fn so that every repository call made with the context it
+// is given either all apply or none do.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user domain.User) error
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
 	GetUserByUserId(ctx context.Context, userId primitive.ObjectID) (domain.User, error)
 	UpdateUser(ctx context.Context, user domain.User) error
 	UpdateUserLastMetricLog(ctx context.Context, user domain.User) error
+	DeleteUser(ctx context.Context, userId primitive.ObjectID) error
+	// GetUsersWithRemindersEnabled returns up to limit users with reminders
+	// enabled whose id sorts after afterId, in id order. A zero afterId starts
+	// from the first user.
+	GetUsersWithRemindersEnabled(ctx context.Context, afterId primitive.ObjectID, limit int) ([]domain.User, error)
 }
 
 type MetricRepository interface {
 	CreateMetric(ctx context.Context, metric domain.Metric) error
-	GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID) (domain.Metric, error)
+	GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID, location *time.Location) (domain.Metric, error)
 	UpdateMetricById(ctx context.Context, metric domain.Metric) error
 	GetMetricById(ctx context.Context, metricId primitive.ObjectID) (domain.Metric, error)
-	GetRecentMetricsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Metric, error)
+	DeleteMetricById(ctx context.Context, metricId primitive.ObjectID) error
+	DeleteMetricsByUserId(ctx context.Context, userId primitive.ObjectID) error
+	GetMetricsByUserId(ctx context.Context, userId primitive.ObjectID, filter MetricFilter) (MetricPage, error)
+	GetMetricSummary(ctx context.Context, userId primitive.ObjectID, filter MetricSummaryFilter) ([]domain.MetricSummaryBucket, error)
+	// GetMetricDays returns when each of a user's metrics was logged, oldest
+	// first, without decrypting them.
+	GetMetricDays(ctx context.Context, userId primitive.ObjectID) ([]MetricDay, error)
+}
+
+// MetricDay is when a metric was logged. LocalDate is empty for metrics
+// stored before it was, their day has to be worked out from CreatedAt.
+type MetricDay struct {
+	MetricId  primitive.ObjectID
+	LocalDate string
+	CreatedAt time.Time
+}
+
+// Date is the local date of the metric, using location when LocalDate is not
+// stored.
+func (d MetricDay) Date(location *time.Location) string {
+	if d.LocalDate != "" {
+		return d.LocalDate
+	}
+	return domain.LocalDate(d.CreatedAt, location)
+}
+
+// MetricFilter narrows down a user's metrics, newest first. From is inclusive,
+// To is exclusive and a zero value leaves that side unbounded. A Limit of 0
+// returns every matching metric.
+type MetricFilter struct {
+	From   time.Time
+	To     time.Time
+	Cursor string
+	Limit  int
+}
+
+type MetricPage struct {
+	Metrics    []domain.Metric
+	Limit      int
+	NextCursor string
+	Total      int64
 }
 
 type RecommendationRepository interface {
@@ -35,4 +96,102 @@ type RecommendationRepository interface {
 	UpdateRecommendationById(ctx context.Context, metric domain.Recommendation) error
 	GetRecommendationById(ctx context.Context, metricId primitive.ObjectID) (domain.Recommendation, error)
 	GetRecommendationByMetricId(ctx context.Context, metricId primitive.ObjectID, metricType string) (domain.Recommendation, error)
+	GetRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) ([]domain.Recommendation, error)
+	// GetPendingRecommendations returns up to limit recommendations that are
+	// still waiting to be generated, oldest first.
+	GetPendingRecommendations(ctx context.Context, limit int) ([]domain.Recommendation, error)
+	DeleteRecommendationsByMetricId(ctx context.Context, metricId primitive.ObjectID) error
+	DeleteRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) error
+}
+
+// MetricSummaryFilter buckets a user's metrics by day, ISO week or month in
+// Location, oldest bucket first.
+type MetricSummaryFilter struct {
+	From        time.Time
+	To          time.Time
+	Granularity domain.SummaryGranularity
+	Location    *time.Location
+}
+
+type AchievementRepository interface {
+	// CreateAchievement returns ErrAchievementExists when the user already
+	// has the badge.
+	CreateAchievement(ctx context.Context, achievement domain.Achievement) error
+	// GetAchievementsByUserId returns a user's achievements, oldest first.
+	GetAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Achievement, error)
+	DeleteAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type GoalRepository interface {
+	CreateGoal(ctx context.Context, goal domain.Goal) error
+	GetGoalById(ctx context.Context, goalId primitive.ObjectID) (domain.Goal, error)
+	// GetGoalsByUserId returns a user's goals, oldest first.
+	GetGoalsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Goal, error)
+	UpdateGoal(ctx context.Context, goal domain.Goal) error
+	// DeleteGoal deletes a goal and its completions.
+	DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error
+	// DeleteGoalsByUserId deletes a user's goals and their completions.
+	DeleteGoalsByUserId(ctx context.Context, userId primitive.ObjectID) error
+	// CreateGoalCompletion returns ErrGoalCompletionExists when the goal is
+	// already completed for the period.
+	CreateGoalCompletion(ctx context.Context, completion domain.GoalCompletion) error
+	// GetGoalCompletionsByUserId returns a user's completions made after
+	// since, oldest first. A zero since returns every completion.
+	GetGoalCompletionsByUserId(ctx context.Context, userId primitive.ObjectID, since time.Time) ([]domain.GoalCompletion, error)
+}
+
+type JournalRepository interface {
+	CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) error
+	GetJournalEntryById(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error)
+	GetJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID, filter JournalFilter) (JournalPage, error)
+	UpdateJournalEntry(ctx context.Context, entry domain.JournalEntry) error
+	DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error
+	DeleteJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type SafetyRepository interface {
+	CreateSafetyEvent(ctx context.Context, event domain.SafetyEvent) error
+	// GetSafetyEventsByUserId returns up to limit of a user's events, newest
+	// first. A limit of 0 returns every event.
+	GetSafetyEventsByUserId(ctx context.Context, userId primitive.ObjectID, limit int) ([]domain.SafetyEvent, error)
+	// SaveSafetyOverride creates the user's override or replaces it.
+	SaveSafetyOverride(ctx context.Context, override domain.SafetyOverride) error
+	GetSafetyOverride(ctx context.Context, userId primitive.ObjectID) (domain.SafetyOverride, error)
+	DeleteSafetyOverride(ctx context.Context, userId primitive.ObjectID) error
+	// DeleteSafetyDataByUserId deletes a user's override and events.
+	DeleteSafetyDataByUserId(ctx context.Context, userId primitive.ObjectID) error
+}
+
+// JournalFilter narrows down a user's journal entries, newest first and paged
+// like MetricFilter. Search matches entries with every one of its
+// domain.SearchTerms in their title, body or tags and Tag those tagged with it.
+type JournalFilter struct {
+	Search string
+	Tag    string
+	From   time.Time
+	To     time.Time
+	Cursor string
+	Limit  int
+}
+
+type JournalPage struct {
+	Entries    []domain.JournalEntry
+	Limit      int
+	NextCursor string
+	Total      int64
+}
+
+type ShareRepository interface {
+	CreateShare(ctx context.Context, share domain.Share) error
+	GetShareById(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error)
+	GetShareByCodeHash(ctx context.Context, codeHash string) (domain.Share, error)
+	// GetSharesByOwnerId returns every share of a user, newest first.
+	GetSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) ([]domain.Share, error)
+	// AcceptShare marks a share accepted as long as it is neither accepted nor
+	// revoked yet, otherwise it returns ErrShareNotFound so a code can only be
+	// accepted once. acceptedBy is zero unless a logged in clinician accepted it.
+	AcceptShare(ctx context.Context, shareId, acceptedBy primitive.ObjectID, acceptedAt time.Time) error
+	// RevokeShare keeps the time a share was first revoked.
+	RevokeShare(ctx context.Context, shareId primitive.ObjectID, revokedAt time.Time) error
+	DeleteSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) error
 }
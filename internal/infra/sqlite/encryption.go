@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+)
+
+// Reencrypt wraps the data keys of journal entries again with the keyring's
+// current master key, the bodies they sealed stay as they are. It returns how
+// many entries it changed and is safe to run again.
+func Reencrypt(ctx context.Context, db *sql.DB, keyring *encryption.Keyring) (map[string]int64, error) {
+	type staleEntry struct {
+		id      string
+		keyId   string
+		dataKey []byte
+	}
+
+	// read them all first, the single connection cannot update while the
+	// rows are open.
+	rows, err := db.QueryContext(ctx, `SELECT id, key_id, data_key FROM journal_entries WHERE key_id <> ?`, keyring.CurrentKeyId())
+	if err != nil {
+		return nil, err
+	}
+	staleEntries := []staleEntry{}
+	for rows.Next() {
+		var entry staleEntry
+		if err := rows.Scan(&entry.id, &entry.keyId, &entry.dataKey); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		staleEntries = append(staleEntries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	changed := map[string]int64{"journal_entries": 0}
+	for _, entry := range staleEntries {
+		keyId, wrapped, err := keyring.Rewrap(entry.keyId, entry.dataKey)
+		if err != nil {
+			return changed, fmt.Errorf("failed to wrap the data key of journal entry %s again: %w", entry.id, err)
+		}
+		// the data key filter skips an entry written again since it was read.
+		result, err := db.ExecContext(ctx, `UPDATE journal_entries SET key_id = ?, data_key = ? WHERE id = ? AND data_key = ?`,
+			keyId, wrapped, entry.id, entry.dataKey,
+		)
+		if err != nil {
+			return changed, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return changed, err
+		}
+		changed["journal_entries"] += affected
+	}
+	return changed, nil
+}
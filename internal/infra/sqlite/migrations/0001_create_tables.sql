@@ -0,0 +1,64 @@
+-- ids are the hex form of a primitive.ObjectID and times are unix
+-- milliseconds in UTC, the precision the mongo backend keeps.
+CREATE TABLE users (
+    id                     TEXT    PRIMARY KEY,
+    email                  TEXT    NOT NULL,
+    first_name             TEXT    NOT NULL DEFAULT '',
+    last_name              TEXT    NOT NULL DEFAULT '',
+    password               TEXT    NOT NULL DEFAULT '',
+    timezone               TEXT    NOT NULL DEFAULT '',
+    is_email_verified      INTEGER NOT NULL DEFAULT 0,
+    is_onboarding_complete INTEGER NOT NULL DEFAULT 0,
+    last_metric_log        INTEGER,
+    created_at             INTEGER NOT NULL,
+    updated_at             INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX users_email ON users (email);
+
+CREATE TABLE metrics (
+    id                TEXT    PRIMARY KEY,
+    owner_id          TEXT    NOT NULL,
+    stress_level      INTEGER NOT NULL,
+    mood              TEXT    NOT NULL,
+    sleep_quality     TEXT    NOT NULL,
+    feeling           TEXT    NOT NULL DEFAULT '',
+    stress_less_score INTEGER NOT NULL,
+    created_at        INTEGER NOT NULL,
+    updated_at        INTEGER NOT NULL
+);
+
+CREATE INDEX metrics_owner_id_created_at ON metrics (owner_id, created_at DESC, id DESC);
+
+CREATE TABLE recommendations (
+    id          TEXT    PRIMARY KEY,
+    metric_id   TEXT    NOT NULL,
+    metric_type TEXT    NOT NULL,
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL
+);
+
+CREATE INDEX recommendations_metric_id_metric_type ON recommendations (metric_id, metric_type);
+
+CREATE TABLE recommendation_items (
+    recommendation_id TEXT    NOT NULL REFERENCES recommendations (id) ON DELETE CASCADE,
+    position          INTEGER NOT NULL,
+    heading           TEXT    NOT NULL,
+    text              TEXT    NOT NULL,
+    image_url         TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (recommendation_id, position)
+);
+
+CREATE TABLE cache_entries (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    expires_at INTEGER
+);
+
+CREATE INDEX cache_entries_expires_at ON cache_entries (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE cache_set_members (
+    key    TEXT NOT NULL,
+    member TEXT NOT NULL,
+    PRIMARY KEY (key, member)
+);
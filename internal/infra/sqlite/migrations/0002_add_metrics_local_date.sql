@@ -0,0 +1,5 @@
+-- sqlite has no time zone data to backfill older rows with, they keep a NULL
+-- local_date and stay out of the unique index.
+ALTER TABLE metrics ADD COLUMN local_date TEXT;
+
+CREATE UNIQUE INDEX metrics_owner_id_local_date ON metrics (owner_id, local_date);
@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN reminders_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN reminder_time TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN quiet_hours_start TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN quiet_hours_end TEXT NOT NULL DEFAULT '';
+-- a JSON array of {endpoint, p256dh, auth, created_at}
+ALTER TABLE users ADD COLUMN push_subscriptions TEXT NOT NULL DEFAULT '[]';
+
+CREATE INDEX users_reminders_enabled ON users (id) WHERE reminders_enabled = 1;
@@ -0,0 +1,9 @@
+CREATE TABLE achievements (
+    id         TEXT    PRIMARY KEY,
+    user_id    TEXT    NOT NULL,
+    badge      TEXT    NOT NULL,
+    metric_id  TEXT    NOT NULL,
+    awarded_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX achievements_user_id_badge ON achievements (user_id, badge);
@@ -0,0 +1,22 @@
+-- body is encrypted, tags is a JSON array and journal_search_terms holds the
+-- blind indexes entries are searched by.
+CREATE TABLE journal_entries (
+    id         TEXT    PRIMARY KEY,
+    user_id    TEXT    NOT NULL,
+    title      TEXT    NOT NULL,
+    body       BLOB    NOT NULL,
+    tags       TEXT    NOT NULL DEFAULT '[]',
+    metric_id  TEXT,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX journal_entries_user_id_created_at ON journal_entries (user_id, created_at, id);
+
+CREATE TABLE journal_search_terms (
+    entry_id TEXT NOT NULL REFERENCES journal_entries (id) ON DELETE CASCADE,
+    term     TEXT NOT NULL,
+    PRIMARY KEY (entry_id, term)
+);
+
+CREATE INDEX journal_search_terms_term ON journal_search_terms (term, entry_id);
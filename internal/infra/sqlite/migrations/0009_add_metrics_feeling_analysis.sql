@@ -0,0 +1,3 @@
+-- older logs keep a neutral polarity and no themes. themes is a JSON array.
+ALTER TABLE metrics ADD COLUMN feeling_polarity REAL NOT NULL DEFAULT 0;
+ALTER TABLE metrics ADD COLUMN themes TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,26 @@
+-- safety_flags and flags are JSON arrays.
+ALTER TABLE metrics ADD COLUMN safety_flags TEXT NOT NULL DEFAULT '[]';
+
+-- the audit trail of flagged logs and clinician overrides. metric_id is set
+-- on flagged logs and actor_id on overrides.
+CREATE TABLE safety_events (
+    id         TEXT    PRIMARY KEY,
+    user_id    TEXT    NOT NULL,
+    kind       TEXT    NOT NULL,
+    metric_id  TEXT,
+    flags      TEXT    NOT NULL DEFAULT '[]',
+    escalated  INTEGER NOT NULL DEFAULT 0,
+    country    TEXT    NOT NULL DEFAULT '',
+    actor_id   TEXT,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX safety_events_user_id_created_at ON safety_events (user_id, created_at, id);
+
+CREATE TABLE safety_overrides (
+    user_id                   TEXT    PRIMARY KEY,
+    low_mood_days             INTEGER NOT NULL DEFAULT 0,
+    suppress_escalation_until INTEGER,
+    clinician_id              TEXT    NOT NULL,
+    updated_at                INTEGER NOT NULL
+);
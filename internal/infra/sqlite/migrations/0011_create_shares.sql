@@ -0,0 +1,16 @@
+-- scopes is a JSON array. accepted_at and revoked_at are NULL until the share
+-- is accepted or revoked.
+CREATE TABLE shares (
+    id          TEXT    PRIMARY KEY,
+    owner_id    TEXT    NOT NULL,
+    label       TEXT    NOT NULL DEFAULT '',
+    code_hash   TEXT    NOT NULL UNIQUE,
+    scopes      TEXT    NOT NULL DEFAULT '[]',
+    expires_at  INTEGER NOT NULL,
+    accepted_at INTEGER,
+    revoked_at  INTEGER,
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL
+);
+
+CREATE INDEX shares_owner_id_created_at ON shares (owner_id, created_at, id);
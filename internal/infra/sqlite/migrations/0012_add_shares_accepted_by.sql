@@ -0,0 +1,2 @@
+-- accepted_by is NULL unless a logged in clinician accepted the share.
+ALTER TABLE shares ADD COLUMN accepted_by TEXT;
@@ -0,0 +1,153 @@
+// Package sqlite stores everything, cache included, in a single SQLite file so
+// the app can be self hosted as one binary.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sqliteDriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var contextTimeoutDuration = 5 * time.Second
+
+// DefaultPath is used when no path is configured.
+const DefaultPath = "stressless.db"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens the database file at path, DefaultPath when empty, creating it
+// if needed, and applies any pending migrations.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// sqlite allows a single writer, one connection avoids "database is
+	// locked" errors without slowing down a single box deployment much.
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite: %w", err)
+	}
+	return db, nil
+}
+
+// Migrate applies every embedded migration that is not yet recorded in
+// schema_migrations, in file name order and each in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version, err := migrationVersion(name)
+		if err != nil {
+			return err
+		}
+		if err := applyMigration(ctx, db, name, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, name string, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`, version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	script, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, toMillis(time.Now())); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrationVersion reads the numeric prefix of names like
+// migrations/0001_create_tables.sql.
+func migrationVersion(name string) (int, error) {
+	base := strings.TrimPrefix(name, "migrations/")
+	prefix, _, _ := strings.Cut(base, "_")
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration %s does not start with a version number", name)
+	}
+	return version, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(millis int64) time.Time {
+	return time.UnixMilli(millis).UTC()
+}
+
+func toObjectID(hex string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return primitive.ObjectID{}, fmt.Errorf("invalid id %q in sqlite: %w", hex, err)
+	}
+	return id, nil
+}
+
+// inPlaceholders returns a "?, ?, ?" list with one placeholder per id, along
+// with the hex ids as query arguments.
+func inPlaceholders(ids []primitive.ObjectID) (string, []any) {
+	placeholders := make([]string, 0, len(ids))
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		placeholders = append(placeholders, "?")
+		args = append(args, id.Hex())
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqliteDriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteAchievementRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteAchievementRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteAchievementRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite achievement repo, db is nil")
+	}
+	return &SQLiteAchievementRepository{db: db, logger: logger}, nil
+}
+
+const achievementColumns = `id, user_id, badge, metric_id, awarded_at`
+
+func (s *SQLiteAchievementRepository) CreateAchievement(ctx context.Context, achievement domain.Achievement) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteAchievement(achievement)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO achievements (`+achievementColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		row.ID, row.UserId, row.Badge, row.MetricId, row.AwardedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrAchievementExists
+	}
+	if err != nil {
+		s.logger.Error("failed to persist achievement", zap.Error(err))
+		return fmt.Errorf("failed to persist achievement: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteAchievementRepository) GetAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Achievement, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+achievementColumns+` FROM achievements WHERE user_id = ? ORDER BY awarded_at, id`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to find achievements by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find achievements by user id: %w", err)
+	}
+	defer rows.Close()
+
+	achievements := []domain.Achievement{}
+	for rows.Next() {
+		row := sqliteAchievement{}
+		if err := rows.Scan(&row.ID, &row.UserId, &row.Badge, &row.MetricId, &row.AwardedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		achievement, err := toDomainAchievement(row)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+	return achievements, rows.Err()
+}
+
+func (s *SQLiteAchievementRepository) DeleteAchievementsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM achievements WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete achievements by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete achievements by user id: %w", err)
+	}
+	return nil
+}
+
+type sqliteAchievement struct {
+	ID        string
+	UserId    string
+	Badge     string
+	MetricId  string
+	AwardedAt int64
+}
+
+func toSQLiteAchievement(achievement domain.Achievement) sqliteAchievement {
+	return sqliteAchievement{
+		ID:        achievement.ID.Hex(),
+		UserId:    achievement.UserId.Hex(),
+		Badge:     string(achievement.Badge),
+		MetricId:  achievement.MetricId.Hex(),
+		AwardedAt: toMillis(achievement.AwardedAt),
+	}
+}
+
+func toDomainAchievement(row sqliteAchievement) (domain.Achievement, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Achievement{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.Achievement{}, err
+	}
+	metricId, err := toObjectID(row.MetricId)
+	if err != nil {
+		return domain.Achievement{}, err
+	}
+	return domain.Achievement{
+		ID:        id,
+		UserId:    userId,
+		Badge:     domain.BadgeId(row.Badge),
+		MetricId:  metricId,
+		AwardedAt: fromMillis(row.AwardedAt),
+	}, nil
+}
@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.uber.org/zap"
+)
+
+// sweepInterval is how often expired keys are deleted, reads already ignore
+// expired keys so this only bounds the size of the file.
+var sweepInterval = time.Minute
+
+type SQLiteCache struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewSQLiteCache returns a cache stored in db and starts sweeping expired
+// keys in the background until ctx is done.
+func NewSQLiteCache(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteCache, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite cache, db is nil")
+	}
+	cache := &SQLiteCache{db: db, logger: logger}
+	go cache.sweep(ctx)
+	return cache, nil
+}
+
+func (s *SQLiteCache) SetOne(ctx context.Context, key, value string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	expiresAt := sql.NullInt64{}
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: toMillis(time.Now().Add(ttl)), Valid: true}
+	}
+	err := s.withinTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cache_set_members WHERE key = ?`, key); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO cache_entries (key, value, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+			key, value, expiresAt,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set cache key: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteCache) SetOneIfNotExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	now := toMillis(time.Now())
+	expiresAt := sql.NullInt64{}
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: toMillis(time.Now().Add(ttl)), Valid: true}
+	}
+	// an expired row the sweeper has not removed yet counts as missing.
+	result, err := s.db.ExecContext(ctx, `INSERT INTO cache_entries (key, value, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at
+		WHERE cache_entries.expires_at IS NOT NULL AND cache_entries.expires_at <= ?`,
+		key, value, expiresAt, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set cache key: %w", err)
+	}
+	set, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return set > 0, nil
+}
+
+func (s *SQLiteCache) SetOneIfExists(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	now := toMillis(time.Now())
+	expiresAt := sql.NullInt64{}
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: toMillis(time.Now().Add(ttl)), Valid: true}
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE cache_entries SET value = ?, expires_at = ?
+		WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		value, expiresAt, key, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set cache key: %w", err)
+	}
+	set, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return set > 0, nil
+}
+
+func (s *SQLiteCache) SetOneIfEquals(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	now := toMillis(time.Now())
+	expiresAt := sql.NullInt64{}
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: toMillis(time.Now().Add(ttl)), Valid: true}
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE cache_entries SET value = ?, expires_at = ?
+		WHERE key = ? AND value = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		value, expiresAt, key, expected, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set cache key: %w", err)
+	}
+	set, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return set > 0, nil
+}
+
+func (s *SQLiteCache) GetOne(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	var value string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM cache_entries WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		key, toMillis(time.Now()),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", infra.ErrCacheMiss
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache key: %w", err)
+	}
+	return value, nil
+}
+
+func (s *SQLiteCache) GetAndDeleteOne(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	var value string
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM cache_entries WHERE key = ? AND (expires_at IS NULL OR expires_at > ?) RETURNING value`,
+		key, toMillis(time.Now()),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", infra.ErrCacheMiss
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get and delete cache key: %w", err)
+	}
+	return value, nil
+}
+
+func (s *SQLiteCache) DeleteOne(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	err := s.withinTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cache_entries WHERE key = ?`, key); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM cache_set_members WHERE key = ?`, key)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete cache key: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteCache) AddToSet(ctx context.Context, key, member string) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO cache_set_members (key, member) VALUES (?, ?) ON CONFLICT DO NOTHING`, key, member)
+	if err != nil {
+		return fmt.Errorf("failed to add to cache set: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteCache) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT member FROM cache_set_members WHERE key = ?`, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache set members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []string{}
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (s *SQLiteCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM cache_set_members WHERE key = ? AND member = ?`, key, member)
+	if err != nil {
+		return fmt.Errorf("failed to remove from cache set: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteCache) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteCache) sweep(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.db.ExecContext(ctx, `DELETE FROM cache_entries WHERE expires_at IS NOT NULL AND expires_at <= ?`, toMillis(time.Now()))
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("failed to sweep expired cache keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *SQLiteCache) withinTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
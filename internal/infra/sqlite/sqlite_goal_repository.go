@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteGoalRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteGoalRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteGoalRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite goal repo, db is nil")
+	}
+	return &SQLiteGoalRepository{db: db, logger: logger}, nil
+}
+
+const (
+	goalColumns           = `id, user_id, kind, period, target, threshold, created_at, updated_at`
+	goalCompletionColumns = `id, goal_id, user_id, period_start, value, completed_at`
+)
+
+func (s *SQLiteGoalRepository) CreateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteGoal(goal)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO goals (`+goalColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.ID, row.UserId, row.Kind, row.Period, row.Target, row.Threshold, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		s.logger.Error("failed to persist goal", zap.Error(err))
+		return fmt.Errorf("failed to persist goal: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteGoalRepository) GetGoalById(ctx context.Context, goalId primitive.ObjectID) (domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	goal, err := scanGoal(conn(ctx, s.db).QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE id = ?`, goalId.Hex()))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find goal by id", zap.Error(err))
+		}
+		return domain.Goal{}, infra.ErrGoalNotFound
+	}
+	return goal, nil
+}
+
+func (s *SQLiteGoalRepository) GetGoalsByUserId(ctx context.Context, userId primitive.ObjectID) ([]domain.Goal, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE user_id = ? ORDER BY created_at, id`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to find goals by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goals by user id: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []domain.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+func (s *SQLiteGoalRepository) UpdateGoal(ctx context.Context, goal domain.Goal) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteGoal(goal)
+	result, err := conn(ctx, s.db).ExecContext(ctx, `UPDATE goals SET
+		user_id = ?, kind = ?, period = ?, target = ?, threshold = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		row.UserId, row.Kind, row.Period, row.Target, row.Threshold, row.CreatedAt, row.UpdatedAt,
+		row.ID,
+	)
+	if err != nil {
+		s.logger.Error("failed to update goal", zap.Error(err))
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteGoalRepository) DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM goal_completions WHERE goal_id = ?`, goalId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete goal completions", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions: %w", err)
+	}
+	result, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM goals WHERE id = ?`, goalId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete goal", zap.Error(err))
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrGoalNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteGoalRepository) DeleteGoalsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM goal_completions WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete goal completions by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goal completions by user id: %w", err)
+	}
+	_, err = conn(ctx, s.db).ExecContext(ctx, `DELETE FROM goals WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete goals by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete goals by user id: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteGoalRepository) CreateGoalCompletion(ctx context.Context, completion domain.GoalCompletion) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteGoalCompletion(completion)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO goal_completions (`+goalCompletionColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		row.ID, row.GoalId, row.UserId, row.PeriodStart, row.Value, row.CompletedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrGoalCompletionExists
+	}
+	if err != nil {
+		s.logger.Error("failed to persist goal completion", zap.Error(err))
+		return fmt.Errorf("failed to persist goal completion: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteGoalRepository) GetGoalCompletionsByUserId(ctx context.Context, userId primitive.ObjectID, since time.Time) ([]domain.GoalCompletion, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+goalCompletionColumns+` FROM goal_completions
+		WHERE user_id = ? AND completed_at > ? ORDER BY completed_at, id`, userId.Hex(), toMillis(since))
+	if err != nil {
+		s.logger.Error("failed to find goal completions by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find goal completions by user id: %w", err)
+	}
+	defer rows.Close()
+
+	completions := []domain.GoalCompletion{}
+	for rows.Next() {
+		row := sqliteGoalCompletion{}
+		if err := rows.Scan(&row.ID, &row.GoalId, &row.UserId, &row.PeriodStart, &row.Value, &row.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal completion: %w", err)
+		}
+		completion, err := toDomainGoalCompletion(row)
+		if err != nil {
+			return nil, err
+		}
+		completions = append(completions, completion)
+	}
+	return completions, rows.Err()
+}
+
+func scanGoal(row rowScanner) (domain.Goal, error) {
+	g := sqliteGoal{}
+	err := row.Scan(&g.ID, &g.UserId, &g.Kind, &g.Period, &g.Target, &g.Threshold, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	return toDomainGoal(g)
+}
+
+type sqliteGoal struct {
+	ID        string
+	UserId    string
+	Kind      string
+	Period    string
+	Target    int
+	Threshold string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+func toSQLiteGoal(goal domain.Goal) sqliteGoal {
+	return sqliteGoal{
+		ID:        goal.ID.Hex(),
+		UserId:    goal.UserId.Hex(),
+		Kind:      string(goal.Kind),
+		Period:    string(goal.Period),
+		Target:    goal.Target,
+		Threshold: goal.Threshold,
+		CreatedAt: toMillis(goal.CreatedAt),
+		UpdatedAt: toMillis(goal.UpdatedAt),
+	}
+}
+
+func toDomainGoal(row sqliteGoal) (domain.Goal, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.Goal{}, err
+	}
+	return domain.Goal{
+		ID:        id,
+		UserId:    userId,
+		Kind:      domain.GoalKind(row.Kind),
+		Period:    domain.GoalPeriod(row.Period),
+		Target:    row.Target,
+		Threshold: row.Threshold,
+		CreatedAt: fromMillis(row.CreatedAt),
+		UpdatedAt: fromMillis(row.UpdatedAt),
+	}, nil
+}
+
+type sqliteGoalCompletion struct {
+	ID          string
+	GoalId      string
+	UserId      string
+	PeriodStart string
+	Value       int
+	CompletedAt int64
+}
+
+func toSQLiteGoalCompletion(completion domain.GoalCompletion) sqliteGoalCompletion {
+	return sqliteGoalCompletion{
+		ID:          completion.ID.Hex(),
+		GoalId:      completion.GoalId.Hex(),
+		UserId:      completion.UserId.Hex(),
+		PeriodStart: completion.PeriodStart,
+		Value:       completion.Value,
+		CompletedAt: toMillis(completion.CompletedAt),
+	}
+}
+
+func toDomainGoalCompletion(row sqliteGoalCompletion) (domain.GoalCompletion, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	goalId, err := toObjectID(row.GoalId)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.GoalCompletion{}, err
+	}
+	return domain.GoalCompletion{
+		ID:          id,
+		GoalId:      goalId,
+		UserId:      userId,
+		PeriodStart: row.PeriodStart,
+		Value:       row.Value,
+		CompletedAt: fromMillis(row.CompletedAt),
+	}, nil
+}
@@ -0,0 +1,328 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/encryption"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// SQLiteJournalRepository seals entry bodies. Entries are searched
+// through the blind indexes of their search terms.
+type SQLiteJournalRepository struct {
+	db      *sql.DB
+	keyring *encryption.Keyring
+	logger  *zap.Logger
+}
+
+func NewSQLiteJournalRepo(ctx context.Context, db *sql.DB, keyring *encryption.Keyring, logger *zap.Logger) (*SQLiteJournalRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite journal repo, db is nil")
+	}
+	if keyring == nil {
+		return nil, errors.New("failed to initialize sqlite journal repo, keyring is nil")
+	}
+	return &SQLiteJournalRepository{db: db, keyring: keyring, logger: logger}, nil
+}
+
+const journalEntryColumns = `id, user_id, title, body, tags, metric_id, key_id, data_key, created_at, updated_at`
+
+func (s *SQLiteJournalRepository) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row, err := s.toSQLiteJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	err = withinTx(ctx, s.db, func(ctx context.Context) error {
+		tx := conn(ctx, s.db)
+		_, err := tx.ExecContext(ctx, `INSERT INTO journal_entries (`+journalEntryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			row.ID, row.UserId, row.Title, row.Body, row.Tags, row.MetricId, row.KeyId, row.DataKey, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return insertJournalSearchTerms(ctx, tx, row.ID, row.SearchTerms)
+	})
+	if err != nil {
+		s.logger.Error("failed to persist journal entry", zap.Error(err))
+		return fmt.Errorf("failed to persist journal entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteJournalRepository) GetJournalEntryById(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := sqliteJournalEntry{}
+	err := scanJournalEntry(conn(ctx, s.db).QueryRowContext(ctx, `SELECT `+journalEntryColumns+` FROM journal_entries WHERE id = ?`, entryId.Hex()), &row)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find journal entry by id", zap.Error(err))
+		}
+		return domain.JournalEntry{}, infra.ErrJournalEntryNotFound
+	}
+	return s.toDomainJournalEntry(row)
+}
+
+func (s *SQLiteJournalRepository) GetJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID, journalFilter infra.JournalFilter) (infra.JournalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	conditions := []string{"user_id = ?"}
+	args := []any{userId.Hex()}
+	if !journalFilter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, toMillis(journalFilter.From))
+	}
+	if !journalFilter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, toMillis(journalFilter.To))
+	}
+	if journalFilter.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(journal_entries.tags) WHERE json_each.value = ?)")
+		args = append(args, journalFilter.Tag)
+	}
+	if terms := domain.SearchTerms(journalFilter.Search); len(terms) > 0 {
+		conditions = append(conditions, `id IN (SELECT entry_id FROM journal_search_terms WHERE term IN (?`+strings.Repeat(", ?", len(terms)-1)+`)
+			GROUP BY entry_id HAVING count(*) = ?)`)
+		for _, term := range s.keyring.BlindIndexes(terms) {
+			args = append(args, term)
+		}
+		args = append(args, len(terms))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	err := conn(ctx, s.db).QueryRowContext(ctx, `SELECT count(*) FROM journal_entries WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		s.logger.Error("failed to count journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+
+	if journalFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(journalFilter.Cursor)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		createdAt := toMillis(cursor.CreatedAt)
+		args = append(args, createdAt, createdAt, cursor.ID.Hex())
+		where += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+	}
+
+	query := `SELECT ` + journalEntryColumns + ` FROM journal_entries WHERE ` + where + ` ORDER BY created_at DESC, id DESC`
+	if journalFilter.Limit > 0 {
+		args = append(args, journalFilter.Limit+1)
+		query += " LIMIT ?"
+	}
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("failed retrieve journal entries by user id", zap.Error(err))
+		return infra.JournalPage{}, err
+	}
+	defer rows.Close()
+
+	entries := []domain.JournalEntry{}
+	for rows.Next() {
+		row := sqliteJournalEntry{}
+		if err := scanJournalEntry(rows, &row); err != nil {
+			return infra.JournalPage{}, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		entry, err := s.toDomainJournalEntry(row)
+		if err != nil {
+			return infra.JournalPage{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return infra.JournalPage{}, err
+	}
+
+	page := infra.JournalPage{Entries: entries, Limit: journalFilter.Limit, Total: total}
+	if journalFilter.Limit > 0 && len(entries) > journalFilter.Limit {
+		page.Entries = entries[:journalFilter.Limit]
+		last := page.Entries[len(page.Entries)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func (s *SQLiteJournalRepository) UpdateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row, err := s.toSQLiteJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	found := false
+	err = withinTx(ctx, s.db, func(ctx context.Context) error {
+		tx := conn(ctx, s.db)
+		result, err := tx.ExecContext(ctx, `UPDATE journal_entries SET
+			user_id = ?, title = ?, body = ?, tags = ?, metric_id = ?, key_id = ?, data_key = ?, created_at = ?, updated_at = ?
+			WHERE id = ?`,
+			row.UserId, row.Title, row.Body, row.Tags, row.MetricId, row.KeyId, row.DataKey, row.CreatedAt, row.UpdatedAt, row.ID,
+		)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil || updated == 0 {
+			return err
+		}
+		found = true
+		if _, err := tx.ExecContext(ctx, `DELETE FROM journal_search_terms WHERE entry_id = ?`, row.ID); err != nil {
+			return err
+		}
+		return insertJournalSearchTerms(ctx, tx, row.ID, row.SearchTerms)
+	})
+	if err != nil {
+		s.logger.Error("failed to update journal entry", zap.Error(err))
+		return fmt.Errorf("failed to update journal entry: %w", err)
+	}
+	if !found {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteJournalRepository) DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM journal_entries WHERE id = ?`, entryId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete journal entry", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteJournalRepository) DeleteJournalEntriesByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM journal_entries WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete journal entries by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete journal entries by user id: %w", err)
+	}
+	return nil
+}
+
+func insertJournalSearchTerms(ctx context.Context, tx querier, entryId string, terms []string) error {
+	for _, term := range terms {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO journal_search_terms (entry_id, term) VALUES (?, ?)`, entryId, term); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanJournalEntry(row rowScanner, entry *sqliteJournalEntry) error {
+	return row.Scan(&entry.ID, &entry.UserId, &entry.Title, &entry.Body, &entry.Tags, &entry.MetricId, &entry.KeyId, &entry.DataKey, &entry.CreatedAt, &entry.UpdatedAt)
+}
+
+type sqliteJournalEntry struct {
+	ID          string
+	UserId      string
+	Title       string
+	Body        []byte
+	Tags        string
+	MetricId    sql.NullString
+	KeyId       string
+	DataKey     []byte
+	SearchTerms []string
+	CreatedAt   int64
+	UpdatedAt   int64
+}
+
+func (s *SQLiteJournalRepository) toSQLiteJournalEntry(entry domain.JournalEntry) (sqliteJournalEntry, error) {
+	dataKey, err := s.keyring.NewDataKey()
+	if err != nil {
+		return sqliteJournalEntry{}, err
+	}
+	body, err := dataKey.Encrypt(entry.Body, entry.ID[:])
+	if err != nil {
+		return sqliteJournalEntry{}, fmt.Errorf("failed to encrypt journal entry: %w", err)
+	}
+	tags := entry.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	encodedTags, _ := json.Marshal(tags)
+	row := sqliteJournalEntry{
+		ID:          entry.ID.Hex(),
+		UserId:      entry.UserId.Hex(),
+		Title:       entry.Title,
+		Body:        body,
+		KeyId:       dataKey.KeyId,
+		DataKey:     dataKey.Wrapped,
+		Tags:        string(encodedTags),
+		SearchTerms: s.keyring.BlindIndexes(entry.SearchTerms()),
+		CreatedAt:   toMillis(entry.CreatedAt),
+		UpdatedAt:   toMillis(entry.UpdatedAt),
+	}
+	if entry.HasMetric() {
+		row.MetricId = sql.NullString{String: entry.MetricId.Hex(), Valid: true}
+	}
+	return row, nil
+}
+
+func (s *SQLiteJournalRepository) toDomainJournalEntry(row sqliteJournalEntry) (domain.JournalEntry, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	dataKey, err := s.keyring.OpenDataKey(row.KeyId, row.DataKey)
+	if err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to open the data key of journal entry %s: %w", row.ID, err)
+	}
+	body, err := dataKey.Decrypt(row.Body, id[:])
+	if err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to decrypt journal entry %s: %w", row.ID, err)
+	}
+	tags := []string{}
+	if err := json.Unmarshal([]byte(row.Tags), &tags); err != nil {
+		return domain.JournalEntry{}, fmt.Errorf("failed to decode journal entry tags: %w", err)
+	}
+	entry := domain.JournalEntry{
+		ID:        id,
+		UserId:    userId,
+		Title:     row.Title,
+		Body:      body,
+		Tags:      tags,
+		CreatedAt: fromMillis(row.CreatedAt),
+		UpdatedAt: fromMillis(row.UpdatedAt),
+	}
+	if row.MetricId.Valid {
+		metricId, err := toObjectID(row.MetricId.String)
+		if err != nil {
+			return domain.JournalEntry{}, err
+		}
+		entry.MetricId = metricId
+	}
+	return entry, nil
+}
@@ -0,0 +1,351 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteMetricRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteMetricRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteMetricRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite metric repo, db is nil")
+	}
+	return &SQLiteMetricRepository{db: db, logger: logger}, nil
+}
+
+const metricColumns = `id, owner_id, stress_level, mood, sleep_quality, feeling, feeling_polarity, themes, safety_flags, stress_less_score, local_date, created_at, updated_at`
+
+func (s *SQLiteMetricRepository) CreateMetric(ctx context.Context, metric domain.Metric) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteMetric(metric)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO metrics (`+metricColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.ID, row.OwnerId, row.StressLevel, row.Mood, row.SleepQuality, row.Feeling, row.FeelingPolarity, row.Themes, row.SafetyFlags, row.StressLessScore, row.LocalDate, row.CreatedAt, row.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrDailyLogExists
+	}
+	if err != nil {
+		s.logger.Error("failed to persist metric", zap.Error(err))
+		return fmt.Errorf("failed to persist metric: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteMetricRepository) UpdateMetricById(ctx context.Context, metric domain.Metric) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteMetric(metric)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `UPDATE metrics SET
+		owner_id = ?, stress_level = ?, mood = ?, sleep_quality = ?, feeling = ?, feeling_polarity = ?, themes = ?,
+		safety_flags = ?, stress_less_score = ?, local_date = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		row.OwnerId, row.StressLevel, row.Mood, row.SleepQuality, row.Feeling, row.FeelingPolarity, row.Themes, row.SafetyFlags, row.StressLessScore, row.LocalDate, row.CreatedAt, row.UpdatedAt,
+		row.ID,
+	)
+	if err != nil {
+		s.logger.Error("failed to update metric", zap.Error(err))
+		return fmt.Errorf("failed to update metric: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteMetricRepository) GetMetricById(ctx context.Context, metricId primitive.ObjectID) (domain.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	metric, err := scanMetric(conn(ctx, s.db).QueryRowContext(ctx, `SELECT `+metricColumns+` FROM metrics WHERE id = ?`, metricId.Hex()))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find metric by id", zap.Error(err))
+		}
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metric, nil
+}
+
+func (s *SQLiteMetricRepository) DeleteMetricById(ctx context.Context, metricId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM metrics WHERE id = ?`, metricId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete metric", zap.Error(err))
+		return fmt.Errorf("failed to delete metric: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrMetricNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteMetricRepository) DeleteMetricsByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM metrics WHERE owner_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete metrics by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete metrics by user id: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteMetricRepository) GetMetricDays(ctx context.Context, userId primitive.ObjectID) ([]infra.MetricDay, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT id, COALESCE(local_date, ''), created_at FROM metrics WHERE owner_id = ? ORDER BY created_at, id`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to find metric days", zap.Error(err))
+		return nil, fmt.Errorf("failed to find metric days: %w", err)
+	}
+	defer rows.Close()
+
+	days := []infra.MetricDay{}
+	for rows.Next() {
+		var id, localDate string
+		var createdAt int64
+		if err := rows.Scan(&id, &localDate, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan metric day: %w", err)
+		}
+		metricId, err := toObjectID(id)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, infra.MetricDay{MetricId: metricId, LocalDate: localDate, CreatedAt: fromMillis(createdAt)})
+	}
+	return days, rows.Err()
+}
+
+func (s *SQLiteMetricRepository) GetUserTodayLogIfExists(ctx context.Context, userId primitive.ObjectID, location *time.Location) (domain.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	startTime, endTime := domain.DayBounds(time.Now(), location)
+
+	metric, err := scanMetric(conn(ctx, s.db).QueryRowContext(ctx,
+		`SELECT `+metricColumns+` FROM metrics WHERE owner_id = ? AND created_at >= ? AND created_at < ? LIMIT 1`,
+		userId.Hex(), toMillis(startTime), toMillis(endTime),
+	))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find metric for today", zap.Error(err))
+		}
+		return domain.Metric{}, infra.ErrMetricNotFound
+	}
+	return metric, nil
+}
+
+func (s *SQLiteMetricRepository) GetMetricsByUserId(ctx context.Context, userId primitive.ObjectID, metricFilter infra.MetricFilter) (infra.MetricPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	where, args := metricRangeFilter(userId, metricFilter.From, metricFilter.To)
+
+	var total int64
+	err := conn(ctx, s.db).QueryRowContext(ctx, `SELECT count(*) FROM metrics WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		s.logger.Error("failed to count metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
+
+	if metricFilter.Cursor != "" {
+		cursor, err := infra.DecodeMetricCursor(metricFilter.Cursor)
+		if err != nil {
+			return infra.MetricPage{}, err
+		}
+		createdAt := toMillis(cursor.CreatedAt)
+		args = append(args, createdAt, createdAt, cursor.ID.Hex())
+		where += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+	}
+
+	query := `SELECT ` + metricColumns + ` FROM metrics WHERE ` + where + ` ORDER BY created_at DESC, id DESC`
+	if metricFilter.Limit > 0 {
+		args = append(args, metricFilter.Limit+1)
+		query += " LIMIT ?"
+	}
+
+	metrics, err := s.queryMetrics(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("failed retrieve metrics by user id", zap.Error(err))
+		return infra.MetricPage{}, err
+	}
+
+	page := infra.MetricPage{Metrics: metrics, Limit: metricFilter.Limit, Total: total}
+	if metricFilter.Limit > 0 && len(metrics) > metricFilter.Limit {
+		page.Metrics = metrics[:metricFilter.Limit]
+		last := page.Metrics[len(page.Metrics)-1]
+		page.NextCursor = infra.EncodeMetricCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// GetMetricSummary loads the metrics in range and buckets them in Go, sqlite
+// has no time zone support to group by local day in SQL.
+func (s *SQLiteMetricRepository) GetMetricSummary(ctx context.Context, userId primitive.ObjectID, summaryFilter infra.MetricSummaryFilter) ([]domain.MetricSummaryBucket, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	if !summaryFilter.Granularity.IsValid() {
+		return nil, fmt.Errorf("unsupported summary granularity %q", summaryFilter.Granularity)
+	}
+	location := summaryFilter.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	where, args := metricRangeFilter(userId, summaryFilter.From, summaryFilter.To)
+	metrics, err := s.queryMetrics(ctx, `SELECT `+metricColumns+` FROM metrics WHERE `+where, args...)
+	if err != nil {
+		s.logger.Error("failed to aggregate metric summary", zap.Error(err))
+		return nil, err
+	}
+	return domain.SummarizeMetrics(metrics, summaryFilter.Granularity, location)
+}
+
+func (s *SQLiteMetricRepository) queryMetrics(ctx context.Context, query string, args ...any) ([]domain.Metric, error) {
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []domain.Metric{}
+	for rows.Next() {
+		metric, err := scanMetric(rows)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
+}
+
+// metricRangeFilter builds the where clause shared by the metric listings,
+// From is inclusive and To exclusive.
+func metricRangeFilter(userId primitive.ObjectID, from, to time.Time) (string, []any) {
+	conditions := []string{"owner_id = ?"}
+	args := []any{userId.Hex()}
+	if !from.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, toMillis(from))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, toMillis(to))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMetric(row rowScanner) (domain.Metric, error) {
+	m := sqliteMetric{}
+	err := row.Scan(&m.ID, &m.OwnerId, &m.StressLevel, &m.Mood, &m.SleepQuality, &m.Feeling, &m.FeelingPolarity, &m.Themes, &m.SafetyFlags, &m.StressLessScore, &m.LocalDate, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	return toDomainMetric(m)
+}
+
+type sqliteMetric struct {
+	ID              string
+	OwnerId         string
+	StressLevel     int
+	Mood            string
+	SleepQuality    string
+	Feeling         string
+	FeelingPolarity float64
+	// Themes and SafetyFlags are JSON arrays.
+	Themes          string
+	SafetyFlags     string
+	StressLessScore int
+	LocalDate       sql.NullString
+	CreatedAt       int64
+	UpdatedAt       int64
+}
+
+func toSQLiteMetric(metric domain.Metric) sqliteMetric {
+	themes := metric.Themes
+	if themes == nil {
+		themes = []domain.Theme{}
+	}
+	encodedThemes, _ := json.Marshal(themes)
+	safetyFlags := metric.SafetyFlags
+	if safetyFlags == nil {
+		safetyFlags = []domain.SafetyFlag{}
+	}
+	encodedSafetyFlags, _ := json.Marshal(safetyFlags)
+	return sqliteMetric{
+		ID:              metric.ID.Hex(),
+		OwnerId:         metric.OwnerId.Hex(),
+		StressLevel:     metric.StressLevel,
+		Mood:            string(metric.Mood),
+		SleepQuality:    string(metric.SleepQuality),
+		Feeling:         metric.Feeling,
+		FeelingPolarity: metric.FeelingPolarity,
+		Themes:          string(encodedThemes),
+		SafetyFlags:     string(encodedSafetyFlags),
+		StressLessScore: metric.StressLessScore,
+		LocalDate:       sql.NullString{String: metric.LocalDate, Valid: metric.LocalDate != ""},
+		CreatedAt:       toMillis(metric.CreatedAt),
+		UpdatedAt:       toMillis(metric.UpdatedAt),
+	}
+}
+
+func toDomainMetric(m sqliteMetric) (domain.Metric, error) {
+	id, err := toObjectID(m.ID)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	ownerId, err := toObjectID(m.OwnerId)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	themes := []domain.Theme{}
+	if err := json.Unmarshal([]byte(m.Themes), &themes); err != nil {
+		return domain.Metric{}, fmt.Errorf("invalid themes of metric %s: %w", m.ID, err)
+	}
+	safetyFlags := []domain.SafetyFlag{}
+	if err := json.Unmarshal([]byte(m.SafetyFlags), &safetyFlags); err != nil {
+		return domain.Metric{}, fmt.Errorf("invalid safety flags of metric %s: %w", m.ID, err)
+	}
+	return domain.Metric{
+		ID:              id,
+		OwnerId:         ownerId,
+		StressLevel:     m.StressLevel,
+		Mood:            domain.Mood(m.Mood),
+		SleepQuality:    domain.SleepQuality(m.SleepQuality),
+		Feeling:         m.Feeling,
+		FeelingPolarity: m.FeelingPolarity,
+		Themes:          themes,
+		SafetyFlags:     safetyFlags,
+		StressLessScore: m.StressLessScore,
+		LocalDate:       m.LocalDate.String,
+		CreatedAt:       fromMillis(m.CreatedAt),
+		UpdatedAt:       fromMillis(m.UpdatedAt),
+	}, nil
+}
@@ -0,0 +1,272 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteRecommendationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteRecommendationRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteRecommendationRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite recommendation repo, db is nil")
+	}
+	return &SQLiteRecommendationRepository{db: db, logger: logger}, nil
+}
+
+const recommendationColumns = `id, metric_id, metric_type, status, created_at, updated_at`
+
+func (s *SQLiteRecommendationRepository) CreateRecommendation(ctx context.Context, recommendation domain.Recommendation) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	err := withinTx(ctx, s.db, func(ctx context.Context) error {
+		tx := conn(ctx, s.db)
+		row := toSQLiteRecommendation(recommendation)
+		_, err := tx.ExecContext(ctx, `INSERT INTO recommendations (`+recommendationColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+			row.ID, row.MetricId, row.MetricType, row.Status, row.CreatedAt, row.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return insertRecommendationItems(ctx, tx, row.ID, recommendation.Items)
+	})
+	if err != nil {
+		s.logger.Error("failed to persist recommendation", zap.Error(err))
+		return fmt.Errorf("failed to persist recommendation: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteRecommendationRepository) UpdateRecommendationById(ctx context.Context, recommendation domain.Recommendation) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	err := withinTx(ctx, s.db, func(ctx context.Context) error {
+		tx := conn(ctx, s.db)
+		row := toSQLiteRecommendation(recommendation)
+		result, err := tx.ExecContext(ctx, `UPDATE recommendations SET
+			metric_id = ?, metric_type = ?, status = ?, created_at = ?, updated_at = ?
+			WHERE id = ?`,
+			row.MetricId, row.MetricType, row.Status, row.CreatedAt, row.UpdatedAt, row.ID,
+		)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil || updated == 0 {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recommendation_items WHERE recommendation_id = ?`, row.ID); err != nil {
+			return err
+		}
+		return insertRecommendationItems(ctx, tx, row.ID, recommendation.Items)
+	})
+	if err != nil {
+		s.logger.Error("failed to update recommendation", zap.Error(err))
+		return fmt.Errorf("failed to update recommendation: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteRecommendationRepository) GetRecommendationById(ctx context.Context, recommendationId primitive.ObjectID) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, `SELECT `+recommendationColumns+` FROM recommendations WHERE id = ?`, recommendationId.Hex())
+}
+
+func (s *SQLiteRecommendationRepository) GetRecommendationByMetricId(ctx context.Context, metricId primitive.ObjectID, metricType string) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, `SELECT `+recommendationColumns+` FROM recommendations WHERE metric_id = ? AND metric_type = ? LIMIT 1`, metricId.Hex(), metricType)
+}
+
+func (s *SQLiteRecommendationRepository) GetRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) ([]domain.Recommendation, error) {
+	if len(metricIds) == 0 {
+		return []domain.Recommendation{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	placeholders, args := inPlaceholders(metricIds)
+	recommendations, err := s.listRecommendations(ctx,
+		`SELECT `+recommendationColumns+` FROM recommendations WHERE metric_id IN (`+placeholders+`) ORDER BY created_at DESC, id`,
+		args...,
+	)
+	if err != nil {
+		s.logger.Error("failed to find recommendations by metric ids", zap.Error(err))
+		return nil, fmt.Errorf("failed to find recommendations by metric ids: %w", err)
+	}
+	return recommendations, nil
+}
+
+func (s *SQLiteRecommendationRepository) GetPendingRecommendations(ctx context.Context, limit int) ([]domain.Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	recommendations, err := s.listRecommendations(ctx,
+		`SELECT `+recommendationColumns+` FROM recommendations WHERE status = ? ORDER BY created_at, id LIMIT ?`,
+		string(domain.RecommendationPending), limit,
+	)
+	if err != nil {
+		s.logger.Error("failed to find pending recommendations", zap.Error(err))
+		return nil, fmt.Errorf("failed to find pending recommendations: %w", err)
+	}
+	return recommendations, nil
+}
+
+func (s *SQLiteRecommendationRepository) DeleteRecommendationsByMetricId(ctx context.Context, metricId primitive.ObjectID) error {
+	return s.DeleteRecommendationsByMetricIds(ctx, []primitive.ObjectID{metricId})
+}
+
+func (s *SQLiteRecommendationRepository) DeleteRecommendationsByMetricIds(ctx context.Context, metricIds []primitive.ObjectID) error {
+	if len(metricIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	placeholders, args := inPlaceholders(metricIds)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM recommendations WHERE metric_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		s.logger.Error("failed to delete recommendations by metric ids", zap.Error(err))
+		return fmt.Errorf("failed to delete recommendations by metric ids: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteRecommendationRepository) getRecommendation(ctx context.Context, query string, args ...any) (domain.Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	recommendations, err := s.listRecommendations(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("failed to find recommendation", zap.Error(err))
+		return domain.Recommendation{}, infra.ErrRecommendationNotFound
+	}
+	if len(recommendations) == 0 {
+		return domain.Recommendation{}, infra.ErrRecommendationNotFound
+	}
+	return recommendations[0], nil
+}
+
+// listRecommendations runs a query selecting recommendationColumns and loads
+// the items of every row with one more query.
+func (s *SQLiteRecommendationRepository) listRecommendations(ctx context.Context, query string, args ...any) ([]domain.Recommendation, error) {
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := []domain.Recommendation{}
+	byId := map[string]int{}
+	for rows.Next() {
+		row := sqliteRecommendation{}
+		if err := rows.Scan(&row.ID, &row.MetricId, &row.MetricType, &row.Status, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		recommendation, err := toDomainRecommendation(row)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		byId[row.ID] = len(recommendations)
+		recommendations = append(recommendations, recommendation)
+	}
+	// the pool holds a single connection, and a transaction always does, the
+	// rows have to be released before the items can be queried.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.ID)
+	}
+	placeholders, itemArgs := inPlaceholders(ids)
+	itemRows, err := conn(ctx, s.db).QueryContext(ctx,
+		`SELECT recommendation_id, position, heading, text, image_url FROM recommendation_items
+		WHERE recommendation_id IN (`+placeholders+`) ORDER BY recommendation_id, position`,
+		itemArgs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var recommendationId string
+		item := domain.RecommendationItem{}
+		if err := itemRows.Scan(&recommendationId, &item.Index, &item.Heading, &item.Text, &item.ImageUrl); err != nil {
+			return nil, err
+		}
+		i := byId[recommendationId]
+		recommendations[i].Items = append(recommendations[i].Items, item)
+	}
+	return recommendations, itemRows.Err()
+}
+
+func insertRecommendationItems(ctx context.Context, tx querier, recommendationId string, items []domain.RecommendationItem) error {
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, `INSERT INTO recommendation_items (recommendation_id, position, heading, text, image_url) VALUES (?, ?, ?, ?, ?)`,
+			recommendationId, item.Index, item.Heading, item.Text, item.ImageUrl,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sqliteRecommendation struct {
+	ID         string
+	MetricId   string
+	MetricType string
+	Status     string
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+func toSQLiteRecommendation(recommendation domain.Recommendation) sqliteRecommendation {
+	return sqliteRecommendation{
+		ID:         recommendation.ID.Hex(),
+		MetricId:   recommendation.MetricId.Hex(),
+		MetricType: recommendation.MetricType,
+		Status:     string(recommendation.Status),
+		CreatedAt:  toMillis(recommendation.CreatedAt),
+		UpdatedAt:  toMillis(recommendation.UpdatedAt),
+	}
+}
+
+func toDomainRecommendation(row sqliteRecommendation) (domain.Recommendation, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	metricId, err := toObjectID(row.MetricId)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return domain.Recommendation{
+		ID:         id,
+		MetricId:   metricId,
+		MetricType: row.MetricType,
+		Items:      []domain.RecommendationItem{},
+		Status:     domain.RecommendationStatus(row.Status),
+		CreatedAt:  fromMillis(row.CreatedAt),
+		UpdatedAt:  fromMillis(row.UpdatedAt),
+	}, nil
+}
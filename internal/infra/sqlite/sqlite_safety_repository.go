@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteSafetyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteSafetyRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteSafetyRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite safety repo, db is nil")
+	}
+	return &SQLiteSafetyRepository{db: db, logger: logger}, nil
+}
+
+const (
+	safetyEventColumns    = `id, user_id, kind, metric_id, flags, escalated, country, actor_id, created_at`
+	safetyOverrideColumns = `user_id, low_mood_days, suppress_escalation_until, clinician_id, updated_at`
+)
+
+func (s *SQLiteSafetyRepository) CreateSafetyEvent(ctx context.Context, event domain.SafetyEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteSafetyEvent(event)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO safety_events (`+safetyEventColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.ID, row.UserId, row.Kind, row.MetricId, row.Flags, row.Escalated, row.Country, row.ActorId, row.CreatedAt,
+	)
+	if err != nil {
+		s.logger.Error("failed to persist safety event", zap.Error(err))
+		return fmt.Errorf("failed to persist safety event: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteSafetyRepository) GetSafetyEventsByUserId(ctx context.Context, userId primitive.ObjectID, limit int) ([]domain.SafetyEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	query := `SELECT ` + safetyEventColumns + ` FROM safety_events WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+	args := []any{userId.Hex()}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("failed to find safety events by user id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find safety events by user id: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.SafetyEvent{}
+	for rows.Next() {
+		row := sqliteSafetyEvent{}
+		err := rows.Scan(&row.ID, &row.UserId, &row.Kind, &row.MetricId, &row.Flags, &row.Escalated, &row.Country, &row.ActorId, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan safety event: %w", err)
+		}
+		event, err := toDomainSafetyEvent(row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLiteSafetyRepository) SaveSafetyOverride(ctx context.Context, override domain.SafetyOverride) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteSafetyOverride(override)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO safety_overrides (`+safetyOverrideColumns+`) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+		low_mood_days = excluded.low_mood_days, suppress_escalation_until = excluded.suppress_escalation_until,
+		clinician_id = excluded.clinician_id, updated_at = excluded.updated_at`,
+		row.UserId, row.LowMoodDays, row.SuppressEscalationUntil, row.ClinicianId, row.UpdatedAt,
+	)
+	if err != nil {
+		s.logger.Error("failed to save safety override", zap.Error(err))
+		return fmt.Errorf("failed to save safety override: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteSafetyRepository) GetSafetyOverride(ctx context.Context, userId primitive.ObjectID) (domain.SafetyOverride, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := sqliteSafetyOverride{}
+	err := conn(ctx, s.db).QueryRowContext(ctx, `SELECT `+safetyOverrideColumns+` FROM safety_overrides WHERE user_id = ?`, userId.Hex()).
+		Scan(&row.UserId, &row.LowMoodDays, &row.SuppressEscalationUntil, &row.ClinicianId, &row.UpdatedAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find safety override", zap.Error(err))
+			return domain.SafetyOverride{}, fmt.Errorf("failed to find safety override: %w", err)
+		}
+		return domain.SafetyOverride{}, infra.ErrSafetyOverrideNotFound
+	}
+	return toDomainSafetyOverride(row)
+}
+
+func (s *SQLiteSafetyRepository) DeleteSafetyOverride(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM safety_overrides WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete safety override", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return infra.ErrSafetyOverrideNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteSafetyRepository) DeleteSafetyDataByUserId(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM safety_overrides WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete safety override by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety override by user id: %w", err)
+	}
+	_, err = conn(ctx, s.db).ExecContext(ctx, `DELETE FROM safety_events WHERE user_id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete safety events by user id", zap.Error(err))
+		return fmt.Errorf("failed to delete safety events by user id: %w", err)
+	}
+	return nil
+}
+
+type sqliteSafetyEvent struct {
+	ID       string
+	UserId   string
+	Kind     string
+	MetricId sql.NullString
+	// Flags is a JSON array.
+	Flags     string
+	Escalated bool
+	Country   string
+	ActorId   sql.NullString
+	CreatedAt int64
+}
+
+func toSQLiteSafetyEvent(event domain.SafetyEvent) sqliteSafetyEvent {
+	flags := event.Flags
+	if flags == nil {
+		flags = []domain.SafetyFlag{}
+	}
+	encodedFlags, _ := json.Marshal(flags)
+	return sqliteSafetyEvent{
+		ID:        event.ID.Hex(),
+		UserId:    event.UserId.Hex(),
+		Kind:      string(event.Kind),
+		MetricId:  nullObjectID(event.MetricId),
+		Flags:     string(encodedFlags),
+		Escalated: event.Escalated,
+		Country:   event.Country,
+		ActorId:   nullObjectID(event.ActorId),
+		CreatedAt: toMillis(event.CreatedAt),
+	}
+}
+
+func toDomainSafetyEvent(row sqliteSafetyEvent) (domain.SafetyEvent, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	metricId, err := fromNullObjectID(row.MetricId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	actorId, err := fromNullObjectID(row.ActorId)
+	if err != nil {
+		return domain.SafetyEvent{}, err
+	}
+	flags := []domain.SafetyFlag{}
+	if err := json.Unmarshal([]byte(row.Flags), &flags); err != nil {
+		return domain.SafetyEvent{}, fmt.Errorf("invalid flags of safety event %s: %w", row.ID, err)
+	}
+	return domain.SafetyEvent{
+		ID:        id,
+		UserId:    userId,
+		Kind:      domain.SafetyEventKind(row.Kind),
+		MetricId:  metricId,
+		Flags:     flags,
+		Escalated: row.Escalated,
+		Country:   row.Country,
+		ActorId:   actorId,
+		CreatedAt: fromMillis(row.CreatedAt),
+	}, nil
+}
+
+type sqliteSafetyOverride struct {
+	UserId                  string
+	LowMoodDays             int
+	SuppressEscalationUntil sql.NullInt64
+	ClinicianId             string
+	UpdatedAt               int64
+}
+
+func toSQLiteSafetyOverride(override domain.SafetyOverride) sqliteSafetyOverride {
+	return sqliteSafetyOverride{
+		UserId:                  override.UserId.Hex(),
+		LowMoodDays:             override.LowMoodDays,
+		SuppressEscalationUntil: sql.NullInt64{Int64: toMillis(override.SuppressEscalationUntil), Valid: !override.SuppressEscalationUntil.IsZero()},
+		ClinicianId:             override.ClinicianId.Hex(),
+		UpdatedAt:               toMillis(override.UpdatedAt),
+	}
+}
+
+func toDomainSafetyOverride(row sqliteSafetyOverride) (domain.SafetyOverride, error) {
+	userId, err := toObjectID(row.UserId)
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+	clinicianId, err := toObjectID(row.ClinicianId)
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+	override := domain.SafetyOverride{
+		UserId:      userId,
+		LowMoodDays: row.LowMoodDays,
+		ClinicianId: clinicianId,
+		UpdatedAt:   fromMillis(row.UpdatedAt),
+	}
+	if row.SuppressEscalationUntil.Valid {
+		override.SuppressEscalationUntil = fromMillis(row.SuppressEscalationUntil.Int64)
+	}
+	return override, nil
+}
+
+// nullObjectID stores a zero id as NULL.
+func nullObjectID(id primitive.ObjectID) sql.NullString {
+	return sql.NullString{String: id.Hex(), Valid: !id.IsZero()}
+}
+
+func fromNullObjectID(id sql.NullString) (primitive.ObjectID, error) {
+	if !id.Valid {
+		return primitive.NilObjectID, nil
+	}
+	return toObjectID(id.String)
+}
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteShareRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteShareRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteShareRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite share repo, db is nil")
+	}
+	return &SQLiteShareRepository{db: db, logger: logger}, nil
+}
+
+const shareColumns = `id, owner_id, label, code_hash, scopes, expires_at, accepted_at, accepted_by, revoked_at, created_at, updated_at`
+
+func (s *SQLiteShareRepository) CreateShare(ctx context.Context, share domain.Share) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteShare(share)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO shares (`+shareColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.ID, row.OwnerId, row.Label, row.CodeHash, row.Scopes, row.ExpiresAt, row.AcceptedAt, row.AcceptedBy, row.RevokedAt, row.CreatedAt, row.UpdatedAt,
+	)
+	if err != nil {
+		s.logger.Error("failed to persist share", zap.Error(err))
+		return fmt.Errorf("failed to persist share: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteShareRepository) GetShareById(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error) {
+	return s.getShare(ctx, `id = ?`, shareId.Hex())
+}
+
+func (s *SQLiteShareRepository) GetShareByCodeHash(ctx context.Context, codeHash string) (domain.Share, error) {
+	return s.getShare(ctx, `code_hash = ?`, codeHash)
+}
+
+func (s *SQLiteShareRepository) getShare(ctx context.Context, where string, arg any) (domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := sqliteShare{}
+	err := scanShare(conn(ctx, s.db).QueryRowContext(ctx, `SELECT `+shareColumns+` FROM shares WHERE `+where, arg), &row)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to find share", zap.Error(err))
+			return domain.Share{}, fmt.Errorf("failed to find share: %w", err)
+		}
+		return domain.Share{}, infra.ErrShareNotFound
+	}
+	return toDomainShare(row)
+}
+
+func (s *SQLiteShareRepository) GetSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) ([]domain.Share, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+shareColumns+` FROM shares WHERE owner_id = ? ORDER BY created_at DESC, id DESC`, ownerId.Hex())
+	if err != nil {
+		s.logger.Error("failed to find shares by owner id", zap.Error(err))
+		return nil, fmt.Errorf("failed to find shares by owner id: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []domain.Share{}
+	for rows.Next() {
+		row := sqliteShare{}
+		if err := scanShare(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		share, err := toDomainShare(row)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *SQLiteShareRepository) AcceptShare(ctx context.Context, shareId, acceptedBy primitive.ObjectID, acceptedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, `UPDATE shares SET accepted_at = ?, accepted_by = ?, updated_at = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL`,
+		toMillis(acceptedAt), nullObjectID(acceptedBy), toMillis(acceptedAt), shareId.Hex(),
+	)
+	if err != nil {
+		s.logger.Error("failed to accept share", zap.Error(err))
+		return fmt.Errorf("failed to accept share: %w", err)
+	}
+	accepted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if accepted == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteShareRepository) RevokeShare(ctx context.Context, shareId primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, `UPDATE shares SET
+		updated_at = CASE WHEN revoked_at IS NULL THEN ? ELSE updated_at END, revoked_at = COALESCE(revoked_at, ?)
+		WHERE id = ?`,
+		toMillis(revokedAt), toMillis(revokedAt), shareId.Hex(),
+	)
+	if err != nil {
+		s.logger.Error("failed to revoke share", zap.Error(err))
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return infra.ErrShareNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteShareRepository) DeleteSharesByOwnerId(ctx context.Context, ownerId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM shares WHERE owner_id = ?`, ownerId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete shares by owner id", zap.Error(err))
+		return fmt.Errorf("failed to delete shares by owner id: %w", err)
+	}
+	return nil
+}
+
+type sqliteShare struct {
+	ID       string
+	OwnerId  string
+	Label    string
+	CodeHash string
+	// Scopes is a JSON array.
+	Scopes     string
+	ExpiresAt  int64
+	AcceptedAt sql.NullInt64
+	AcceptedBy sql.NullString
+	RevokedAt  sql.NullInt64
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+func scanShare(row rowScanner, share *sqliteShare) error {
+	return row.Scan(&share.ID, &share.OwnerId, &share.Label, &share.CodeHash, &share.Scopes, &share.ExpiresAt,
+		&share.AcceptedAt, &share.AcceptedBy, &share.RevokedAt, &share.CreatedAt, &share.UpdatedAt)
+}
+
+func toSQLiteShare(share domain.Share) sqliteShare {
+	scopes := share.Scopes
+	if scopes == nil {
+		scopes = []domain.ShareScope{}
+	}
+	encodedScopes, _ := json.Marshal(scopes)
+	return sqliteShare{
+		ID:         share.ID.Hex(),
+		OwnerId:    share.OwnerId.Hex(),
+		Label:      share.Label,
+		CodeHash:   share.CodeHash,
+		Scopes:     string(encodedScopes),
+		ExpiresAt:  toMillis(share.ExpiresAt),
+		AcceptedAt: sql.NullInt64{Int64: toMillis(share.AcceptedAt), Valid: !share.AcceptedAt.IsZero()},
+		AcceptedBy: nullObjectID(share.AcceptedBy),
+		RevokedAt:  sql.NullInt64{Int64: toMillis(share.RevokedAt), Valid: !share.RevokedAt.IsZero()},
+		CreatedAt:  toMillis(share.CreatedAt),
+		UpdatedAt:  toMillis(share.UpdatedAt),
+	}
+}
+
+func toDomainShare(row sqliteShare) (domain.Share, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	ownerId, err := toObjectID(row.OwnerId)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	acceptedBy, err := fromNullObjectID(row.AcceptedBy)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	scopes := []domain.ShareScope{}
+	if err := json.Unmarshal([]byte(row.Scopes), &scopes); err != nil {
+		return domain.Share{}, fmt.Errorf("invalid scopes of share %s: %w", row.ID, err)
+	}
+	share := domain.Share{
+		ID:         id,
+		OwnerId:    ownerId,
+		Label:      row.Label,
+		CodeHash:   row.CodeHash,
+		Scopes:     scopes,
+		AcceptedBy: acceptedBy,
+		ExpiresAt:  fromMillis(row.ExpiresAt),
+		CreatedAt:  fromMillis(row.CreatedAt),
+		UpdatedAt:  fromMillis(row.UpdatedAt),
+	}
+	if row.AcceptedAt.Valid {
+		share.AcceptedAt = fromMillis(row.AcceptedAt.Int64)
+	}
+	if row.RevokedAt.Valid {
+		share.RevokedAt = fromMillis(row.RevokedAt.Int64)
+	}
+	return share, nil
+}
@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SQLiteUserRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteUserRepo(ctx context.Context, db *sql.DB, logger *zap.Logger) (*SQLiteUserRepository, error) {
+	if db == nil {
+		return nil, errors.New("failed to initialize sqlite user repo, db is nil")
+	}
+	return &SQLiteUserRepository{db: db, logger: logger}, nil
+}
+
+const userColumns = `id, email, first_name, last_name, password, timezone, is_email_verified, is_onboarding_complete, last_metric_log,
+	reminders_enabled, reminder_time, quiet_hours_start, quiet_hours_end, push_subscriptions, created_at, updated_at`
+
+func (s *SQLiteUserRepository) CreateUser(ctx context.Context, user domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteUser(user)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.ID, row.Email, row.FirstName, row.LastName, row.Password, row.Timezone,
+		row.IsEmailVerified, row.IsOnBoardingComplete, row.LastMetricLog,
+		row.RemindersEnabled, row.ReminderTime, row.QuietHoursStart, row.QuietHoursEnd, row.PushSubscriptions,
+		row.CreatedAt, row.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return infra.ErrDuplicateEmail
+	}
+	if err != nil {
+		s.logger.Error("failed to persist user", zap.Error(err))
+		return fmt.Errorf("failed to persist user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteUserRepository) UpdateUser(ctx context.Context, user domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := toSQLiteUser(user)
+	_, err := conn(ctx, s.db).ExecContext(ctx, `UPDATE users SET
+		email = ?, first_name = ?, last_name = ?, password = ?, timezone = ?,
+		is_email_verified = ?, is_onboarding_complete = ?, last_metric_log = ?,
+		reminders_enabled = ?, reminder_time = ?, quiet_hours_start = ?, quiet_hours_end = ?,
+		push_subscriptions = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		row.Email, row.FirstName, row.LastName, row.Password, row.Timezone,
+		row.IsEmailVerified, row.IsOnBoardingComplete, row.LastMetricLog,
+		row.RemindersEnabled, row.ReminderTime, row.QuietHoursStart, row.QuietHoursEnd, row.PushSubscriptions,
+		row.CreatedAt, row.UpdatedAt,
+		row.ID,
+	)
+	if err != nil {
+		s.logger.Error("failed to update user", zap.Error(err))
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteUserRepository) UpdateUserLastMetricLog(ctx context.Context, user domain.User) error {
+	updatedUser := user
+	updatedUser.LastMetricLog = time.Now()
+	updatedUser.UpdatedAt = time.Now()
+
+	return s.UpdateUser(ctx, updatedUser)
+}
+
+func (s *SQLiteUserRepository) DeleteUser(ctx context.Context, userId primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userId.Hex())
+	if err != nil {
+		s.logger.Error("failed to delete user", zap.Error(err))
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteUserRepository) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	return s.getUser(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email)
+}
+
+func (s *SQLiteUserRepository) GetUserByUserId(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
+	return s.getUser(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, userId.Hex())
+}
+
+func (s *SQLiteUserRepository) GetUsersWithRemindersEnabled(ctx context.Context, afterId primitive.ObjectID, limit int) ([]domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	// the zero ObjectID is all zeros in hex, so it sorts before every id
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+userColumns+` FROM users WHERE reminders_enabled = 1 AND id > ? ORDER BY id LIMIT ?`, afterId.Hex(), limit)
+	if err != nil {
+		s.logger.Error("failed to find users with reminders enabled", zap.Error(err))
+		return nil, fmt.Errorf("failed to find users with reminders enabled: %w", err)
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		row := sqliteUser{}
+		if err := rows.Scan(row.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user, err := toDomainUser(row)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLiteUserRepository) getUser(ctx context.Context, query string, arg any) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextTimeoutDuration)
+	defer cancel()
+
+	row := sqliteUser{}
+	err := conn(ctx, s.db).QueryRowContext(ctx, query, arg).Scan(row.fields()...)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to retrieve user", zap.Error(err))
+		}
+		return domain.User{}, infra.ErrUserNotFound
+	}
+	return toDomainUser(row)
+}
+
+// PushSubscriptions is a JSON array of pushSubscriptionJSON.
+type sqliteUser struct {
+	ID                   string
+	Email                string
+	FirstName            string
+	LastName             string
+	Password             string
+	Timezone             string
+	IsEmailVerified      bool
+	IsOnBoardingComplete bool
+	LastMetricLog        sql.NullInt64
+	RemindersEnabled     bool
+	ReminderTime         string
+	QuietHoursStart      string
+	QuietHoursEnd        string
+	PushSubscriptions    string
+	CreatedAt            int64
+	UpdatedAt            int64
+}
+
+// fields are the scan targets for userColumns, in order.
+func (row *sqliteUser) fields() []any {
+	return []any{
+		&row.ID, &row.Email, &row.FirstName, &row.LastName, &row.Password, &row.Timezone,
+		&row.IsEmailVerified, &row.IsOnBoardingComplete, &row.LastMetricLog,
+		&row.RemindersEnabled, &row.ReminderTime, &row.QuietHoursStart, &row.QuietHoursEnd, &row.PushSubscriptions,
+		&row.CreatedAt, &row.UpdatedAt,
+	}
+}
+
+func toSQLiteUser(user domain.User) sqliteUser {
+	return sqliteUser{
+		ID:                   user.ID.Hex(),
+		Email:                user.Email,
+		FirstName:            user.FirstName,
+		LastName:             user.LastName,
+		Password:             user.Password,
+		Timezone:             user.Timezone,
+		IsEmailVerified:      user.IsEmailVerified,
+		IsOnBoardingComplete: user.IsOnBoardingComplete,
+		LastMetricLog:        sql.NullInt64{Int64: toMillis(user.LastMetricLog), Valid: !user.LastMetricLog.IsZero()},
+		RemindersEnabled:     user.Reminders.Enabled,
+		ReminderTime:         user.Reminders.Time,
+		QuietHoursStart:      user.Reminders.QuietHoursStart,
+		QuietHoursEnd:        user.Reminders.QuietHoursEnd,
+		PushSubscriptions:    encodePushSubscriptions(user.PushSubscriptions),
+		CreatedAt:            toMillis(user.CreatedAt),
+		UpdatedAt:            toMillis(user.UpdatedAt),
+	}
+}
+
+func toDomainUser(row sqliteUser) (domain.User, error) {
+	id, err := toObjectID(row.ID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user := domain.User{
+		ID:                   id,
+		Email:                row.Email,
+		FirstName:            row.FirstName,
+		LastName:             row.LastName,
+		Password:             row.Password,
+		Timezone:             row.Timezone,
+		IsEmailVerified:      row.IsEmailVerified,
+		IsOnBoardingComplete: row.IsOnBoardingComplete,
+		Reminders: domain.ReminderPreferences{
+			Enabled:         row.RemindersEnabled,
+			Time:            row.ReminderTime,
+			QuietHoursStart: row.QuietHoursStart,
+			QuietHoursEnd:   row.QuietHoursEnd,
+		},
+		CreatedAt: fromMillis(row.CreatedAt),
+		UpdatedAt: fromMillis(row.UpdatedAt),
+	}
+	if row.LastMetricLog.Valid {
+		user.LastMetricLog = fromMillis(row.LastMetricLog.Int64)
+	}
+	subscriptions, err := decodePushSubscriptions(row.PushSubscriptions)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user.PushSubscriptions = subscriptions
+	return user, nil
+}
+
+type pushSubscriptionJSON struct {
+	Endpoint  string `json:"endpoint"`
+	P256dh    string `json:"p256dh"`
+	Auth      string `json:"auth"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func encodePushSubscriptions(subscriptions []domain.PushSubscription) string {
+	rows := []pushSubscriptionJSON{}
+	for _, subscription := range subscriptions {
+		rows = append(rows, pushSubscriptionJSON{
+			Endpoint:  subscription.Endpoint,
+			P256dh:    subscription.P256dh,
+			Auth:      subscription.Auth,
+			CreatedAt: subscription.CreatedAt.UnixMilli(),
+		})
+	}
+	encoded, _ := json.Marshal(rows)
+	return string(encoded)
+}
+
+func decodePushSubscriptions(encoded string) ([]domain.PushSubscription, error) {
+	rows := []pushSubscriptionJSON{}
+	if err := json.Unmarshal([]byte(encoded), &rows); err != nil {
+		return nil, fmt.Errorf("invalid push_subscriptions: %w", err)
+	}
+	subscriptions := []domain.PushSubscription{}
+	for _, row := range rows {
+		subscriptions = append(subscriptions, domain.PushSubscription{
+			Endpoint:  row.Endpoint,
+			P256dh:    row.P256dh,
+			Auth:      row.Auth,
+			CreatedAt: time.UnixMilli(row.CreatedAt).UTC(),
+		})
+	}
+	return subscriptions, nil
+}
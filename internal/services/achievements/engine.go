@@ -0,0 +1,120 @@
+package achievements
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// rule reports whether a user earned a badge with the log they just created.
+type rule struct {
+	badge  domain.BadgeId
+	earned func(streak domain.Streak, metric domain.Metric) bool
+}
+
+// rules go by the whole history through the streak where they can, so a badge
+// missed by a failed award is still given with the next log.
+var rules = []rule{
+	{domain.FirstLogBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return streak.TotalDays >= 1
+	}},
+	{domain.ThreeDayStreakBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return streak.Longest >= 3
+	}},
+	{domain.SevenDayStreakBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return streak.Longest >= 7
+	}},
+	{domain.ThirtyDayStreakBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return streak.Longest >= 30
+	}},
+	{domain.ThirtyLogsBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return streak.TotalDays >= 30
+	}},
+	{domain.FirstExcellentSleepBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return metric.SleepQuality == domain.EXCELLENT
+	}},
+	{domain.FirstOverjoyedMoodBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return metric.Mood == domain.OVERJOYED
+	}},
+	{domain.CalmDayBadge, func(streak domain.Streak, metric domain.Metric) bool {
+		return metric.StressLevel == 1
+	}},
+}
+
+// Engine works out streaks from a user's metric history and awards badges.
+type Engine struct {
+	metricRepo      infra.MetricRepository
+	achievementRepo infra.AchievementRepository
+	logger          *zap.Logger
+}
+
+func NewEngine(metricRepo infra.MetricRepository, achievementRepo infra.AchievementRepository, logger *zap.Logger) (*Engine, error) {
+	if metricRepo == nil {
+		return nil, errors.New("failed to initialize achievements engine, metricRepo is nil")
+	}
+	if achievementRepo == nil {
+		return nil, errors.New("failed to initialize achievements engine, achievementRepo is nil")
+	}
+	return &Engine{metricRepo: metricRepo, achievementRepo: achievementRepo, logger: logger}, nil
+}
+
+// Streak is the user's streak as of now, counted in days of their timezone.
+func (e *Engine) Streak(ctx context.Context, user domain.User, now time.Time) (domain.Streak, error) {
+	days, err := e.metricRepo.GetMetricDays(ctx, user.ID)
+	if err != nil {
+		return domain.Streak{}, err
+	}
+	location := user.Location()
+	dates := make([]string, 0, len(days))
+	for _, day := range days {
+		dates = append(dates, day.Date(location))
+	}
+	return domain.CalculateStreak(dates, domain.LocalDate(now, location)), nil
+}
+
+// Award gives the user every badge they earned with metric and do not have
+// yet, and returns the new ones.
+func (e *Engine) Award(ctx context.Context, user domain.User, metric domain.Metric) ([]domain.Achievement, error) {
+	streak, err := e.Streak(ctx, user, metric.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := e.achievementRepo.GetAchievementsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	earned := map[domain.BadgeId]bool{}
+	for _, achievement := range existing {
+		earned[achievement.Badge] = true
+	}
+
+	awarded := []domain.Achievement{}
+	for _, rule := range rules {
+		if earned[rule.badge] || !rule.earned(streak, metric) {
+			continue
+		}
+		achievement := domain.Achievement{
+			ID:        primitive.NewObjectID(),
+			UserId:    user.ID,
+			Badge:     rule.badge,
+			MetricId:  metric.ID,
+			AwardedAt: time.Now(),
+		}
+		err := e.achievementRepo.CreateAchievement(ctx, achievement)
+		if errors.Is(err, infra.ErrAchievementExists) {
+			// a concurrent log awarded it first
+			continue
+		}
+		if err != nil {
+			return awarded, err
+		}
+		e.logger.Info("badge awarded", zap.String("user_id", user.ID.Hex()), zap.String("badge", string(rule.badge)))
+		awarded = append(awarded, achievement)
+	}
+	return awarded, nil
+}
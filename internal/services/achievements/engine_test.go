@@ -0,0 +1,266 @@
+package achievements_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/achievements"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type engineFixture struct {
+	engine          *achievements.Engine
+	metricRepo      *memory.MemoryMetricRepository
+	achievementRepo *memory.MemoryAchievementRepository
+	user            domain.User
+}
+
+func newEngine(t *testing.T, timezone string) engineFixture {
+	t.Helper()
+	metricRepo, achievementRepo := memory.NewMemoryMetricRepo(), memory.NewMemoryAchievementRepo()
+	engine, err := achievements.NewEngine(metricRepo, achievementRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	user := domain.User{ID: primitive.NewObjectID(), Timezone: timezone}
+	return engineFixture{engine: engine, metricRepo: metricRepo, achievementRepo: achievementRepo, user: user}
+}
+
+// log saves an unremarkable daily log at createdAt, changed by edit, the way
+// the user service does.
+func (f engineFixture) log(t *testing.T, createdAt time.Time, edit func(*domain.Metric)) domain.Metric {
+	t.Helper()
+	metric := domain.Metric{
+		ID:           primitive.NewObjectID(),
+		OwnerId:      f.user.ID,
+		StressLevel:  3,
+		Mood:         domain.NEUTRAL,
+		SleepQuality: domain.FAIR,
+		LocalDate:    domain.LocalDate(createdAt, f.user.Location()),
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+	if edit != nil {
+		edit(&metric)
+	}
+	if err := f.metricRepo.CreateMetric(context.Background(), metric); err != nil {
+		t.Fatalf("CreateMetric: %v", err)
+	}
+	return metric
+}
+
+// logAndAward logs at createdAt and returns the badges the log was awarded.
+func (f engineFixture) logAndAward(t *testing.T, createdAt time.Time, edit func(*domain.Metric)) []domain.BadgeId {
+	t.Helper()
+	metric := f.log(t, createdAt, edit)
+	awarded, err := f.engine.Award(context.Background(), f.user, metric)
+	if err != nil {
+		t.Fatalf("Award: %v", err)
+	}
+	badges := []domain.BadgeId{}
+	for _, achievement := range awarded {
+		if achievement.UserId != f.user.ID || achievement.MetricId != metric.ID {
+			t.Errorf("badge %s awarded to %s for %s, want %s for %s", achievement.Badge,
+				achievement.UserId.Hex(), achievement.MetricId.Hex(), f.user.ID.Hex(), metric.ID.Hex())
+		}
+		badges = append(badges, achievement.Badge)
+	}
+	return badges
+}
+
+func expectBadges(t *testing.T, got []domain.BadgeId, want ...domain.BadgeId) {
+	t.Helper()
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("badges: got %v, want %v", got, want)
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func TestAwardFirstLog(t *testing.T) {
+	f := newEngine(t, "Africa/Lagos")
+	at := time.Date(2023, 11, 20, 9, 0, 0, 0, time.UTC)
+
+	expectBadges(t, f.logAndAward(t, at, nil), domain.FirstLogBadge)
+	expectBadges(t, f.logAndAward(t, at.AddDate(0, 0, 1), nil))
+}
+
+func TestAwardBadgesOfTheLog(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*domain.Metric)
+		want domain.BadgeId
+	}{
+		{"excellent sleep", func(m *domain.Metric) { m.SleepQuality = domain.EXCELLENT }, domain.FirstExcellentSleepBadge},
+		{"overjoyed mood", func(m *domain.Metric) { m.Mood = domain.OVERJOYED }, domain.FirstOverjoyedMoodBadge},
+		{"stress level of 1", func(m *domain.Metric) { m.StressLevel = 1 }, domain.CalmDayBadge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEngine(t, "UTC")
+			at := time.Date(2023, 11, 20, 9, 0, 0, 0, time.UTC)
+
+			expectBadges(t, f.logAndAward(t, at, nil), domain.FirstLogBadge)
+			expectBadges(t, f.logAndAward(t, at.AddDate(0, 0, 1), tt.edit), tt.want)
+			// each badge is only earned once, skipping a day so no streak badge is due
+			expectBadges(t, f.logAndAward(t, at.AddDate(0, 0, 3), tt.edit))
+		})
+	}
+}
+
+func TestAwardStreakBadges(t *testing.T) {
+	f := newEngine(t, "UTC")
+	start := time.Date(2023, 11, 1, 9, 0, 0, 0, time.UTC)
+
+	awarded := map[domain.BadgeId]int{}
+	for day := 1; day <= 30; day++ {
+		for _, badge := range f.logAndAward(t, start.AddDate(0, 0, day-1), nil) {
+			awarded[badge] = day
+		}
+	}
+	want := map[domain.BadgeId]int{
+		domain.FirstLogBadge:        1,
+		domain.ThreeDayStreakBadge:  3,
+		domain.SevenDayStreakBadge:  7,
+		domain.ThirtyDayStreakBadge: 30,
+		domain.ThirtyLogsBadge:      30,
+	}
+	if fmt.Sprint(awarded) != fmt.Sprint(want) {
+		t.Errorf("day each badge was awarded: got %v, want %v", awarded, want)
+	}
+}
+
+func TestAwardStreakAfterAGap(t *testing.T) {
+	f := newEngine(t, "UTC")
+	start := time.Date(2023, 11, 1, 9, 0, 0, 0, time.UTC)
+
+	f.logAndAward(t, start, nil)
+	f.logAndAward(t, start.AddDate(0, 0, 1), nil)
+	expectBadges(t, f.logAndAward(t, start.AddDate(0, 0, 3), nil))
+	expectBadges(t, f.logAndAward(t, start.AddDate(0, 0, 4), nil))
+	expectBadges(t, f.logAndAward(t, start.AddDate(0, 0, 5), nil), domain.ThreeDayStreakBadge)
+}
+
+func TestAwardMissedBadgesWithTheNextLog(t *testing.T) {
+	f := newEngine(t, "UTC")
+	start := time.Date(2023, 11, 1, 9, 0, 0, 0, time.UTC)
+
+	// logs whose awards failed
+	f.log(t, start, nil)
+	f.log(t, start.AddDate(0, 0, 1), nil)
+
+	expectBadges(t, f.logAndAward(t, start.AddDate(0, 0, 2), nil), domain.FirstLogBadge, domain.ThreeDayStreakBadge)
+}
+
+func TestStreakInTheUserTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		// logs are at these UTC times
+		logs []time.Time
+		now  time.Time
+		want domain.Streak
+	}{
+		{
+			"late evening utc is the next day in lagos", "Africa/Lagos",
+			[]time.Time{
+				time.Date(2023, 11, 18, 23, 30, 0, 0, time.UTC),
+				time.Date(2023, 11, 19, 23, 30, 0, 0, time.UTC),
+				time.Date(2023, 11, 20, 23, 30, 0, 0, time.UTC),
+			},
+			time.Date(2023, 11, 20, 23, 45, 0, 0, time.UTC),
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-11-21"},
+		},
+		{
+			"two logs on one utc day are two days in new york", "America/New_York",
+			[]time.Time{
+				time.Date(2023, 11, 20, 3, 0, 0, 0, time.UTC),
+				time.Date(2023, 11, 20, 20, 0, 0, 0, time.UTC),
+			},
+			time.Date(2023, 11, 20, 21, 0, 0, 0, time.UTC),
+			domain.Streak{Current: 2, Longest: 2, TotalDays: 2, LastLogDate: "2023-11-20"},
+		},
+		{
+			"the streak ends at local midnight", "America/New_York",
+			[]time.Time{time.Date(2023, 11, 19, 20, 0, 0, 0, time.UTC)},
+			// 00:30 of the 21st in new york
+			time.Date(2023, 11, 21, 5, 30, 0, 0, time.UTC),
+			domain.Streak{Current: 0, Longest: 1, MissedDays: 1, TotalDays: 1, LastLogDate: "2023-11-19"},
+		},
+		{
+			"daylight saving time starts", "America/New_York",
+			[]time.Time{
+				// 20:00 local on the 11th, 12th and 13th of march
+				time.Date(2023, 3, 12, 1, 0, 0, 0, time.UTC),
+				time.Date(2023, 3, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC),
+			},
+			time.Date(2023, 3, 14, 1, 0, 0, 0, time.UTC),
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-03-13"},
+		},
+		{
+			"daylight saving time ends", "America/New_York",
+			[]time.Time{
+				// 23:30 local on the 4th, 5th and 6th of november
+				time.Date(2023, 11, 5, 3, 30, 0, 0, time.UTC),
+				time.Date(2023, 11, 6, 4, 30, 0, 0, time.UTC),
+				time.Date(2023, 11, 7, 4, 30, 0, 0, time.UTC),
+			},
+			time.Date(2023, 11, 7, 4, 45, 0, 0, time.UTC),
+			domain.Streak{Current: 3, Longest: 3, TotalDays: 3, LastLogDate: "2023-11-06"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEngine(t, tt.timezone)
+			for _, at := range tt.logs {
+				f.log(t, at, nil)
+			}
+			got, err := f.engine.Streak(context.Background(), f.user, tt.now)
+			if err != nil {
+				t.Fatalf("Streak: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Streak: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreakOfLogsWithoutLocalDate(t *testing.T) {
+	f := newEngine(t, "Africa/Lagos")
+	location := mustLoadLocation(t, "Africa/Lagos")
+
+	// logs saved before local dates were stored get theirs from CreatedAt
+	for _, at := range []time.Time{
+		time.Date(2023, 11, 18, 23, 30, 0, 0, time.UTC),
+		time.Date(2023, 11, 19, 23, 30, 0, 0, time.UTC),
+	} {
+		f.log(t, at, func(m *domain.Metric) { m.LocalDate = "" })
+	}
+	now := time.Date(2023, 11, 20, 12, 0, 0, 0, location)
+
+	got, err := f.engine.Streak(context.Background(), f.user, now)
+	if err != nil {
+		t.Fatalf("Streak: %v", err)
+	}
+	want := domain.Streak{Current: 2, Longest: 2, TotalDays: 2, LastLogDate: "2023-11-20"}
+	if got != want {
+		t.Errorf("Streak: got %+v, want %+v", got, want)
+	}
+}
@@ -2,14 +2,56 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type JWTClaims struct {
-	ID    primitive.ObjectID
-	Email string
+	ID        primitive.ObjectID
+	Email     string
+	SessionId string
+	// ShareId is set on delegated tokens, which read ID's data with Scopes
+	// on behalf of an invitee of the share.
+	ShareId primitive.ObjectID
+	Scopes  []domain.ShareScope
+}
+
+func (c JWTClaims) IsDelegated() bool {
+	return !c.ShareId.IsZero()
+}
+
+func (c JWTClaims) HasScope(scope domain.ShareScope) bool {
+	for _, granted := range c.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// DeviceInfo describes the client a session was started from.
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+type Session struct {
+	ID         string
+	UserId     primitive.ObjectID
+	Device     DeviceInfo
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
 }
 
 type ctxKey int
@@ -27,6 +69,19 @@ func GetJWTClaims(ctx context.Context) (JWTClaims, bool) {
 
 type AuthService interface {
 	DecodeJWT(ctx context.Context, tokenString string) (JWTClaims, error)
-	GenerateJWT(ctx context.Context, user domain.User) (string, error)
-	IsUserLoggedIn(ctx context.Context, authHeader, userId string) bool
+	GenerateTokenPair(ctx context.Context, user domain.User, device DeviceInfo) (TokenPair, error)
+	RefreshTokenPair(ctx context.Context, refreshToken string) (TokenPair, error)
+	IsUserLoggedIn(ctx context.Context, jwtClaims JWTClaims) bool
+	ListSessions(ctx context.Context, userId primitive.ObjectID) ([]Session, error)
+	RevokeSession(ctx context.Context, userId primitive.ObjectID, sessionId string) error
+	RevokeAllSessions(ctx context.Context, userId primitive.ObjectID) error
+	// GenerateDelegatedToken signs the token an invitee reads a share with,
+	// it expires with the share.
+	GenerateDelegatedToken(ctx context.Context, share domain.Share) (string, error)
+}
+
+// ShareVerifier checks a delegated token's share is still active, so that
+// revoking it takes effect at once.
+type ShareVerifier interface {
+	VerifyShare(ctx context.Context, jwtClaims JWTClaims) error
 }
@@ -2,8 +2,14 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,23 +22,54 @@ import (
 )
 
 type RedisAuthService struct {
-	Cache     infra.Cache
-	SecretKey string
-	logger    *zap.Logger
+	Cache           infra.Cache
+	SecretKey       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	logger          *zap.Logger
 }
 
 var (
-	ErrInvalidToken    = errors.New("invalid token")
-	ErrExpiredToken    = errors.New("expired token")
-	ErrGeneratingToken = errors.New("Error generating JWT token")
-	ErrDecodingToken   = errors.New("error decoding JWT token")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrExpiredToken       = errors.New("expired token")
+	ErrGeneratingToken    = errors.New("Error generating JWT token")
+	ErrDecodingToken      = errors.New("error decoding JWT token")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+	ErrSessionNotFound    = errors.New("session not found")
 )
 
 const (
-	JWT_HASH_NAME       = "afriHacks2023-stressless-jwt-clients"
-	SessionTTLInMinutes = 60 * 24 // 24hours
+	SESSION_HASH_NAME         = "afriHacks2023-stressless-sessions"
+	USER_SESSIONS_HASH_NAME   = "afriHacks2023-stressless-user-sessions"
+	REFRESH_TOKEN_HASH_NAME   = "afriHacks2023-stressless-refresh-tokens"
+	REFRESH_CLAIM_HASH_NAME   = "afriHacks2023-stressless-refresh-claims"
+	DefaultAccessTokenTTL     = 15 * time.Minute
+	DefaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	refreshTokenLengthInBytes = 32
+	lastSeenUpdateInterval    = time.Minute
 )
 
+// refreshTokenRecord is stored under the hash of a refresh token. Every token
+// issued from the same login belongs to one session, presenting a token that
+// has already been claimed revokes that session.
+type refreshTokenRecord struct {
+	UserId    primitive.ObjectID `json:"user_id"`
+	Email     string             `json:"email"`
+	SessionId string             `json:"session_id"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+type sessionRecord struct {
+	ID         string             `json:"id"`
+	UserId     primitive.ObjectID `json:"user_id"`
+	DeviceName string             `json:"device_name"`
+	UserAgent  string             `json:"user_agent"`
+	IPAddress  string             `json:"ip_address"`
+	CreatedAt  time.Time          `json:"created_at"`
+	LastSeenAt time.Time          `json:"last_seen_at"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+}
+
 func NewRedisAuthService(ctx context.Context, cache infra.Cache, configurations *config.Configurations, logger *zap.Logger) (*RedisAuthService, error) {
 	if cache == nil {
 		return nil, fmt.Errorf("failed to initialize auth service, cache is nil")
@@ -42,25 +79,273 @@ func NewRedisAuthService(ctx context.Context, cache infra.Cache, configurations
 		return nil, err
 	}
 
-	return &RedisAuthService{cache, configurations.JwtSecretKey, logger}, nil
+	accessTokenTTL, err := parseTTL(configurations.AccessTokenTTL, DefaultAccessTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize auth service, invalid access token ttl: %w", err)
+	}
+	refreshTokenTTL, err := parseTTL(configurations.RefreshTokenTTL, DefaultRefreshTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize auth service, invalid refresh token ttl: %w", err)
+	}
+
+	return &RedisAuthService{cache, configurations.JwtSecretKey, accessTokenTTL, refreshTokenTTL, logger}, nil
+}
+
+func parseTTL(raw string, fallback time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return 0, errors.New("ttl must be positive")
+	}
+	return ttl, nil
+}
+
+func (r *RedisAuthService) GenerateTokenPair(ctx context.Context, user domain.User, device DeviceInfo) (TokenPair, error) {
+	sessionId, err := randomToken(16)
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+
+	now := time.Now()
+	session := sessionRecord{
+		ID:         sessionId,
+		UserId:     user.ID,
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(r.RefreshTokenTTL),
+	}
+	if err := r.saveSession(ctx, session); err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+	if err := r.Cache.AddToSet(ctx, constructUserSessionsKey(user.ID), sessionId); err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+
+	return r.issueTokenPair(ctx, user.ID, user.Email, sessionId)
+}
+
+func (r *RedisAuthService) RefreshTokenPair(ctx context.Context, refreshToken string) (TokenPair, error) {
+	rawRecord, err := r.Cache.GetOne(ctx, constructRefreshTokenKey(refreshToken))
+	if err != nil {
+		return TokenPair{}, ErrInvalidToken
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(rawRecord), &record); err != nil {
+		return TokenPair{}, ErrInvalidToken
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return TokenPair{}, ErrExpiredToken
+	}
+
+	// only the first of any concurrent refreshes claims the token, every other
+	// one is treated as a reuse.
+	claimed, err := r.Cache.SetOneIfNotExists(ctx, constructRefreshClaimKey(refreshToken), "1", time.Until(record.ExpiresAt))
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+	if !claimed {
+		r.logger.Warn("refresh token reuse detected, revoking session",
+			zap.String("user_id", record.UserId.Hex()),
+			zap.String("session_id", record.SessionId),
+		)
+		if err := r.RevokeSession(ctx, record.UserId, record.SessionId); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	session, err := r.getSession(ctx, record.SessionId)
+	if err != nil || session.UserId != record.UserId {
+		return TokenPair{}, ErrInvalidToken
+	}
+
+	session.LastSeenAt = time.Now()
+	session.ExpiresAt = session.LastSeenAt.Add(r.RefreshTokenTTL)
+	updated, err := r.updateSession(ctx, session)
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+	if !updated {
+		// revoked since it was read
+		return TokenPair{}, ErrInvalidToken
+	}
+
+	return r.issueTokenPair(ctx, record.UserId, record.Email, record.SessionId)
+}
+
+func (r *RedisAuthService) ListSessions(ctx context.Context, userId primitive.ObjectID) ([]Session, error) {
+	sessionIds, err := r.Cache.GetSetMembers(ctx, constructUserSessionsKey(userId))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, sessionId := range sessionIds {
+		session, err := r.getSession(ctx, sessionId)
+		if errors.Is(err, ErrSessionNotFound) {
+			// the session expired, drop it from the user's index
+			if err := r.Cache.RemoveFromSet(ctx, constructUserSessionsKey(userId), sessionId); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, toSession(session))
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *RedisAuthService) RevokeSession(ctx context.Context, userId primitive.ObjectID, sessionId string) error {
+	session, err := r.getSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if session.UserId != userId {
+		return ErrSessionNotFound
+	}
+
+	if err := r.Cache.DeleteOne(ctx, constructSessionKey(sessionId)); err != nil {
+		return err
+	}
+	return r.Cache.RemoveFromSet(ctx, constructUserSessionsKey(userId), sessionId)
+}
+
+func (r *RedisAuthService) RevokeAllSessions(ctx context.Context, userId primitive.ObjectID) error {
+	sessionIds, err := r.Cache.GetSetMembers(ctx, constructUserSessionsKey(userId))
+	if err != nil {
+		return err
+	}
+	for _, sessionId := range sessionIds {
+		if err := r.Cache.DeleteOne(ctx, constructSessionKey(sessionId)); err != nil {
+			return err
+		}
+	}
+	return r.Cache.DeleteOne(ctx, constructUserSessionsKey(userId))
+}
+
+func (r *RedisAuthService) getSession(ctx context.Context, sessionId string) (sessionRecord, error) {
+	rawSession, err := r.Cache.GetOne(ctx, constructSessionKey(sessionId))
+	if errors.Is(err, infra.ErrCacheMiss) {
+		return sessionRecord{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return sessionRecord{}, err
+	}
+
+	var session sessionRecord
+	if err := json.Unmarshal([]byte(rawSession), &session); err != nil {
+		return sessionRecord{}, err
+	}
+	return session, nil
 }
 
-func (r *RedisAuthService) GenerateJWT(ctx context.Context, user domain.User) (string, error) {
+func (r *RedisAuthService) saveSession(ctx context.Context, session sessionRecord) error {
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return r.Cache.SetOne(ctx, constructSessionKey(session.ID), string(rawSession), time.Until(session.ExpiresAt))
+}
+
+// updateSession saves session only if it still exists, so a session revoked
+// while it was being read is not brought back.
+func (r *RedisAuthService) updateSession(ctx context.Context, session sessionRecord) (bool, error) {
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return false, err
+	}
+	return r.Cache.SetOneIfExists(ctx, constructSessionKey(session.ID), string(rawSession), time.Until(session.ExpiresAt))
+}
+
+func toSession(session sessionRecord) Session {
+	return Session{
+		ID:     session.ID,
+		UserId: session.UserId,
+		Device: DeviceInfo{
+			Name:      session.DeviceName,
+			UserAgent: session.UserAgent,
+			IPAddress: session.IPAddress,
+		},
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
+
+func (r *RedisAuthService) issueTokenPair(ctx context.Context, userId primitive.ObjectID, email, sessionId string) (TokenPair, error) {
+	accessTokenExpiresAt := time.Now().Add(r.AccessTokenTTL)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   user.ID,
-		"email": user.Email,
-		"exp":   time.Now().Add(time.Minute * SessionTTLInMinutes).Unix(),
+		"sub":   userId,
+		"email": email,
+		"jti":   sessionId,
+		"exp":   accessTokenExpiresAt.Unix(),
 	})
-	tokenString, err := token.SignedString([]byte(r.SecretKey))
+	accessToken, err := token.SignedString([]byte(r.SecretKey))
 	if err != nil {
-		return "", ErrGeneratingToken
+		return TokenPair{}, ErrGeneratingToken
 	}
 
-	err = r.Cache.SetOne(ctx, constructUserIdKey(user.ID.String()), tokenString)
+	refreshToken, err := randomToken(refreshTokenLengthInBytes)
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+	refreshTokenExpiresAt := time.Now().Add(r.RefreshTokenTTL)
+	record, err := json.Marshal(refreshTokenRecord{
+		UserId:    userId,
+		Email:     email,
+		SessionId: sessionId,
+		ExpiresAt: refreshTokenExpiresAt,
+	})
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+	err = r.Cache.SetOne(ctx, constructRefreshTokenKey(refreshToken), string(record), r.RefreshTokenTTL)
+	if err != nil {
+		return TokenPair{}, ErrGeneratingToken
+	}
+
+	return TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessTokenExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshTokenExpiresAt,
+	}, nil
+}
+
+// GenerateDelegatedToken has no session or refresh token behind it, the share
+// itself is checked on every request instead.
+func (r *RedisAuthService) GenerateDelegatedToken(ctx context.Context, share domain.Share) (string, error) {
+	scopes := make([]string, 0, len(share.Scopes))
+	for _, scope := range share.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   share.OwnerId,
+		"jti":   share.ID.Hex(),
+		"share": share.ID.Hex(),
+		"scope": strings.Join(scopes, " "),
+		"exp":   share.ExpiresAt.Unix(),
+	})
+	delegatedToken, err := token.SignedString([]byte(r.SecretKey))
 	if err != nil {
 		return "", ErrGeneratingToken
 	}
-	return tokenString, nil
+	return delegatedToken, nil
 }
 
 func (r *RedisAuthService) DecodeJWT(ctx context.Context, authHeader string) (JWTClaims, error) {
@@ -102,20 +387,71 @@ func (r *RedisAuthService) DecodeJWT(ctx context.Context, authHeader string) (JW
 			jwtClaims.Email = userEmail.(string)
 		}
 
+		sessionId, ok := claims["jti"].(string)
+		if !ok || sessionId == "" {
+			return JWTClaims{}, ErrInvalidToken
+		}
+		jwtClaims.SessionId = sessionId
+
+		if shareId, ok := claims["share"].(string); ok {
+			jwtClaims.ShareId, err = primitive.ObjectIDFromHex(shareId)
+			if err != nil {
+				return JWTClaims{}, ErrDecodingToken
+			}
+			scope, _ := claims["scope"].(string)
+			for _, granted := range strings.Fields(scope) {
+				jwtClaims.Scopes = append(jwtClaims.Scopes, domain.ShareScope(granted))
+			}
+		}
+
 		return jwtClaims, nil
 	}
 	return JWTClaims{}, ErrInvalidToken
 }
 
-func (r *RedisAuthService) IsUserLoggedIn(ctx context.Context, authHeader, userId string) bool {
-	token := strings.Split(authHeader, " ")[1]
-	cachedToken, err := r.Cache.GetOne(ctx, constructUserIdKey(userId))
-	if err != nil || cachedToken != token {
+func (r *RedisAuthService) IsUserLoggedIn(ctx context.Context, jwtClaims JWTClaims) bool {
+	session, err := r.getSession(ctx, jwtClaims.SessionId)
+	if err != nil || session.UserId != jwtClaims.ID {
 		return false
 	}
+
+	if time.Since(session.LastSeenAt) > lastSeenUpdateInterval {
+		session.LastSeenAt = time.Now()
+		updated, err := r.updateSession(ctx, session)
+		if err != nil {
+			r.logger.Warn("failed to update session last seen", zap.String("session_id", session.ID), zap.Error(err))
+		}
+		if err == nil && !updated {
+			// revoked since it was read
+			return false
+		}
+	}
 	return true
 }
 
-func constructUserIdKey(key string) string {
-	return JWT_HASH_NAME + key
+func randomToken(lengthInBytes int) (string, error) {
+	b := make([]byte, lengthInBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func constructSessionKey(sessionId string) string {
+	return SESSION_HASH_NAME + sessionId
+}
+
+func constructUserSessionsKey(userId primitive.ObjectID) string {
+	return USER_SESSIONS_HASH_NAME + userId.Hex()
+}
+
+// constructRefreshTokenKey only keeps a hash of the refresh token in the cache.
+func constructRefreshTokenKey(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return REFRESH_TOKEN_HASH_NAME + hex.EncodeToString(hash[:])
+}
+
+func constructRefreshClaimKey(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return REFRESH_CLAIM_HASH_NAME + hex.EncodeToString(hash[:])
 }
@@ -0,0 +1,166 @@
+package goals
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Evaluator measures goals against a user's metric history and records the
+// periods they were completed in.
+type Evaluator struct {
+	metricRepo infra.MetricRepository
+	goalRepo   infra.GoalRepository
+	logger     *zap.Logger
+}
+
+func NewEvaluator(metricRepo infra.MetricRepository, goalRepo infra.GoalRepository, logger *zap.Logger) (*Evaluator, error) {
+	if metricRepo == nil {
+		return nil, errors.New("failed to initialize goals evaluator, metricRepo is nil")
+	}
+	if goalRepo == nil {
+		return nil, errors.New("failed to initialize goals evaluator, goalRepo is nil")
+	}
+	return &Evaluator{metricRepo: metricRepo, goalRepo: goalRepo, logger: logger}, nil
+}
+
+// Progress measures each goal over its period containing now, in the user's
+// timezone.
+func (e *Evaluator) Progress(ctx context.Context, user domain.User, goals []domain.Goal, now time.Time) ([]domain.GoalProgress, error) {
+	location := user.Location()
+	since := time.Time{}
+	periods := newPeriodMetrics(e.metricRepo, user.ID)
+	progress := []domain.GoalProgress{}
+	for _, goal := range goals {
+		start, end := goal.Period.Bounds(now.In(location))
+		metrics, err := periods.get(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if since.IsZero() || start.Before(since) {
+			since = start
+		}
+		progress = append(progress, domain.GoalProgress{
+			Goal:        goal,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Result:      goal.Measure(metrics),
+		})
+	}
+	if len(progress) == 0 {
+		return progress, nil
+	}
+
+	// a period is only completed once it started
+	completions, err := e.goalRepo.GetGoalCompletionsByUserId(ctx, user.ID, since.Add(-time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	for i := range progress {
+		periodStart := domain.LocalDate(progress[i].PeriodStart, location)
+		for _, completion := range completions {
+			if completion.GoalId == progress[i].Goal.ID && completion.PeriodStart == periodStart {
+				completion := completion
+				progress[i].Completion = &completion
+			}
+		}
+	}
+	return progress, nil
+}
+
+// Evaluate completes the user's goals that metric, just logged, lets them
+// meet and returns the new completions. Goals that count days are checked
+// over the period of metric, averages over the period before it as they are
+// only completed once it is over.
+func (e *Evaluator) Evaluate(ctx context.Context, user domain.User, metric domain.Metric) ([]domain.GoalCompletion, error) {
+	goals, err := e.goalRepo.GetGoalsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := user.Location()
+	loggedAt := metric.CreatedAt.In(location)
+	periods := newPeriodMetrics(e.metricRepo, user.ID)
+	completed := []domain.GoalCompletion{}
+	for _, goal := range goals {
+		start, end := goal.Period.Bounds(loggedAt)
+		if !goal.Kind.CountsDays() {
+			start, end = goal.Period.Bounds(start.AddDate(0, 0, -1))
+			if !goal.CreatedAt.Before(end) {
+				continue
+			}
+		}
+		metrics, err := periods.get(ctx, start, end)
+		if err != nil {
+			return completed, err
+		}
+		result := goal.Measure(metrics)
+		if !result.Met {
+			continue
+		}
+
+		completion := domain.GoalCompletion{
+			ID:          primitive.NewObjectID(),
+			GoalId:      goal.ID,
+			UserId:      user.ID,
+			PeriodStart: domain.LocalDate(start, location),
+			Value:       result.Value,
+			CompletedAt: time.Now(),
+		}
+		err = e.goalRepo.CreateGoalCompletion(ctx, completion)
+		if errors.Is(err, infra.ErrGoalCompletionExists) {
+			// met with an earlier log or by a concurrent one
+			continue
+		}
+		if err != nil {
+			return completed, err
+		}
+		e.logger.Info("goal completed", zap.String("user_id", user.ID.Hex()), zap.String("goal_id", goal.ID.Hex()), zap.String("period_start", completion.PeriodStart))
+		completed = append(completed, completion)
+	}
+	return completed, nil
+}
+
+// periodMetrics loads the metrics of each period once, goals over the same
+// period share them.
+type periodMetrics struct {
+	metricRepo infra.MetricRepository
+	userId     primitive.ObjectID
+	loaded     map[[2]int64][]domain.Metric
+}
+
+func newPeriodMetrics(metricRepo infra.MetricRepository, userId primitive.ObjectID) *periodMetrics {
+	return &periodMetrics{metricRepo: metricRepo, userId: userId, loaded: map[[2]int64][]domain.Metric{}}
+}
+
+// get returns the metrics logged from start up to end, newest first and at
+// most one a day.
+func (p *periodMetrics) get(ctx context.Context, start, end time.Time) ([]domain.Metric, error) {
+	key := [2]int64{start.Unix(), end.Unix()}
+	if metrics, ok := p.loaded[key]; ok {
+		return metrics, nil
+	}
+	page, err := p.metricRepo.GetMetricsByUserId(ctx, p.userId, infra.MetricFilter{From: start, To: end})
+	if err != nil {
+		return nil, err
+	}
+
+	// logs stored before local dates were recorded can share a day
+	days := map[string]bool{}
+	metrics := []domain.Metric{}
+	for _, metric := range page.Metrics {
+		day := infra.MetricDay{LocalDate: metric.LocalDate, CreatedAt: metric.CreatedAt}.Date(start.Location())
+		if days[day] {
+			continue
+		}
+		days[day] = true
+		metrics = append(metrics, metric)
+	}
+	p.loaded[key] = metrics
+	return metrics, nil
+}
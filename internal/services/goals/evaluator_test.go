@@ -0,0 +1,247 @@
+package goals_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/goals"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type evaluatorFixture struct {
+	evaluator  *goals.Evaluator
+	metricRepo *memory.MemoryMetricRepository
+	goalRepo   *memory.MemoryGoalRepository
+	user       domain.User
+}
+
+func newEvaluator(t *testing.T, timezone string) evaluatorFixture {
+	t.Helper()
+	metricRepo, goalRepo := memory.NewMemoryMetricRepo(), memory.NewMemoryGoalRepo()
+	evaluator, err := goals.NewEvaluator(metricRepo, goalRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewEvaluator: %v", err)
+	}
+	user := domain.User{ID: primitive.NewObjectID(), Timezone: timezone}
+	return evaluatorFixture{evaluator: evaluator, metricRepo: metricRepo, goalRepo: goalRepo, user: user}
+}
+
+func (f evaluatorFixture) goal(t *testing.T, kind domain.GoalKind, period domain.GoalPeriod, target int, threshold string, createdAt time.Time) domain.Goal {
+	t.Helper()
+	goal := domain.Goal{
+		ID:        primitive.NewObjectID(),
+		UserId:    f.user.ID,
+		Kind:      kind,
+		Period:    period,
+		Target:    target,
+		Threshold: threshold,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := f.goalRepo.CreateGoal(context.Background(), goal); err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	return goal
+}
+
+// logAndEvaluate saves a daily log at createdAt, changed by edit, and returns
+// the period starts of the goals it completed.
+func (f evaluatorFixture) logAndEvaluate(t *testing.T, createdAt time.Time, edit func(*domain.Metric)) []string {
+	t.Helper()
+	metric := domain.Metric{
+		ID:              primitive.NewObjectID(),
+		OwnerId:         f.user.ID,
+		StressLevel:     3,
+		Mood:            domain.NEUTRAL,
+		SleepQuality:    domain.FAIR,
+		StressLessScore: 50,
+		LocalDate:       domain.LocalDate(createdAt, f.user.Location()),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	if edit != nil {
+		edit(&metric)
+	}
+	if err := f.metricRepo.CreateMetric(context.Background(), metric); err != nil {
+		t.Fatalf("CreateMetric: %v", err)
+	}
+	completions, err := f.evaluator.Evaluate(context.Background(), f.user, metric)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	periodStarts := []string{}
+	for _, completion := range completions {
+		if completion.UserId != f.user.ID {
+			t.Errorf("completion of %s, want %s", completion.UserId.Hex(), f.user.ID.Hex())
+		}
+		periodStarts = append(periodStarts, completion.PeriodStart)
+	}
+	return periodStarts
+}
+
+func expectPeriodStarts(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("completed periods: got %v, want %v", got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("completed periods: got %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+// monday is the start of a week, 9:00 in UTC.
+var monday = time.Date(2023, 11, 13, 9, 0, 0, 0, time.UTC)
+
+func TestEvaluateLogDaysGoal(t *testing.T) {
+	f := newEvaluator(t, "UTC")
+	f.goal(t, domain.LogDaysGoal, domain.WeeklyGoal, 3, "", monday.AddDate(0, 0, -7))
+
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday, nil))
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 2), nil))
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 6), nil), "2023-11-13")
+	// a goal is completed once a period
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 6).Add(time.Hour), func(m *domain.Metric) { m.LocalDate = "" }))
+	// and starts again the next one
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 7), nil))
+}
+
+func TestEvaluateThresholdGoals(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      domain.GoalKind
+		threshold string
+		below     func(*domain.Metric)
+		atLeast   func(*domain.Metric)
+		above     func(*domain.Metric)
+	}{
+		{
+			"sleep quality", domain.SleepQualityGoal, string(domain.GOOD),
+			func(m *domain.Metric) { m.SleepQuality = domain.FAIR },
+			func(m *domain.Metric) { m.SleepQuality = domain.GOOD },
+			func(m *domain.Metric) { m.SleepQuality = domain.EXCELLENT },
+		},
+		{
+			"mood", domain.MoodGoal, string(domain.HAPPY),
+			func(m *domain.Metric) { m.Mood = domain.NEUTRAL },
+			func(m *domain.Metric) { m.Mood = domain.HAPPY },
+			func(m *domain.Metric) { m.Mood = domain.OVERJOYED },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEvaluator(t, "UTC")
+			f.goal(t, tt.kind, domain.MonthlyGoal, 2, tt.threshold, monday.AddDate(0, -1, 0))
+
+			expectPeriodStarts(t, f.logAndEvaluate(t, monday, tt.atLeast))
+			expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 1), tt.below))
+			expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 2), tt.above), "2023-11-01")
+		})
+	}
+}
+
+func TestEvaluateAverageScoreGoalOnceThePeriodIsOver(t *testing.T) {
+	f := newEvaluator(t, "UTC")
+	f.goal(t, domain.AverageScoreGoal, domain.WeeklyGoal, 70, "", monday.AddDate(0, 0, -7))
+	score := func(score int) func(*domain.Metric) {
+		return func(m *domain.Metric) { m.StressLessScore = score }
+	}
+
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday, score(90)))
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 1), score(60)))
+	// the first log of the next week completes the one before, averaging 75
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 7), score(10)), "2023-11-13")
+	// which averaged 10, below the target
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 14), score(90)))
+}
+
+func TestEvaluateAverageScoreGoalCreatedDuringThePeriod(t *testing.T) {
+	f := newEvaluator(t, "UTC")
+	f.logAndEvaluate(t, monday, func(m *domain.Metric) { m.StressLessScore = 90 })
+	f.goal(t, domain.AverageScoreGoal, domain.WeeklyGoal, 70, "", monday.AddDate(0, 0, 7).Add(-time.Hour))
+
+	// the week before the goal was created is not completed
+	expectPeriodStarts(t, f.logAndEvaluate(t, monday.AddDate(0, 0, 7), nil))
+}
+
+func TestEvaluatePeriodInTheUserTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		loggedAt time.Time
+		want     string
+	}{
+		{"sunday evening in utc is monday in lagos", "Africa/Lagos", time.Date(2023, 11, 19, 23, 30, 0, 0, time.UTC), "2023-11-20"},
+		{"monday night in utc is sunday in new york", "America/New_York", time.Date(2023, 11, 20, 3, 0, 0, 0, time.UTC), "2023-11-13"},
+		{"the last of the month in utc is the first in lagos", "Africa/Lagos", time.Date(2023, 11, 30, 23, 30, 0, 0, time.UTC), "2023-12-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEvaluator(t, tt.timezone)
+			period := domain.WeeklyGoal
+			if tt.loggedAt.Day() == 30 {
+				period = domain.MonthlyGoal
+			}
+			f.goal(t, domain.LogDaysGoal, period, 1, "", tt.loggedAt.AddDate(0, -1, 0))
+
+			expectPeriodStarts(t, f.logAndEvaluate(t, tt.loggedAt, nil), tt.want)
+		})
+	}
+}
+
+func TestEvaluateLogsAcrossALocalPeriodBoundary(t *testing.T) {
+	f := newEvaluator(t, "Africa/Lagos")
+	f.goal(t, domain.LogDaysGoal, domain.WeeklyGoal, 2, "", monday.AddDate(0, 0, -7))
+
+	// saturday, then sunday 23:30 in utc which is already monday in lagos
+	expectPeriodStarts(t, f.logAndEvaluate(t, time.Date(2023, 11, 18, 12, 0, 0, 0, time.UTC), nil))
+	expectPeriodStarts(t, f.logAndEvaluate(t, time.Date(2023, 11, 19, 23, 30, 0, 0, time.UTC), nil))
+	expectPeriodStarts(t, f.logAndEvaluate(t, time.Date(2023, 11, 21, 12, 0, 0, 0, time.UTC), nil), "2023-11-20")
+}
+
+func TestProgress(t *testing.T) {
+	f := newEvaluator(t, "Africa/Lagos")
+	location := f.user.Location()
+	logDays := f.goal(t, domain.LogDaysGoal, domain.WeeklyGoal, 2, "", monday.AddDate(0, 0, -7))
+	average := f.goal(t, domain.AverageScoreGoal, domain.MonthlyGoal, 60, "", monday.AddDate(0, 0, -7))
+
+	f.logAndEvaluate(t, monday, func(m *domain.Metric) { m.StressLessScore = 40 })
+	f.logAndEvaluate(t, monday.AddDate(0, 0, 1), func(m *domain.Metric) { m.StressLessScore = 60 })
+
+	progress, err := f.evaluator.Progress(context.Background(), f.user, []domain.Goal{logDays, average}, monday.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("Progress: %v", err)
+	}
+	if len(progress) != 2 {
+		t.Fatalf("Progress: got %d goals, want 2", len(progress))
+	}
+
+	week := progress[0]
+	if want := time.Date(2023, 11, 13, 0, 0, 0, 0, location); !week.PeriodStart.Equal(want) || !week.PeriodEnd.Equal(want.AddDate(0, 0, 7)) {
+		t.Errorf("week: got %v to %v, want the week starting %v", week.PeriodStart, week.PeriodEnd, want)
+	}
+	if week.Result != (domain.GoalResult{Value: 2, Percent: 100, Met: true}) {
+		t.Errorf("week result: got %+v", week.Result)
+	}
+	if week.Completion == nil || week.Completion.PeriodStart != "2023-11-13" {
+		t.Errorf("week completion: got %+v, want one for 2023-11-13", week.Completion)
+	}
+
+	month := progress[1]
+	if want := time.Date(2023, 11, 1, 0, 0, 0, 0, location); !month.PeriodStart.Equal(want) {
+		t.Errorf("month: got %v, want %v", month.PeriodStart, want)
+	}
+	if month.Result != (domain.GoalResult{Value: 50, Percent: 83, Met: false}) {
+		t.Errorf("month result: got %+v", month.Result)
+	}
+	if month.Completion != nil {
+		t.Errorf("month completion: got %+v, want none", month.Completion)
+	}
+}
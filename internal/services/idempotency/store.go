@@ -0,0 +1,136 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+)
+
+const IDEMPOTENCY_HASH_NAME = "afriHacks2023-stressless-idempotency"
+
+// pendingTTL bounds how long a crashed request blocks retries of its key.
+const pendingTTL = 2 * time.Minute
+
+var (
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrKeyReused         = errors.New("idempotency key was already used for a different request")
+)
+
+// Store remembers the result of requests made with an idempotency key so a
+// retried request gets the original result instead of running again. Results
+// are kept in the cache as JSON, so they should be ids to read back rather
+// than data the databases keep encrypted.
+type Store struct {
+	cache infra.Cache
+	ttl   time.Duration
+}
+
+func NewStore(cache infra.Cache, ttl time.Duration) (*Store, error) {
+	if cache == nil {
+		return nil, errors.New("failed to initialize idempotency store, cache is nil")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("failed to initialize idempotency store, ttl must be positive")
+	}
+	return &Store{cache, ttl}, nil
+}
+
+type record struct {
+	Fingerprint string          `json:"fingerprint"`
+	Done        bool            `json:"done"`
+	Result      json.RawMessage `json:"result,omitempty"`
+}
+
+// Do runs fn the first time key is used in scope and stores its result. Later
+// calls with the same key and fingerprint return the stored result, with a
+// different fingerprint they fail with ErrKeyReused. When fn fails nothing is
+// stored so the request can be retried.
+func Do[T any](ctx context.Context, s *Store, scope, key, fingerprint string, fn func() (T, error)) (T, error) {
+	var zero T
+	cacheKey := constructKey(scope, key)
+
+	pending, err := json.Marshal(record{Fingerprint: fingerprint})
+	if err != nil {
+		return zero, err
+	}
+	claimed, err := s.cache.SetOneIfNotExists(ctx, cacheKey, string(pending), pendingTTL)
+	if err != nil {
+		return zero, err
+	}
+	if !claimed {
+		return storedResult[T](ctx, s, cacheKey, fingerprint)
+	}
+
+	result, err := fn()
+	if err != nil {
+		if releaseErr := s.cache.DeleteOne(ctx, cacheKey); releaseErr != nil {
+			return zero, errors.Join(err, releaseErr)
+		}
+		return zero, err
+	}
+
+	rawResult, err := json.Marshal(result)
+	if err != nil {
+		return zero, fmt.Errorf("failed to encode idempotent result: %w", err)
+	}
+	done, err := json.Marshal(record{Fingerprint: fingerprint, Done: true, Result: rawResult})
+	if err != nil {
+		return zero, err
+	}
+	if err := s.cache.SetOne(ctx, cacheKey, string(done), s.ttl); err != nil {
+		return zero, err
+	}
+	return result, nil
+}
+
+func storedResult[T any](ctx context.Context, s *Store, cacheKey, fingerprint string) (T, error) {
+	var zero T
+	raw, err := s.cache.GetOne(ctx, cacheKey)
+	if errors.Is(err, infra.ErrCacheMiss) {
+		// the first request failed or expired in between, let the client retry
+		return zero, ErrRequestInProgress
+	}
+	if err != nil {
+		return zero, err
+	}
+
+	var stored record
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		return zero, fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+	if stored.Fingerprint != fingerprint {
+		return zero, ErrKeyReused
+	}
+	if !stored.Done {
+		return zero, ErrRequestInProgress
+	}
+
+	var result T
+	if err := json.Unmarshal(stored.Result, &result); err != nil {
+		return zero, fmt.Errorf("failed to decode idempotent result: %w", err)
+	}
+	return result, nil
+}
+
+// Fingerprint hashes the parts of a request that must match for a retry to be
+// treated as the same request.
+func Fingerprint(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func constructKey(scope, key string) string {
+	// keys come from clients, hashing keeps them a bounded length
+	keyHash := sha256.Sum256([]byte(key))
+	return IDEMPOTENCY_HASH_NAME + ":" + scope + ":" + hex.EncodeToString(keyHash[:])
+}
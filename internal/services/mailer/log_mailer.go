@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer is meant for local development, it logs who every message is for
+// and appends the whole message to filePath when one is set instead of
+// sending it. Bodies are left out of the log since they carry live tokens.
+type LogMailer struct {
+	filePath string
+	logger   *zap.Logger
+	mu       sync.Mutex
+}
+
+func NewLogMailer(filePath string, logger *zap.Logger) *LogMailer {
+	return &LogMailer{filePath: filePath, logger: logger}
+}
+
+func (l *LogMailer) Send(ctx context.Context, message Message) error {
+	l.logger.Info("email sent",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject),
+	)
+	if l.filePath == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n----------\n\n",
+		time.Now().Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write mail log file: %w", err)
+	}
+	return nil
+}
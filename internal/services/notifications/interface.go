@@ -0,0 +1,18 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+type Notification struct {
+	Title string
+	Body  string
+	// Url is the page the notification opens, it may be empty.
+	Url string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, user domain.User, notification Notification) error
+}
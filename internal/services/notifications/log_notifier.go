@@ -0,0 +1,28 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"go.uber.org/zap"
+)
+
+// LogNotifier is meant for local development, it logs every notification
+// instead of sending it.
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, user domain.User, notification Notification) error {
+	l.logger.Info("notification sent",
+		zap.String("user_id", user.ID.Hex()),
+		zap.String("title", notification.Title),
+		zap.String("body", notification.Body),
+		zap.String("url", notification.Url),
+	)
+	return nil
+}
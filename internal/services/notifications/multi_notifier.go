@@ -0,0 +1,22 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+// MultiNotifier sends every notification through each of its notifiers, one
+// failing does not stop the others.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, user domain.User, notification Notification) error {
+	errs := []error{}
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, user, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+// request body keyed with the webhook secret.
+const WebhookSignatureHeader = "X-Stressless-Signature"
+
+// WebhookNotifier posts every notification as JSON to a url, for relaying
+// reminders to chat apps, SMS gateways and the like.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+type webhookPayload struct {
+	UserId string    `json:"user_id"`
+	Email  string    `json:"email"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	Url    string    `json:"url,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+func NewWebhookNotifier(url, secret string) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, errors.New("failed to initialize webhook notifier, url is empty")
+	}
+	return &WebhookNotifier{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, user domain.User, notification Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		UserId: user.ID.Hex(),
+		Email:  user.Email,
+		Title:  notification.Title,
+		Body:   notification.Body,
+		Url:    notification.Url,
+		SentAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call notification webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/hkdf"
+)
+
+// pushTTL is how long a push service keeps a message for an offline browser.
+const pushTTL = 24 * time.Hour
+
+// recordSize is the aes128gcm record size, every payload fits in one record.
+const recordSize = 4096
+
+// WebPushNotifier sends notifications to every browser a user subscribed
+// with, using VAPID (RFC 8292) and aes128gcm encrypted payloads (RFC 8291).
+// Subscriptions the push service reports as gone are removed from the user.
+type WebPushNotifier struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	userRepo   infra.UserRepository
+	client     *http.Client
+	logger     *zap.Logger
+}
+
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Url   string `json:"url,omitempty"`
+}
+
+// NewWebPushNotifier takes the VAPID key pair as unpadded base64url, the
+// public key as an uncompressed P-256 point and the private key as its 32 byte
+// scalar. subject is a mailto: or https: contact for push services.
+func NewWebPushNotifier(publicKey, privateKey, subject string, userRepo infra.UserRepository, logger *zap.Logger) (*WebPushNotifier, error) {
+	if publicKey == "" || privateKey == "" {
+		return nil, errors.New("failed to initialize web push notifier, vapid keys are empty")
+	}
+	if subject == "" {
+		return nil, errors.New("failed to initialize web push notifier, vapid subject is empty")
+	}
+	if userRepo == nil {
+		return nil, errors.New("failed to initialize web push notifier, userRepo is nil")
+	}
+	key, err := parseVapidKeys(publicKey, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize web push notifier: %w", err)
+	}
+	return &WebPushNotifier{
+		publicKey:  publicKey,
+		privateKey: key,
+		subject:    subject,
+		userRepo:   userRepo,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+	}, nil
+}
+
+// GenerateVapidKeys returns a new VAPID key pair in the format
+// NewWebPushNotifier expects.
+func GenerateVapidKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+func (w *WebPushNotifier) Notify(ctx context.Context, user domain.User, notification Notification) error {
+	if len(user.PushSubscriptions) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(pushPayload{notification.Title, notification.Body, notification.Url})
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	gone := map[string]bool{}
+	for _, subscription := range user.PushSubscriptions {
+		err := w.send(ctx, subscription, payload)
+		if errors.Is(err, errSubscriptionGone) {
+			gone[subscription.Endpoint] = true
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(gone) > 0 {
+		if err := w.removeSubscriptions(ctx, user, gone); err != nil {
+			w.logger.Error("failed to remove expired push subscriptions", zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var errSubscriptionGone = errors.New("push subscription is gone")
+
+func (w *WebPushNotifier) send(ctx context.Context, subscription domain.PushSubscription, payload []byte) error {
+	body, err := encryptPushPayload(subscription, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt push payload: %w", err)
+	}
+	authorization, err := w.vapidAuthorization(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// removeSubscriptions reloads the user so subscriptions added while sending
+// are kept.
+func (w *WebPushNotifier) removeSubscriptions(ctx context.Context, user domain.User, endpoints map[string]bool) error {
+	current, err := w.userRepo.GetUserByUserId(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	kept := []domain.PushSubscription{}
+	for _, subscription := range current.PushSubscriptions {
+		if !endpoints[subscription.Endpoint] {
+			kept = append(kept, subscription)
+		}
+	}
+	if len(kept) == len(current.PushSubscriptions) {
+		return nil
+	}
+	current.PushSubscriptions = kept
+	current.UpdatedAt = time.Now()
+	return w.userRepo.UpdateUser(ctx, current)
+}
+
+func (w *WebPushNotifier) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": w.subject,
+	})
+	signed, err := token.SignedString(w.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign vapid token: %w", err)
+	}
+	return "vapid t=" + signed + ", k=" + w.publicKey, nil
+}
+
+func parseVapidKeys(publicKey, privateKey string) (*ecdsa.PrivateKey, error) {
+	d, err := decodeBase64Url(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+	public, err := decodeBase64Url(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid public key: %w", err)
+	}
+	point := key.PublicKey().Bytes()
+	if !bytes.Equal(public, point) {
+		return nil, errors.New("vapid public key does not match the private key")
+	}
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// encryptPushPayload encrypts payload for a subscription as a single
+// aes128gcm record, as described in RFC 8291 section 3.4.
+func encryptPushPayload(subscription domain.PushSubscription, payload []byte) ([]byte, error) {
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return sealPushPayload(subscription, payload, serverKey, salt)
+}
+
+// sealPushPayload is encryptPushPayload with the server key and salt given,
+// which only tests need to choose.
+func sealPushPayload(subscription domain.PushSubscription, payload []byte, serverKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	userPublicBytes, err := decodeBase64Url(subscription.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	userPublic, err := ecdh.P256().NewPublicKey(userPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64Url(subscription.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	sharedSecret, err := serverKey.ECDH(userPublic)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicBytes := serverKey.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), userPublicBytes...)
+	keyInfo = append(keyInfo, serverPublicBytes...)
+	ikm, err := hkdfBytes(sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last record, no padding follows it.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > recordSize {
+		return nil, errors.New("push payload is too large")
+	}
+
+	header := make([]byte, 0, 16+4+1+len(serverPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublicBytes)))
+	header = append(header, serverPublicBytes...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeBase64Url accepts base64url with or without padding, browsers and
+// libraries disagree on which to send.
+func decodeBase64Url(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
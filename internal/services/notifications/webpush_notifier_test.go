@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"encoding/base64"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+// The keys, salt and message of RFC 8291 Appendix A.
+const (
+	rfc8291Plaintext        = "When I grow up, I want to be a watermelon"
+	rfc8291ServerPrivateKey = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfc8291UserPrivateKey   = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfc8291UserPublicKey    = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfc8291AuthSecret       = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfc8291Salt             = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfc8291Message          = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func rfc8291Subscription() domain.PushSubscription {
+	return domain.PushSubscription{P256dh: rfc8291UserPublicKey, Auth: rfc8291AuthSecret}
+}
+
+func TestSealPushPayloadMatchesRFC8291(t *testing.T) {
+	serverKey, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291ServerPrivateKey))
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+
+	body, err := sealPushPayload(rfc8291Subscription(), []byte(rfc8291Plaintext), serverKey, mustDecode(t, rfc8291Salt))
+	if err != nil {
+		t.Fatalf("sealPushPayload: %v", err)
+	}
+	if got := base64.RawURLEncoding.EncodeToString(body); got != rfc8291Message {
+		t.Errorf("got message\n%s\nwant\n%s", got, rfc8291Message)
+	}
+}
+
+// TestEncryptPushPayloadDecrypts decrypts a message with a fresh server key
+// and salt the way a user agent would, following RFC 8291 section 3.4.
+func TestEncryptPushPayloadDecrypts(t *testing.T) {
+	body, err := encryptPushPayload(rfc8291Subscription(), []byte(rfc8291Plaintext))
+	if err != nil {
+		t.Fatalf("encryptPushPayload: %v", err)
+	}
+
+	salt, keyLength := body[:16], int(body[20])
+	serverPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+keyLength])
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	userKey, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291UserPrivateKey))
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	sharedSecret, err := userKey.ECDH(serverPublic)
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), userKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, serverPublic.Bytes()...)
+	ikm := mustHkdf(t, sharedSecret, mustDecode(t, rfc8291AuthSecret), keyInfo, 32)
+	contentKey := mustHkdf(t, ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := mustHkdf(t, ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("NewGCM: %v", err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, body[21+keyLength:], nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got, want := string(plaintext), rfc8291Plaintext+"\x02"; got != want {
+		t.Errorf("got plaintext %q, want %q", got, want)
+	}
+}
+
+func TestEncryptPushPayloadRejectsLargePayloads(t *testing.T) {
+	if _, err := encryptPushPayload(rfc8291Subscription(), make([]byte, recordSize)); err == nil {
+		t.Error("encrypted a payload larger than a record")
+	}
+}
+
+func mustDecode(t *testing.T, value string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", value, err)
+	}
+	return b
+}
+
+func mustHkdf(t *testing.T, secret, salt, info []byte, length int) []byte {
+	t.Helper()
+	out, err := hkdfBytes(secret, salt, info, length)
+	if err != nil {
+		t.Fatalf("hkdf: %v", err)
+	}
+	return out
+}
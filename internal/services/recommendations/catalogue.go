@@ -0,0 +1,154 @@
+package recommendations
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+//go:embed catalogue.json
+var defaultCatalogue []byte
+
+var ErrNoMatchingCatalogueRule = errors.New("no matching catalogue rule")
+
+// maxThemeItems is the most theme items added to a recommendation.
+const maxThemeItems = 2
+
+type catalogueItem struct {
+	Heading  string `json:"heading"`
+	Text     string `json:"text"`
+	ImageUrl string `json:"image_url"`
+}
+
+// catalogueRule matches either a numeric range (Min and Max, both inclusive) or
+// a list of string values.
+type catalogueRule struct {
+	Min    *int            `json:"min,omitempty"`
+	Max    *int            `json:"max,omitempty"`
+	Values []string        `json:"values,omitempty"`
+	Items  []catalogueItem `json:"items"`
+}
+
+type Catalogue struct {
+	Version string                     `json:"version"`
+	Rules   map[string][]catalogueRule `json:"rules"`
+	// Themes are items about what a feeling is about, they take the place of
+	// the last items of the rule they are added to.
+	Themes map[domain.Theme][]catalogueItem `json:"themes,omitempty"`
+}
+
+var catalogueMetricTypes = []string{
+	domain.StressLessScoreMetricType,
+	domain.StressLevelMetricType,
+	domain.SleepQualityMetricType,
+	domain.MoodMetricType,
+}
+
+func LoadDefaultCatalogue() (*Catalogue, error) {
+	return LoadCatalogue(defaultCatalogue)
+}
+
+func LoadCatalogue(content []byte) (*Catalogue, error) {
+	var catalogue Catalogue
+	if err := json.Unmarshal(content, &catalogue); err != nil {
+		return nil, fmt.Errorf("failed to parse recommendation catalogue: %w", err)
+	}
+	if catalogue.Version == "" {
+		return nil, errors.New("recommendation catalogue has no version")
+	}
+
+	for _, metricType := range catalogueMetricTypes {
+		rules, ok := catalogue.Rules[metricType]
+		if !ok || len(rules) == 0 {
+			return nil, fmt.Errorf("recommendation catalogue %s has no rules for %s", catalogue.Version, metricType)
+		}
+		for i, rule := range rules {
+			if len(rule.Items) == 0 {
+				return nil, fmt.Errorf("recommendation catalogue %s: rule %d for %s has no items", catalogue.Version, i, metricType)
+			}
+			if len(rule.Values) == 0 && rule.Min == nil && rule.Max == nil {
+				return nil, fmt.Errorf("recommendation catalogue %s: rule %d for %s matches nothing", catalogue.Version, i, metricType)
+			}
+		}
+	}
+	for theme, items := range catalogue.Themes {
+		if !theme.IsValid() {
+			return nil, fmt.Errorf("recommendation catalogue %s has items for unknown theme %q", catalogue.Version, theme)
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("recommendation catalogue %s: theme %s has no items", catalogue.Version, theme)
+		}
+	}
+	return &catalogue, nil
+}
+
+func (c *Catalogue) itemsForNumber(metricType string, value int) ([]domain.RecommendationItem, error) {
+	for _, rule := range c.Rules[metricType] {
+		if rule.Min != nil && value < *rule.Min {
+			continue
+		}
+		if rule.Max != nil && value > *rule.Max {
+			continue
+		}
+		if rule.Min == nil && rule.Max == nil {
+			continue
+		}
+		return toRecommendationItems(rule.Items), nil
+	}
+	return nil, fmt.Errorf("%w: %s=%d", ErrNoMatchingCatalogueRule, metricType, value)
+}
+
+func (c *Catalogue) itemsForValue(metricType string, value string) ([]domain.RecommendationItem, error) {
+	for _, rule := range c.Rules[metricType] {
+		for _, v := range rule.Values {
+			if v == value {
+				return toRecommendationItems(rule.Items), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s=%s", ErrNoMatchingCatalogueRule, metricType, value)
+}
+
+// withThemeItems puts the item at offset of each of the first maxThemeItems
+// themes that have items in front of items, dropping as many from the end so
+// a recommendation keeps its length.
+func (c *Catalogue) withThemeItems(items []domain.RecommendationItem, themes []domain.Theme, offset int) []domain.RecommendationItem {
+	themeItems := []catalogueItem{}
+	for _, theme := range themes {
+		if len(themeItems) == maxThemeItems {
+			break
+		}
+		if candidates := c.Themes[theme]; len(candidates) > 0 {
+			themeItems = append(themeItems, candidates[offset%len(candidates)])
+		}
+	}
+	if len(themeItems) == 0 {
+		return items
+	}
+
+	result := toRecommendationItems(themeItems)
+	for _, item := range items {
+		if len(result) == len(items) {
+			break
+		}
+		item.Index = len(result)
+		result = append(result, item)
+	}
+	return result
+}
+
+func toRecommendationItems(items []catalogueItem) []domain.RecommendationItem {
+	result := make([]domain.RecommendationItem, 0, len(items))
+	for i, item := range items {
+		result = append(result, domain.RecommendationItem{
+			Index:    i,
+			Heading:  item.Heading,
+			Text:     item.Text,
+			ImageUrl: item.ImageUrl,
+		})
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package recommendations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+)
+
+// validRules has one rule for each metric type, the least a catalogue needs.
+const validRules = `"rules": {
+	"stress_less_score": [{"min": 0, "max": 100, "items": [{"heading": "h", "text": "t"}]}],
+	"stress_level": [{"min": 1, "max": 5, "items": [{"heading": "h", "text": "t"}]}],
+	"sleep_quality": [{"values": ["good"], "items": [{"heading": "h", "text": "t"}]}],
+	"mood": [{"values": ["happy"], "items": [{"heading": "h", "text": "t"}]}]
+}`
+
+func TestLoadCatalogue(t *testing.T) {
+	tests := []struct {
+		name      string
+		catalogue string
+		wantErr   bool
+	}{
+		{"valid", `{"version": "1", ` + validRules + `}`, false},
+		{"valid with themes", `{"version": "1", ` + validRules + `, "themes": {"work": [{"heading": "h", "text": "t"}]}}`, false},
+		{"not json", `{`, true},
+		{"no version", `{` + validRules + `}`, true},
+		{"missing metric type", `{"version": "1", "rules": {"mood": [{"values": ["happy"], "items": [{"heading": "h"}]}]}}`, true},
+		{"rule without items", `{"version": "1", "rules": {
+			"stress_less_score": [{"min": 0, "max": 100, "items": []}],
+			"stress_level": [{"min": 1, "max": 5, "items": [{"heading": "h"}]}],
+			"sleep_quality": [{"values": ["good"], "items": [{"heading": "h"}]}],
+			"mood": [{"values": ["happy"], "items": [{"heading": "h"}]}]
+		}}`, true},
+		{"rule matching nothing", `{"version": "1", "rules": {
+			"stress_less_score": [{"items": [{"heading": "h"}]}],
+			"stress_level": [{"min": 1, "max": 5, "items": [{"heading": "h"}]}],
+			"sleep_quality": [{"values": ["good"], "items": [{"heading": "h"}]}],
+			"mood": [{"values": ["happy"], "items": [{"heading": "h"}]}]
+		}}`, true},
+		{"unknown theme", `{"version": "1", ` + validRules + `, "themes": {"weather": [{"heading": "h"}]}}`, true},
+		{"theme without items", `{"version": "1", ` + validRules + `, "themes": {"work": []}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := recommendations.LoadCatalogue([]byte(tt.catalogue))
+			if tt.wantErr && err == nil {
+				t.Errorf("LoadCatalogue: got no error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("LoadCatalogue: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultCatalogueCoversEveryScore(t *testing.T) {
+	catalogue, err := recommendations.LoadDefaultCatalogue()
+	if err != nil {
+		t.Fatalf("LoadDefaultCatalogue: %v", err)
+	}
+	if catalogue.Version == "" {
+		t.Errorf("default catalogue has no version")
+	}
+
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+	for score := 0; score <= 100; score++ {
+		metric := newMetric()
+		metric.StressLessScore = score
+		if _, err := service.GetRecommendationUsingStressScore(context.Background(), metric); err != nil {
+			t.Errorf("GetRecommendationUsingStressScore(%d): %v", score, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package recommendations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrLLMRequestFailed = errors.New("llm request failed")
+	ErrLLMInvalidReply  = errors.New("llm returned an invalid reply")
+	errLLMRetryable     = errors.New("retryable")
+)
+
+const llmSystemPrompt = `You are a supportive wellbeing coach inside the StressLess app.
+You receive a JSON object describing how a user feels today and the metric_type the advice should focus on.
+feeling_polarity rates their own words from -1 (very negative) to 1 (very positive), themes are what those words are about, tailor the advice to them when there are any.
+Reply ONLY with a JSON object of the form {"items":[{"heading":"...","text":"..."}]} containing exactly 4 short, practical and kind recommendations.
+Do not diagnose. If the user seems to be in danger, advise them to contact a professional or someone they trust.`
+
+type LLMOptions struct {
+	BaseUrl    string
+	ApiKey     string
+	Model      string
+	Timeout    time.Duration
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+// LLMRecommendationService asks an OpenAI compatible chat completions API for
+// recommendations. The StressLess score and any failed request are delegated to
+// the fallback service so a flaky provider never blocks a daily log.
+type LLMRecommendationService struct {
+	client   *http.Client
+	options  LLMOptions
+	fallback RecommendationService
+	logger   *zap.Logger
+}
+
+func NewLLMRecommendationService(options LLMOptions, fallback RecommendationService, logger *zap.Logger) (*LLMRecommendationService, error) {
+	if options.BaseUrl == "" {
+		return nil, errors.New("failed to initialize llm recommendation service, base url is empty")
+	}
+	if options.Model == "" {
+		return nil, errors.New("failed to initialize llm recommendation service, model is empty")
+	}
+	if fallback == nil {
+		return nil, errors.New("failed to initialize llm recommendation service, fallback is nil")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = 250 * time.Millisecond
+	}
+	options.BaseUrl = strings.TrimSuffix(options.BaseUrl, "/")
+
+	return &LLMRecommendationService{
+		client:   &http.Client{Timeout: options.Timeout},
+		options:  options,
+		fallback: fallback,
+		logger:   logger,
+	}, nil
+}
+
+func (s *LLMRecommendationService) GetStresslessScore(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (int, error) {
+	return s.fallback.GetStresslessScore(ctx, stressLevel, mood, sleepQuality, feeling)
+}
+
+func (s *LLMRecommendationService) GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, metric, domain.StressLessScoreMetricType, s.fallback.GetRecommendationUsingStressScore)
+}
+
+func (s *LLMRecommendationService) GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, metric, domain.StressLevelMetricType, s.fallback.GetRecommendationUsingStressLevel)
+}
+
+func (s *LLMRecommendationService) GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, metric, domain.SleepQualityMetricType, s.fallback.GetRecommendationUsingSleepQuality)
+}
+
+func (s *LLMRecommendationService) GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.getRecommendation(ctx, metric, domain.MoodMetricType, s.fallback.GetRecommendationUsingMood)
+}
+
+func (s *LLMRecommendationService) getRecommendation(ctx context.Context, metric domain.Metric, metricType string, fallback func(context.Context, domain.Metric) (domain.Recommendation, error)) (domain.Recommendation, error) {
+	items, err := s.requestItems(ctx, metric, metricType)
+	if err != nil {
+		s.logger.Warn("llm recommendation failed, using fallback",
+			zap.String("metric_id", metric.ID.Hex()),
+			zap.String("metric_type", metricType),
+			zap.Error(err),
+		)
+		return fallback(ctx, metric)
+	}
+	return newRecommendation(metric, metricType, items), nil
+}
+
+// LLMPrompt is the user message sent to the provider, it is exported so fake
+// providers can decode it.
+type LLMPrompt struct {
+	MetricType      string `json:"metric_type"`
+	StressLevel     int    `json:"stress_level"`
+	MaxStressLevel  int    `json:"max_stress_level"`
+	Mood            string `json:"mood"`
+	SleepQuality    string `json:"sleep_quality"`
+	StressLessScore int    `json:"stress_less_score"`
+	Feeling         string `json:"feeling"`
+	// FeelingPolarity goes from -1, very negative, to 1, very positive.
+	FeelingPolarity float64  `json:"feeling_polarity"`
+	Themes          []string `json:"themes"`
+}
+
+// LLMReply is the structured JSON the provider is asked to reply with.
+type LLMReply struct {
+	Items []LLMReplyItem `json:"items"`
+}
+
+type LLMReplyItem struct {
+	Heading string `json:"heading"`
+	Text    string `json:"text"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (s *LLMRecommendationService) requestItems(ctx context.Context, metric domain.Metric, metricType string) ([]domain.RecommendationItem, error) {
+	prompt, err := json.Marshal(LLMPrompt{
+		MetricType:      metricType,
+		StressLevel:     metric.StressLevel,
+		MaxStressLevel:  MaxStressLevel,
+		Mood:            string(metric.Mood),
+		SleepQuality:    string(metric.SleepQuality),
+		StressLessScore: metric.StressLessScore,
+		Feeling:         metric.Feeling,
+		FeelingPolarity: metric.FeelingPolarity,
+		Themes:          themeNames(metric.Themes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: s.options.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: llmSystemPrompt},
+			{Role: "user", Content: string(prompt)},
+		},
+		Temperature:    0.7,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= s.options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := s.options.RetryDelay * time.Duration(1<<(attempt-1))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		var reply chatCompletionResponse
+		reply, lastErr = s.doRequest(ctx, body)
+		if lastErr == nil {
+			return parseLLMReply(reply)
+		}
+		if ctx.Err() != nil || !errors.Is(lastErr, errLLMRetryable) {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+func (s *LLMRecommendationService) doRequest(ctx context.Context, body []byte) (chatCompletionResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.BaseUrl+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return chatCompletionResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.options.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.options.ApiKey)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return chatCompletionResponse{}, fmt.Errorf("%w: %w: %v", ErrLLMRequestFailed, errLLMRetryable, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		io.Copy(io.Discard, res.Body)
+		return chatCompletionResponse{}, fmt.Errorf("%w: %w status %d", ErrLLMRequestFailed, errLLMRetryable, res.StatusCode)
+	}
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, res.Body)
+		return chatCompletionResponse{}, fmt.Errorf("%w: unexpected status %d", ErrLLMRequestFailed, res.StatusCode)
+	}
+
+	var reply chatCompletionResponse
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		return chatCompletionResponse{}, fmt.Errorf("%w: %v", ErrLLMInvalidReply, err)
+	}
+	return reply, nil
+}
+
+func themeNames(themes []domain.Theme) []string {
+	names := []string{}
+	for _, theme := range themes {
+		names = append(names, string(theme))
+	}
+	return names
+}
+
+func parseLLMReply(reply chatCompletionResponse) ([]domain.RecommendationItem, error) {
+	if len(reply.Choices) == 0 {
+		return nil, fmt.Errorf("%w: no choices", ErrLLMInvalidReply)
+	}
+
+	content := strings.TrimSpace(reply.Choices[0].Message.Content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var parsed LLMReply
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLLMInvalidReply, err)
+	}
+
+	items := []domain.RecommendationItem{}
+	for _, item := range parsed.Items {
+		if strings.TrimSpace(item.Heading) == "" || strings.TrimSpace(item.Text) == "" {
+			continue
+		}
+		items = append(items, domain.RecommendationItem{
+			Index:   len(items),
+			Heading: strings.TrimSpace(item.Heading),
+			Text:    strings.TrimSpace(item.Text),
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no usable items", ErrLLMInvalidReply)
+	}
+	return items, nil
+}
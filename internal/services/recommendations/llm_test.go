@@ -0,0 +1,284 @@
+package recommendations_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations/llmfake"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const fallbackHeading = "fallback"
+
+// fallbackService stands in for the rule based service and counts how often
+// the LLM service falls back to it.
+type fallbackService struct {
+	calls int
+}
+
+func (f *fallbackService) GetStresslessScore(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (int, error) {
+	return 42, nil
+}
+
+func (f *fallbackService) GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return f.recommendation(metric, domain.StressLessScoreMetricType), nil
+}
+
+func (f *fallbackService) GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return f.recommendation(metric, domain.StressLevelMetricType), nil
+}
+
+func (f *fallbackService) GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return f.recommendation(metric, domain.SleepQualityMetricType), nil
+}
+
+func (f *fallbackService) GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return f.recommendation(metric, domain.MoodMetricType), nil
+}
+
+func (f *fallbackService) recommendation(metric domain.Metric, metricType string) domain.Recommendation {
+	f.calls++
+	return domain.Recommendation{
+		MetricId:   metric.ID,
+		MetricType: metricType,
+		Items:      []domain.RecommendationItem{{Heading: fallbackHeading, Text: "from the fallback"}},
+	}
+}
+
+func newMetric() domain.Metric {
+	return domain.Metric{
+		ID:              primitive.NewObjectID(),
+		OwnerId:         primitive.NewObjectID(),
+		StressLevel:     4,
+		Mood:            domain.SAD,
+		SleepQuality:    domain.POOR,
+		StressLessScore: 30,
+		Feeling:         "work is a lot this week",
+		FeelingPolarity: -0.4,
+		Themes:          []domain.Theme{domain.WorkTheme},
+	}
+}
+
+func newService(t *testing.T, baseUrl string, maxRetries int, retryDelay time.Duration) (*recommendations.LLMRecommendationService, *fallbackService) {
+	t.Helper()
+	fallback := &fallbackService{}
+	service, err := recommendations.NewLLMRecommendationService(recommendations.LLMOptions{
+		BaseUrl:    baseUrl + "/",
+		Model:      "fake-model",
+		Timeout:    time.Second,
+		MaxRetries: maxRetries,
+		RetryDelay: retryDelay,
+	}, fallback, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewLLMRecommendationService: %v", err)
+	}
+	return service, fallback
+}
+
+func TestLLMRecommendation(t *testing.T) {
+	server, fake := llmfake.NewHttpTestServer()
+	defer server.Close()
+	service, fallback := newService(t, server.URL, 0, time.Millisecond)
+
+	metric := newMetric()
+	recommendation, err := service.GetRecommendationUsingMood(context.Background(), metric)
+	if err != nil {
+		t.Fatalf("GetRecommendationUsingMood: %v", err)
+	}
+	if fallback.calls != 0 {
+		t.Fatalf("fell back %d times, want 0", fallback.calls)
+	}
+	if len(recommendation.Items) != 4 {
+		t.Fatalf("got %d items, want 4", len(recommendation.Items))
+	}
+	for i, item := range recommendation.Items {
+		if item.Index != i || item.Heading == "" || item.Text == "" {
+			t.Errorf("item %d: got %+v", i, item)
+		}
+	}
+	if recommendation.MetricId != metric.ID || recommendation.MetricType != domain.MoodMetricType {
+		t.Errorf("got metric %s and type %s, want %s and %s", recommendation.MetricId.Hex(), recommendation.MetricType, metric.ID.Hex(), domain.MoodMetricType)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	prompt := requests[0]
+	if prompt.MetricType != domain.MoodMetricType || prompt.Feeling != metric.Feeling || prompt.FeelingPolarity != metric.FeelingPolarity {
+		t.Errorf("got prompt %+v", prompt)
+	}
+	if len(prompt.Themes) != 1 || prompt.Themes[0] != string(domain.WorkTheme) {
+		t.Errorf("got themes %v, want [%s]", prompt.Themes, domain.WorkTheme)
+	}
+}
+
+func TestLLMRecommendationRetriesWithBackoff(t *testing.T) {
+	server, fake := llmfake.NewHttpTestServer()
+	defer server.Close()
+	retryDelay := 20 * time.Millisecond
+	service, fallback := newService(t, server.URL, 2, retryDelay)
+
+	fake.FailNext(2, http.StatusServiceUnavailable)
+	start := time.Now()
+	recommendation, err := service.GetRecommendationUsingStressLevel(context.Background(), newMetric())
+	if err != nil {
+		t.Fatalf("GetRecommendationUsingStressLevel: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if fallback.calls != 0 {
+		t.Fatalf("fell back %d times, want 0", fallback.calls)
+	}
+	if len(recommendation.Items) != 4 {
+		t.Fatalf("got %d items, want 4", len(recommendation.Items))
+	}
+	if got := len(fake.Requests()); got != 3 {
+		t.Fatalf("got %d requests, want 3", got)
+	}
+	// the retries wait retryDelay and then twice as long
+	if want := 3 * retryDelay; elapsed < want {
+		t.Errorf("retried after %v, want at least %v", elapsed, want)
+	}
+}
+
+func TestLLMRecommendationFallsBack(t *testing.T) {
+	cases := []struct {
+		name         string
+		failures     int
+		status       int
+		maxRetries   int
+		wantRequests int
+	}{
+		{"retries_exhausted", 3, http.StatusInternalServerError, 2, 3},
+		{"rate_limited", 2, http.StatusTooManyRequests, 1, 2},
+		{"not_retryable", 1, http.StatusBadRequest, 2, 1},
+		{"unauthorized", 1, http.StatusUnauthorized, 2, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, fake := llmfake.NewHttpTestServer()
+			defer server.Close()
+			service, fallback := newService(t, server.URL, c.maxRetries, time.Millisecond)
+
+			fake.FailNext(c.failures, c.status)
+			recommendation, err := service.GetRecommendationUsingSleepQuality(context.Background(), newMetric())
+			if err != nil {
+				t.Fatalf("GetRecommendationUsingSleepQuality: %v", err)
+			}
+			if fallback.calls != 1 {
+				t.Fatalf("fell back %d times, want 1", fallback.calls)
+			}
+			if len(recommendation.Items) != 1 || recommendation.Items[0].Heading != fallbackHeading {
+				t.Errorf("got items %+v, want the fallback's", recommendation.Items)
+			}
+			if got := len(fake.Requests()); got != c.wantRequests {
+				t.Errorf("got %d requests, want %d", got, c.wantRequests)
+			}
+		})
+	}
+}
+
+func TestLLMRecommendationFallsBackWhenUnreachable(t *testing.T) {
+	server, _ := llmfake.NewHttpTestServer()
+	baseUrl := server.URL
+	server.Close()
+	service, fallback := newService(t, baseUrl, 1, time.Millisecond)
+
+	if _, err := service.GetRecommendationUsingStressScore(context.Background(), newMetric()); err != nil {
+		t.Fatalf("GetRecommendationUsingStressScore: %v", err)
+	}
+	if fallback.calls != 1 {
+		t.Fatalf("fell back %d times, want 1", fallback.calls)
+	}
+}
+
+func TestLLMRecommendationParsesReplies(t *testing.T) {
+	cases := []struct {
+		name         string
+		body         string
+		wantFallback bool
+		wantHeadings []string
+	}{
+		{
+			name:         "fenced_json",
+			body:         chatCompletion("```json\n{\"items\":[{\"heading\":\" Breathe \",\"text\":\" Slowly \"}]}\n```"),
+			wantHeadings: []string{"Breathe"},
+		},
+		{
+			name:         "drops_blank_items",
+			body:         chatCompletion(`{"items":[{"heading":"","text":"no heading"},{"heading":"Walk","text":"Ten minutes outside"}]}`),
+			wantHeadings: []string{"Walk"},
+		},
+		{name: "not_json_content", body: chatCompletion("Here are some tips: breathe."), wantFallback: true},
+		{name: "no_items", body: chatCompletion(`{"items":[]}`), wantFallback: true},
+		{name: "only_blank_items", body: chatCompletion(`{"items":[{"heading":" ","text":""}]}`), wantFallback: true},
+		{name: "no_choices", body: `{"choices":[]}`, wantFallback: true},
+		{name: "not_json_body", body: `<html>gateway</html>`, wantFallback: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+			service, fallback := newService(t, server.URL, 2, time.Millisecond)
+
+			recommendation, err := service.GetRecommendationUsingMood(context.Background(), newMetric())
+			if err != nil {
+				t.Fatalf("GetRecommendationUsingMood: %v", err)
+			}
+			// an invalid reply is not retried
+			if requests != 1 {
+				t.Errorf("got %d requests, want 1", requests)
+			}
+			if c.wantFallback {
+				if fallback.calls != 1 {
+					t.Errorf("fell back %d times, want 1", fallback.calls)
+				}
+				return
+			}
+			if fallback.calls != 0 {
+				t.Fatalf("fell back %d times, want 0", fallback.calls)
+			}
+			headings := []string{}
+			for _, item := range recommendation.Items {
+				headings = append(headings, item.Heading)
+			}
+			if len(headings) != len(c.wantHeadings) || headings[0] != c.wantHeadings[0] {
+				t.Errorf("got headings %q, want %q", headings, c.wantHeadings)
+			}
+		})
+	}
+}
+
+func TestLLMStresslessScoreUsesFallback(t *testing.T) {
+	service, _ := newService(t, "http://127.0.0.1:0", 0, time.Millisecond)
+
+	score, err := service.GetStresslessScore(context.Background(), 3, domain.SAD, domain.POOR, "")
+	if err != nil {
+		t.Fatalf("GetStresslessScore: %v", err)
+	}
+	if score != 42 {
+		t.Errorf("got score %d, want the fallback's 42", score)
+	}
+}
+
+func chatCompletion(content string) string {
+	body, _ := json.Marshal(map[string]interface{}{
+		"choices": []map[string]interface{}{
+			{"message": map[string]string{"role": "assistant", "content": content}},
+		},
+	})
+	return string(body)
+}
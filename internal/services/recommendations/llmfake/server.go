@@ -0,0 +1,117 @@
+package llmfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+)
+
+// Server is an offline stand-in for an OpenAI compatible chat completions API.
+// It replies with deterministic recommendations built from the prompt and can
+// be told to fail a number of requests to exercise retries and fallbacks.
+type Server struct {
+	mu           sync.Mutex
+	failuresLeft int
+	failStatus   int
+	requests     []recommendations.LLMPrompt
+}
+
+func New() *Server {
+	return &Server{failStatus: http.StatusInternalServerError}
+}
+
+// NewHttpTestServer starts the fake on a random local port, callers must Close it.
+func NewHttpTestServer() (*httptest.Server, *Server) {
+	fake := New()
+	return httptest.NewServer(fake), fake
+}
+
+// FailNext makes the next n requests fail with the given status code.
+func (s *Server) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failuresLeft = n
+	s.failStatus = status
+}
+
+func (s *Server) Requests() []recommendations.LLMPrompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recommendations.LLMPrompt{}, s.requests...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	var request struct {
+		Model    string    `json:"model"`
+		Messages []message `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+		http.Error(w, `{"error":{"message":"invalid request"}}`, http.StatusBadRequest)
+		return
+	}
+
+	var prompt recommendations.LLMPrompt
+	if err := json.Unmarshal([]byte(request.Messages[len(request.Messages)-1].Content), &prompt); err != nil {
+		http.Error(w, `{"error":{"message":"user message is not json"}}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, prompt)
+	if s.failuresLeft > 0 {
+		s.failuresLeft--
+		status := s.failStatus
+		s.mu.Unlock()
+		http.Error(w, `{"error":{"message":"injected failure"}}`, status)
+		return
+	}
+	s.mu.Unlock()
+
+	content, err := json.Marshal(reply(prompt))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     "chatcmpl-fake",
+		"object": "chat.completion",
+		"model":  request.Model,
+		"choices": []map[string]interface{}{
+			{
+				"index":         0,
+				"finish_reason": "stop",
+				"message": map[string]string{
+					"role":    "assistant",
+					"content": string(content),
+				},
+			},
+		},
+	})
+}
+
+func reply(prompt recommendations.LLMPrompt) recommendations.LLMReply {
+	var result recommendations.LLMReply
+	for i := 1; i <= 4; i++ {
+		result.Items = append(result.Items, recommendations.LLMReplyItem{
+			Heading: fmt.Sprintf("%s tip %d", prompt.MetricType, i),
+			Text: fmt.Sprintf("Suggestion %d for someone feeling %s after %s sleep with stress level %d/%d.",
+				i, prompt.Mood, prompt.SleepQuality, prompt.StressLevel, prompt.MaxStressLevel),
+		})
+	}
+	return result
+}
@@ -0,0 +1,17 @@
+package recommendations
+
+import (
+	"context"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+type (
+	RecommendationService interface {
+		GetStresslessScore(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (int, error)
+		GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error)
+		GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error)
+		GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error)
+		GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error)
+	}
+)
@@ -0,0 +1,88 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type RuleBasedRecommendationService struct {
+	weights   ScoreWeights
+	catalogue *Catalogue
+	analyzer  *sentiment.Analyzer
+	logger    *zap.Logger
+}
+
+func NewRuleBasedRecommendationService(weights ScoreWeights, catalogue *Catalogue, analyzer *sentiment.Analyzer, logger *zap.Logger) (*RuleBasedRecommendationService, error) {
+	if catalogue == nil {
+		return nil, errors.New("failed to initialize rule based recommendation service, catalogue is nil")
+	}
+	if analyzer == nil {
+		return nil, errors.New("failed to initialize rule based recommendation service, analyzer is nil")
+	}
+	if weights.total() == 0 {
+		return nil, ErrInvalidScoreWeights
+	}
+	return &RuleBasedRecommendationService{weights, catalogue, analyzer, logger}, nil
+}
+
+func (s *RuleBasedRecommendationService) CatalogueVersion() string {
+	return s.catalogue.Version
+}
+
+func (s *RuleBasedRecommendationService) GetStresslessScore(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (int, error) {
+	return computeStresslessScore(s.weights, stressLevel, mood, sleepQuality, s.analyzer.Analyze(feeling)), nil
+}
+
+func (s *RuleBasedRecommendationService) GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	items, err := s.catalogue.itemsForNumber(domain.StressLessScoreMetricType, metric.StressLessScore)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	items = s.catalogue.withThemeItems(items, metric.Themes, 0)
+	return newRecommendation(metric, domain.StressLessScoreMetricType, items), nil
+}
+
+func (s *RuleBasedRecommendationService) GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	stressLevel := clamp(metric.StressLevel, MinStressLevel, MaxStressLevel)
+	items, err := s.catalogue.itemsForNumber(domain.StressLevelMetricType, stressLevel)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	// the second item of a theme so it differs from the stress score's
+	items = s.catalogue.withThemeItems(items, metric.Themes, 1)
+	return newRecommendation(metric, domain.StressLevelMetricType, items), nil
+}
+
+func (s *RuleBasedRecommendationService) GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	items, err := s.catalogue.itemsForValue(domain.SleepQualityMetricType, string(metric.SleepQuality))
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return newRecommendation(metric, domain.SleepQualityMetricType, items), nil
+}
+
+func (s *RuleBasedRecommendationService) GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	items, err := s.catalogue.itemsForValue(domain.MoodMetricType, string(metric.Mood))
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return newRecommendation(metric, domain.MoodMetricType, items), nil
+}
+
+func newRecommendation(metric domain.Metric, metricType string, items []domain.RecommendationItem) domain.Recommendation {
+	return domain.Recommendation{
+		ID:         primitive.NewObjectID(),
+		MetricId:   metric.ID,
+		MetricType: metricType,
+		Items:      items,
+		Status:     domain.RecommendationReady,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,200 @@
+package recommendations_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+	"go.uber.org/zap"
+)
+
+func newRuleBasedService(t *testing.T, weights recommendations.ScoreWeights) *recommendations.RuleBasedRecommendationService {
+	t.Helper()
+	catalogue, err := recommendations.LoadDefaultCatalogue()
+	if err != nil {
+		t.Fatalf("LoadDefaultCatalogue: %v", err)
+	}
+	analyzer, err := sentiment.LoadDefaultAnalyzer()
+	if err != nil {
+		t.Fatalf("LoadDefaultAnalyzer: %v", err)
+	}
+	service, err := recommendations.NewRuleBasedRecommendationService(weights, catalogue, analyzer, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRuleBasedRecommendationService: %v", err)
+	}
+	return service
+}
+
+// expectItems checks a recommendation is ready, for metric and metricType,
+// and starts with the headings in want.
+func expectItems(t *testing.T, recommendation domain.Recommendation, metric domain.Metric, metricType string, want ...string) {
+	t.Helper()
+	if recommendation.MetricId != metric.ID || recommendation.MetricType != metricType {
+		t.Errorf("recommendation is for %s %s, want %s %s", recommendation.MetricId.Hex(), recommendation.MetricType, metric.ID.Hex(), metricType)
+	}
+	if recommendation.Status != domain.RecommendationReady {
+		t.Errorf("Status: got %s, want %s", recommendation.Status, domain.RecommendationReady)
+	}
+	if len(recommendation.Items) != 4 {
+		t.Fatalf("got %d items, want 4", len(recommendation.Items))
+	}
+	for i, item := range recommendation.Items {
+		if item.Index != i {
+			t.Errorf("item %d has index %d", i, item.Index)
+		}
+		if i < len(want) && item.Heading != want[i] {
+			t.Errorf("item %d: got %q, want %q", i, item.Heading, want[i])
+		}
+	}
+}
+
+func TestRuleBasedRecommendationUsingStressLevel(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		stressLevel int
+		want        string
+	}{
+		{-1, "Enjoy the calm"},
+		{1, "Enjoy the calm"},
+		{2, "Enjoy the calm"},
+		{3, "Identify the trigger"},
+		{4, "Ground yourself"},
+		{5, "Ground yourself"},
+		{10, "Ground yourself"},
+	}
+	for _, tt := range tests {
+		metric := newMetric()
+		metric.StressLevel, metric.Themes = tt.stressLevel, []domain.Theme{}
+		recommendation, err := service.GetRecommendationUsingStressLevel(context.Background(), metric)
+		if err != nil {
+			t.Fatalf("GetRecommendationUsingStressLevel(%d): %v", tt.stressLevel, err)
+		}
+		expectItems(t, recommendation, metric, domain.StressLevelMetricType, tt.want)
+	}
+}
+
+func TestRuleBasedRecommendationUsingStressScore(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{0, "Pause and breathe"},
+		{39, "Pause and breathe"},
+		{40, "Take short breaks"},
+		{69, "Take short breaks"},
+		{70, "Keep it up"},
+		{100, "Keep it up"},
+	}
+	for _, tt := range tests {
+		metric := newMetric()
+		metric.StressLessScore, metric.Themes = tt.score, []domain.Theme{}
+		recommendation, err := service.GetRecommendationUsingStressScore(context.Background(), metric)
+		if err != nil {
+			t.Fatalf("GetRecommendationUsingStressScore(%d): %v", tt.score, err)
+		}
+		expectItems(t, recommendation, metric, domain.StressLessScoreMetricType, tt.want)
+	}
+}
+
+func TestRuleBasedRecommendationUsingMood(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		mood domain.Mood
+		want string
+	}{
+		{domain.OVERJOYED, "Savour the moment"},
+		{domain.HAPPY, "Savour the moment"},
+		{domain.NEUTRAL, "Do something you enjoy"},
+		{domain.SAD, "Be kind to yourself"},
+		{domain.DEPRESSED, "Be kind to yourself"},
+	}
+	for _, tt := range tests {
+		metric := newMetric()
+		metric.Mood = tt.mood
+		recommendation, err := service.GetRecommendationUsingMood(context.Background(), metric)
+		if err != nil {
+			t.Fatalf("GetRecommendationUsingMood(%s): %v", tt.mood, err)
+		}
+		expectItems(t, recommendation, metric, domain.MoodMetricType, tt.want)
+	}
+}
+
+func TestRuleBasedRecommendationUsingSleepQuality(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		sleepQuality domain.SleepQuality
+		want         string
+	}{
+		{domain.EXCELLENT, "Protect your sleep"},
+		{domain.GOOD, "Protect your sleep"},
+		{domain.FAIR, "Keep a schedule"},
+		{domain.POOR, "Go easy on yourself"},
+		{domain.WORST, "Go easy on yourself"},
+	}
+	for _, tt := range tests {
+		metric := newMetric()
+		metric.SleepQuality = tt.sleepQuality
+		recommendation, err := service.GetRecommendationUsingSleepQuality(context.Background(), metric)
+		if err != nil {
+			t.Fatalf("GetRecommendationUsingSleepQuality(%s): %v", tt.sleepQuality, err)
+		}
+		expectItems(t, recommendation, metric, domain.SleepQualityMetricType, tt.want)
+	}
+}
+
+func TestRuleBasedRecommendationWithoutMatchingRule(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	metric := newMetric()
+	metric.Mood = "furious"
+	if _, err := service.GetRecommendationUsingMood(context.Background(), metric); !errors.Is(err, recommendations.ErrNoMatchingCatalogueRule) {
+		t.Errorf("GetRecommendationUsingMood(furious): got error %v, want %v", err, recommendations.ErrNoMatchingCatalogueRule)
+	}
+	metric.StressLessScore = 101
+	if _, err := service.GetRecommendationUsingStressScore(context.Background(), metric); !errors.Is(err, recommendations.ErrNoMatchingCatalogueRule) {
+		t.Errorf("GetRecommendationUsingStressScore(101): got error %v, want %v", err, recommendations.ErrNoMatchingCatalogueRule)
+	}
+}
+
+func TestRuleBasedRecommendationWithThemes(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		name       string
+		themes     []domain.Theme
+		scoreWant  []string
+		stressWant []string
+	}{
+		{"no themes", []domain.Theme{}, []string{"Pause and breathe"}, []string{"Ground yourself"}},
+		{"one theme", []domain.Theme{domain.WorkTheme}, []string{"Leave work at work", "Pause and breathe"}, []string{"One thing at a time", "Ground yourself"}},
+		{"at most two themes", []domain.Theme{domain.MoneyTheme, domain.SleepTheme, domain.WorkTheme},
+			[]string{"Take stock of your money", "Wind down earlier", "Pause and breathe"},
+			[]string{"One money step", "Keep a sleep schedule", "Ground yourself"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := newMetric()
+			metric.StressLessScore, metric.StressLevel, metric.Themes = 10, 5, tt.themes
+
+			recommendation, err := service.GetRecommendationUsingStressScore(context.Background(), metric)
+			if err != nil {
+				t.Fatalf("GetRecommendationUsingStressScore: %v", err)
+			}
+			expectItems(t, recommendation, metric, domain.StressLessScoreMetricType, tt.scoreWant...)
+
+			recommendation, err = service.GetRecommendationUsingStressLevel(context.Background(), metric)
+			if err != nil {
+				t.Fatalf("GetRecommendationUsingStressLevel: %v", err)
+			}
+			expectItems(t, recommendation, metric, domain.StressLevelMetricType, tt.stressWant...)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package recommendations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+)
+
+const (
+	MinStressLevel = 1
+	MaxStressLevel = 5
+
+	// FeelingScoreWeight is the key of the feeling weight in ParseScoreWeights,
+	// the other keys are metric types.
+	FeelingScoreWeight = "feeling"
+)
+
+var ErrInvalidScoreWeights = errors.New("invalid score weights")
+
+// ScoreWeights controls how much each input contributes to the StressLess score.
+// The weights are relative to each other, they do not have to sum up to 1.
+// Feeling only counts when the feeling says something positive or negative.
+type ScoreWeights struct {
+	StressLevel  float64
+	Mood         float64
+	SleepQuality float64
+	Feeling      float64
+}
+
+var DefaultScoreWeights = ScoreWeights{
+	StressLevel:  0.4,
+	Mood:         0.3,
+	SleepQuality: 0.3,
+	Feeling:      0.2,
+}
+
+var moodScores = map[domain.Mood]float64{
+	domain.OVERJOYED: 1,
+	domain.HAPPY:     0.75,
+	domain.NEUTRAL:   0.5,
+	domain.SAD:       0.25,
+	domain.DEPRESSED: 0,
+}
+
+var sleepQualityScores = map[domain.SleepQuality]float64{
+	domain.EXCELLENT: 1,
+	domain.GOOD:      0.75,
+	domain.FAIR:      0.5,
+	domain.POOR:      0.25,
+	domain.WORST:     0,
+}
+
+// ParseScoreWeights reads weights in the form "stress_level=0.4,mood=0.3,sleep_quality=0.3,feeling=0.2".
+// Any weight that is not set keeps its default value.
+func ParseScoreWeights(raw string) (ScoreWeights, error) {
+	weights := DefaultScoreWeights
+	if strings.TrimSpace(raw) == "" {
+		return weights, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return ScoreWeights{}, fmt.Errorf("%w: %q is not a key=value pair", ErrInvalidScoreWeights, pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return ScoreWeights{}, fmt.Errorf("%w: %q is not a non-negative number", ErrInvalidScoreWeights, value)
+		}
+
+		switch strings.TrimSpace(key) {
+		case domain.StressLevelMetricType:
+			weights.StressLevel = weight
+		case domain.MoodMetricType:
+			weights.Mood = weight
+		case domain.SleepQualityMetricType:
+			weights.SleepQuality = weight
+		case FeelingScoreWeight:
+			weights.Feeling = weight
+		default:
+			return ScoreWeights{}, fmt.Errorf("%w: unknown weight %q", ErrInvalidScoreWeights, key)
+		}
+	}
+
+	if weights.total() == 0 {
+		return ScoreWeights{}, fmt.Errorf("%w: at least one of stress_level, mood or sleep_quality must be greater than zero", ErrInvalidScoreWeights)
+	}
+	return weights, nil
+}
+
+// total leaves Feeling out, a feeling without sentiment has no say in the
+// score so the other weights have to be enough on their own.
+func (w ScoreWeights) total() float64 {
+	return w.StressLevel + w.Mood + w.SleepQuality
+}
+
+// computeStresslessScore maps the inputs to a score between 0 and 100, where a
+// higher score means the user is less stressed.
+func computeStresslessScore(weights ScoreWeights, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling sentiment.Analysis) int {
+	if weights.total() == 0 {
+		weights = DefaultScoreWeights
+	}
+
+	stressLevelScore := float64(MaxStressLevel-clamp(stressLevel, MinStressLevel, MaxStressLevel)) / float64(MaxStressLevel-MinStressLevel)
+
+	weighted := weights.StressLevel*stressLevelScore +
+		weights.Mood*moodScores[mood] +
+		weights.SleepQuality*sleepQualityScores[sleepQuality]
+	total := weights.total()
+	if feeling.HasSentiment() {
+		weighted += weights.Feeling * (feeling.Polarity + 1) / 2
+		total += weights.Feeling
+	}
+
+	return clamp(int(math.Round(100*weighted/total)), 0, 100)
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
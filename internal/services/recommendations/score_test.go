@@ -0,0 +1,109 @@
+package recommendations_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+)
+
+func TestStresslessScore(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.DefaultScoreWeights)
+
+	tests := []struct {
+		name         string
+		stressLevel  int
+		mood         domain.Mood
+		sleepQuality domain.SleepQuality
+		feeling      string
+		want         int
+	}{
+		{"best inputs", 1, domain.OVERJOYED, domain.EXCELLENT, "", 100},
+		{"worst inputs", 5, domain.DEPRESSED, domain.WORST, "", 0},
+		{"middle inputs", 3, domain.NEUTRAL, domain.FAIR, "", 50},
+
+		{"stress level 1", 1, domain.NEUTRAL, domain.FAIR, "", 70},
+		{"stress level 2", 2, domain.NEUTRAL, domain.FAIR, "", 60},
+		{"stress level 4", 4, domain.NEUTRAL, domain.FAIR, "", 40},
+		{"stress level 5", 5, domain.NEUTRAL, domain.FAIR, "", 30},
+		{"stress level 10 counts as 5", 10, domain.NEUTRAL, domain.FAIR, "", 30},
+		{"stress level 0 counts as 1", 0, domain.NEUTRAL, domain.FAIR, "", 70},
+		{"negative stress level counts as 1", -3, domain.NEUTRAL, domain.FAIR, "", 70},
+
+		{"overjoyed", 5, domain.OVERJOYED, domain.WORST, "", 30},
+		{"happy", 5, domain.HAPPY, domain.WORST, "", 22},
+		{"neutral", 5, domain.NEUTRAL, domain.WORST, "", 15},
+		{"sad", 5, domain.SAD, domain.WORST, "", 8},
+		{"depressed", 5, domain.DEPRESSED, domain.WORST, "", 0},
+
+		{"excellent sleep", 5, domain.DEPRESSED, domain.EXCELLENT, "", 30},
+		{"good sleep", 5, domain.DEPRESSED, domain.GOOD, "", 22},
+		{"fair sleep", 5, domain.DEPRESSED, domain.FAIR, "", 15},
+		{"poor sleep", 5, domain.DEPRESSED, domain.POOR, "", 8},
+		{"worst sleep", 5, domain.DEPRESSED, domain.WORST, "", 0},
+
+		{"feeling without sentiment has no say", 3, domain.NEUTRAL, domain.FAIR, "went to the market", 50},
+		{"positive feeling raises the score", 3, domain.NEUTRAL, domain.FAIR, "happy", 55},
+		{"negative feeling lowers the score", 3, domain.NEUTRAL, domain.FAIR, "depressed and hopeless", 43},
+		{"best inputs and a positive feeling stay at 100", 1, domain.OVERJOYED, domain.EXCELLENT, "amazing awesome wonderful fantastic", 100},
+		{"worst inputs and a negative feeling stay above 0", 5, domain.DEPRESSED, domain.WORST, "depressed hopeless miserable", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.GetStresslessScore(context.Background(), tt.stressLevel, tt.mood, tt.sleepQuality, tt.feeling)
+			if err != nil {
+				t.Fatalf("GetStresslessScore: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetStresslessScore(%d, %s, %s, %q): got %d, want %d", tt.stressLevel, tt.mood, tt.sleepQuality, tt.feeling, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStresslessScoreWeights(t *testing.T) {
+	service := newRuleBasedService(t, recommendations.ScoreWeights{Mood: 1})
+
+	got, err := service.GetStresslessScore(context.Background(), 5, domain.HAPPY, domain.WORST, "")
+	if err != nil {
+		t.Fatalf("GetStresslessScore: %v", err)
+	}
+	if got != 75 {
+		t.Errorf("GetStresslessScore with only the mood weighted: got %d, want 75", got)
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    recommendations.ScoreWeights
+		wantErr bool
+	}{
+		{raw: "", want: recommendations.DefaultScoreWeights},
+		{raw: "mood=1", want: recommendations.ScoreWeights{StressLevel: 0.4, Mood: 1, SleepQuality: 0.3, Feeling: 0.2}},
+		{raw: " stress_level = 0.5 , feeling=0 ", want: recommendations.ScoreWeights{StressLevel: 0.5, Mood: 0.3, SleepQuality: 0.3}},
+		{raw: "mood", wantErr: true},
+		{raw: "mood=-1", wantErr: true},
+		{raw: "mood=high", wantErr: true},
+		{raw: "weather=1", wantErr: true},
+		{raw: "stress_level=0,mood=0,sleep_quality=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := recommendations.ParseScoreWeights(tt.raw)
+		if tt.wantErr {
+			if !errors.Is(err, recommendations.ErrInvalidScoreWeights) {
+				t.Errorf("ParseScoreWeights(%q): got error %v, want %v", tt.raw, err, recommendations.ErrInvalidScoreWeights)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseScoreWeights(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseScoreWeights(%q): got %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
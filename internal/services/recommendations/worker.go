@@ -0,0 +1,318 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// JobQueueName is the infra.JobQueue queue generation jobs are sent on, a job
+// is the hex id of a pending recommendation.
+const JobQueueName = "recommendations"
+
+// recoverBatchSize caps how many pending recommendations Start enqueues again.
+const recoverBatchSize = 1000
+
+// recoverLockKey is taken by the instance that enqueues pending
+// recommendations again, instances that start within recoverLockTTL of it
+// skip that so the same jobs are not queued once per instance.
+const (
+	recoverLockKey = "worker:recommendations:recover"
+	recoverLockTTL = 5 * time.Minute
+)
+
+type WorkerOptions struct {
+	Concurrency int
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it doubles on every retry
+	// after that.
+	Backoff time.Duration
+}
+
+// Worker generates pending recommendations in the background so a slow
+// provider never holds up a request.
+type Worker struct {
+	queue              infra.JobQueue
+	cache              infra.Cache
+	metricRepo         infra.MetricRepository
+	recommendationRepo infra.RecommendationRepository
+	service            RecommendationService
+	options            WorkerOptions
+	logger             *zap.Logger
+
+	stop  context.CancelFunc
+	abort context.CancelFunc
+	wg    sync.WaitGroup
+}
+
+func NewWorker(queue infra.JobQueue, cache infra.Cache, metricRepo infra.MetricRepository, recommendationRepo infra.RecommendationRepository, service RecommendationService, options WorkerOptions, logger *zap.Logger) (*Worker, error) {
+	if queue == nil {
+		return nil, errors.New("failed to initialize recommendation worker, queue is nil")
+	}
+	if cache == nil {
+		return nil, errors.New("failed to initialize recommendation worker, cache is nil")
+	}
+	if metricRepo == nil {
+		return nil, errors.New("failed to initialize recommendation worker, metricRepo is nil")
+	}
+	if recommendationRepo == nil {
+		return nil, errors.New("failed to initialize recommendation worker, recommendationRepo is nil")
+	}
+	if service == nil {
+		return nil, errors.New("failed to initialize recommendation worker, service is nil")
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = 2 * time.Second
+	}
+	return &Worker{
+		queue:              queue,
+		cache:              cache,
+		metricRepo:         metricRepo,
+		recommendationRepo: recommendationRepo,
+		service:            service,
+		options:            options,
+		logger:             logger,
+	}, nil
+}
+
+// EnqueueGeneration schedules a pending recommendation to be generated.
+func EnqueueGeneration(ctx context.Context, queue infra.JobQueue, recommendationId primitive.ObjectID) error {
+	return queue.Enqueue(ctx, JobQueueName, recommendationId.Hex())
+}
+
+// Start enqueues recommendations left pending by a previous run, their jobs
+// may have been lost with it, and starts the workers.
+func (w *Worker) Start(ctx context.Context) error {
+	if err := w.recoverPending(ctx); err != nil {
+		return err
+	}
+
+	// dequeueing stops as soon as Shutdown is called, jobs already running
+	// are only cancelled once its deadline passes.
+	dequeueCtx, stop := context.WithCancel(ctx)
+	jobCtx, abort := context.WithCancel(context.Background())
+	w.stop, w.abort = stop, abort
+
+	for i := 0; i < w.options.Concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.run(dequeueCtx, jobCtx)
+		}()
+	}
+	return nil
+}
+
+// recoverPending enqueues pending recommendations again when this instance
+// takes the recover lock. The lock is left to expire so instances starting
+// together, as in a rolling deploy, recover only once.
+func (w *Worker) recoverPending(ctx context.Context) error {
+	locked, err := w.cache.SetOneIfNotExists(ctx, recoverLockKey, time.Now().UTC().Format(time.RFC3339), recoverLockTTL)
+	if err != nil {
+		return fmt.Errorf("failed to take the recover lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+
+	pending, err := w.recommendationRepo.GetPendingRecommendations(ctx, recoverBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load pending recommendations: %w", err)
+	}
+	for _, recommendation := range pending {
+		if err := EnqueueGeneration(ctx, w.queue, recommendation.ID); err != nil {
+			return fmt.Errorf("failed to enqueue pending recommendation: %w", err)
+		}
+	}
+	if len(pending) > 0 {
+		w.logger.Info("enqueued pending recommendations", zap.Int("count", len(pending)))
+	}
+	return nil
+}
+
+// Shutdown stops taking new jobs and waits for running ones to finish, or
+// cancels them once ctx is done. Cancelled jobs stay pending and are handed
+// back to the queue.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	if w.stop == nil {
+		return nil
+	}
+	w.stop()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		w.abort()
+		return nil
+	case <-ctx.Done():
+		w.abort()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) run(dequeueCtx, jobCtx context.Context) {
+	for {
+		payload, err := w.queue.Dequeue(dequeueCtx, JobQueueName)
+		if dequeueCtx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.logger.Error("failed to dequeue recommendation job", zap.Error(err))
+			if !sleep(dequeueCtx, w.options.Backoff) {
+				return
+			}
+			continue
+		}
+
+		recommendationId, err := primitive.ObjectIDFromHex(payload)
+		if err != nil {
+			w.logger.Error("dropping invalid recommendation job", zap.String("payload", payload))
+			continue
+		}
+		w.process(dequeueCtx, jobCtx, recommendationId)
+	}
+}
+
+// process generates a recommendation, retrying with exponential backoff. A
+// retry that would wait past Shutdown is handed back to the queue instead.
+func (w *Worker) process(dequeueCtx, jobCtx context.Context, recommendationId primitive.ObjectID) {
+	logger := w.logger.With(zap.String("recommendation_id", recommendationId.Hex()))
+	for attempt := 1; ; attempt++ {
+		err := w.generate(jobCtx, recommendationId)
+		if err == nil {
+			return
+		}
+		if jobCtx.Err() != nil {
+			logger.Warn("recommendation job cancelled by shutdown", zap.Error(err))
+			w.handBack(recommendationId)
+			return
+		}
+		if attempt >= w.options.MaxAttempts {
+			logger.Error("giving up on recommendation", zap.Int("attempts", attempt), zap.Error(err))
+			if err := w.markFailed(jobCtx, recommendationId); err != nil {
+				logger.Error("failed to mark recommendation as failed", zap.Error(err))
+			}
+			return
+		}
+
+		delay := w.options.Backoff << (attempt - 1)
+		logger.Warn("retrying recommendation", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		if !sleep(dequeueCtx, delay) {
+			if err := EnqueueGeneration(jobCtx, w.queue, recommendationId); err != nil {
+				logger.Error("failed to hand recommendation back to the queue", zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+// handBack enqueues a job cancelled by Shutdown again, so an instance that
+// starts before the recover lock expires still generates it.
+func (w *Worker) handBack(recommendationId primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := EnqueueGeneration(ctx, w.queue, recommendationId); err != nil {
+		w.logger.Error("failed to hand recommendation back to the queue", zap.String("recommendation_id", recommendationId.Hex()), zap.Error(err))
+	}
+}
+
+func (w *Worker) generate(ctx context.Context, recommendationId primitive.ObjectID) error {
+	recommendation, err := w.recommendationRepo.GetRecommendationById(ctx, recommendationId)
+	if errors.Is(err, infra.ErrRecommendationNotFound) {
+		// the daily log was deleted before its job ran
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if recommendation.Status != domain.RecommendationPending {
+		// a duplicate job already generated it
+		return nil
+	}
+
+	metric, err := w.metricRepo.GetMetricById(ctx, recommendation.MetricId)
+	if errors.Is(err, infra.ErrMetricNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	generated, err := w.generateForMetricType(ctx, metric, recommendation.MetricType)
+	if err != nil {
+		return err
+	}
+
+	// the log may have been edited while this job was generating, in which
+	// case the recommendation was reset and its newer job will fill it in.
+	current, err := w.recommendationRepo.GetRecommendationById(ctx, recommendationId)
+	if err != nil {
+		return err
+	}
+	if !current.UpdatedAt.Equal(recommendation.UpdatedAt) {
+		return nil
+	}
+
+	recommendation.Items = generated.Items
+	recommendation.Status = domain.RecommendationReady
+	recommendation.UpdatedAt = time.Now()
+	return w.recommendationRepo.UpdateRecommendationById(ctx, recommendation)
+}
+
+func (w *Worker) generateForMetricType(ctx context.Context, metric domain.Metric, metricType string) (domain.Recommendation, error) {
+	switch metricType {
+	case domain.StressLessScoreMetricType:
+		return w.service.GetRecommendationUsingStressScore(ctx, metric)
+	case domain.StressLevelMetricType:
+		return w.service.GetRecommendationUsingStressLevel(ctx, metric)
+	case domain.SleepQualityMetricType:
+		return w.service.GetRecommendationUsingSleepQuality(ctx, metric)
+	case domain.MoodMetricType:
+		return w.service.GetRecommendationUsingMood(ctx, metric)
+	default:
+		return domain.Recommendation{}, fmt.Errorf("no recommendations for metric type %q", metricType)
+	}
+}
+
+func (w *Worker) markFailed(ctx context.Context, recommendationId primitive.ObjectID) error {
+	recommendation, err := w.recommendationRepo.GetRecommendationById(ctx, recommendationId)
+	if err != nil {
+		return err
+	}
+	if recommendation.Status != domain.RecommendationPending {
+		return nil
+	}
+	recommendation.Status = domain.RecommendationFailed
+	recommendation.UpdatedAt = time.Now()
+	return w.recommendationRepo.UpdateRecommendationById(ctx, recommendation)
+}
+
+// sleep waits for d and reports false if ctx was done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package recommendations_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// countingQueue counts the jobs enqueued on a memory queue.
+type countingQueue struct {
+	*memory.MemoryJobQueue
+	mu       sync.Mutex
+	enqueued int
+}
+
+func (c *countingQueue) Enqueue(ctx context.Context, queue, payload string) error {
+	c.mu.Lock()
+	c.enqueued++
+	c.mu.Unlock()
+	return c.MemoryJobQueue.Enqueue(ctx, queue, payload)
+}
+
+func (c *countingQueue) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enqueued
+}
+
+func newWorker(t *testing.T, queue infra.JobQueue, cache infra.Cache, recommendationRepo infra.RecommendationRepository) *recommendations.Worker {
+	t.Helper()
+	worker, err := recommendations.NewWorker(queue, cache, memory.NewMemoryMetricRepo(), recommendationRepo,
+		newRuleBasedService(t, recommendations.DefaultScoreWeights), recommendations.WorkerOptions{Concurrency: 1}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewWorker: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := worker.Shutdown(ctx); err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+	})
+	return worker
+}
+
+func TestStartRecoversPendingRecommendationsOnce(t *testing.T) {
+	recommendationRepo := memory.NewMemoryRecommendationRepo()
+	for i := 0; i < 3; i++ {
+		metric := domain.Metric{ID: primitive.NewObjectID(), OwnerId: primitive.NewObjectID()}
+		if err := recommendationRepo.CreateRecommendation(context.Background(), domain.NewPendingRecommendation(metric, domain.MoodMetricType)); err != nil {
+			t.Fatalf("CreateRecommendation: %v", err)
+		}
+	}
+	queue, cache := &countingQueue{MemoryJobQueue: memory.NewMemoryJobQueue()}, memory.NewMemoryCache()
+
+	// instances sharing a queue start together
+	for i := 0; i < 2; i++ {
+		if err := newWorker(t, queue, cache, recommendationRepo).Start(context.Background()); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	}
+	if queue.count() != 3 {
+		t.Errorf("jobs enqueued by two instances: got %d, want 3", queue.count())
+	}
+
+	// a restarted instance whose queue and lock are kept in process recovers them again
+	if err := newWorker(t, queue, memory.NewMemoryCache(), recommendationRepo).Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if queue.count() != 6 {
+		t.Errorf("jobs enqueued after a restart: got %d, want 6", queue.count())
+	}
+}
@@ -0,0 +1,199 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/notifications"
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const lockKey = "scheduler:reminders:lock"
+
+// sentKeyTTL outlives the longest local day, so a reminder is never sent twice
+// for the same date however the user's timezone changes.
+const sentKeyTTL = 48 * time.Hour
+
+// pageSize is how many users are loaded from the repository at a time.
+const pageSize = 200
+
+type SchedulerOptions struct {
+	// Interval is how often users are checked for a due reminder, a reminder
+	// goes out up to one interval after its time.
+	Interval time.Duration
+	// AppBaseUrl is opened when a reminder is clicked.
+	AppBaseUrl string
+}
+
+// Scheduler reminds users who have not logged today once their reminder time
+// has passed. Every instance runs one, the instance holding the lock in cache
+// does the work for that interval.
+type Scheduler struct {
+	userRepo   infra.UserRepository
+	cache      infra.Cache
+	notifier   notifications.Notifier
+	options    SchedulerOptions
+	instanceId string
+	logger     *zap.Logger
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(userRepo infra.UserRepository, cache infra.Cache, notifier notifications.Notifier, options SchedulerOptions, logger *zap.Logger) (*Scheduler, error) {
+	if userRepo == nil {
+		return nil, errors.New("failed to initialize reminder scheduler, userRepo is nil")
+	}
+	if cache == nil {
+		return nil, errors.New("failed to initialize reminder scheduler, cache is nil")
+	}
+	if notifier == nil {
+		return nil, errors.New("failed to initialize reminder scheduler, notifier is nil")
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	return &Scheduler{
+		userRepo:   userRepo,
+		cache:      cache,
+		notifier:   notifier,
+		options:    options,
+		instanceId: xid.New().String(),
+		logger:     logger,
+	}, nil
+}
+
+// Start runs the scheduler in the background until Shutdown is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, stop := context.WithCancel(ctx)
+	s.stop = stop
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Tick(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				s.logger.Error("failed to send reminders", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops the scheduler and waits for a running tick to finish, or
+// returns once ctx is done.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	s.stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Tick sends every reminder due at now, when this instance holds the lock.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	locked, err := s.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to take the reminder lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+
+	afterId := primitive.NilObjectID
+	for {
+		users, err := s.userRepo.GetUsersWithRemindersEnabled(ctx, afterId, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to load users with reminders enabled: %w", err)
+		}
+		for _, user := range users {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.remind(ctx, user, now)
+		}
+		if len(users) < pageSize {
+			return nil
+		}
+		afterId = users[len(users)-1].ID
+	}
+}
+
+// lock takes the lock for one interval, or extends it when this instance
+// still holds it. The lock is never released early so other instances skip
+// the rest of the interval. If the holder dies another instance takes over
+// once it expires.
+func (s *Scheduler) lock(ctx context.Context) (bool, error) {
+	locked, err := s.cache.SetOneIfNotExists(ctx, lockKey, s.instanceId, s.options.Interval)
+	if err != nil || locked {
+		return locked, err
+	}
+	// compared and extended in one step, so a lock that expired and was
+	// taken by another instance in between is left to it.
+	return s.cache.SetOneIfEquals(ctx, lockKey, s.instanceId, s.instanceId, s.options.Interval)
+}
+
+// remind claims the user's reminder for their local date before sending it,
+// so a reminder is sent at most once a day even when a lock changes hands
+// mid tick. A reminder that fails to send gives up its claim so the next tick
+// tries again.
+func (s *Scheduler) remind(ctx context.Context, user domain.User, now time.Time) {
+	location := user.Location()
+	if !user.Reminders.IsDue(now, user.LastMetricLog, location) {
+		return
+	}
+	logger := s.logger.With(zap.String("user_id", user.ID.Hex()))
+
+	key := fmt.Sprintf("reminder-sent:%s:%s", user.ID.Hex(), domain.LocalDate(now, location))
+	claimed, err := s.cache.SetOneIfNotExists(ctx, key, now.UTC().Format(time.RFC3339), sentKeyTTL)
+	if err != nil {
+		logger.Error("failed to claim reminder", zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	if err := s.notifier.Notify(ctx, user, s.notification(user)); err != nil {
+		logger.Error("failed to send reminder", zap.Error(err))
+		if err := s.cache.DeleteOne(ctx, key); err != nil {
+			logger.Error("failed to release reminder claim", zap.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) notification(user domain.User) notifications.Notification {
+	title := "How was your day?"
+	if user.FirstName != "" {
+		title = fmt.Sprintf("How was your day, %s?", user.FirstName)
+	}
+	return notifications.Notification{
+		Title: title,
+		Body:  "Take a minute to log your stress, sleep and mood for today.",
+		Url:   s.options.AppBaseUrl,
+	}
+}
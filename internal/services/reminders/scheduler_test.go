@@ -0,0 +1,123 @@
+package reminders_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/notifications"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/reminders"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// fakeNotifier records who it notified and fails while failing is set.
+type fakeNotifier struct {
+	mu       sync.Mutex
+	failing  bool
+	notified []primitive.ObjectID
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, user domain.User, notification notifications.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		return errors.New("notifier is down")
+	}
+	f.notified = append(f.notified, user.ID)
+	return nil
+}
+
+func (f *fakeNotifier) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+func (f *fakeNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.notified)
+}
+
+// reminderDue is 21:00 in Lagos, after the default reminder time.
+var reminderDue = time.Date(2023, 11, 20, 20, 0, 0, 0, time.UTC)
+
+func newUserWithReminders(t *testing.T, userRepo *memory.MemoryUserRepository) domain.User {
+	t.Helper()
+	user := domain.User{
+		ID:        primitive.NewObjectID(),
+		Email:     primitive.NewObjectID().Hex() + "@example.com",
+		Timezone:  "Africa/Lagos",
+		Reminders: domain.ReminderPreferences{Enabled: true},
+	}
+	if err := userRepo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func newScheduler(t *testing.T, userRepo *memory.MemoryUserRepository, cache *memory.MemoryCache, notifier notifications.Notifier) *reminders.Scheduler {
+	t.Helper()
+	scheduler, err := reminders.NewScheduler(userRepo, cache, notifier, reminders.SchedulerOptions{Interval: time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	return scheduler
+}
+
+func TestTickSendsAReminderOnceADay(t *testing.T) {
+	userRepo, cache, notifier := memory.NewMemoryUserRepo(), memory.NewMemoryCache(), &fakeNotifier{}
+	newUserWithReminders(t, userRepo)
+	scheduler := newScheduler(t, userRepo, cache, notifier)
+
+	for i := 0; i < 3; i++ {
+		if err := scheduler.Tick(context.Background(), reminderDue.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("Tick: %v", err)
+		}
+	}
+	if notifier.count() != 1 {
+		t.Errorf("reminders sent: got %d, want 1", notifier.count())
+	}
+}
+
+func TestTickRetriesAReminderThatFailedToSend(t *testing.T) {
+	userRepo, cache, notifier := memory.NewMemoryUserRepo(), memory.NewMemoryCache(), &fakeNotifier{failing: true}
+	newUserWithReminders(t, userRepo)
+	scheduler := newScheduler(t, userRepo, cache, notifier)
+
+	if err := scheduler.Tick(context.Background(), reminderDue); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	notifier.setFailing(false)
+	if err := scheduler.Tick(context.Background(), reminderDue.Add(time.Minute)); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if notifier.count() != 1 {
+		t.Errorf("reminders sent after a failure: got %d, want 1", notifier.count())
+	}
+}
+
+func TestTickSkipsWhileAnotherInstanceHoldsTheLock(t *testing.T) {
+	userRepo, cache, notifier := memory.NewMemoryUserRepo(), memory.NewMemoryCache(), &fakeNotifier{}
+	newUserWithReminders(t, userRepo)
+	holder := newScheduler(t, userRepo, cache, &fakeNotifier{})
+	scheduler := newScheduler(t, userRepo, cache, notifier)
+
+	// the holder takes the lock with a tick of no due reminders
+	if err := holder.Tick(context.Background(), reminderDue.Add(-3*time.Hour)); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := scheduler.Tick(context.Background(), reminderDue); err != nil {
+			t.Fatalf("Tick: %v", err)
+		}
+	}
+	if notifier.count() != 0 {
+		t.Errorf("reminders sent without the lock: got %d, want 0", notifier.count())
+	}
+}
@@ -0,0 +1,132 @@
+package safety
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+)
+
+//go:embed catalogue.json
+var defaultCatalogue []byte
+
+// DefaultCountry is the catalogue entry for users whose timezone is not listed
+// under any country.
+const DefaultCountry = "default"
+
+type resource struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+}
+
+type country struct {
+	Name      string     `json:"name"`
+	Timezones []string   `json:"timezones"`
+	Resources []resource `json:"resources"`
+}
+
+// Catalogue is the content of catalogue.json: the high-risk phrases a feeling
+// is checked for and the crisis resources of each country, by ISO country
+// code.
+type Catalogue struct {
+	Version   string             `json:"version"`
+	Phrases   []string           `json:"phrases"`
+	Heading   string             `json:"heading"`
+	Text      string             `json:"text"`
+	Countries map[string]country `json:"countries"`
+
+	phrases           []string
+	countryByTimezone map[string]string
+}
+
+func LoadDefaultCatalogue() (*Catalogue, error) {
+	return LoadCatalogue(defaultCatalogue)
+}
+
+func LoadCatalogue(content []byte) (*Catalogue, error) {
+	var catalogue Catalogue
+	if err := json.Unmarshal(content, &catalogue); err != nil {
+		return nil, fmt.Errorf("failed to parse safety catalogue: %w", err)
+	}
+	if catalogue.Version == "" {
+		return nil, errors.New("safety catalogue has no version")
+	}
+	if len(catalogue.Phrases) == 0 {
+		return nil, fmt.Errorf("safety catalogue %s has no phrases", catalogue.Version)
+	}
+	if catalogue.Heading == "" {
+		return nil, fmt.Errorf("safety catalogue %s has no heading", catalogue.Version)
+	}
+	if _, ok := catalogue.Countries[DefaultCountry]; !ok {
+		return nil, fmt.Errorf("safety catalogue %s has no %s country", catalogue.Version, DefaultCountry)
+	}
+
+	for _, phrase := range catalogue.Phrases {
+		tokens := sentiment.Tokenize(phrase)
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("safety catalogue %s: phrase %q has no words", catalogue.Version, phrase)
+		}
+		catalogue.phrases = append(catalogue.phrases, strings.Join(tokens, " "))
+	}
+	catalogue.countryByTimezone = map[string]string{}
+	for code, c := range catalogue.Countries {
+		if len(c.Resources) == 0 {
+			return nil, fmt.Errorf("safety catalogue %s: country %s has no resources", catalogue.Version, code)
+		}
+		for _, timezone := range c.Timezones {
+			if other, exists := catalogue.countryByTimezone[timezone]; exists {
+				return nil, fmt.Errorf("safety catalogue %s: timezone %s is listed under %s and %s", catalogue.Version, timezone, other, code)
+			}
+			catalogue.countryByTimezone[timezone] = code
+		}
+	}
+	return &catalogue, nil
+}
+
+// HasHighRiskPhrase reports whether text contains one of the catalogue's
+// phrases. A phrase counts even after a negation, "I don't want to die" is
+// still worth a look.
+func (c *Catalogue) HasHighRiskPhrase(text string) bool {
+	tokens := sentiment.Tokenize(text)
+	if len(tokens) == 0 {
+		return false
+	}
+	joined := " " + strings.Join(tokens, " ") + " "
+	for _, phrase := range c.phrases {
+		if strings.Contains(joined, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// CountryFor returns the code of the country a timezone belongs to, or
+// DefaultCountry.
+func (c *Catalogue) CountryFor(timezone string) string {
+	if code, ok := c.countryByTimezone[timezone]; ok {
+		return code
+	}
+	return DefaultCountry
+}
+
+// CrisisItem is the recommendation item listing the crisis resources of a
+// country, falling back to the default ones.
+func (c *Catalogue) CrisisItem(code string) domain.RecommendationItem {
+	entry, ok := c.Countries[code]
+	if !ok {
+		entry = c.Countries[DefaultCountry]
+	}
+	contacts := make([]string, 0, len(entry.Resources))
+	for _, r := range entry.Resources {
+		contacts = append(contacts, r.Name+": "+r.Contact)
+	}
+	return domain.RecommendationItem{
+		Index:   0,
+		Heading: c.Heading,
+		Text:    strings.TrimSpace(c.Text + " " + strings.Join(contacts, ". ") + "."),
+	}
+}
@@ -0,0 +1,231 @@
+// Package safety looks for signs that a user may be in crisis in their daily
+// logs: high-risk phrases in the feeling and runs of low mood days. A flagged
+// log gets the crisis resources of the user's country at the top of its
+// recommendations unless a clinician suppressed them.
+package safety
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidThresholds = errors.New("invalid safety thresholds")
+
+// Thresholds decide when a run of days counts as sustained low mood.
+type Thresholds struct {
+	// LowMoodDays is how many low days in a row, the one being logged
+	// included, flag a log.
+	LowMoodDays int
+	// LowPolarity is the feeling polarity at or below which a day is low
+	// whatever the mood.
+	LowPolarity float64
+}
+
+var DefaultThresholds = Thresholds{
+	LowMoodDays: 3,
+	LowPolarity: -0.6,
+}
+
+// ParseThresholds reads thresholds in the form "low_mood_days=3,low_polarity=-0.6".
+// Any threshold that is not set keeps its default value.
+func ParseThresholds(raw string) (Thresholds, error) {
+	thresholds := DefaultThresholds
+	if strings.TrimSpace(raw) == "" {
+		return thresholds, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return Thresholds{}, fmt.Errorf("%w: %q is not a key=value pair", ErrInvalidThresholds, pair)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "low_mood_days":
+			days, err := strconv.Atoi(value)
+			if err != nil || days < 1 {
+				return Thresholds{}, fmt.Errorf("%w: low_mood_days %q is not a positive whole number", ErrInvalidThresholds, value)
+			}
+			thresholds.LowMoodDays = days
+		case "low_polarity":
+			polarity, err := strconv.ParseFloat(value, 64)
+			if err != nil || polarity < -1 || polarity > 0 {
+				return Thresholds{}, fmt.Errorf("%w: low_polarity %q is not a number from -1 to 0", ErrInvalidThresholds, value)
+			}
+			thresholds.LowPolarity = polarity
+		default:
+			return Thresholds{}, fmt.Errorf("%w: unknown threshold %q", ErrInvalidThresholds, key)
+		}
+	}
+	return thresholds, nil
+}
+
+type Options struct {
+	Thresholds Thresholds
+	// ClinicianEmails are the users allowed to see and change other users'
+	// safety overrides.
+	ClinicianEmails []string
+}
+
+// Assessment is what Assess found in a daily log.
+type Assessment struct {
+	Flags []domain.SafetyFlag
+	// Escalate reports whether the log's recommendations should start with
+	// the crisis resources of Country.
+	Escalate bool
+	Country  string
+}
+
+func (a Assessment) Flagged() bool {
+	return len(a.Flags) > 0
+}
+
+type Module struct {
+	catalogue  *Catalogue
+	thresholds Thresholds
+	clinicians map[string]bool
+	metricRepo infra.MetricRepository
+	safetyRepo infra.SafetyRepository
+	logger     *zap.Logger
+}
+
+func NewModule(catalogue *Catalogue, options Options, metricRepo infra.MetricRepository, safetyRepo infra.SafetyRepository, logger *zap.Logger) (*Module, error) {
+	if catalogue == nil {
+		return nil, errors.New("failed to initialize safety module, catalogue is nil")
+	}
+	if metricRepo == nil {
+		return nil, errors.New("failed to initialize safety module, metricRepo is nil")
+	}
+	if safetyRepo == nil {
+		return nil, errors.New("failed to initialize safety module, safetyRepo is nil")
+	}
+	if options.Thresholds.LowMoodDays < 1 {
+		return nil, ErrInvalidThresholds
+	}
+	clinicians := map[string]bool{}
+	for _, email := range options.ClinicianEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			clinicians[email] = true
+		}
+	}
+	return &Module{catalogue, options.Thresholds, clinicians, metricRepo, safetyRepo, logger}, nil
+}
+
+func (m *Module) CatalogueVersion() string {
+	return m.catalogue.Version
+}
+
+func (m *Module) IsClinician(email string) bool {
+	return m.clinicians[strings.ToLower(strings.TrimSpace(email))]
+}
+
+// Assess checks a daily log that is about to be saved. It never fails, a
+// lookup that does would only hide a crisis, so it is logged and the checks
+// carry on with what is known.
+func (m *Module) Assess(ctx context.Context, user domain.User, metric domain.Metric) Assessment {
+	override := m.override(ctx, user.ID)
+
+	assessment := Assessment{Flags: []domain.SafetyFlag{}}
+	if m.catalogue.HasHighRiskPhrase(metric.Feeling) {
+		assessment.Flags = append(assessment.Flags, domain.HighRiskPhraseFlag)
+	}
+	if m.endsLowMoodRun(ctx, user, metric, override) {
+		assessment.Flags = append(assessment.Flags, domain.SustainedLowMoodFlag)
+	}
+	if assessment.Flagged() {
+		assessment.Escalate = !override.SuppressesEscalation(metric.CreatedAt)
+		assessment.Country = m.catalogue.CountryFor(user.Timezone)
+	}
+	return assessment
+}
+
+// FlaggedEvent is the audit event of a log Assess flagged.
+func (m *Module) FlaggedEvent(user domain.User, metric domain.Metric, assessment Assessment) domain.SafetyEvent {
+	return domain.SafetyEvent{
+		ID:        primitive.NewObjectID(),
+		UserId:    user.ID,
+		Kind:      domain.MetricFlaggedEvent,
+		MetricId:  metric.ID,
+		Flags:     assessment.Flags,
+		Escalated: assessment.Escalate,
+		Country:   assessment.Country,
+		CreatedAt: metric.CreatedAt,
+	}
+}
+
+// CrisisItem returns the crisis resources for user, unless a clinician
+// suppressed them at the time.
+func (m *Module) CrisisItem(ctx context.Context, user domain.User, at time.Time) (domain.RecommendationItem, bool) {
+	if m.override(ctx, user.ID).SuppressesEscalation(at) {
+		return domain.RecommendationItem{}, false
+	}
+	return m.catalogue.CrisisItem(m.catalogue.CountryFor(user.Timezone)), true
+}
+
+// override returns the user's override, or none when it cannot be read.
+func (m *Module) override(ctx context.Context, userId primitive.ObjectID) domain.SafetyOverride {
+	override, err := m.safetyRepo.GetSafetyOverride(ctx, userId)
+	if err != nil {
+		if !errors.Is(err, infra.ErrSafetyOverrideNotFound) {
+			m.logger.Error("failed to load safety override, using none", zap.String("user_id", userId.Hex()), zap.Error(err))
+		}
+		return domain.SafetyOverride{}
+	}
+	return override
+}
+
+func (m *Module) isLowDay(metric domain.Metric) bool {
+	return metric.Mood == domain.DEPRESSED || metric.FeelingPolarity <= m.thresholds.LowPolarity
+}
+
+// endsLowMoodRun reports whether metric is a low day that makes enough low
+// days in a row with the user's earlier logs.
+func (m *Module) endsLowMoodRun(ctx context.Context, user domain.User, metric domain.Metric, override domain.SafetyOverride) bool {
+	if !m.isLowDay(metric) {
+		return false
+	}
+	days := m.thresholds.LowMoodDays
+	if override.LowMoodDays > 0 {
+		days = override.LowMoodDays
+	}
+	location := user.Location()
+	today := domain.LocalDate(metric.CreatedAt, location)
+	if days == 1 {
+		return true
+	}
+
+	dayStart, _ := domain.DayBounds(metric.CreatedAt, location)
+	page, err := m.metricRepo.GetMetricsByUserId(ctx, user.ID, infra.MetricFilter{
+		From: dayStart.AddDate(0, 0, -(days - 1)),
+		To:   metric.CreatedAt,
+		// a day has one log, or two on the day of onboarding
+		Limit: 2 * days,
+	})
+	if err != nil {
+		m.logger.Error("failed to load recent metrics for the low mood check", zap.String("user_id", user.ID.Hex()), zap.Error(err))
+		return false
+	}
+
+	lowDates := []string{today}
+	for _, earlier := range page.Metrics {
+		if earlier.ID == metric.ID || !m.isLowDay(earlier) {
+			continue
+		}
+		date := earlier.LocalDate
+		if date == "" {
+			date = domain.LocalDate(earlier.CreatedAt, location)
+		}
+		lowDates = append(lowDates, date)
+	}
+	return domain.CalculateStreak(lowDates, today).Current >= days
+}
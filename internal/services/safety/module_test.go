@@ -0,0 +1,446 @@
+package safety_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/safety"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func loadCatalogue(t *testing.T) *safety.Catalogue {
+	t.Helper()
+	catalogue, err := safety.LoadDefaultCatalogue()
+	if err != nil {
+		t.Fatalf("LoadDefaultCatalogue: %v", err)
+	}
+	return catalogue
+}
+
+func TestHasHighRiskPhrase(t *testing.T) {
+	catalogue := loadCatalogue(t)
+
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"phrase", "I want to die", true},
+		{"phrase in a sentence", "Some days I think everyone is better off without me, honestly", true},
+		{"phrase in capitals and punctuation", "I'm SUICIDAL!!!", true},
+		{"curly apostrophe", "I don’t want to live anymore", true},
+		{"slang", "exams again, kms", true},
+		{"pidgin", "I don tire for this life", true},
+		{"pidgin phrase", "if this continue make i die", true},
+		{"negated phrase still counts", "I don't want to die, I just want to sleep", true},
+		{"negated single word still counts", "I'm not suicidal, just tired", true},
+		{"no phrase", "Had a long day at work but dinner was nice", false},
+		{"empty", "", false},
+		{"only punctuation", "...!?", false},
+		{"word inside another word", "I want to dine out tonight", false},
+		{"phrase split across a longer word", "My skills test went fine", false},
+		{"near miss", "I nearly died laughing at the film", false},
+		{"similar words in another order", "the die is cast, I want it", false},
+		{"idiom", "I killed it at the presentation", false},
+		{"part of a pidgin phrase", "the sniper movie was long", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := catalogue.HasHighRiskPhrase(test.text); got != test.want {
+				t.Errorf("HasHighRiskPhrase(%q): got %v, want %v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCountryFor(t *testing.T) {
+	catalogue := loadCatalogue(t)
+
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{"Africa/Lagos", "NG"},
+		{"Africa/Accra", "GH"},
+		{"America/Los_Angeles", "US"},
+		{"Europe/Berlin", safety.DefaultCountry},
+		{"UTC", safety.DefaultCountry},
+		{"", safety.DefaultCountry},
+		{"Not/AZone", safety.DefaultCountry},
+	}
+	for _, test := range tests {
+		if got := catalogue.CountryFor(test.timezone); got != test.want {
+			t.Errorf("CountryFor(%q): got %q, want %q", test.timezone, got, test.want)
+		}
+	}
+}
+
+func TestCrisisItem(t *testing.T) {
+	catalogue := loadCatalogue(t)
+
+	nigeria := catalogue.CrisisItem("NG")
+	if nigeria.Index != 0 {
+		t.Errorf("index: got %d, want 0", nigeria.Index)
+	}
+	if nigeria.Heading != catalogue.Heading {
+		t.Errorf("heading: got %q, want %q", nigeria.Heading, catalogue.Heading)
+	}
+	if !strings.Contains(nigeria.Text, "112") {
+		t.Errorf("text of NG does not list its resources: %q", nigeria.Text)
+	}
+
+	unknown := catalogue.CrisisItem("XX")
+	fallback := catalogue.CrisisItem(safety.DefaultCountry)
+	if unknown.Text != fallback.Text {
+		t.Errorf("an unknown country got %q, want the default resources %q", unknown.Text, fallback.Text)
+	}
+}
+
+type moduleFixture struct {
+	module     *safety.Module
+	metricRepo *memory.MemoryMetricRepository
+	safetyRepo *memory.MemorySafetyRepository
+	user       domain.User
+}
+
+func newModule(t *testing.T, timezone string, thresholds safety.Thresholds) moduleFixture {
+	t.Helper()
+	metricRepo := memory.NewMemoryMetricRepo()
+	safetyRepo := memory.NewMemorySafetyRepo()
+	module, err := safety.NewModule(loadCatalogue(t), safety.Options{Thresholds: thresholds}, metricRepo, safetyRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewModule: %v", err)
+	}
+	user := domain.User{ID: primitive.NewObjectID(), Timezone: timezone}
+	return moduleFixture{module, metricRepo, safetyRepo, user}
+}
+
+// logAt saves a daily log of the fixture's user created at createdAt.
+func (f moduleFixture) logAt(t *testing.T, createdAt time.Time, mood domain.Mood, polarity float64) domain.Metric {
+	t.Helper()
+	metric := domain.Metric{
+		ID:              primitive.NewObjectID(),
+		OwnerId:         f.user.ID,
+		Mood:            mood,
+		FeelingPolarity: polarity,
+		LocalDate:       domain.LocalDate(createdAt, f.user.Location()),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	if err := f.metricRepo.CreateMetric(context.Background(), metric); err != nil {
+		t.Fatalf("CreateMetric: %v", err)
+	}
+	return metric
+}
+
+func hasFlag(assessment safety.Assessment, flag domain.SafetyFlag) bool {
+	for _, f := range assessment.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAssessSustainedLowMood(t *testing.T) {
+	// noon in Lagos
+	today := time.Date(2023, 11, 20, 11, 0, 0, 0, time.UTC)
+	day := func(daysAgo int) time.Time { return today.AddDate(0, 0, -daysAgo) }
+
+	type earlierLog struct {
+		at       time.Time
+		mood     domain.Mood
+		polarity float64
+	}
+	tests := []struct {
+		name          string
+		lowMoodDays   int
+		override      int
+		earlier       []earlierLog
+		todayMood     domain.Mood
+		todayPolarity float64
+		want          bool
+	}{
+		{
+			name:        "run reaches the threshold",
+			lowMoodDays: 3,
+			earlier:     []earlierLog{{day(2), domain.DEPRESSED, 0}, {day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.DEPRESSED,
+			want:        true,
+		},
+		{
+			name:        "run one day short",
+			lowMoodDays: 3,
+			earlier:     []earlierLog{{day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.DEPRESSED,
+			want:        false,
+		},
+		{
+			name:        "missed day breaks the run",
+			lowMoodDays: 3,
+			earlier:     []earlierLog{{day(3), domain.DEPRESSED, 0}, {day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.DEPRESSED,
+			want:        false,
+		},
+		{
+			name:        "good day breaks the run",
+			lowMoodDays: 3,
+			earlier:     []earlierLog{{day(2), domain.DEPRESSED, 0}, {day(1), domain.HAPPY, 0.5}},
+			todayMood:   domain.DEPRESSED,
+			want:        false,
+		},
+		{
+			name:        "today is not low",
+			lowMoodDays: 3,
+			earlier:     []earlierLog{{day(2), domain.DEPRESSED, 0}, {day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.SAD,
+			want:        false,
+		},
+		{
+			name:          "polarity at the threshold is a low day",
+			lowMoodDays:   3,
+			earlier:       []earlierLog{{day(2), domain.SAD, -0.6}, {day(1), domain.NEUTRAL, -0.6}},
+			todayMood:     domain.SAD,
+			todayPolarity: -0.6,
+			want:          true,
+		},
+		{
+			name:          "polarity just above the threshold is not",
+			lowMoodDays:   3,
+			earlier:       []earlierLog{{day(2), domain.SAD, -0.6}, {day(1), domain.SAD, -0.59}},
+			todayMood:     domain.SAD,
+			todayPolarity: -0.6,
+			want:          false,
+		},
+		{
+			name:        "a single low day with a threshold of one",
+			lowMoodDays: 1,
+			todayMood:   domain.DEPRESSED,
+			want:        true,
+		},
+		{
+			name:        "override shortens the run",
+			lowMoodDays: 3,
+			override:    2,
+			earlier:     []earlierLog{{day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.DEPRESSED,
+			want:        true,
+		},
+		{
+			name:        "override lengthens the run",
+			lowMoodDays: 3,
+			override:    4,
+			earlier:     []earlierLog{{day(2), domain.DEPRESSED, 0}, {day(1), domain.DEPRESSED, 0}},
+			todayMood:   domain.DEPRESSED,
+			want:        false,
+		},
+		{
+			// 23:30 UTC is already the next day in Lagos, so these are three
+			// local days in a row even though two share a UTC date
+			name:        "days are counted in the user's timezone",
+			lowMoodDays: 3,
+			earlier: []earlierLog{
+				{time.Date(2023, 11, 18, 23, 30, 0, 0, time.UTC), domain.DEPRESSED, 0},
+				{time.Date(2023, 11, 18, 10, 0, 0, 0, time.UTC), domain.DEPRESSED, 0},
+			},
+			todayMood: domain.DEPRESSED,
+			want:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			thresholds := safety.DefaultThresholds
+			thresholds.LowMoodDays = test.lowMoodDays
+			f := newModule(t, "Africa/Lagos", thresholds)
+			if test.override > 0 {
+				err := f.safetyRepo.SaveSafetyOverride(context.Background(), domain.SafetyOverride{UserId: f.user.ID, LowMoodDays: test.override})
+				if err != nil {
+					t.Fatalf("SaveSafetyOverride: %v", err)
+				}
+			}
+			for _, earlier := range test.earlier {
+				f.logAt(t, earlier.at, earlier.mood, earlier.polarity)
+			}
+			metric := domain.Metric{
+				ID:              primitive.NewObjectID(),
+				OwnerId:         f.user.ID,
+				Mood:            test.todayMood,
+				FeelingPolarity: test.todayPolarity,
+				CreatedAt:       today,
+			}
+
+			assessment := f.module.Assess(context.Background(), f.user, metric)
+			if got := hasFlag(assessment, domain.SustainedLowMoodFlag); got != test.want {
+				t.Errorf("sustained low mood flag: got %v, want %v (flags %v)", got, test.want, assessment.Flags)
+			}
+		})
+	}
+}
+
+func TestAssessLowMoodRunAcrossLocalMidnight(t *testing.T) {
+	f := newModule(t, "Africa/Lagos", safety.DefaultThresholds)
+	// 22:30 and 23:30 UTC on the 18th are the 18th and the 19th in Lagos
+	f.logAt(t, time.Date(2023, 11, 18, 22, 30, 0, 0, time.UTC), domain.DEPRESSED, 0)
+	f.logAt(t, time.Date(2023, 11, 19, 23, 30, 0, 0, time.UTC), domain.DEPRESSED, 0)
+	metric := domain.Metric{
+		ID:        primitive.NewObjectID(),
+		OwnerId:   f.user.ID,
+		Mood:      domain.DEPRESSED,
+		CreatedAt: time.Date(2023, 11, 19, 23, 45, 0, 0, time.UTC),
+	}
+
+	// the 19th at 23:30 UTC is the 20th in Lagos, like the log being made,
+	// so the run is the 18th and the 20th with the 19th missing
+	assessment := f.module.Assess(context.Background(), f.user, metric)
+	if hasFlag(assessment, domain.SustainedLowMoodFlag) {
+		t.Errorf("flagged a run with a missing local day: %v", assessment.Flags)
+	}
+}
+
+func TestAssessEscalation(t *testing.T) {
+	now := time.Date(2023, 11, 20, 11, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		timezone     string
+		feeling      string
+		suppressedTo time.Time
+		wantFlagged  bool
+		wantEscalate bool
+		wantCountry  string
+	}{
+		{"phrase in Lagos", "Africa/Lagos", "I want to end it all", time.Time{}, true, true, "NG"},
+		{"phrase in Accra", "Africa/Accra", "I want to end it all", time.Time{}, true, true, "GH"},
+		{"phrase in New York", "America/New_York", "I want to end it all", time.Time{}, true, true, "US"},
+		{"phrase elsewhere", "Europe/Berlin", "I want to end it all", time.Time{}, true, true, safety.DefaultCountry},
+		{"suppressed by a clinician", "Africa/Lagos", "I want to end it all", now.Add(time.Hour), true, false, "NG"},
+		{"suppression has ended", "Africa/Lagos", "I want to end it all", now.Add(-time.Hour), true, true, "NG"},
+		{"nothing to flag", "Africa/Lagos", "a calm day", time.Time{}, false, false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newModule(t, test.timezone, safety.DefaultThresholds)
+			if !test.suppressedTo.IsZero() {
+				err := f.safetyRepo.SaveSafetyOverride(context.Background(), domain.SafetyOverride{UserId: f.user.ID, SuppressEscalationUntil: test.suppressedTo})
+				if err != nil {
+					t.Fatalf("SaveSafetyOverride: %v", err)
+				}
+			}
+			metric := domain.Metric{ID: primitive.NewObjectID(), OwnerId: f.user.ID, Mood: domain.NEUTRAL, Feeling: test.feeling, CreatedAt: now}
+
+			assessment := f.module.Assess(context.Background(), f.user, metric)
+			if assessment.Flagged() != test.wantFlagged {
+				t.Errorf("flagged: got %v, want %v", assessment.Flagged(), test.wantFlagged)
+			}
+			if test.wantFlagged && !hasFlag(assessment, domain.HighRiskPhraseFlag) {
+				t.Errorf("flags: got %v, want %s", assessment.Flags, domain.HighRiskPhraseFlag)
+			}
+			if assessment.Escalate != test.wantEscalate {
+				t.Errorf("escalate: got %v, want %v", assessment.Escalate, test.wantEscalate)
+			}
+			if assessment.Country != test.wantCountry {
+				t.Errorf("country: got %q, want %q", assessment.Country, test.wantCountry)
+			}
+		})
+	}
+}
+
+// staticRecommendations recommends the same two items for every metric.
+type staticRecommendations struct{}
+
+func (staticRecommendations) GetStresslessScore(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (int, error) {
+	return 50, nil
+}
+
+func (s staticRecommendations) GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.recommendation(metric, domain.StressLessScoreMetricType), nil
+}
+
+func (s staticRecommendations) GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.recommendation(metric, domain.StressLevelMetricType), nil
+}
+
+func (s staticRecommendations) GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.recommendation(metric, domain.SleepQualityMetricType), nil
+}
+
+func (s staticRecommendations) GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	return s.recommendation(metric, domain.MoodMetricType), nil
+}
+
+func (staticRecommendations) recommendation(metric domain.Metric, metricType string) domain.Recommendation {
+	return domain.Recommendation{
+		ID:         primitive.NewObjectID(),
+		MetricId:   metric.ID,
+		MetricType: metricType,
+		Items: []domain.RecommendationItem{
+			{Index: 0, Heading: "first"},
+			{Index: 1, Heading: "second"},
+		},
+	}
+}
+
+func TestEscalatingRecommendationService(t *testing.T) {
+	now := time.Date(2023, 11, 20, 11, 0, 0, 0, time.UTC)
+	catalogue := loadCatalogue(t)
+
+	tests := []struct {
+		name         string
+		flags        []domain.SafetyFlag
+		suppressedTo time.Time
+		wantHeadings []string
+	}{
+		{"flagged log starts with the crisis item", []domain.SafetyFlag{domain.HighRiskPhraseFlag}, time.Time{}, []string{catalogue.Heading, "first", "second"}},
+		{"low mood run starts with the crisis item", []domain.SafetyFlag{domain.SustainedLowMoodFlag}, time.Time{}, []string{catalogue.Heading, "first", "second"}},
+		{"unflagged log is left as it is", nil, time.Time{}, []string{"first", "second"}},
+		{"suppressed escalation is left as it is", []domain.SafetyFlag{domain.HighRiskPhraseFlag}, now.Add(time.Hour), []string{"first", "second"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newModule(t, "Africa/Lagos", safety.DefaultThresholds)
+			userRepo := memory.NewMemoryUserRepo()
+			if err := userRepo.CreateUser(context.Background(), f.user); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			if !test.suppressedTo.IsZero() {
+				err := f.safetyRepo.SaveSafetyOverride(context.Background(), domain.SafetyOverride{UserId: f.user.ID, SuppressEscalationUntil: test.suppressedTo})
+				if err != nil {
+					t.Fatalf("SaveSafetyOverride: %v", err)
+				}
+			}
+			service, err := safety.NewEscalatingRecommendationService(staticRecommendations{}, f.module, userRepo, zap.NewNop())
+			if err != nil {
+				t.Fatalf("NewEscalatingRecommendationService: %v", err)
+			}
+			metric := domain.Metric{ID: primitive.NewObjectID(), OwnerId: f.user.ID, SafetyFlags: test.flags, CreatedAt: now}
+
+			for _, get := range []func(context.Context, domain.Metric) (domain.Recommendation, error){
+				service.GetRecommendationUsingStressScore,
+				service.GetRecommendationUsingStressLevel,
+				service.GetRecommendationUsingSleepQuality,
+				service.GetRecommendationUsingMood,
+			} {
+				recommendation, err := get(context.Background(), metric)
+				if err != nil {
+					t.Fatalf("recommendation: %v", err)
+				}
+				if len(recommendation.Items) != len(test.wantHeadings) {
+					t.Fatalf("%s items: got %d, want %d", recommendation.MetricType, len(recommendation.Items), len(test.wantHeadings))
+				}
+				for i, item := range recommendation.Items {
+					if item.Index != i || item.Heading != test.wantHeadings[i] {
+						t.Errorf("%s item %d: got index %d heading %q, want index %d heading %q", recommendation.MetricType, i, item.Index, item.Heading, i, test.wantHeadings[i])
+					}
+				}
+				if len(test.wantHeadings) == 3 && !strings.Contains(recommendation.Items[0].Text, "112") {
+					t.Errorf("%s crisis item does not list the Nigerian resources: %q", recommendation.MetricType, recommendation.Items[0].Text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package safety
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"go.uber.org/zap"
+)
+
+// EscalatingRecommendationService puts the crisis resources at index 0 of
+// every recommendation of a flagged log, ahead of whatever the wrapped service
+// recommends.
+type EscalatingRecommendationService struct {
+	recommendations.RecommendationService
+	module   *Module
+	userRepo infra.UserRepository
+	logger   *zap.Logger
+}
+
+func NewEscalatingRecommendationService(service recommendations.RecommendationService, module *Module, userRepo infra.UserRepository, logger *zap.Logger) (*EscalatingRecommendationService, error) {
+	if service == nil {
+		return nil, errors.New("failed to initialize escalating recommendation service, service is nil")
+	}
+	if module == nil {
+		return nil, errors.New("failed to initialize escalating recommendation service, module is nil")
+	}
+	if userRepo == nil {
+		return nil, errors.New("failed to initialize escalating recommendation service, userRepo is nil")
+	}
+	return &EscalatingRecommendationService{service, module, userRepo, logger}, nil
+}
+
+func (s *EscalatingRecommendationService) GetRecommendationUsingStressScore(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	recommendation, err := s.RecommendationService.GetRecommendationUsingStressScore(ctx, metric)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return s.withCrisisItem(ctx, metric, recommendation), nil
+}
+
+func (s *EscalatingRecommendationService) GetRecommendationUsingStressLevel(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	recommendation, err := s.RecommendationService.GetRecommendationUsingStressLevel(ctx, metric)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return s.withCrisisItem(ctx, metric, recommendation), nil
+}
+
+func (s *EscalatingRecommendationService) GetRecommendationUsingSleepQuality(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	recommendation, err := s.RecommendationService.GetRecommendationUsingSleepQuality(ctx, metric)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return s.withCrisisItem(ctx, metric, recommendation), nil
+}
+
+func (s *EscalatingRecommendationService) GetRecommendationUsingMood(ctx context.Context, metric domain.Metric) (domain.Recommendation, error) {
+	recommendation, err := s.RecommendationService.GetRecommendationUsingMood(ctx, metric)
+	if err != nil {
+		return domain.Recommendation{}, err
+	}
+	return s.withCrisisItem(ctx, metric, recommendation), nil
+}
+
+func (s *EscalatingRecommendationService) withCrisisItem(ctx context.Context, metric domain.Metric, recommendation domain.Recommendation) domain.Recommendation {
+	if !metric.IsFlagged() {
+		return recommendation
+	}
+
+	// without the user the default resources are better than none
+	user, err := s.userRepo.GetUserByUserId(ctx, metric.OwnerId)
+	if err != nil {
+		s.logger.Error("failed to load user of a flagged metric", zap.String("metric_id", metric.ID.Hex()), zap.Error(err))
+		user = domain.User{ID: metric.OwnerId}
+	}
+	item, ok := s.module.CrisisItem(ctx, user, metric.CreatedAt)
+	if !ok {
+		return recommendation
+	}
+
+	items := []domain.RecommendationItem{item}
+	for _, existing := range recommendation.Items {
+		existing.Index = len(items)
+		items = append(items, existing)
+	}
+	recommendation.Items = items
+	return recommendation
+}
@@ -0,0 +1,326 @@
+// Package sentiment reads how positive a free text feeling is and what it is
+// about, offline, from an embedded lexicon of English, English slang and
+// Nigerian Pidgin words and phrases.
+package sentiment
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+//go:embed lexicon.json
+var defaultLexicon []byte
+
+const (
+	// MaxThemes is the most themes an analysis returns.
+	MaxThemes = 3
+
+	// negationWindow is how many words before a sentiment word a negator
+	// flips it from.
+	negationWindow = 3
+	negationScalar = -0.74
+	// normalizationAlpha approximates the highest sum of valences a feeling is
+	// expected to reach, polarity gets close to 1 around it.
+	normalizationAlpha = 15
+)
+
+// lexicon is the content of lexicon.json. Words and theme keywords can be
+// phrases of several words.
+type lexicon struct {
+	Version string `json:"version"`
+	// Words maps words and phrases to their valence, from -4 to 4.
+	Words     map[string]float64 `json:"words"`
+	Negators  []string           `json:"negators"`
+	StopWords []string           `json:"stop_words"`
+	// Boosters make the sentiment word right after them stronger, or weaker
+	// when negative.
+	Boosters map[string]float64 `json:"boosters"`
+	// TrailingBoosters come right after the word they make stronger, like the
+	// pidgin "tire die".
+	TrailingBoosters map[string]float64  `json:"trailing_boosters"`
+	Themes           map[string][]string `json:"themes"`
+}
+
+// Analysis is what Analyze reads from a feeling.
+type Analysis struct {
+	// Polarity goes from -1, very negative, to 1, very positive.
+	Polarity float64
+	Themes   []domain.Theme
+	// SentimentWords counts the words and phrases that carried sentiment.
+	SentimentWords int
+}
+
+// HasSentiment reports whether the feeling said anything positive or negative,
+// a Polarity of 0 is otherwise ambiguous.
+func (a Analysis) HasSentiment() bool {
+	return a.SentimentWords > 0
+}
+
+type Analyzer struct {
+	version          string
+	words            phrases[float64]
+	themes           phrases[domain.Theme]
+	negators         map[string]bool
+	stopWords        map[string]bool
+	boosters         map[string]float64
+	trailingBoosters map[string]float64
+}
+
+func LoadDefaultAnalyzer() (*Analyzer, error) {
+	return LoadAnalyzer(defaultLexicon)
+}
+
+func LoadAnalyzer(content []byte) (*Analyzer, error) {
+	var l lexicon
+	if err := json.Unmarshal(content, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse sentiment lexicon: %w", err)
+	}
+	if l.Version == "" {
+		return nil, errors.New("sentiment lexicon has no version")
+	}
+	if len(l.Words) == 0 {
+		return nil, fmt.Errorf("sentiment lexicon %s has no words", l.Version)
+	}
+
+	analyzer := &Analyzer{
+		version:          l.Version,
+		words:            phrases[float64]{},
+		themes:           phrases[domain.Theme]{},
+		negators:         toSet(l.Negators),
+		stopWords:        toSet(l.StopWords),
+		boosters:         map[string]float64{},
+		trailingBoosters: map[string]float64{},
+	}
+	for word, valence := range l.Words {
+		if valence < -4 || valence > 4 || valence == 0 {
+			return nil, fmt.Errorf("sentiment lexicon %s: %q has valence %v, want -4 to 4 but not 0", l.Version, word, valence)
+		}
+		if err := analyzer.words.add(tokenize(word), valence); err != nil {
+			return nil, fmt.Errorf("sentiment lexicon %s: %w", l.Version, err)
+		}
+	}
+	for word, boost := range l.Boosters {
+		analyzer.boosters[normalizeWord(word)] = boost
+	}
+	for word, boost := range l.TrailingBoosters {
+		analyzer.trailingBoosters[normalizeWord(word)] = boost
+	}
+	for name, keywords := range l.Themes {
+		theme := domain.Theme(name)
+		if !theme.IsValid() {
+			return nil, fmt.Errorf("sentiment lexicon %s: unknown theme %q", l.Version, name)
+		}
+		for _, keyword := range keywords {
+			// themes are matched without stop words, so are their keywords
+			if err := analyzer.themes.add(analyzer.withoutStopWords(tokenize(keyword)), theme); err != nil {
+				return nil, fmt.Errorf("sentiment lexicon %s: theme %s: %w", l.Version, name, err)
+			}
+		}
+	}
+	return analyzer, nil
+}
+
+func (a *Analyzer) Version() string {
+	return a.version
+}
+
+// Analyze scores text the way VADER does: every sentiment word or phrase adds
+// its valence, made stronger or weaker by the boosters around it and flipped
+// by a negator shortly before it. Words after a "but" count more than the ones
+// before it. The sum is then squashed between -1 and 1.
+func (a *Analyzer) Analyze(text string) Analysis {
+	tokens := tokenize(text)
+
+	valences := []float64{}
+	butAt := -1
+	// a negator inside the last phrase, like the "no" of "no wahala", does
+	// not flip the words after it.
+	lastPhraseEnd := 0
+	for i := 0; i < len(tokens); {
+		if tokens[i] == "but" && butAt == -1 {
+			butAt = len(valences)
+		}
+		valence, length, ok := a.words.match(tokens, i)
+		if !ok {
+			i++
+			continue
+		}
+		valence = boost(valence, a.boosterBefore(tokens, i))
+		if i+length < len(tokens) {
+			valence = boost(valence, a.trailingBoosters[tokens[i+length]])
+		}
+		if a.negatedBefore(tokens, lastPhraseEnd, i) {
+			valence *= negationScalar
+		}
+		valences = append(valences, valence)
+		i += length
+		lastPhraseEnd = i
+	}
+
+	sum := 0.0
+	for i, valence := range valences {
+		if butAt != -1 {
+			if i < butAt {
+				valence *= 0.5
+			} else {
+				valence *= 1.5
+			}
+		}
+		sum += valence
+	}
+
+	return Analysis{
+		Polarity:       math.Round(sum/math.Sqrt(sum*sum+normalizationAlpha)*100) / 100,
+		Themes:         a.detectThemes(tokens),
+		SentimentWords: len(valences),
+	}
+}
+
+func (a *Analyzer) boosterBefore(tokens []string, i int) float64 {
+	if i == 0 {
+		return 0
+	}
+	return a.boosters[tokens[i-1]]
+}
+
+// negatedBefore reports whether one of the negationWindow words before
+// tokens[i], but not before tokens[from], is a negator.
+func (a *Analyzer) negatedBefore(tokens []string, from, i int) bool {
+	for j := i - 1; j >= from && j >= i-negationWindow; j-- {
+		if a.negators[tokens[j]] {
+			return true
+		}
+	}
+	return false
+}
+
+// detectThemes returns the themes with the most keywords in tokens, ties in
+// the order of domain.Themes.
+func (a *Analyzer) detectThemes(tokens []string) []domain.Theme {
+	tokens = a.withoutStopWords(tokens)
+	counts := map[domain.Theme]int{}
+	for i := 0; i < len(tokens); {
+		theme, length, ok := a.themes.match(tokens, i)
+		if !ok {
+			i++
+			continue
+		}
+		counts[theme]++
+		i += length
+	}
+
+	themes := []domain.Theme{}
+	for _, theme := range domain.Themes {
+		if counts[theme] > 0 {
+			themes = append(themes, theme)
+		}
+	}
+	sort.SliceStable(themes, func(i, j int) bool {
+		return counts[themes[i]] > counts[themes[j]]
+	})
+	if len(themes) > MaxThemes {
+		themes = themes[:MaxThemes]
+	}
+	return themes
+}
+
+func (a *Analyzer) withoutStopWords(tokens []string) []string {
+	kept := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if !a.stopWords[token] {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+// boost moves valence away from 0 by amount, or towards it when amount is
+// negative.
+func boost(valence, amount float64) float64 {
+	if valence < 0 {
+		return valence - amount
+	}
+	return valence + amount
+}
+
+// phrases maps phrases of one or more words to a value, the longest phrase
+// wins when several start at the same word.
+type phrases[V any] struct {
+	values    map[string]V
+	maxLength int
+}
+
+func (p *phrases[V]) add(tokens []string, value V) error {
+	if len(tokens) == 0 {
+		return errors.New("empty word")
+	}
+	if p.values == nil {
+		p.values = map[string]V{}
+	}
+	key := strings.Join(tokens, " ")
+	if _, exists := p.values[key]; exists {
+		return fmt.Errorf("%q is listed twice", key)
+	}
+	p.values[key] = value
+	if len(tokens) > p.maxLength {
+		p.maxLength = len(tokens)
+	}
+	return nil
+}
+
+// match returns the value of the longest phrase starting at tokens[i] and how
+// many tokens it spans.
+func (p *phrases[V]) match(tokens []string, i int) (V, int, bool) {
+	length := p.maxLength
+	if remaining := len(tokens) - i; remaining < length {
+		length = remaining
+	}
+	for ; length > 0; length-- {
+		if value, ok := p.values[strings.Join(tokens[i:i+length], " ")]; ok {
+			return value, length, true
+		}
+	}
+	var zero V
+	return zero, 0, false
+}
+
+// Tokenize splits text into words the way Analyze does.
+func Tokenize(text string) []string {
+	return tokenize(text)
+}
+
+// tokenize lowercases text and splits it into words, keeping apostrophes and
+// hyphens inside them so "don't" and "k-leg" stay whole.
+func tokenize(text string) []string {
+	text = strings.NewReplacer("’", "'", "‘", "'").Replace(strings.ToLower(text))
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if token := normalizeWord(field); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func normalizeWord(word string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(word)), "'-")
+}
+
+func toSet(words []string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range words {
+		set[normalizeWord(word)] = true
+	}
+	return set
+}
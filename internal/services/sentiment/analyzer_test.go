@@ -0,0 +1,129 @@
+package sentiment_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+)
+
+func loadAnalyzer(t *testing.T) *sentiment.Analyzer {
+	t.Helper()
+	analyzer, err := sentiment.LoadDefaultAnalyzer()
+	if err != nil {
+		t.Fatalf("LoadDefaultAnalyzer: %v", err)
+	}
+	return analyzer
+}
+
+func TestAnalyzePolarity(t *testing.T) {
+	analyzer := loadAnalyzer(t)
+
+	tests := []struct {
+		name           string
+		feeling        string
+		polarity       float64
+		sentimentWords int
+	}{
+		{"empty", "", 0, 0},
+		{"no sentiment words", "went to the market", 0, 0},
+		{"positive word", "happy", 0.61, 1},
+		{"negative word", "tired", -0.46, 1},
+		{"case and punctuation are ignored", "HAPPY!!!", 0.61, 1},
+		{"negated", "not happy", -0.5, 1},
+		{"negated within three words", "i am not really that happy", -0.5, 1},
+		{"negator too far back", "not that it matters but happy", 0.76, 1},
+		{"curly apostrophe negates", "i don’t feel good", -0.36, 1},
+		{"boosted", "very happy", 0.65, 1},
+		{"boosted negative", "extremely tired", -0.54, 1},
+		{"dampened", "slightly happy", 0.57, 1},
+		{"boosted then negated", "not very happy", -0.53, 1},
+		{"words after but count more", "tired but happy", 0.67, 2},
+		{"phrase wins over its words", "burnt out", -0.61, 1},
+		{"pidgin phrase", "body don tire", -0.61, 1},
+		{"pidgin trailing booster", "i don tire die", -0.53, 1},
+		{"the no of no wahala is not a negator", "no wahala", 0.25, 1},
+		{"no wahala does not negate what follows", "no wahala happy", 0.72, 2},
+		{"pidgin negative", "yawa don gas", -0.61, 1},
+		{"pidgin positive", "e sweet me", 0.61, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := analyzer.Analyze(tt.feeling)
+			if analysis.Polarity != tt.polarity {
+				t.Errorf("Analyze(%q).Polarity: got %v, want %v", tt.feeling, analysis.Polarity, tt.polarity)
+			}
+			if analysis.SentimentWords != tt.sentimentWords {
+				t.Errorf("Analyze(%q).SentimentWords: got %d, want %d", tt.feeling, analysis.SentimentWords, tt.sentimentWords)
+			}
+			if analysis.HasSentiment() != (tt.sentimentWords > 0) {
+				t.Errorf("Analyze(%q).HasSentiment: got %v", tt.feeling, analysis.HasSentiment())
+			}
+		})
+	}
+}
+
+func TestAnalyzePolarityIsBounded(t *testing.T) {
+	analyzer := loadAnalyzer(t)
+
+	for _, feeling := range []string{
+		"amazing awesome wonderful fantastic amazing awesome wonderful fantastic",
+		"depressed hopeless depressed hopeless depressed hopeless depressed hopeless",
+	} {
+		polarity := analyzer.Analyze(feeling).Polarity
+		if polarity < -1 || polarity > 1 {
+			t.Errorf("Analyze(%q).Polarity: got %v, want from -1 to 1", feeling, polarity)
+		}
+	}
+}
+
+func TestAnalyzeThemes(t *testing.T) {
+	analyzer := loadAnalyzer(t)
+
+	tests := []struct {
+		name    string
+		feeling string
+		themes  []domain.Theme
+	}{
+		{"no theme", "happy", []domain.Theme{}},
+		{"one theme", "my boss is stressing me", []domain.Theme{domain.WorkTheme}},
+		{"negated feeling keeps its theme", "i did not sleep", []domain.Theme{domain.SleepTheme}},
+		{"most keywords first", "rent and bills after my boss yelled", []domain.Theme{domain.MoneyTheme, domain.WorkTheme}},
+		{"ties in the order of domain.Themes", "sick of school and work", []domain.Theme{domain.WorkTheme, domain.SchoolTheme, domain.HealthTheme}},
+		{"stop words inside a keyword", "we broke up", []domain.Theme{domain.RelationshipsTheme}},
+		{"pidgin keyword", "oga dey vex", []domain.Theme{domain.WorkTheme}},
+		{"pidgin phrase keyword", "no money for transport", []domain.Theme{domain.MoneyTheme}},
+		{"at most MaxThemes", "work school family money health sleep", []domain.Theme{domain.WorkTheme, domain.SchoolTheme, domain.FamilyTheme}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			themes := analyzer.Analyze(tt.feeling).Themes
+			if fmt.Sprint(themes) != fmt.Sprint(tt.themes) {
+				t.Errorf("Analyze(%q).Themes: got %v, want %v", tt.feeling, themes, tt.themes)
+			}
+		})
+	}
+}
+
+func TestLoadAnalyzerRejectsInvalidLexicons(t *testing.T) {
+	tests := []struct {
+		name    string
+		lexicon string
+	}{
+		{"not json", `{`},
+		{"no version", `{"words": {"happy": 3}}`},
+		{"no words", `{"version": "1"}`},
+		{"valence out of range", `{"version": "1", "words": {"happy": 5}}`},
+		{"zero valence", `{"version": "1", "words": {"happy": 0}}`},
+		{"word listed twice", `{"version": "1", "words": {"happy": 3, "HAPPY": 2}}`},
+		{"unknown theme", `{"version": "1", "words": {"happy": 3}, "themes": {"weather": ["rain"]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sentiment.LoadAnalyzer([]byte(tt.lexicon)); err == nil {
+				t.Errorf("LoadAnalyzer(%s): got no error", tt.lexicon)
+			}
+		})
+	}
+}
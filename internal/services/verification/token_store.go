@@ -0,0 +1,106 @@
+package verification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Purpose string
+
+const (
+	EmailVerification Purpose = "email-verification"
+	PasswordReset     Purpose = "password-reset"
+)
+
+const ONE_TIME_TOKEN_HASH_NAME = "afriHacks2023-stressless-one-time-tokens"
+
+var ErrInvalidOrExpiredToken = errors.New("token is invalid or has expired")
+
+// TokenStore issues single use tokens that expire after a ttl. Only a hash of
+// each token is kept in the cache and issuing a new token for a user and
+// purpose invalidates the previous one.
+type TokenStore struct {
+	cache infra.Cache
+}
+
+func NewTokenStore(cache infra.Cache) (*TokenStore, error) {
+	if cache == nil {
+		return nil, errors.New("failed to initialize token store, cache is nil")
+	}
+	return &TokenStore{cache}, nil
+}
+
+func (t *TokenStore) Issue(ctx context.Context, purpose Purpose, userId primitive.ObjectID, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	userKey := constructUserTokenKey(purpose, userId)
+	previousHash, err := t.cache.GetOne(ctx, userKey)
+	if err != nil && !errors.Is(err, infra.ErrCacheMiss) {
+		return "", err
+	}
+	if previousHash != "" {
+		if err := t.cache.DeleteOne(ctx, constructTokenKey(purpose, previousHash)); err != nil {
+			return "", err
+		}
+	}
+
+	tokenHash := hashToken(token)
+	if err := t.cache.SetOne(ctx, constructTokenKey(purpose, tokenHash), userId.Hex(), ttl); err != nil {
+		return "", err
+	}
+	if err := t.cache.SetOne(ctx, userKey, tokenHash, ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume returns the user the token was issued for and invalidates it.
+func (t *TokenStore) Consume(ctx context.Context, purpose Purpose, token string) (primitive.ObjectID, error) {
+	tokenHash := hashToken(token)
+	tokenKey := constructTokenKey(purpose, tokenHash)
+
+	// deleting the token as it is read lets only one of any concurrent
+	// requests consume it.
+	rawUserId, err := t.cache.GetAndDeleteOne(ctx, tokenKey)
+	if errors.Is(err, infra.ErrCacheMiss) {
+		return primitive.NilObjectID, ErrInvalidOrExpiredToken
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	userId, err := primitive.ObjectIDFromHex(rawUserId)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidOrExpiredToken
+	}
+
+	if err := t.cache.DeleteOne(ctx, constructUserTokenKey(purpose, userId)); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return userId, nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func constructTokenKey(purpose Purpose, tokenHash string) string {
+	return ONE_TIME_TOKEN_HASH_NAME + ":" + string(purpose) + ":" + tokenHash
+}
+
+func constructUserTokenKey(purpose Purpose, userId primitive.ObjectID) string {
+	return ONE_TIME_TOKEN_HASH_NAME + ":" + string(purpose) + ":user:" + userId.Hex()
+}
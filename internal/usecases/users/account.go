@@ -0,0 +1,128 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/mailer"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/verification"
+	"go.uber.org/zap"
+)
+
+const (
+	EmailVerificationTokenTTL = 24 * time.Hour
+	PasswordResetTokenTTL     = time.Hour
+)
+
+func (u *UserService) VerifyEmail(ctx context.Context, token string) (domain.User, error) {
+	userId, err := u.tokenStore.Consume(ctx, verification.EmailVerification, token)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if existingUser.IsEmailVerified {
+		return existingUser, nil
+	}
+
+	existingUser.IsEmailVerified = true
+	existingUser.UpdatedAt = time.Now()
+	if err := u.userRepo.UpdateUser(ctx, existingUser); err != nil {
+		return domain.User{}, err
+	}
+	return existingUser, nil
+}
+
+func (u *UserService) ResendVerificationEmail(ctx context.Context) error {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, jwtClaims.ID)
+	if err != nil {
+		return err
+	}
+	if existingUser.IsEmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return u.sendVerificationEmail(ctx, existingUser)
+}
+
+// RequestPasswordReset does not report unknown emails so it can not be used to
+// find out who has an account.
+func (u *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	existingUser, err := u.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, infra.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := u.tokenStore.Issue(ctx, verification.PasswordReset, existingUser.ID, PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	err = u.mailer.Send(ctx, mailer.Message{
+		To:      existingUser.Email,
+		Subject: "Reset your StressLess password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Use the link below within the next hour to choose a new one:\n\n%s/reset-password?token=%s\n\nIf you did not ask for this you can ignore this email.\n\nThe StressLess team",
+			existingUser.FirstName, u.appBaseUrl, token),
+	})
+	if err != nil {
+		// an error here would only ever be seen for emails that have an account
+		u.logger.Error("failed to send password reset email", zap.String("user_id", existingUser.ID.Hex()), zap.Error(err))
+	}
+	return nil
+}
+
+// ResetPassword sets a new password and logs the user out of every device.
+func (u *UserService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userId, err := u.tokenStore.Consume(ctx, verification.PasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashAndSalt([]byte(newPassword))
+	if err != nil {
+		return err
+	}
+	existingUser.Password = hashedPassword
+	// the reset link was delivered to the inbox, so the address is proven
+	existingUser.IsEmailVerified = true
+	existingUser.UpdatedAt = time.Now()
+	if err := u.userRepo.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+
+	return u.authService.RevokeAllSessions(ctx, existingUser.ID)
+}
+
+func (u *UserService) sendVerificationEmail(ctx context.Context, user domain.User) error {
+	token, err := u.tokenStore.Issue(ctx, verification.EmailVerification, user.ID, EmailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your StressLess email",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to StressLess! Please confirm your email address using the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in 24 hours.\n\nThe StressLess team",
+			user.FirstName, u.appBaseUrl, token),
+	})
+}
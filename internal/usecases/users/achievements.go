@@ -0,0 +1,42 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"go.uber.org/zap"
+)
+
+type UserAchievements struct {
+	Streak       domain.Streak
+	Achievements []domain.Achievement
+}
+
+func (u *UserService) GetAchievements(ctx context.Context) (UserAchievements, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return UserAchievements{}, err
+	}
+	streak, err := u.achievementEngine.Streak(ctx, existingUser, time.Now())
+	if err != nil {
+		return UserAchievements{}, err
+	}
+	achievements, err := u.achievementRepo.GetAchievementsByUserId(ctx, existingUser.ID)
+	if err != nil {
+		return UserAchievements{}, err
+	}
+	return UserAchievements{streak, achievements}, nil
+}
+
+func (u *UserService) GetStreak(ctx context.Context, user domain.User) (domain.Streak, error) {
+	return u.achievementEngine.Streak(ctx, user, time.Now())
+}
+
+// awardAchievements only logs a failure, the log is saved already and badges
+// that go by the streak are awarded with the next one.
+func (u *UserService) awardAchievements(ctx context.Context, user domain.User, metric domain.Metric) {
+	if _, err := u.achievementEngine.Award(ctx, user, metric); err != nil {
+		u.logger.Error("failed to award achievements", zap.String("user_id", user.ID.Hex()), zap.Error(err))
+	}
+}
@@ -0,0 +1,186 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/idempotency"
+)
+
+// MetricUpdate holds the fields of a daily log a user wants to change, nil
+// fields are left as they are.
+type MetricUpdate struct {
+	StressLevel  *int
+	Mood         *domain.Mood
+	SleepQuality *domain.SleepQuality
+	Feeling      *string
+}
+
+// CreateDailyLogIdempotently is CreateDailyLog for clients that retry. Only
+// the first request made with idempotencyKey creates a log, retries get the
+// log it created read back from the repository. Only its id is kept with the
+// key so the feeling is never cached in the clear.
+func (u *UserService) CreateDailyLogIdempotently(ctx context.Context, idempotencyKey string, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (domain.Metric, error) {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return domain.Metric{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+
+	scope := "daily-log:" + jwtClaims.ID.Hex()
+	fingerprint := idempotency.Fingerprint(strconv.Itoa(stressLevel), string(mood), string(sleepQuality), feeling)
+	var created domain.Metric
+	metricId, err := idempotency.Do(ctx, u.idempotencyStore, scope, idempotencyKey, fingerprint, func() (primitive.ObjectID, error) {
+		metric, err := u.CreateDailyLog(ctx, stressLevel, mood, sleepQuality, feeling)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		created = metric
+		return metric.ID, nil
+	})
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	if created.ID == metricId {
+		return created, nil
+	}
+	return u.metricRepo.GetMetricById(ctx, metricId)
+}
+
+// UpdateDailyLog checks the changed log for a crisis again, so its safety
+// flags always match what it now says. Like a new log it can earn badges and
+// complete goals, what was earned before the change is kept.
+func (u *UserService) UpdateDailyLog(ctx context.Context, metricId primitive.ObjectID, update MetricUpdate) (domain.Metric, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	metric, err := u.metricRepo.GetMetricById(ctx, metricId)
+	if err != nil {
+		return domain.Metric{}, err
+	}
+	if metric.OwnerId != existingUser.ID {
+		return domain.Metric{}, ErrUserDoesNotOwnMetric
+	}
+
+	if update.StressLevel != nil {
+		metric.StressLevel = *update.StressLevel
+	}
+	if update.Mood != nil {
+		metric.Mood = *update.Mood
+	}
+	if update.SleepQuality != nil {
+		metric.SleepQuality = *update.SleepQuality
+	}
+	if update.Feeling != nil {
+		metric.Feeling = *update.Feeling
+	}
+
+	stressLessScore, err := u.recommendationService.GetStresslessScore(ctx, metric.StressLevel, metric.Mood, metric.SleepQuality, metric.Feeling)
+	if err != nil {
+		return domain.Metric{}, fmt.Errorf("error generating stressScore: %w", err)
+	}
+	metric.StressLessScore = stressLessScore
+	feelingAnalysis := u.sentimentAnalyzer.Analyze(metric.Feeling)
+	metric.FeelingPolarity = feelingAnalysis.Polarity
+	metric.Themes = feelingAnalysis.Themes
+	metric.UpdatedAt = time.Now()
+	assessment := u.safetyModule.Assess(ctx, existingUser, metric)
+	metric.SafetyFlags = assessment.Flags
+
+	var rs []domain.Recommendation
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// a retried transaction starts over
+		rs = []domain.Recommendation{}
+		if err := u.metricRepo.UpdateMetricById(ctx, metric); err != nil {
+			return err
+		}
+		for _, recommendation := range pendingRecommendations(metric) {
+			stored, err := u.replaceRecommendation(ctx, recommendation)
+			if err != nil {
+				return fmt.Errorf("error saving recommendation : %w", err)
+			}
+			rs = append(rs, stored)
+		}
+		return u.recordAssessment(ctx, existingUser, metric, assessment)
+	})
+	if err != nil {
+		return domain.Metric{}, err
+	}
+
+	u.logAssessment(existingUser, metric, assessment)
+	u.enqueueRecommendations(ctx, rs)
+	u.awardAchievements(ctx, existingUser, metric)
+	u.evaluateGoals(ctx, existingUser, metric)
+	return metric, nil
+}
+
+// replaceRecommendation overwrites the stored recommendation of the same metric
+// and type in place so its id stays stable, or creates it if there is none. It
+// returns the recommendation as stored.
+func (u *UserService) replaceRecommendation(ctx context.Context, recommendation domain.Recommendation) (domain.Recommendation, error) {
+	existing, err := u.recommendationRepo.GetRecommendationByMetricId(ctx, recommendation.MetricId, recommendation.MetricType)
+	if err != nil {
+		if errors.Is(err, infra.ErrRecommendationNotFound) {
+			return recommendation, u.recommendationRepo.CreateRecommendation(ctx, recommendation)
+		}
+		return domain.Recommendation{}, err
+	}
+
+	recommendation.ID = existing.ID
+	recommendation.CreatedAt = existing.CreatedAt
+	return recommendation, u.recommendationRepo.UpdateRecommendationById(ctx, recommendation)
+}
+
+func (u *UserService) DeleteDailyLog(ctx context.Context, metricId primitive.ObjectID) error {
+	metric, err := u.GetMetricByMetricId(ctx, metricId)
+	if err != nil {
+		return err
+	}
+
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return u.deleteDailyLog(ctx, metric)
+	})
+}
+
+func (u *UserService) deleteDailyLog(ctx context.Context, metric domain.Metric) error {
+	err := u.recommendationRepo.DeleteRecommendationsByMetricId(ctx, metric.ID)
+	if err != nil {
+		return err
+	}
+
+	err = u.metricRepo.DeleteMetricById(ctx, metric.ID)
+	if err != nil {
+		return err
+	}
+
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, metric.OwnerId)
+	if err != nil {
+		return err
+	}
+
+	latest, err := u.metricRepo.GetMetricsByUserId(ctx, existingUser.ID, infra.MetricFilter{Limit: 1})
+	if err != nil {
+		return err
+	}
+
+	lastMetricLog := time.Time{}
+	if len(latest.Metrics) > 0 {
+		lastMetricLog = latest.Metrics[0].CreatedAt
+	}
+	if existingUser.LastMetricLog.Equal(lastMetricLog) {
+		return nil
+	}
+
+	updatedUser := existingUser
+	updatedUser.LastMetricLog = lastMetricLog
+	updatedUser.UpdatedAt = time.Now()
+	return u.userRepo.UpdateUser(ctx, updatedUser)
+}
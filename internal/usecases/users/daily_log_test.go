@@ -0,0 +1,372 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/config"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra/memory"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/achievements"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/goals"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/idempotency"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/mailer"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/safety"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/verification"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/usecases/users"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type serviceFixture struct {
+	service            *users.UserService
+	userRepo           *memory.MemoryUserRepository
+	metricRepo         *memory.MemoryMetricRepository
+	recommendationRepo *memory.MemoryRecommendationRepository
+	achievementRepo    *memory.MemoryAchievementRepository
+	goalRepo           *memory.MemoryGoalRepository
+}
+
+// newUserService wires a UserService the way cmd/main.go does with every
+// driver set to memory.
+func newUserService(t *testing.T) serviceFixture {
+	t.Helper()
+	ctx, logger := context.Background(), zap.NewNop()
+	userRepo, metricRepo := memory.NewMemoryUserRepo(), memory.NewMemoryMetricRepo()
+	recommendationRepo, achievementRepo := memory.NewMemoryRecommendationRepo(), memory.NewMemoryAchievementRepo()
+	goalRepo, safetyRepo, cache := memory.NewMemoryGoalRepo(), memory.NewMemorySafetyRepo(), memory.NewMemoryCache()
+
+	authService, err := auth.NewRedisAuthService(ctx, cache, &config.Configurations{JwtSecretKey: "secret"}, logger)
+	if err != nil {
+		t.Fatalf("NewRedisAuthService: %v", err)
+	}
+	catalogue, err := recommendations.LoadDefaultCatalogue()
+	if err != nil {
+		t.Fatalf("LoadDefaultCatalogue: %v", err)
+	}
+	analyzer, err := sentiment.LoadDefaultAnalyzer()
+	if err != nil {
+		t.Fatalf("LoadDefaultAnalyzer: %v", err)
+	}
+	recommendationService, err := recommendations.NewRuleBasedRecommendationService(recommendations.DefaultScoreWeights, catalogue, analyzer, logger)
+	if err != nil {
+		t.Fatalf("NewRuleBasedRecommendationService: %v", err)
+	}
+	safetyCatalogue, err := safety.LoadDefaultCatalogue()
+	if err != nil {
+		t.Fatalf("LoadDefaultCatalogue: %v", err)
+	}
+	safetyModule, err := safety.NewModule(safetyCatalogue, safety.Options{Thresholds: safety.DefaultThresholds}, metricRepo, safetyRepo, logger)
+	if err != nil {
+		t.Fatalf("NewModule: %v", err)
+	}
+	tokenStore, err := verification.NewTokenStore(cache)
+	if err != nil {
+		t.Fatalf("NewTokenStore: %v", err)
+	}
+	idempotencyStore, err := idempotency.NewStore(cache, time.Hour)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	achievementEngine, err := achievements.NewEngine(metricRepo, achievementRepo, logger)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	goalEvaluator, err := goals.NewEvaluator(metricRepo, goalRepo, logger)
+	if err != nil {
+		t.Fatalf("NewEvaluator: %v", err)
+	}
+
+	service, err := users.NewUserService(userRepo, authService, metricRepo, recommendationService, analyzer, safetyModule, recommendationRepo,
+		memory.NewMemoryTransactor(), memory.NewMemoryJobQueue(), achievementRepo, achievementEngine, goalRepo, goalEvaluator,
+		memory.NewMemoryJournalRepo(), safetyRepo, memory.NewMemoryShareRepo(), mailer.NewLogMailer("", logger), tokenStore, idempotencyStore,
+		"http://localhost", logger)
+	if err != nil {
+		t.Fatalf("NewUserService: %v", err)
+	}
+	return serviceFixture{service, userRepo, metricRepo, recommendationRepo, achievementRepo, goalRepo}
+}
+
+// newUser saves a user in timezone and returns a context logged in as them.
+func (f serviceFixture) newUser(t *testing.T, timezone string) (context.Context, domain.User) {
+	t.Helper()
+	user := domain.User{
+		ID:        primitive.NewObjectID(),
+		Email:     primitive.NewObjectID().Hex() + "@example.com",
+		FirstName: "Ada",
+		Timezone:  timezone,
+		CreatedAt: time.Now().AddDate(0, -1, 0),
+		UpdatedAt: time.Now().AddDate(0, -1, 0),
+	}
+	if err := f.userRepo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return auth.SetJWTClaims(context.Background(), auth.JWTClaims{ID: user.ID, Email: user.Email}), user
+}
+
+// logDaysAgo saves a log the user made days ago, before the service under
+// test existed, and returns it as stored.
+func (f serviceFixture) logDaysAgo(t *testing.T, user domain.User, days int) domain.Metric {
+	t.Helper()
+	createdAt := time.Now().AddDate(0, 0, -days)
+	metric := domain.Metric{
+		ID:              primitive.NewObjectID(),
+		OwnerId:         user.ID,
+		StressLevel:     3,
+		Mood:            domain.NEUTRAL,
+		SleepQuality:    domain.FAIR,
+		StressLessScore: 50,
+		LocalDate:       domain.LocalDate(createdAt, user.Location()),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	if err := f.metricRepo.CreateMetric(context.Background(), metric); err != nil {
+		t.Fatalf("CreateMetric: %v", err)
+	}
+	stored, err := f.metricRepo.GetMetricById(context.Background(), metric.ID)
+	if err != nil {
+		t.Fatalf("GetMetricById: %v", err)
+	}
+	return stored
+}
+
+func (f serviceFixture) lastMetricLog(t *testing.T, userId primitive.ObjectID) time.Time {
+	t.Helper()
+	user, err := f.userRepo.GetUserByUserId(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("GetUserByUserId: %v", err)
+	}
+	return user.LastMetricLog
+}
+
+// recommendationIds returns the ids of the metric's recommendations by type,
+// failing unless there is one of every type.
+func (f serviceFixture) recommendationIds(t *testing.T, metricId primitive.ObjectID) map[string]primitive.ObjectID {
+	t.Helper()
+	ids := map[string]primitive.ObjectID{}
+	for _, metricType := range domain.RecommendationMetricTypes {
+		recommendation, err := f.recommendationRepo.GetRecommendationByMetricId(context.Background(), metricId, metricType)
+		if err != nil {
+			t.Fatalf("GetRecommendationByMetricId(%s): %v", metricType, err)
+		}
+		ids[metricType] = recommendation.ID
+	}
+	return ids
+}
+
+func (f serviceFixture) expectNoRecommendations(t *testing.T, metricId primitive.ObjectID) {
+	t.Helper()
+	for _, metricType := range domain.RecommendationMetricTypes {
+		if _, err := f.recommendationRepo.GetRecommendationByMetricId(context.Background(), metricId, metricType); !errors.Is(err, infra.ErrRecommendationNotFound) {
+			t.Errorf("GetRecommendationByMetricId(%s): got error %v, want %v", metricType, err, infra.ErrRecommendationNotFound)
+		}
+	}
+}
+
+func TestCreateDailyLog(t *testing.T) {
+	f := newUserService(t)
+	ctx, user := f.newUser(t, "Africa/Lagos")
+	// stored times are cut to milliseconds
+	before := time.Now().Truncate(time.Millisecond)
+
+	metric, err := f.service.CreateDailyLog(ctx, 1, domain.OVERJOYED, domain.EXCELLENT, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog: %v", err)
+	}
+	if metric.OwnerId != user.ID || metric.StressLessScore != 100 {
+		t.Errorf("CreateDailyLog: got owner %s and score %d, want %s and 100", metric.OwnerId.Hex(), metric.StressLessScore, user.ID.Hex())
+	}
+	if want := domain.LocalDate(metric.CreatedAt, user.Location()); metric.LocalDate != want {
+		t.Errorf("LocalDate: got %q, want %q", metric.LocalDate, want)
+	}
+	if _, err := f.metricRepo.GetMetricById(context.Background(), metric.ID); err != nil {
+		t.Errorf("GetMetricById: %v", err)
+	}
+	f.recommendationIds(t, metric.ID)
+	if lastMetricLog := f.lastMetricLog(t, user.ID); lastMetricLog.Before(before) {
+		t.Errorf("LastMetricLog: got %v, want at least %v", lastMetricLog, before)
+	}
+
+	badges, err := f.achievementRepo.GetAchievementsByUserId(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetAchievementsByUserId: %v", err)
+	}
+	awarded := map[domain.BadgeId]bool{}
+	for _, badge := range badges {
+		awarded[badge.Badge] = true
+	}
+	for _, want := range []domain.BadgeId{domain.FirstLogBadge, domain.FirstExcellentSleepBadge, domain.FirstOverjoyedMoodBadge, domain.CalmDayBadge} {
+		if !awarded[want] {
+			t.Errorf("badge %s was not awarded, got %v", want, awarded)
+		}
+	}
+}
+
+func TestCreateDailyLogTwiceInADay(t *testing.T) {
+	f := newUserService(t)
+	ctx, _ := f.newUser(t, "Africa/Lagos")
+
+	first, err := f.service.CreateDailyLog(ctx, 3, domain.NEUTRAL, domain.FAIR, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog: %v", err)
+	}
+	second, err := f.service.CreateDailyLog(ctx, 5, domain.SAD, domain.POOR, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog again: %v", err)
+	}
+	if second.ID != first.ID || second.StressLevel != 3 {
+		t.Errorf("second log of the day: got %s with stress level %d, want the first %s with 3", second.ID.Hex(), second.StressLevel, first.ID.Hex())
+	}
+}
+
+func TestUpdateDailyLog(t *testing.T) {
+	f := newUserService(t)
+	ctx, user := f.newUser(t, "Africa/Lagos")
+	metric, err := f.service.CreateDailyLog(ctx, 3, domain.NEUTRAL, domain.FAIR, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog: %v", err)
+	}
+	ids := f.recommendationIds(t, metric.ID)
+
+	stressLevel, mood, feeling := 1, domain.OVERJOYED, "stressed about my rent"
+	updated, err := f.service.UpdateDailyLog(ctx, metric.ID, users.MetricUpdate{StressLevel: &stressLevel, Mood: &mood, Feeling: &feeling})
+	if err != nil {
+		t.Fatalf("UpdateDailyLog: %v", err)
+	}
+	if updated.StressLevel != 1 || updated.Mood != domain.OVERJOYED || updated.SleepQuality != domain.FAIR {
+		t.Errorf("UpdateDailyLog: got %d, %s and %s, want 1, %s and the unchanged %s", updated.StressLevel, updated.Mood, updated.SleepQuality, domain.OVERJOYED, domain.FAIR)
+	}
+	if updated.StressLessScore <= metric.StressLessScore {
+		t.Errorf("StressLessScore: got %d, want it above %d", updated.StressLessScore, metric.StressLessScore)
+	}
+	if updated.LocalDate != metric.LocalDate || !updated.CreatedAt.Equal(metric.CreatedAt.Truncate(time.Millisecond)) {
+		t.Errorf("UpdateDailyLog moved the log from %s to %s", metric.LocalDate, updated.LocalDate)
+	}
+	if len(updated.Themes) != 1 || updated.Themes[0] != domain.MoneyTheme {
+		t.Errorf("Themes: got %v, want [%s]", updated.Themes, domain.MoneyTheme)
+	}
+
+	stored, err := f.metricRepo.GetMetricById(context.Background(), metric.ID)
+	if err != nil {
+		t.Fatalf("GetMetricById: %v", err)
+	}
+	if stored.StressLessScore != updated.StressLessScore || stored.Feeling != feeling {
+		t.Errorf("stored log: got score %d and feeling %q, want %d and %q", stored.StressLessScore, stored.Feeling, updated.StressLessScore, feeling)
+	}
+	for metricType, id := range f.recommendationIds(t, metric.ID) {
+		if id != ids[metricType] {
+			t.Errorf("%s recommendation: got %s, want it replaced in place as %s", metricType, id.Hex(), ids[metricType].Hex())
+		}
+	}
+
+	otherCtx, _ := f.newUser(t, "Africa/Lagos")
+	if _, err := f.service.UpdateDailyLog(otherCtx, metric.ID, users.MetricUpdate{StressLevel: &stressLevel}); !errors.Is(err, users.ErrUserDoesNotOwnMetric) {
+		t.Errorf("UpdateDailyLog of another user's log: got error %v, want %v", err, users.ErrUserDoesNotOwnMetric)
+	}
+	if lastMetricLog := f.lastMetricLog(t, user.ID); lastMetricLog.Before(metric.CreatedAt.Truncate(time.Millisecond)) {
+		t.Errorf("LastMetricLog: got %v, want at least %v", lastMetricLog, metric.CreatedAt)
+	}
+}
+
+func TestUpdateDailyLogAwardsBadgesAndCompletesGoals(t *testing.T) {
+	f := newUserService(t)
+	ctx, user := f.newUser(t, "Africa/Lagos")
+	goal := domain.Goal{
+		ID:        primitive.NewObjectID(),
+		UserId:    user.ID,
+		Kind:      domain.SleepQualityGoal,
+		Period:    domain.WeeklyGoal,
+		Target:    1,
+		Threshold: string(domain.EXCELLENT),
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.CreatedAt,
+	}
+	if err := f.goalRepo.CreateGoal(context.Background(), goal); err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	metric, err := f.service.CreateDailyLog(ctx, 3, domain.NEUTRAL, domain.FAIR, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog: %v", err)
+	}
+
+	sleepQuality := domain.EXCELLENT
+	if _, err := f.service.UpdateDailyLog(ctx, metric.ID, users.MetricUpdate{SleepQuality: &sleepQuality}); err != nil {
+		t.Fatalf("UpdateDailyLog: %v", err)
+	}
+
+	achievements, err := f.achievementRepo.GetAchievementsByUserId(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetAchievementsByUserId: %v", err)
+	}
+	awarded := false
+	for _, achievement := range achievements {
+		if achievement.Badge == domain.FirstExcellentSleepBadge {
+			awarded = achievement.MetricId == metric.ID
+		}
+	}
+	if !awarded {
+		t.Errorf("badge %s was not awarded for the updated log, got %+v", domain.FirstExcellentSleepBadge, achievements)
+	}
+
+	completions, err := f.goalRepo.GetGoalCompletionsByUserId(context.Background(), user.ID, user.CreatedAt)
+	if err != nil {
+		t.Fatalf("GetGoalCompletionsByUserId: %v", err)
+	}
+	if len(completions) != 1 || completions[0].GoalId != goal.ID {
+		t.Errorf("goal completions: got %+v, want one of %s", completions, goal.ID.Hex())
+	}
+}
+
+func TestDeleteDailyLog(t *testing.T) {
+	f := newUserService(t)
+	ctx, user := f.newUser(t, "Africa/Lagos")
+	older := f.logDaysAgo(t, user, 3)
+	oldest := f.logDaysAgo(t, user, 5)
+	today, err := f.service.CreateDailyLog(ctx, 3, domain.NEUTRAL, domain.FAIR, "")
+	if err != nil {
+		t.Fatalf("CreateDailyLog: %v", err)
+	}
+
+	otherCtx, _ := f.newUser(t, "Africa/Lagos")
+	if err := f.service.DeleteDailyLog(otherCtx, today.ID); !errors.Is(err, users.ErrUserDoesNotOwnMetric) {
+		t.Errorf("DeleteDailyLog of another user's log: got error %v, want %v", err, users.ErrUserDoesNotOwnMetric)
+	}
+
+	if err := f.service.DeleteDailyLog(ctx, today.ID); err != nil {
+		t.Fatalf("DeleteDailyLog: %v", err)
+	}
+	if _, err := f.metricRepo.GetMetricById(context.Background(), today.ID); !errors.Is(err, infra.ErrMetricNotFound) {
+		t.Errorf("GetMetricById of a deleted log: got error %v, want %v", err, infra.ErrMetricNotFound)
+	}
+	f.expectNoRecommendations(t, today.ID)
+	// the last log is now the one before today's
+	if lastMetricLog := f.lastMetricLog(t, user.ID); !lastMetricLog.Equal(older.CreatedAt) {
+		t.Errorf("LastMetricLog after deleting the latest log: got %v, want %v", lastMetricLog, older.CreatedAt)
+	}
+
+	// deleting an older log leaves it as it is
+	if err := f.service.DeleteDailyLog(ctx, oldest.ID); err != nil {
+		t.Fatalf("DeleteDailyLog: %v", err)
+	}
+	if lastMetricLog := f.lastMetricLog(t, user.ID); !lastMetricLog.Equal(older.CreatedAt) {
+		t.Errorf("LastMetricLog after deleting an older log: got %v, want %v", lastMetricLog, older.CreatedAt)
+	}
+
+	if err := f.service.DeleteDailyLog(ctx, older.ID); err != nil {
+		t.Fatalf("DeleteDailyLog: %v", err)
+	}
+	if lastMetricLog := f.lastMetricLog(t, user.ID); !lastMetricLog.IsZero() {
+		t.Errorf("LastMetricLog after deleting every log: got %v, want none", lastMetricLog)
+	}
+
+	// a new log can be made today again
+	if _, err := f.service.CreateDailyLog(ctx, 3, domain.NEUTRAL, domain.FAIR, ""); err != nil {
+		t.Errorf("CreateDailyLog after deleting today's log: %v", err)
+	}
+}
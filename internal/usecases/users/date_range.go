@@ -0,0 +1,85 @@
+package users
+
+import (
+	"errors"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInvalidFrom = errors.New("from must be a date (YYYY-MM-DD) or an RFC3339 timestamp")
+	ErrInvalidTo   = errors.New("to must be a date (YYYY-MM-DD) or an RFC3339 timestamp")
+)
+
+// DateRange is the from, to and timezone of a query as the client sent them.
+// Dates without a time are read in Timezone, or the user's own timezone when
+// it is empty, and a to date includes that whole day.
+type DateRange struct {
+	From     string
+	To       string
+	Timezone string
+}
+
+type MetricQuery struct {
+	DateRange DateRange
+	Cursor    string
+	Limit     int
+}
+
+type JournalQuery struct {
+	Search    string
+	Tag       string
+	DateRange DateRange
+	Cursor    string
+	Limit     int
+}
+
+// resolve returns the range's bounds, zero when they are open, and the
+// timezone its dates were read in.
+func (r DateRange) resolve(user domain.User) (time.Time, time.Time, *time.Location, error) {
+	location := user.Location()
+	if r.Timezone != "" {
+		if !domain.IsValidTimezone(r.Timezone) {
+			return time.Time{}, time.Time{}, nil, ErrInvalidTimezone
+		}
+		loaded, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, ErrInvalidTimezone
+		}
+		location = loaded
+	}
+
+	var from, to time.Time
+	if r.From != "" {
+		parsedFrom, _, err := parseDateOrTimestamp(r.From, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, ErrInvalidFrom
+		}
+		from = parsedFrom
+	}
+	if r.To != "" {
+		parsedTo, isDate, err := parseDateOrTimestamp(r.To, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, ErrInvalidTo
+		}
+		if isDate {
+			parsedTo = parsedTo.AddDate(0, 0, 1)
+		}
+		to = parsedTo
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, nil, ErrInvalidDateRange
+	}
+	return from, to, location, nil
+}
+
+func parseDateOrTimestamp(value string, location *time.Location) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		return date, true, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	return timestamp, false, err
+}
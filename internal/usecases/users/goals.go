@@ -0,0 +1,178 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// MaxGoals caps the goals a user can have at once.
+const MaxGoals = 20
+
+var (
+	ErrInvalidGoalKind      = errors.New("kind must be one of average_stressless_score, sleep_quality_days, mood_days or log_days")
+	ErrInvalidGoalPeriod    = errors.New("period must be one of week or month")
+	ErrInvalidGoalTarget    = errors.New("target must be a score from 1 to 100 for average_stressless_score and a number of days within the period otherwise")
+	ErrInvalidGoalThreshold = errors.New("threshold must be a sleep quality for sleep_quality_days, a mood for mood_days and empty otherwise")
+	ErrTooManyGoals         = errors.New("too many goals, delete one first")
+	ErrUserDoesNotOwnGoal   = errors.New("user does not own goal")
+)
+
+// GoalUpdate holds the fields of a goal a user wants to change, nil fields
+// are left as they are. The kind of a goal cannot change.
+type GoalUpdate struct {
+	Period    *domain.GoalPeriod
+	Target    *int
+	Threshold *string
+}
+
+func (u *UserService) CreateGoal(ctx context.Context, kind domain.GoalKind, period domain.GoalPeriod, target int, threshold string) (domain.GoalProgress, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.GoalProgress{}, err
+	}
+
+	createdAt := time.Now()
+	goal := domain.Goal{
+		ID:        primitive.NewObjectID(),
+		UserId:    existingUser.ID,
+		Kind:      kind,
+		Period:    period,
+		Target:    target,
+		Threshold: threshold,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := validateGoal(goal); err != nil {
+		return domain.GoalProgress{}, err
+	}
+
+	existingGoals, err := u.goalRepo.GetGoalsByUserId(ctx, existingUser.ID)
+	if err != nil {
+		return domain.GoalProgress{}, err
+	}
+	if len(existingGoals) >= MaxGoals {
+		return domain.GoalProgress{}, ErrTooManyGoals
+	}
+
+	if err := u.goalRepo.CreateGoal(ctx, goal); err != nil {
+		return domain.GoalProgress{}, err
+	}
+	return u.goalProgress(ctx, existingUser, goal)
+}
+
+// GetGoals returns the logged in user's goals measured over their current
+// period, oldest goal first.
+func (u *UserService) GetGoals(ctx context.Context) ([]domain.GoalProgress, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingGoals, err := u.goalRepo.GetGoalsByUserId(ctx, existingUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	return u.goalEvaluator.Progress(ctx, existingUser, existingGoals, time.Now())
+}
+
+func (u *UserService) GetGoal(ctx context.Context, goalId primitive.ObjectID) (domain.GoalProgress, error) {
+	existingUser, goal, err := u.getOwnGoal(ctx, goalId)
+	if err != nil {
+		return domain.GoalProgress{}, err
+	}
+	return u.goalProgress(ctx, existingUser, goal)
+}
+
+func (u *UserService) UpdateGoal(ctx context.Context, goalId primitive.ObjectID, update GoalUpdate) (domain.GoalProgress, error) {
+	existingUser, goal, err := u.getOwnGoal(ctx, goalId)
+	if err != nil {
+		return domain.GoalProgress{}, err
+	}
+
+	if update.Period != nil {
+		goal.Period = *update.Period
+	}
+	if update.Target != nil {
+		goal.Target = *update.Target
+	}
+	if update.Threshold != nil {
+		goal.Threshold = *update.Threshold
+	}
+	if err := validateGoal(goal); err != nil {
+		return domain.GoalProgress{}, err
+	}
+	goal.UpdatedAt = time.Now()
+
+	if err := u.goalRepo.UpdateGoal(ctx, goal); err != nil {
+		return domain.GoalProgress{}, err
+	}
+	return u.goalProgress(ctx, existingUser, goal)
+}
+
+// DeleteGoal deletes the goal along with its completions.
+func (u *UserService) DeleteGoal(ctx context.Context, goalId primitive.ObjectID) error {
+	_, goal, err := u.getOwnGoal(ctx, goalId)
+	if err != nil {
+		return err
+	}
+	return u.goalRepo.DeleteGoal(ctx, goal.ID)
+}
+
+// GetGoalCompletions returns the logged in user's goal completions made after
+// since, oldest first, so clients can poll for new ones.
+func (u *UserService) GetGoalCompletions(ctx context.Context, since time.Time) ([]domain.GoalCompletion, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.goalRepo.GetGoalCompletionsByUserId(ctx, existingUser.ID, since)
+}
+
+func (u *UserService) getOwnGoal(ctx context.Context, goalId primitive.ObjectID) (domain.User, domain.Goal, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.User{}, domain.Goal{}, err
+	}
+	goal, err := u.goalRepo.GetGoalById(ctx, goalId)
+	if err != nil {
+		return domain.User{}, domain.Goal{}, err
+	}
+	if goal.UserId != existingUser.ID {
+		return domain.User{}, domain.Goal{}, ErrUserDoesNotOwnGoal
+	}
+	return existingUser, goal, nil
+}
+
+func (u *UserService) goalProgress(ctx context.Context, user domain.User, goal domain.Goal) (domain.GoalProgress, error) {
+	progress, err := u.goalEvaluator.Progress(ctx, user, []domain.Goal{goal}, time.Now())
+	if err != nil {
+		return domain.GoalProgress{}, err
+	}
+	return progress[0], nil
+}
+
+func validateGoal(goal domain.Goal) error {
+	switch {
+	case !goal.Kind.IsValid():
+		return ErrInvalidGoalKind
+	case !goal.Period.IsValid():
+		return ErrInvalidGoalPeriod
+	case !goal.HasValidTarget():
+		return ErrInvalidGoalTarget
+	case !goal.HasValidThreshold():
+		return ErrInvalidGoalThreshold
+	}
+	return nil
+}
+
+// evaluateGoals only logs a failure, the log is saved already and goals that
+// count days are completed with the next one.
+func (u *UserService) evaluateGoals(ctx context.Context, user domain.User, metric domain.Metric) {
+	if _, err := u.goalEvaluator.Evaluate(ctx, user, metric); err != nil {
+		u.logger.Error("failed to evaluate goals", zap.String("user_id", user.ID.Hex()), zap.Error(err))
+	}
+}
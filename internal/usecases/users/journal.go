@@ -0,0 +1,207 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxJournalTitleLength = 200
+	MaxJournalBodyLength  = 20000
+	MaxJournalTags        = 10
+
+	DefaultJournalPageSize = 20
+	MaxJournalPageSize     = 100
+)
+
+var (
+	ErrInvalidJournalTitle        = errors.New("title must be at most 200 characters")
+	ErrInvalidJournalBody         = errors.New("body must be 1 to 20000 characters")
+	ErrInvalidJournalTag          = errors.New("tags must be 1 to 32 letters, digits, dashes or underscores")
+	ErrTooManyJournalTags         = errors.New("an entry can have at most 10 tags")
+	ErrUserDoesNotOwnJournalEntry = errors.New("user does not own journal entry")
+)
+
+// JournalEntryUpdate holds the fields of an entry a user wants to change, nil
+// fields are left as they are. A zero MetricId unlinks the entry from its
+// daily log.
+type JournalEntryUpdate struct {
+	Title    *string
+	Body     *string
+	Tags     *[]string
+	MetricId *primitive.ObjectID
+}
+
+func (u *UserService) CreateJournalEntry(ctx context.Context, title, body string, tags []string, metricId primitive.ObjectID) (domain.JournalEntry, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+
+	createdAt := time.Now()
+	entry := domain.JournalEntry{
+		ID:        primitive.NewObjectID(),
+		UserId:    existingUser.ID,
+		Title:     strings.TrimSpace(title),
+		Body:      body,
+		MetricId:  metricId,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if entry.Tags, err = normalizeJournalTags(tags); err != nil {
+		return domain.JournalEntry{}, err
+	}
+	if err := u.validateJournalEntry(ctx, entry); err != nil {
+		return domain.JournalEntry{}, err
+	}
+
+	if err := u.journalRepo.CreateJournalEntry(ctx, entry); err != nil {
+		return domain.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+func (u *UserService) GetJournalEntry(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	return u.getOwnJournalEntry(ctx, entryId)
+}
+
+// GetJournalEntries returns the logged in user's entries newest first. A
+// search matches entries having every word of it in their title, body or
+// tags.
+func (u *UserService) GetJournalEntries(ctx context.Context, journalQuery JournalQuery) (infra.JournalPage, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return infra.JournalPage{}, err
+	}
+
+	from, to, _, err := journalQuery.DateRange.resolve(existingUser)
+	if err != nil {
+		return infra.JournalPage{}, err
+	}
+	filter := infra.JournalFilter{
+		Search: journalQuery.Search,
+		Tag:    journalQuery.Tag,
+		From:   from,
+		To:     to,
+		Cursor: journalQuery.Cursor,
+		Limit:  journalQuery.Limit,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultJournalPageSize
+	}
+	if filter.Limit > MaxJournalPageSize {
+		filter.Limit = MaxJournalPageSize
+	}
+	if filter.Tag != "" {
+		filter.Tag = domain.NormalizeTag(filter.Tag)
+		if !domain.IsValidTag(filter.Tag) {
+			return infra.JournalPage{}, ErrInvalidJournalTag
+		}
+	}
+
+	return u.journalRepo.GetJournalEntriesByUserId(ctx, existingUser.ID, filter)
+}
+
+func (u *UserService) UpdateJournalEntry(ctx context.Context, entryId primitive.ObjectID, update JournalEntryUpdate) (domain.JournalEntry, error) {
+	entry, err := u.getOwnJournalEntry(ctx, entryId)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+
+	if update.Title != nil {
+		entry.Title = strings.TrimSpace(*update.Title)
+	}
+	if update.Body != nil {
+		entry.Body = *update.Body
+	}
+	if update.Tags != nil {
+		if entry.Tags, err = normalizeJournalTags(*update.Tags); err != nil {
+			return domain.JournalEntry{}, err
+		}
+	}
+	if update.MetricId != nil {
+		entry.MetricId = *update.MetricId
+	}
+	if err := u.validateJournalEntry(ctx, entry); err != nil {
+		return domain.JournalEntry{}, err
+	}
+	entry.UpdatedAt = time.Now()
+
+	if err := u.journalRepo.UpdateJournalEntry(ctx, entry); err != nil {
+		return domain.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+func (u *UserService) DeleteJournalEntry(ctx context.Context, entryId primitive.ObjectID) error {
+	entry, err := u.getOwnJournalEntry(ctx, entryId)
+	if err != nil {
+		return err
+	}
+	return u.journalRepo.DeleteJournalEntry(ctx, entry.ID)
+}
+
+func (u *UserService) getOwnJournalEntry(ctx context.Context, entryId primitive.ObjectID) (domain.JournalEntry, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	entry, err := u.journalRepo.GetJournalEntryById(ctx, entryId)
+	if err != nil {
+		return domain.JournalEntry{}, err
+	}
+	if entry.UserId != existingUser.ID {
+		return domain.JournalEntry{}, ErrUserDoesNotOwnJournalEntry
+	}
+	return entry, nil
+}
+
+// validateJournalEntry checks the entry's fields and that the daily log it
+// links to, if any, belongs to the same user.
+func (u *UserService) validateJournalEntry(ctx context.Context, entry domain.JournalEntry) error {
+	switch {
+	case utf8.RuneCountInString(entry.Title) > MaxJournalTitleLength:
+		return ErrInvalidJournalTitle
+	case strings.TrimSpace(entry.Body) == "", utf8.RuneCountInString(entry.Body) > MaxJournalBodyLength:
+		return ErrInvalidJournalBody
+	}
+	if !entry.HasMetric() {
+		return nil
+	}
+	metric, err := u.metricRepo.GetMetricById(ctx, entry.MetricId)
+	if err != nil {
+		return err
+	}
+	if metric.OwnerId != entry.UserId {
+		return ErrUserDoesNotOwnMetric
+	}
+	return nil
+}
+
+// normalizeJournalTags normalizes every tag and drops duplicates, keeping the
+// order they were given in.
+func normalizeJournalTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = domain.NormalizeTag(tag)
+		if !domain.IsValidTag(tag) {
+			return nil, ErrInvalidJournalTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxJournalTags {
+		return nil, ErrTooManyJournalTags
+	}
+	return normalized, nil
+}
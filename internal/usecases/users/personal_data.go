@@ -0,0 +1,192 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+)
+
+// UserDataExport is everything we store about a user.
+type UserDataExport struct {
+	User            domain.User
+	Metrics         []domain.Metric
+	Recommendations []domain.Recommendation
+	Achievements    []domain.Achievement
+	Goals           []domain.Goal
+	GoalCompletions []domain.GoalCompletion
+	JournalEntries  []domain.JournalEntry
+	SafetyEvents    []domain.SafetyEvent
+	Shares          []domain.Share
+	ExportedAt      time.Time
+}
+
+func (u *UserService) ExportUserData(ctx context.Context) (UserDataExport, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	metrics, recommendations, err := u.exportMetrics(ctx, existingUser.ID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	achievements, err := u.achievementRepo.GetAchievementsByUserId(ctx, existingUser.ID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	goals, err := u.goalRepo.GetGoalsByUserId(ctx, existingUser.ID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	goalCompletions, err := u.goalRepo.GetGoalCompletionsByUserId(ctx, existingUser.ID, time.Time{})
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	journalEntries, err := u.exportJournalEntries(ctx, existingUser.ID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	safetyEvents, err := u.safetyRepo.GetSafetyEventsByUserId(ctx, existingUser.ID, 0)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	shares, err := u.shareRepo.GetSharesByOwnerId(ctx, existingUser.ID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+
+	return UserDataExport{
+		User:            existingUser,
+		Metrics:         metrics,
+		Recommendations: recommendations,
+		Achievements:    achievements,
+		Goals:           goals,
+		GoalCompletions: goalCompletions,
+		JournalEntries:  journalEntries,
+		SafetyEvents:    safetyEvents,
+		Shares:          shares,
+		ExportedAt:      time.Now(),
+	}, nil
+}
+
+// exportMetrics pages through a user's metrics, along with the
+// recommendations of each page, so an export never loads them in one query.
+func (u *UserService) exportMetrics(ctx context.Context, userId primitive.ObjectID) ([]domain.Metric, []domain.Recommendation, error) {
+	metrics, recommendations := []domain.Metric{}, []domain.Recommendation{}
+	filter := infra.MetricFilter{Limit: MaxMetricPageSize}
+	for {
+		page, err := u.metricRepo.GetMetricsByUserId(ctx, userId, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		metricIds := make([]primitive.ObjectID, 0, len(page.Metrics))
+		for _, metric := range page.Metrics {
+			metricIds = append(metricIds, metric.ID)
+		}
+		pageRecommendations, err := u.recommendationRepo.GetRecommendationsByMetricIds(ctx, metricIds)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		metrics = append(metrics, page.Metrics...)
+		recommendations = append(recommendations, pageRecommendations...)
+		if page.NextCursor == "" {
+			return metrics, recommendations, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func (u *UserService) exportJournalEntries(ctx context.Context, userId primitive.ObjectID) ([]domain.JournalEntry, error) {
+	entries := []domain.JournalEntry{}
+	filter := infra.JournalFilter{Limit: MaxJournalPageSize}
+	for {
+		page, err := u.journalRepo.GetJournalEntriesByUserId(ctx, userId, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.Entries...)
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// DeleteAccount erases the logged in user's profile, metrics, recommendations,
+// achievements, goals, journal entries, safety events and shares and ends all
+// of their sessions. Every step tolerates data that is already gone so a failed
+// deletion can simply be retried.
+func (u *UserService) DeleteAccount(ctx context.Context) error {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+	userId := jwtClaims.ID
+
+	// the days only hold the ids of the metrics, nothing has to be decrypted
+	days, err := u.metricRepo.GetMetricDays(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	metricIds := make([]primitive.ObjectID, 0, len(days))
+	for _, day := range days {
+		metricIds = append(metricIds, day.MetricId)
+	}
+
+	err = u.recommendationRepo.DeleteRecommendationsByMetricIds(ctx, metricIds)
+	if err != nil {
+		return err
+	}
+
+	err = u.metricRepo.DeleteMetricsByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.achievementRepo.DeleteAchievementsByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.goalRepo.DeleteGoalsByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.journalRepo.DeleteJournalEntriesByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.safetyRepo.DeleteSafetyDataByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.shareRepo.DeleteSharesByOwnerId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepo.DeleteUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return u.authService.RevokeAllSessions(ctx, userId)
+}
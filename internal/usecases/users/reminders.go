@@ -0,0 +1,114 @@
+package users
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+)
+
+// MaxPushSubscriptions caps the browsers a user can subscribe, the oldest
+// subscription is dropped to make room for a new one.
+const MaxPushSubscriptions = 10
+
+var (
+	ErrInvalidReminderTime      = errors.New("reminder time and quiet hours must be HH:MM in 24 hour time")
+	ErrIncompleteQuietHours     = errors.New("quiet hours need both a start and an end")
+	ErrInvalidPushSubscription  = errors.New("push subscription needs an https endpoint and base64url p256dh and auth keys")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+)
+
+func (u *UserService) UpdateReminderPreferences(ctx context.Context, preferences domain.ReminderPreferences) (domain.User, error) {
+	for _, clock := range []string{preferences.Time, preferences.QuietHoursStart, preferences.QuietHoursEnd} {
+		if clock != "" && !domain.IsValidClock(clock) {
+			return domain.User{}, ErrInvalidReminderTime
+		}
+	}
+	if (preferences.QuietHoursStart == "") != (preferences.QuietHoursEnd == "") {
+		return domain.User{}, ErrIncompleteQuietHours
+	}
+
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	existingUser.Reminders = preferences
+	existingUser.UpdatedAt = time.Now()
+	if err := u.userRepo.UpdateUser(ctx, existingUser); err != nil {
+		return domain.User{}, err
+	}
+	return existingUser, nil
+}
+
+// AddPushSubscription saves a browser's push subscription, replacing the one
+// with the same endpoint when the browser subscribes again.
+func (u *UserService) AddPushSubscription(ctx context.Context, endpoint, p256dh, authSecret string) error {
+	if !isValidPushSubscription(endpoint, p256dh, authSecret) {
+		return ErrInvalidPushSubscription
+	}
+
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return err
+	}
+	subscriptions := []domain.PushSubscription{}
+	for _, subscription := range existingUser.PushSubscriptions {
+		if subscription.Endpoint != endpoint {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	if len(subscriptions) >= MaxPushSubscriptions {
+		subscriptions = subscriptions[len(subscriptions)-MaxPushSubscriptions+1:]
+	}
+	existingUser.PushSubscriptions = append(subscriptions, domain.PushSubscription{
+		Endpoint:  endpoint,
+		P256dh:    p256dh,
+		Auth:      authSecret,
+		CreatedAt: time.Now(),
+	})
+	existingUser.UpdatedAt = time.Now()
+	return u.userRepo.UpdateUser(ctx, existingUser)
+}
+
+func (u *UserService) RemovePushSubscription(ctx context.Context, endpoint string) error {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, jwtClaims.ID)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := []domain.PushSubscription{}
+	for _, subscription := range existingUser.PushSubscriptions {
+		if subscription.Endpoint != endpoint {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	if len(subscriptions) == len(existingUser.PushSubscriptions) {
+		return ErrPushSubscriptionNotFound
+	}
+	existingUser.PushSubscriptions = subscriptions
+	existingUser.UpdatedAt = time.Now()
+	return u.userRepo.UpdateUser(ctx, existingUser)
+}
+
+func isValidPushSubscription(endpoint, p256dh, authSecret string) bool {
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil || endpointUrl.Scheme != "https" || endpointUrl.Host == "" {
+		return false
+	}
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p256dh, "="))
+	if err != nil || len(key) != 65 {
+		return false
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(authSecret, "="))
+	return err == nil && len(secret) == 16
+}
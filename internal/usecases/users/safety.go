@@ -0,0 +1,209 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/safety"
+)
+
+var (
+	ErrNotClinician           = errors.New("only clinicians can manage safety settings")
+	ErrNotSharedWithClinician = errors.New("user has no active share accepted by this clinician")
+	ErrInvalidSafetyOverride  = errors.New("invalid safety override")
+)
+
+const (
+	// MaxOverrideLowMoodDays caps how long a run of low mood days a clinician
+	// can ask for before a log is flagged.
+	MaxOverrideLowMoodDays = 30
+	// safetyEventsShown is how many of a user's latest safety events a
+	// clinician sees.
+	safetyEventsShown = 50
+)
+
+// UserSafety is what a clinician sees of a user's safety checks.
+type UserSafety struct {
+	User        domain.User
+	HasOverride bool
+	Override    domain.SafetyOverride
+	Events      []domain.SafetyEvent
+}
+
+// recordAssessment saves the audit event of a flagged log, in the transaction
+// that saves the log.
+func (u *UserService) recordAssessment(ctx context.Context, user domain.User, metric domain.Metric, assessment safety.Assessment) error {
+	if !assessment.Flagged() {
+		return nil
+	}
+	if err := u.safetyRepo.CreateSafetyEvent(ctx, u.safetyModule.FlaggedEvent(user, metric, assessment)); err != nil {
+		return fmt.Errorf("error saving safety event: %w", err)
+	}
+	return nil
+}
+
+func (u *UserService) logAssessment(user domain.User, metric domain.Metric, assessment safety.Assessment) {
+	if !assessment.Flagged() {
+		return
+	}
+	flags := make([]string, 0, len(assessment.Flags))
+	for _, flag := range assessment.Flags {
+		flags = append(flags, string(flag))
+	}
+	u.logger.Warn("daily log flagged by safety checks",
+		zap.String("user_id", user.ID.Hex()),
+		zap.String("metric_id", metric.ID.Hex()),
+		zap.Strings("flags", flags),
+		zap.Bool("escalated", assessment.Escalate),
+		zap.String("country", assessment.Country),
+	)
+}
+
+// GetUserSafety records every view in the user's safety events, so they are
+// audited like overrides.
+func (u *UserService) GetUserSafety(ctx context.Context, userId primitive.ObjectID) (UserSafety, error) {
+	clinician, err := u.clinicianOf(ctx, userId)
+	if err != nil {
+		return UserSafety{}, err
+	}
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, userId)
+	if err != nil {
+		return UserSafety{}, err
+	}
+
+	result := UserSafety{User: existingUser}
+	override, err := u.safetyRepo.GetSafetyOverride(ctx, userId)
+	switch {
+	case err == nil:
+		result.HasOverride, result.Override = true, override
+	case !errors.Is(err, infra.ErrSafetyOverrideNotFound):
+		return UserSafety{}, err
+	}
+
+	result.Events, err = u.safetyRepo.GetSafetyEventsByUserId(ctx, userId, safetyEventsShown)
+	if err != nil {
+		return UserSafety{}, err
+	}
+	if err := u.safetyRepo.CreateSafetyEvent(ctx, clinicianEvent(domain.SafetyViewedEvent, userId, clinician.ID, time.Now())); err != nil {
+		return UserSafety{}, fmt.Errorf("error saving safety event: %w", err)
+	}
+	return result, nil
+}
+
+// SetSafetyOverride replaces a user's override. A lowMoodDays of 0 keeps the
+// configured threshold and a zero suppressEscalationUntil keeps crisis
+// resources in their recommendations.
+func (u *UserService) SetSafetyOverride(ctx context.Context, userId primitive.ObjectID, lowMoodDays int, suppressEscalationUntil time.Time) (domain.SafetyOverride, error) {
+	clinician, err := u.clinicianOf(ctx, userId)
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+	if lowMoodDays < 0 || lowMoodDays > MaxOverrideLowMoodDays {
+		return domain.SafetyOverride{}, fmt.Errorf("%w: low_mood_days must be from 0 to %d", ErrInvalidSafetyOverride, MaxOverrideLowMoodDays)
+	}
+	now := time.Now()
+	if !suppressEscalationUntil.IsZero() && !suppressEscalationUntil.After(now) {
+		return domain.SafetyOverride{}, fmt.Errorf("%w: suppress_escalation_until must be in the future", ErrInvalidSafetyOverride)
+	}
+	if lowMoodDays == 0 && suppressEscalationUntil.IsZero() {
+		return domain.SafetyOverride{}, fmt.Errorf("%w: set low_mood_days or suppress_escalation_until", ErrInvalidSafetyOverride)
+	}
+	if _, err := u.userRepo.GetUserByUserId(ctx, userId); err != nil {
+		return domain.SafetyOverride{}, err
+	}
+
+	override := domain.SafetyOverride{
+		UserId:                  userId,
+		LowMoodDays:             lowMoodDays,
+		SuppressEscalationUntil: suppressEscalationUntil,
+		ClinicianId:             clinician.ID,
+		UpdatedAt:               now,
+	}
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.safetyRepo.SaveSafetyOverride(ctx, override); err != nil {
+			return err
+		}
+		return u.safetyRepo.CreateSafetyEvent(ctx, clinicianEvent(domain.OverrideSetEvent, userId, clinician.ID, now))
+	})
+	if err != nil {
+		return domain.SafetyOverride{}, err
+	}
+
+	u.logger.Warn("safety override set", zap.String("user_id", userId.Hex()), zap.String("clinician_id", clinician.ID.Hex()),
+		zap.Int("low_mood_days", lowMoodDays), zap.Time("suppress_escalation_until", suppressEscalationUntil))
+	return override, nil
+}
+
+func (u *UserService) RemoveSafetyOverride(ctx context.Context, userId primitive.ObjectID) error {
+	clinician, err := u.clinicianOf(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.safetyRepo.DeleteSafetyOverride(ctx, userId); err != nil {
+			return err
+		}
+		return u.safetyRepo.CreateSafetyEvent(ctx, clinicianEvent(domain.OverrideRemovedEvent, userId, clinician.ID, now))
+	})
+	if err != nil {
+		return err
+	}
+
+	u.logger.Warn("safety override removed", zap.String("user_id", userId.Hex()), zap.String("clinician_id", clinician.ID.Hex()))
+	return nil
+}
+
+// loggedInClinician only lets through verified addresses, otherwise anyone
+// signing up first with a clinician's email would pass.
+func (u *UserService) loggedInClinician(ctx context.Context) (domain.User, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if !existingUser.IsEmailVerified || !u.safetyModule.IsClinician(existingUser.Email) {
+		return domain.User{}, ErrNotClinician
+	}
+	return existingUser, nil
+}
+
+// clinicianOf returns the logged in clinician as long as userId has an active
+// share they accepted while logged in, clinicians only follow the users who
+// shared with them.
+func (u *UserService) clinicianOf(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
+	clinician, err := u.loggedInClinician(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	shares, err := u.shareRepo.GetSharesByOwnerId(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	now := time.Now()
+	for _, share := range shares {
+		if share.AcceptedBy == clinician.ID && share.Status(now) == domain.ActiveShare {
+			return clinician, nil
+		}
+	}
+	u.logger.Warn("clinician denied safety access", zap.String("user_id", userId.Hex()), zap.String("clinician_id", clinician.ID.Hex()))
+	return domain.User{}, ErrNotSharedWithClinician
+}
+
+func clinicianEvent(kind domain.SafetyEventKind, userId, clinicianId primitive.ObjectID, at time.Time) domain.SafetyEvent {
+	return domain.SafetyEvent{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		Kind:      kind,
+		Flags:     []domain.SafetyFlag{},
+		ActorId:   clinicianId,
+		CreatedAt: at,
+	}
+}
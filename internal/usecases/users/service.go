@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,8 +13,15 @@ import (
 
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/achievements"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/goals"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/idempotency"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/mailer"
 	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/recommendations"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/safety"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/sentiment"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/verification"
 )
 
 type UserService struct {
@@ -21,7 +29,22 @@ type UserService struct {
 	authService           auth.AuthService
 	metricRepo            infra.MetricRepository
 	recommendationService recommendations.RecommendationService
+	sentimentAnalyzer     *sentiment.Analyzer
+	safetyModule          *safety.Module
 	recommendationRepo    infra.RecommendationRepository
+	transactor            infra.Transactor
+	jobQueue              infra.JobQueue
+	achievementRepo       infra.AchievementRepository
+	achievementEngine     *achievements.Engine
+	goalRepo              infra.GoalRepository
+	goalEvaluator         *goals.Evaluator
+	journalRepo           infra.JournalRepository
+	safetyRepo            infra.SafetyRepository
+	shareRepo             infra.ShareRepository
+	mailer                mailer.Mailer
+	tokenStore            *verification.TokenStore
+	idempotencyStore      *idempotency.Store
+	appBaseUrl            string
 	logger                *zap.Logger
 }
 
@@ -30,9 +53,18 @@ var (
 	ErrPasswordIncorrect    = errors.New("invalid credentials")
 	ErrInvalidToken         = errors.New("invalid token")
 	ErrUserDoesNotOwnMetric = errors.New("user does not own metric")
+	ErrInvalidDateRange     = errors.New("from must be before to")
+	ErrInvalidGranularity   = errors.New("granularity must be one of day, week or month")
+	ErrInvalidTimezone      = errors.New("timezone must be a valid IANA timezone such as Africa/Lagos")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
 )
 
-func NewUserService(userRepo infra.UserRepository, authService auth.AuthService, metricRepo infra.MetricRepository, recommendationService recommendations.RecommendationService, recommendationRepo infra.RecommendationRepository, logger *zap.Logger) (*UserService, error) {
+const (
+	DefaultMetricPageSize = 20
+	MaxMetricPageSize     = 100
+)
+
+func NewUserService(userRepo infra.UserRepository, authService auth.AuthService, metricRepo infra.MetricRepository, recommendationService recommendations.RecommendationService, sentimentAnalyzer *sentiment.Analyzer, safetyModule *safety.Module, recommendationRepo infra.RecommendationRepository, transactor infra.Transactor, jobQueue infra.JobQueue, achievementRepo infra.AchievementRepository, achievementEngine *achievements.Engine, goalRepo infra.GoalRepository, goalEvaluator *goals.Evaluator, journalRepo infra.JournalRepository, safetyRepo infra.SafetyRepository, shareRepo infra.ShareRepository, mailer mailer.Mailer, tokenStore *verification.TokenStore, idempotencyStore *idempotency.Store, appBaseUrl string, logger *zap.Logger) (*UserService, error) {
 	if userRepo == nil {
 		return &UserService{}, errors.New("UserService failed to initialize, userRepo is nil")
 	}
@@ -45,18 +77,67 @@ func NewUserService(userRepo infra.UserRepository, authService auth.AuthService,
 	if recommendationService == nil {
 		return &UserService{}, errors.New("UserService failed to initialize, recommendationService is nil")
 	}
+	if sentimentAnalyzer == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, sentimentAnalyzer is nil")
+	}
+	if safetyModule == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, safetyModule is nil")
+	}
 	if recommendationRepo == nil {
 		return &UserService{}, errors.New("UserService failed to initialize, recommendationRepo is nil")
 	}
-	return &UserService{userRepo, authService, metricRepo, recommendationService, recommendationRepo, logger}, nil
+	if transactor == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, transactor is nil")
+	}
+	if jobQueue == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, jobQueue is nil")
+	}
+	if achievementRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, achievementRepo is nil")
+	}
+	if achievementEngine == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, achievementEngine is nil")
+	}
+	if goalRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, goalRepo is nil")
+	}
+	if goalEvaluator == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, goalEvaluator is nil")
+	}
+	if journalRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, journalRepo is nil")
+	}
+	if safetyRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, safetyRepo is nil")
+	}
+	if shareRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, shareRepo is nil")
+	}
+	if mailer == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, mailer is nil")
+	}
+	if tokenStore == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, tokenStore is nil")
+	}
+	if idempotencyStore == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, idempotencyStore is nil")
+	}
+	return &UserService{userRepo, authService, metricRepo, recommendationService, sentimentAnalyzer, safetyModule, recommendationRepo, transactor, jobQueue, achievementRepo, achievementEngine, goalRepo, goalEvaluator, journalRepo, safetyRepo, shareRepo, mailer, tokenStore, idempotencyStore, strings.TrimSuffix(appBaseUrl, "/"), logger}, nil
 }
 
-func (u *UserService) CreateUser(ctx context.Context, firstName, lastName, email, password string) (domain.User, error) {
+func (u *UserService) CreateUser(ctx context.Context, firstName, lastName, email, password, timezone string) (domain.User, error) {
 	existingUser, err := u.userRepo.GetUserByEmail(ctx, email)
 	if err == nil && existingUser.Email == email {
 		return domain.User{}, ErrUserAlreadyExists
 	}
 
+	if timezone == "" {
+		timezone = domain.DefaultTimezone
+	}
+	if !domain.IsValidTimezone(timezone) {
+		return domain.User{}, ErrInvalidTimezone
+	}
+
 	hashedPassword, err := hashAndSalt([]byte(password))
 	if err != nil {
 		return domain.User{}, err
@@ -68,33 +149,77 @@ func (u *UserService) CreateUser(ctx context.Context, firstName, lastName, email
 		FirstName:            firstName,
 		LastName:             lastName,
 		Password:             hashedPassword,
+		Timezone:             timezone,
 		IsOnBoardingComplete: false,
 		CreatedAt:            time.Now(),
 		UpdatedAt:            time.Now(),
 	}
 
 	err = u.userRepo.CreateUser(ctx, newUser)
+	if errors.Is(err, infra.ErrDuplicateEmail) {
+		return domain.User{}, ErrUserAlreadyExists
+	}
 	if err != nil {
 		return domain.User{}, err
 	}
+
+	if err := u.sendVerificationEmail(ctx, newUser); err != nil {
+		u.logger.Error("failed to send verification email", zap.String("user_id", newUser.ID.Hex()), zap.Error(err))
+	}
 	return newUser, nil
 }
 
-func (u *UserService) LogUserIn(ctx context.Context, email, password string) (string, error) {
+func (u *UserService) LogUserIn(ctx context.Context, email, password string, device auth.DeviceInfo) (auth.TokenPair, error) {
 	existingUser, err := u.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return auth.TokenPair{}, err
 	}
 
 	if isPasswordCorrect := comparePasswords(existingUser.Password, []byte(password)); !isPasswordCorrect {
-		return "", ErrPasswordIncorrect
+		return auth.TokenPair{}, ErrPasswordIncorrect
 	}
 
-	accessToken, err := u.authService.GenerateJWT(ctx, existingUser)
+	tokenPair, err := u.authService.GenerateTokenPair(ctx, existingUser, device)
 	if err != nil {
-		return "", err
+		return auth.TokenPair{}, err
+	}
+	return tokenPair, nil
+}
+
+func (u *UserService) RefreshTokens(ctx context.Context, refreshToken string) (auth.TokenPair, error) {
+	tokenPair, err := u.authService.RefreshTokenPair(ctx, refreshToken)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+	return tokenPair, nil
+}
+
+func (u *UserService) LogUserOut(ctx context.Context) error {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+	err := u.authService.RevokeSession(ctx, jwtClaims.ID, jwtClaims.SessionId)
+	if err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (u *UserService) GetSessions(ctx context.Context) ([]auth.Session, error) {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return []auth.Session{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
 	}
-	return accessToken, nil
+	return u.authService.ListSessions(ctx, jwtClaims.ID)
+}
+
+func (u *UserService) RevokeSession(ctx context.Context, sessionId string) error {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+	return u.authService.RevokeSession(ctx, jwtClaims.ID, sessionId)
 }
 
 func (u *UserService) GetLoggedInUser(ctx context.Context) (domain.User, error) {
@@ -123,7 +248,8 @@ func (u *UserService) CreateDailyLog(ctx context.Context, stressLevel int, mood
 		return domain.Metric{}, err
 	}
 
-	exisitingMetric, err := u.metricRepo.GetUserTodayLogIfExists(ctx, existingUser.ID)
+	location := existingUser.Location()
+	exisitingMetric, err := u.metricRepo.GetUserTodayLogIfExists(ctx, existingUser.ID, location)
 	if err != nil {
 		if !errors.Is(err, infra.ErrMetricNotFound) {
 			return domain.Metric{}, err
@@ -138,6 +264,8 @@ func (u *UserService) CreateDailyLog(ctx context.Context, stressLevel int, mood
 		return domain.Metric{}, fmt.Errorf("error generating stressScore: %w", err)
 	}
 
+	feelingAnalysis := u.sentimentAnalyzer.Analyze(feeling)
+	createdAt := time.Now()
 	newMetric := domain.Metric{
 		ID:              primitive.NewObjectID(),
 		OwnerId:         existingUser.ID,
@@ -146,59 +274,63 @@ func (u *UserService) CreateDailyLog(ctx context.Context, stressLevel int, mood
 		SleepQuality:    sleepQuality,
 		StressLessScore: stressLessScore,
 		Feeling:         feeling,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		FeelingPolarity: feelingAnalysis.Polarity,
+		Themes:          feelingAnalysis.Themes,
+		LocalDate:       domain.LocalDate(createdAt, location),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	assessment := u.safetyModule.Assess(ctx, existingUser, newMetric)
+	newMetric.SafetyFlags = assessment.Flags
+
+	rs := pendingRecommendations(newMetric)
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.metricRepo.CreateMetric(ctx, newMetric); err != nil {
+			return err
+		}
+		if err := u.userRepo.UpdateUserLastMetricLog(ctx, existingUser); err != nil {
+			return err
+		}
+		for _, recommendation := range rs {
+			if err := u.recommendationRepo.CreateRecommendation(ctx, recommendation); err != nil {
+				return fmt.Errorf("error saving recommendation : %w", err)
+			}
+		}
+		return u.recordAssessment(ctx, existingUser, newMetric, assessment)
+	})
+	if errors.Is(err, infra.ErrDailyLogExists) {
+		// a concurrent request logged today first, return its log
+		return u.metricRepo.GetUserTodayLogIfExists(ctx, existingUser.ID, location)
 	}
-
-	err = u.metricRepo.CreateMetric(ctx, newMetric)
 	if err != nil {
 		return domain.Metric{}, err
 	}
 
-	err = u.userRepo.UpdateUserLastMetricLog(ctx, existingUser)
-	if err != nil {
-		return domain.Metric{}, err
-	}
+	u.logAssessment(existingUser, newMetric, assessment)
+	u.enqueueRecommendations(ctx, rs)
+	u.awardAchievements(ctx, existingUser, newMetric)
+	u.evaluateGoals(ctx, existingUser, newMetric)
+	return newMetric, nil
+}
 
-	rs, err := generateRecommendations(ctx, u, newMetric)
-	if err != nil {
-		return domain.Metric{}, err
+// pendingRecommendations are saved along with a metric, the recommendation
+// worker generates their items in the background.
+func pendingRecommendations(metric domain.Metric) []domain.Recommendation {
+	rs := []domain.Recommendation{}
+	for _, metricType := range domain.RecommendationMetricTypes {
+		rs = append(rs, domain.NewPendingRecommendation(metric, metricType))
 	}
+	return rs
+}
 
+// enqueueRecommendations only logs a failure, the recommendation stays pending
+// and the worker enqueues it again when it next starts.
+func (u *UserService) enqueueRecommendations(ctx context.Context, rs []domain.Recommendation) {
 	for _, recommendation := range rs {
-		err = u.recommendationRepo.CreateRecommendation(ctx, recommendation)
-		if err != nil {
-			return domain.Metric{}, fmt.Errorf("error saving recommendation : %w", err)
+		if err := recommendations.EnqueueGeneration(ctx, u.jobQueue, recommendation.ID); err != nil {
+			u.logger.Error("failed to enqueue recommendation", zap.String("recommendation_id", recommendation.ID.Hex()), zap.Error(err))
 		}
 	}
-
-	return newMetric, nil
-}
-
-func generateRecommendations(ctx context.Context, u *UserService, newMetric domain.Metric) ([]domain.Recommendation, error) {
-	stresslessRecommendation, err := u.recommendationService.GetRecommendationUsingStressScore(ctx, newMetric)
-	if err != nil {
-		return []domain.Recommendation{}, fmt.Errorf("error generating stressless recommendation : %w", err)
-	}
-	stresslevelRecommendation, err := u.recommendationService.GetRecommendationUsingStressLevel(ctx, newMetric)
-	if err != nil {
-		return []domain.Recommendation{}, fmt.Errorf("error generating stresslevel recommendation : %w", err)
-	}
-	stressQualityRecommendation, err := u.recommendationService.GetRecommendationUsingSleepQuality(ctx, newMetric)
-	if err != nil {
-		return []domain.Recommendation{}, fmt.Errorf("error generating stressQuality recommendation : %w", err)
-	}
-	moodRecommendation, err := u.recommendationService.GetRecommendationUsingStressLevel(ctx, newMetric)
-	if err != nil {
-		return []domain.Recommendation{}, fmt.Errorf("error generating mood recommendation : %w", err)
-	}
-	rs := []domain.Recommendation{
-		stresslessRecommendation,
-		stresslevelRecommendation,
-		stressQualityRecommendation,
-		moodRecommendation,
-	}
-	return rs, nil
 }
 
 func (u *UserService) GetMetricByMetricId(ctx context.Context, metricId primitive.ObjectID) (domain.Metric, error) {
@@ -221,7 +353,7 @@ func (u *UserService) GetMetricByMetricId(ctx context.Context, metricId primitiv
 	if metric.OwnerId != existingUser.ID {
 		return domain.Metric{}, ErrUserDoesNotOwnMetric
 	}
-	return metric, nil
+	return withoutFeelings(ctx, metric), nil
 }
 
 func (u *UserService) GetRecommendationByMetricId(ctx context.Context, metricId primitive.ObjectID, metricType string) (domain.Recommendation, error) {
@@ -264,7 +396,7 @@ func (u *UserService) GetMetricForToday(ctx context.Context) (domain.Metric, err
 		return domain.Metric{}, err
 	}
 
-	metric, err := u.metricRepo.GetUserTodayLogIfExists(ctx, userId)
+	metric, err := u.metricRepo.GetUserTodayLogIfExists(ctx, existingUser.ID, existingUser.Location())
 	if err != nil {
 		return domain.Metric{}, err
 	}
@@ -275,27 +407,100 @@ func (u *UserService) GetMetricForToday(ctx context.Context) (domain.Metric, err
 	return metric, nil
 }
 
-func (u *UserService) GetRecentMetricsByUserId(ctx context.Context) ([]domain.Metric, error) {
-	// TODO:TODO: this method has issues
+func (u *UserService) GetMetricsByUserId(ctx context.Context, metricQuery MetricQuery) (infra.MetricPage, error) {
 	jwtClaims, ok := auth.GetJWTClaims(ctx)
 	if !ok {
-		return []domain.Metric{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+		return infra.MetricPage{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
 	}
 	userId := jwtClaims.ID
 
-	// TODO:TODO: I should add the limit, rowperpage and offset stuff
 	existingUser, err := u.userRepo.GetUserByUserId(ctx, userId)
 	if err != nil {
-		return []domain.Metric{}, err
+		return infra.MetricPage{}, err
+	}
+
+	from, to, _, err := metricQuery.DateRange.resolve(existingUser)
+	if err != nil {
+		return infra.MetricPage{}, err
 	}
-	metrics, err := u.metricRepo.GetRecentMetricsByUserId(ctx, existingUser.ID)
+	filter := infra.MetricFilter{From: from, To: to, Cursor: metricQuery.Cursor, Limit: metricQuery.Limit}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultMetricPageSize
+	}
+	if filter.Limit > MaxMetricPageSize {
+		filter.Limit = MaxMetricPageSize
+	}
+
+	page, err := u.metricRepo.GetMetricsByUserId(ctx, existingUser.ID, filter)
 	if err != nil {
-		return []domain.Metric{}, err
+		return infra.MetricPage{}, err
 	}
-	return metrics, nil
+	for i, metric := range page.Metrics {
+		page.Metrics[i] = withoutFeelings(ctx, metric)
+	}
+	return page, nil
+}
+
+// MetricSummary is a summary's buckets and the timezone they were cut in.
+type MetricSummary struct {
+	Location *time.Location
+	Buckets  []domain.MetricSummaryBucket
 }
 
-func (u *UserService) CompleteUserOnboarding(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling string) (domain.User, error) {
+func (u *UserService) GetMetricSummary(ctx context.Context, granularity domain.SummaryGranularity, dateRange DateRange) (MetricSummary, error) {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if !ok {
+		return MetricSummary{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
+	}
+	userId := jwtClaims.ID
+
+	existingUser, err := u.userRepo.GetUserByUserId(ctx, userId)
+	if err != nil {
+		return MetricSummary{}, err
+	}
+
+	if !granularity.IsValid() {
+		return MetricSummary{}, ErrInvalidGranularity
+	}
+	from, to, location, err := dateRange.resolve(existingUser)
+	if err != nil {
+		return MetricSummary{}, err
+	}
+	if from.IsZero() {
+		from = defaultSummaryStart(granularity, to, location)
+	}
+
+	buckets, err := u.metricRepo.GetMetricSummary(ctx, existingUser.ID, infra.MetricSummaryFilter{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Location:    location,
+	})
+	if err != nil {
+		return MetricSummary{}, err
+	}
+	return MetricSummary{Location: location, Buckets: buckets}, nil
+}
+
+// defaultSummaryStart keeps an open ended summary to the last 30 days, 12
+// weeks or 12 months before `to`.
+func defaultSummaryStart(granularity domain.SummaryGranularity, to time.Time, location *time.Location) time.Time {
+	end := to
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = end.In(location)
+	switch granularity {
+	case domain.WeeklySummary:
+		return granularity.BucketStart(end.AddDate(0, 0, -7*11))
+	case domain.MonthlySummary:
+		return granularity.BucketStart(end.AddDate(0, -11, 0))
+	default:
+		return granularity.BucketStart(end.AddDate(0, 0, -29))
+	}
+}
+
+func (u *UserService) CompleteUserOnboarding(ctx context.Context, stressLevel int, mood domain.Mood, sleepQuality domain.SleepQuality, feeling, timezone string) (domain.User, error) {
 	jwtClaims, ok := auth.GetJWTClaims(ctx)
 	if !ok {
 		return domain.User{}, fmt.Errorf("error parsing JWTClaims: %w", ErrInvalidToken)
@@ -309,12 +514,25 @@ func (u *UserService) CompleteUserOnboarding(ctx context.Context, stressLevel in
 	if existingUser.IsOnBoardingComplete {
 		return existingUser, err
 	}
+	if timezone != "" && !domain.IsValidTimezone(timezone) {
+		return domain.User{}, ErrInvalidTimezone
+	}
 
 	stressLessScore, err := u.recommendationService.GetStresslessScore(ctx, stressLevel, mood, sleepQuality, feeling)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("error generating stressScore: %w", err)
 	}
 
+	createdAt := time.Now()
+	updatedUser := existingUser
+	updatedUser.IsOnBoardingComplete = true
+	updatedUser.LastMetricLog = createdAt
+	updatedUser.UpdatedAt = createdAt
+	if timezone != "" {
+		updatedUser.Timezone = timezone
+	}
+
+	feelingAnalysis := u.sentimentAnalyzer.Analyze(feeling)
 	newMetric := domain.Metric{
 		ID:              primitive.NewObjectID(),
 		OwnerId:         userId,
@@ -323,41 +541,38 @@ func (u *UserService) CompleteUserOnboarding(ctx context.Context, stressLevel in
 		SleepQuality:    sleepQuality,
 		StressLessScore: stressLessScore,
 		Feeling:         feeling,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-	err = u.metricRepo.CreateMetric(ctx, newMetric)
-	if err != nil {
-		return domain.User{}, err
-	}
-
-	// TODO:TODO: this url might help  https://frontendmasters.com/courses/openai-node/
-	rs, err := generateRecommendations(ctx, u, newMetric)
-	if err != nil {
-		return domain.User{}, err
-	}
-
-	for _, recommendation := range rs {
-		err = u.recommendationRepo.CreateRecommendation(ctx, recommendation)
-		if err != nil {
-			return domain.User{}, fmt.Errorf("error saving recommendation : %w", err)
+		FeelingPolarity: feelingAnalysis.Polarity,
+		Themes:          feelingAnalysis.Themes,
+		LocalDate:       domain.LocalDate(createdAt, updatedUser.Location()),
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	assessment := u.safetyModule.Assess(ctx, updatedUser, newMetric)
+	newMetric.SafetyFlags = assessment.Flags
+
+	rs := pendingRecommendations(newMetric)
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.metricRepo.CreateMetric(ctx, newMetric); err != nil {
+			return err
 		}
-	}
-
-	updatedUser := domain.User{
-		ID:                   existingUser.ID,
-		Email:                existingUser.Email,
-		FirstName:            existingUser.FirstName,
-		LastName:             existingUser.LastName,
-		Password:             existingUser.Password,
-		IsOnBoardingComplete: true,
-		LastMetricLog:        time.Now(),
-		UpdatedAt:            time.Now(),
-	}
-	err = u.userRepo.UpdateUser(ctx, updatedUser)
+		for _, recommendation := range rs {
+			if err := u.recommendationRepo.CreateRecommendation(ctx, recommendation); err != nil {
+				return fmt.Errorf("error saving recommendation : %w", err)
+			}
+		}
+		if err := u.recordAssessment(ctx, updatedUser, newMetric, assessment); err != nil {
+			return err
+		}
+		return u.userRepo.UpdateUser(ctx, updatedUser)
+	})
 	if err != nil {
 		return domain.User{}, err
 	}
+
+	u.logAssessment(updatedUser, newMetric, assessment)
+	u.enqueueRecommendations(ctx, rs)
+	u.awardAchievements(ctx, updatedUser, newMetric)
+	u.evaluateGoals(ctx, updatedUser, newMetric)
 	return updatedUser, nil
 }
 
@@ -0,0 +1,265 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/domain"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/infra"
+	"github.com/olad5/AfriHacks2023-stressless-backend/internal/services/auth"
+)
+
+const (
+	DefaultShareDays = 30
+	MaxShareDays     = 90
+	// MaxShareLabelLength caps the label of a share, in characters.
+	MaxShareLabelLength = 100
+	shareCodeLength     = 32
+)
+
+var (
+	ErrInvalidShareScope   = errors.New("scopes must be any of stats, logs or feelings, and feelings needs logs")
+	ErrInvalidShareExpiry  = errors.New("expires_in_days must be from 1 to 90")
+	ErrInvalidShareLabel   = errors.New("label must be at most 100 characters")
+	ErrInvalidShareCode    = errors.New("share code is invalid, expired or already used")
+	ErrUserDoesNotOwnShare = errors.New("user does not own share")
+	ErrShareNotActive      = errors.New("share is no longer active")
+)
+
+// NewShare is a share along with its code, which is not stored and only
+// returned when the share is created.
+type NewShare struct {
+	Share domain.Share
+	Code  string
+	Link  string
+}
+
+// AcceptedShare is what an invitee gets for a share's code, the token they
+// read the owner's data with until the share expires or is revoked.
+type AcceptedShare struct {
+	Share          domain.Share
+	OwnerFirstName string
+	Token          string
+}
+
+// CreateShare invites someone to read the logged in user's stats, and their
+// logs and feelings if scopes has them. An expiresInDays of 0 keeps the share
+// for DefaultShareDays.
+func (u *UserService) CreateShare(ctx context.Context, label string, scopes []domain.ShareScope, expiresInDays int) (NewShare, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return NewShare{}, err
+	}
+
+	label = strings.TrimSpace(label)
+	if len([]rune(label)) > MaxShareLabelLength {
+		return NewShare{}, ErrInvalidShareLabel
+	}
+	scopes, err = shareScopes(scopes)
+	if err != nil {
+		return NewShare{}, err
+	}
+	if expiresInDays == 0 {
+		expiresInDays = DefaultShareDays
+	}
+	if expiresInDays < 1 || expiresInDays > MaxShareDays {
+		return NewShare{}, ErrInvalidShareExpiry
+	}
+
+	code, err := shareCode()
+	if err != nil {
+		return NewShare{}, fmt.Errorf("error generating share code: %w", err)
+	}
+	createdAt := time.Now()
+	share := domain.Share{
+		ID:        primitive.NewObjectID(),
+		OwnerId:   existingUser.ID,
+		Label:     label,
+		CodeHash:  hashShareCode(code),
+		Scopes:    scopes,
+		ExpiresAt: createdAt.AddDate(0, 0, expiresInDays),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := u.shareRepo.CreateShare(ctx, share); err != nil {
+		return NewShare{}, err
+	}
+
+	return NewShare{
+		Share: share,
+		Code:  code,
+		Link:  u.appBaseUrl + "/shared?code=" + url.QueryEscape(code),
+	}, nil
+}
+
+// GetShares returns the logged in user's shares, newest first, including the
+// revoked and expired ones.
+func (u *UserService) GetShares(ctx context.Context) ([]domain.Share, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.shareRepo.GetSharesByOwnerId(ctx, existingUser.ID)
+}
+
+// RevokeShare ends a share at once, its invitee's token stops working on
+// their next request.
+func (u *UserService) RevokeShare(ctx context.Context, shareId primitive.ObjectID) (domain.Share, error) {
+	existingUser, err := u.GetLoggedInUser(ctx)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	share, err := u.shareRepo.GetShareById(ctx, shareId)
+	if err != nil {
+		return domain.Share{}, err
+	}
+	if share.OwnerId != existingUser.ID {
+		return domain.Share{}, ErrUserDoesNotOwnShare
+	}
+
+	if err := u.shareRepo.RevokeShare(ctx, share.ID, time.Now()); err != nil {
+		return domain.Share{}, err
+	}
+	return u.shareRepo.GetShareById(ctx, share.ID)
+}
+
+// AcceptShare trades a share's code for its delegated token. A code can only
+// be accepted once, so a leaked link is useless after the invitee used it.
+func (u *UserService) AcceptShare(ctx context.Context, code string) (AcceptedShare, error) {
+	return u.acceptShare(ctx, code, primitive.NilObjectID)
+}
+
+// AcceptShareAsClinician accepts a share for the logged in clinician, which
+// also lets them review the owner's safety checks while the share is active.
+func (u *UserService) AcceptShareAsClinician(ctx context.Context, code string) (AcceptedShare, error) {
+	clinician, err := u.loggedInClinician(ctx)
+	if err != nil {
+		return AcceptedShare{}, err
+	}
+	return u.acceptShare(ctx, code, clinician.ID)
+}
+
+func (u *UserService) acceptShare(ctx context.Context, code string, acceptedBy primitive.ObjectID) (AcceptedShare, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return AcceptedShare{}, ErrInvalidShareCode
+	}
+	share, err := u.shareRepo.GetShareByCodeHash(ctx, hashShareCode(code))
+	if errors.Is(err, infra.ErrShareNotFound) {
+		return AcceptedShare{}, ErrInvalidShareCode
+	}
+	if err != nil {
+		return AcceptedShare{}, err
+	}
+	acceptedAt := time.Now()
+	if share.Status(acceptedAt) != domain.PendingShare {
+		return AcceptedShare{}, ErrInvalidShareCode
+	}
+
+	owner, err := u.userRepo.GetUserByUserId(ctx, share.OwnerId)
+	if err != nil {
+		return AcceptedShare{}, err
+	}
+	token, err := u.authService.GenerateDelegatedToken(ctx, share)
+	if err != nil {
+		return AcceptedShare{}, err
+	}
+
+	err = u.shareRepo.AcceptShare(ctx, share.ID, acceptedBy, acceptedAt)
+	if errors.Is(err, infra.ErrShareNotFound) {
+		// accepted or revoked since it was read
+		return AcceptedShare{}, ErrInvalidShareCode
+	}
+	if err != nil {
+		return AcceptedShare{}, err
+	}
+	share.AcceptedAt, share.AcceptedBy, share.UpdatedAt = acceptedAt, acceptedBy, acceptedAt
+
+	u.logger.Info("share accepted", zap.String("share_id", share.ID.Hex()), zap.String("owner_id", share.OwnerId.Hex()),
+		zap.Bool("by_clinician", !acceptedBy.IsZero()))
+	return AcceptedShare{Share: share, OwnerFirstName: owner.FirstName, Token: token}, nil
+}
+
+// VerifyShare checks the share behind delegated jwtClaims is still active and
+// still grants the scopes in the token.
+func (u *UserService) VerifyShare(ctx context.Context, jwtClaims auth.JWTClaims) error {
+	share, err := u.shareRepo.GetShareById(ctx, jwtClaims.ShareId)
+	if errors.Is(err, infra.ErrShareNotFound) {
+		return ErrShareNotActive
+	}
+	if err != nil {
+		return err
+	}
+	if share.OwnerId != jwtClaims.ID || share.Status(time.Now()) != domain.ActiveShare {
+		return ErrShareNotActive
+	}
+	for _, scope := range jwtClaims.Scopes {
+		if !share.HasScope(scope) {
+			return ErrShareNotActive
+		}
+	}
+	return nil
+}
+
+// withoutFeelings drops everything read from a metric's feeling unless the
+// logged in user owns it or was shared their feelings.
+func withoutFeelings(ctx context.Context, metric domain.Metric) domain.Metric {
+	jwtClaims, ok := auth.GetJWTClaims(ctx)
+	if ok && (!jwtClaims.IsDelegated() || jwtClaims.HasScope(domain.FeelingsShareScope)) {
+		return metric
+	}
+	metric.Feeling = ""
+	metric.FeelingPolarity = 0
+	metric.Themes = []domain.Theme{}
+	metric.SafetyFlags = []domain.SafetyFlag{}
+	return metric
+}
+
+// shareScopes orders the requested scopes and always adds stats, which every
+// share grants.
+func shareScopes(requested []domain.ShareScope) ([]domain.ShareScope, error) {
+	granted := map[domain.ShareScope]bool{domain.StatsShareScope: true}
+	for _, scope := range requested {
+		if !scope.IsValid() {
+			return nil, ErrInvalidShareScope
+		}
+		granted[scope] = true
+	}
+	if granted[domain.FeelingsShareScope] && !granted[domain.LogsShareScope] {
+		return nil, ErrInvalidShareScope
+	}
+
+	scopes := []domain.ShareScope{}
+	for _, scope := range domain.ShareScopes {
+		if granted[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func shareCode() (string, error) {
+	b := make([]byte, shareCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashShareCode is what is stored of a code, so a leaked database does not
+// leak working codes.
+func hashShareCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
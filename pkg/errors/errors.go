@@ -5,6 +5,7 @@ import "errors"
 const (
 	ErrSomethingWentWrong = "something went wrong"
 	ErrUnauthorized       = "unauthorized"
+	ErrForbidden          = "forbidden"
 	ErrInvalidJson        = "Invalid JSON"
 	ErrMissingBody        = "missing body request"
 )
@@ -29,7 +29,7 @@ func seedUser() {
 	}
 
 	writer := csv.NewWriter(f)
-	heading := []string{"_id", "email", "password", "first_name", "last_name", "is_onboarding_complete", "last_metric_log", "updated_at", "created_at"}
+	heading := []string{"_id", "email", "password", "first_name", "last_name", "timezone", "is_onboarding_complete", "last_metric_log", "updated_at", "created_at"}
 	err := writer.Write(heading)
 	if err != nil {
 		fmt.Println(e)
@@ -38,7 +38,7 @@ func seedUser() {
 	users := getUsers()
 	var row []string
 	for _, user := range users {
-		row = []string{user.ID.Hex(), user.Email, user.Password, user.FirstName, user.LastName, strconv.FormatBool(user.IsOnBoardingComplete), user.LastMetricLog.Format(layout), user.UpdatedAt.Format(layout), user.CreatedAt.Format(layout)}
+		row = []string{user.ID.Hex(), user.Email, user.Password, user.FirstName, user.LastName, user.Timezone, strconv.FormatBool(user.IsOnBoardingComplete), user.LastMetricLog.Format(layout), user.UpdatedAt.Format(layout), user.CreatedAt.Format(layout)}
 		e := writer.Write(row)
 		if e != nil {
 			fmt.Println(e)
@@ -63,6 +63,7 @@ func getUsers() []domain.User {
 			FirstName:            "jason",
 			LastName:             "todd",
 			Password:             hashedPassword,
+			Timezone:             "Africa/Lagos",
 			IsOnBoardingComplete: true,
 			CreatedAt:            time.Now(),
 			UpdatedAt:            time.Now(),
@@ -73,6 +74,7 @@ func getUsers() []domain.User {
 			FirstName:            "joe",
 			LastName:             "cole",
 			Password:             hashedPassword,
+			Timezone:             "Africa/Lagos",
 			IsOnBoardingComplete: true,
 			CreatedAt:            time.Now(),
 			UpdatedAt:            time.Now(),
@@ -83,6 +85,7 @@ func getUsers() []domain.User {
 			FirstName:            "miley",
 			LastName:             "johnson",
 			Password:             hashedPassword,
+			Timezone:             "Africa/Lagos",
 			IsOnBoardingComplete: true,
 			CreatedAt:            time.Now(),
 			UpdatedAt:            time.Now(),